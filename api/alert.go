@@ -1,6 +1,30 @@
 package api
 
+// AlertSeverity is the severity of an alert
+type AlertSeverity string
+
+// supported values of AlertSeverity
+const (
+	AlertSeverityInfo     AlertSeverity = "info"
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// String is the Stringer method
+func (s AlertSeverity) String() string {
+	return string(s)
+}
+
+// DefaultAlertSeverity is the severity used by Trigger
+const DefaultAlertSeverity = AlertSeverityWarning
+
 // Alert interface is used for the various monitoring and alerting tools for Kelp.
 type Alert interface {
-	Trigger(description string, details interface{}) error
+	// Trigger sends an alert with the DefaultAlertSeverity
+	Trigger(description string, details interface{}) (string, error)
+	// TriggerWithSeverity sends an alert and returns the dedup key that can be used to resolve it, or "" if the service does not
+	// support resolving alerts
+	TriggerWithSeverity(severity AlertSeverity, description string, details interface{}) (string, error)
+	// Resolve closes the alert that was triggered with the dedup key, it is a noop for services that do not support resolving alerts
+	Resolve(dedupKey string) error
 }
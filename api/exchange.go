@@ -15,6 +15,8 @@ import (
 type ExchangeAPIKey struct {
 	Key    string
 	Secret string
+	// Passphrase is optional and is only required by some exchanges, such as KuCoin, Coinbase Pro, and OKEx
+	Passphrase string
 }
 
 // ExchangeParam specifies an additional parameter to be sent when initializing the exchange
@@ -81,6 +83,20 @@ type TradeFetcher interface {
 	GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*TradeHistoryResult, error)
 }
 
+// InclusiveTimestampCursor is an optional interface implemented by a TradeFetcher whose cursor is an inclusive timestamp,
+// callers paging through trades need to increment the timestamp of the last trade so they don't fetch it again
+type InclusiveTimestampCursor interface {
+	UsesInclusiveTimestampCursor() bool
+}
+
+// UsesInclusiveTimestampCursor returns true if the TradeFetcher reports that its cursor is an inclusive timestamp
+func UsesInclusiveTimestampCursor(tradeFetcher TradeFetcher) bool {
+	if c, ok := tradeFetcher.(InclusiveTimestampCursor); ok {
+		return c.UsesInclusiveTimestampCursor()
+	}
+	return false
+}
+
 // FillTrackable enables any implementing exchange to support fill tracking
 type FillTrackable interface {
 	TradeFetcher
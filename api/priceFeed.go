@@ -1,12 +1,21 @@
 package api
 
-import "log"
+import (
+	"log"
+
+	"github.com/stellar/kelp/model"
+)
 
 // PriceFeed allows you to fetch the price of a feed
 type PriceFeed interface {
 	GetPrice() (float64, error)
 }
 
+// PairPriceFeed allows you to fetch the current price of any trading pair from a single source, unlike a PriceFeed which is bound to one price
+type PairPriceFeed interface {
+	GetPrice(pair model.TradingPair) (float64, error)
+}
+
 // TODO this should be structured as a specific impl. of the PriceFeed interface
 // FeedPair is the struct representing a price feed for a trading pair
 type FeedPair struct {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 )
 
 // SubmitMode is the type of mode to be used when submitting orders to the trader bot
@@ -11,22 +12,39 @@ type SubmitMode uint8
 const (
 	SubmitModeMakerOnly SubmitMode = iota
 	SubmitModeBoth
+	SubmitModeTakerOnly
+	SubmitModePostOnly
+	SubmitModeDryRun // runs the full pipeline but only logs the operations instead of submitting them
 )
 
-// ParseSubmitMode converts a string to the SubmitMode constant
+// ParseSubmitMode converts a string to the SubmitMode constant, ignoring case. An empty string is parsed as SubmitModeBoth and
+// an unrecognized string returns an error so that typos in the config are not silently treated as SubmitModeBoth
 func ParseSubmitMode(submitMode string) (SubmitMode, error) {
-	if submitMode == "maker_only" {
+	normalized := strings.ToLower(strings.TrimSpace(submitMode))
+	if normalized == "maker_only" {
 		return SubmitModeMakerOnly, nil
-	} else if submitMode == "both" || submitMode == "" {
+	} else if normalized == "taker_only" {
+		return SubmitModeTakerOnly, nil
+	} else if normalized == "post_only" {
+		return SubmitModePostOnly, nil
+	} else if normalized == "dry_run" {
+		return SubmitModeDryRun, nil
+	} else if normalized == "both" || normalized == "" {
 		return SubmitModeBoth, nil
 	}
 
-	return SubmitModeBoth, fmt.Errorf("unable to parse submit mode: %s", submitMode)
+	return SubmitModeBoth, fmt.Errorf("unable to parse submit mode '%s', needs to be one of: both, maker_only, taker_only, post_only, dry_run", submitMode)
 }
 
 func (s *SubmitMode) String() string {
 	if *s == SubmitModeMakerOnly {
 		return "maker_only"
+	} else if *s == SubmitModeTakerOnly {
+		return "taker_only"
+	} else if *s == SubmitModePostOnly {
+		return "post_only"
+	} else if *s == SubmitModeDryRun {
+		return "dry_run"
 	}
 
 	return "both"
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSubmitMode(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  SubmitMode
+	}{
+		{input: "maker_only", want: SubmitModeMakerOnly},
+		{input: "post_only", want: SubmitModePostOnly},
+		{input: "taker_only", want: SubmitModeTakerOnly},
+		{input: "dry_run", want: SubmitModeDryRun},
+		{input: "both", want: SubmitModeBoth},
+		{input: "", want: SubmitModeBoth},
+		{input: "Maker_Only", want: SubmitModeMakerOnly},
+		{input: " POST_ONLY ", want: SubmitModePostOnly},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.input, func(t *testing.T) {
+			actual, e := ParseSubmitMode(k.input)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, actual)
+			if k.input != "" {
+				assert.Equal(t, strings.ToLower(strings.TrimSpace(k.input)), actual.String())
+			}
+		})
+	}
+
+	for _, invalid := range []string{"invalid", "makeronly", "maker-only"} {
+		_, e := ParseSubmitMode(invalid)
+		assert.Error(t, e, invalid)
+	}
+}
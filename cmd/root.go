@@ -69,12 +69,14 @@ var RootCmd = &cobra.Command{
 }
 
 var rootCcxtRestURL *string
+var rootCcxtLegacyInstanceNames *bool
 
 func init() {
 	validateBuild()
 	backend.SetVersionString(guiVersion, version)
 
 	rootCcxtRestURL = RootCmd.PersistentFlags().String("ccxt-rest-url", "", "URL to use for the CCXT-rest API. Takes precendence over the CCXT_REST_URL param set in the botConfg file for the trade command and passed as a parameter into the Kelp subprocesses started by the GUI (default URL is https://localhost:3000)")
+	rootCcxtLegacyInstanceNames = RootCmd.PersistentFlags().Bool("ccxt-legacy-instance-names", false, "name CCXT-rest instances using only a hash of the API key, which was the scheme used by older versions of Kelp. Use this to keep using instances created by older versions of Kelp")
 
 	RootCmd.AddCommand(tradeCmd)
 	RootCmd.AddCommand(serverCmd)
@@ -105,6 +107,10 @@ func checkInitRootFlags() {
 		}
 	}
 	// do not set rootCcxtRestURL if not specified in config so each command can handle defaults accordingly
+
+	if *rootCcxtLegacyInstanceNames {
+		sdk.SetLegacyInstanceNames(true)
+	}
 }
 
 func validateBuild() {
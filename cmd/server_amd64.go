@@ -9,6 +9,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -75,6 +76,7 @@ type serverInputOptions struct {
 	tlsCertFile       *string
 	tlsKeyFile        *string
 	guiConfigPath		  *string
+	commandTimeout    *time.Duration
 }
 
 // checks for required flag on CLI
@@ -99,6 +101,16 @@ func readGUIConfig(options serverInputOptions) guiconfig.GUIConfig {
 	if e != nil {
 		panic(fmt.Errorf("could not read GUI config file '%s': %s", *options.guiConfigPath, e))
 	}
+	if guiConfigInFunc.NeedsBearerToken() && guiConfigInFunc.BearerToken == "" {
+		// generate a token for this run so the server is never left open, the GUI picks it up from the link that we log here
+		token, e := guiconfig.GenerateBearerToken()
+		if e != nil {
+			panic(fmt.Errorf("could not generate a bearer token for the GUI: %s", e))
+		}
+		guiConfigInFunc.BearerToken = token
+		log.Printf("BEARER_TOKEN is not set in the GUI config file '%s', generated a bearer token for this run: %s\n", *options.guiConfigPath, token)
+		log.Printf("open the GUI with the link http://localhost:%d/?token=%s to authenticate the browser\n", *options.port, token)
+	}
 	return guiConfigInFunc
 }
 // customConfigVar Variable with its equivalent struct #used to inject config values to jwt config var and to configure route
@@ -120,6 +132,7 @@ func init() {
 	options.tlsCertFile = serverCmd.Flags().String("tls-cert-file", "", "path to TLS certificate file")
 	options.tlsKeyFile = serverCmd.Flags().String("tls-key-file", "", "path to TLS key file")
 	options.guiConfigPath = serverCmd.Flags().StringP("guiconfig", "c", "", "(required) gui-config for auth0 and other basic config file path")
+	options.commandTimeout = serverCmd.Flags().Duration("command-timeout", 2*time.Minute, "max time that a blocking kelp subcommand run by the GUI backend can take before it is killed, use 0 for no limit")
 
 	requiredFlags("guiconfig")
 
@@ -201,6 +214,10 @@ func init() {
 			}
 
 			appURL := fmt.Sprintf("http://localhost:%d", *options.port)
+			if auth0ConfigVar.NeedsBearerToken() {
+				// the frontend stores the token passed in the query param and sends it as the bearer token on every request
+				appURL = fmt.Sprintf("%s/?token=%s", appURL, url.QueryEscape(auth0ConfigVar.BearerToken))
+			}
 			pingURL := fmt.Sprintf("http://localhost:%d/ping", *options.port)
 			// write out tail.html after setting the file to be tailed
 			tailFileCompiled1 := strings.Replace(htmlContent, stringPlaceholder, logFilepath.Native(), -1)
@@ -414,6 +431,7 @@ func init() {
 			quit,
 			metricsTracker,
 			auth0ConfigVar,
+			*options.commandTimeout,
 		)
 		if e != nil {
 			panic(e)
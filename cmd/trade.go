@@ -7,14 +7,18 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/denisbrodbeck/machineid"
 	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 
 	"github.com/stellar/go/clients/horizonclient"
@@ -369,6 +373,22 @@ func makeExchangeShimSdex(
 	return exchangeShim, sdex
 }
 
+// makeAlert makes the alert used by the trader and the strategy, it returns nil if the alert could not be set up
+func makeAlert(botConfig trader.BotConfig) api.Alert {
+	alert, e := monitoring.MakeAlertWithOptions(botConfig.AlertType, botConfig.AlertAPIKey, monitoring.AlertOptions{
+		ChatID:             botConfig.AlertChatID,
+		WebhookMethod:      botConfig.AlertWebhookMethod,
+		WebhookBearerToken: botConfig.AlertWebhookBearerToken,
+		OpsGenieTeam:       botConfig.AlertOpsGenieTeam,
+		CooldownSeconds:    botConfig.AlertCooldownSeconds,
+	})
+	if e != nil {
+		utils.PrintErrorHintf("Unable to set up monitoring for alert type '%s' with the given API key, no alerts will be sent: %s", botConfig.AlertType, e)
+		return nil
+	}
+	return alert
+}
+
 func makeStrategy(
 	l logger.Logger,
 	network string,
@@ -386,6 +406,7 @@ func makeStrategy(
 	threadTracker *multithreading.ThreadTracker,
 	db *sql.DB,
 	metricsTracker *plugins.MetricsTracker,
+	alert api.Alert,
 ) api.Strategy {
 	// setting the temp hack variables for the sdex price feeds
 	e := plugins.SetPrivateSdexHack(client, plugins.MakeIEIF(true), network)
@@ -411,6 +432,7 @@ func makeStrategy(
 		botConfig.IsTradingSdex(),
 		filterFactory,
 		db,
+		alert,
 	)
 	if e != nil {
 		l.Info("")
@@ -436,6 +458,8 @@ func makeBot(
 	options inputs,
 	metricsTracker *plugins.MetricsTracker,
 	botStartTime time.Time,
+	alert api.Alert,
+	onUpdateComplete func(updateTime time.Time),
 ) *trader.Trader {
 	timeController := plugins.MakeIntervalTimeController(
 		time.Duration(botConfig.TickIntervalMillis)*time.Millisecond,
@@ -459,11 +483,6 @@ func makeBot(
 	assetBase := botConfig.AssetBase()
 	assetQuote := botConfig.AssetQuote()
 	dataKey := model.MakeSortedBotKey(assetBase, assetQuote)
-	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
-	if e != nil {
-		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
-	}
-
 	var valueBaseFeed api.PriceFeed
 	var valueQuoteFeed api.PriceFeed
 	if botConfig.DollarValueFeedBaseAsset != "" && botConfig.DollarValueFeedQuoteAsset != "" {
@@ -485,11 +504,8 @@ func makeBot(
 	}
 
 	// start make filters
-	submitFilters := []plugins.SubmitFilter{}
-	if submitMode == api.SubmitModeMakerOnly {
-		submitFilters = append(submitFilters,
-			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair),
-		)
+	submitFilters := []plugins.SubmitFilter{
+		plugins.MakeFilterSubmitMode(submitMode, exchangeShim, sdex, tradingPair),
 	}
 	if len(botConfig.Filters) > 0 && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "buy_twap" && *options.strategy != "delete" {
 		log.Println()
@@ -512,6 +528,16 @@ func makeBot(
 	submitFilters = append(submitFilters,
 		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote),
 	)
+	if submitMode == api.SubmitModeDryRun {
+		// dry run filter is after the exchange constraints filter so it logs the operations exactly as they would have been submitted
+		submitFilters = append(submitFilters,
+			plugins.MakeFilterDryRun(assetBase, assetQuote),
+		)
+	}
+	log.Printf("using %d submit filters for submit mode '%s' (in order):\n", len(submitFilters), submitMode.String())
+	for i, filter := range submitFilters {
+		log.Printf("    %d. %s: %s\n", i+1, filter.Name(), filter.Describe())
+	}
 	// end make filters
 
 	return trader.MakeTrader(
@@ -539,9 +565,31 @@ func makeBot(
 		alert,
 		metricsTracker,
 		botStartTime,
+		onUpdateComplete,
 	)
 }
 
+// makeBotStatsReporter returns the function that reports the stats of the bot at the end of every update cycle when the bot was started
+// from the GUI, which reads the stats from the output of the bot. It returns nil otherwise
+func makeBotStatsReporter(options inputs, countingAlert *monitoring.CountingAlert) func(updateTime time.Time) {
+	if *options.trigger != constants.TriggerUI && *options.trigger != constants.TriggerKaas {
+		return nil
+	}
+
+	return func(updateTime time.Time) {
+		line, e := model.BotStats{
+			LastCycleTime:     updateTime,
+			AlertTriggerCount: countingAlert.TriggerCount(),
+		}.LogLine()
+		if e != nil {
+			log.Printf("could not report bot stats: %s\n", e)
+			return
+		}
+		// written to stdout directly because that is the output read by the GUI, regardless of where the logs are written
+		fmt.Println(line)
+	}
+}
+
 func convertDeprecatedBotConfigValues(l logger.Logger, botConfig trader.BotConfig) trader.BotConfig {
 	if botConfig.CentralizedMinBaseVolumeOverride != nil && botConfig.MinCentralizedBaseVolumeDeprecated != nil {
 		l.Infof("deprecation warning: cannot set both '%s' (deprecated) and '%s' in the trader config, using value from '%s'\n", "MIN_CENTRALIZED_BASE_VOLUME", "CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE", "CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE")
@@ -724,23 +772,47 @@ func runTradeCmd(options inputs) {
 		tradingPair,
 		sdexAssetMap,
 	)
+	// the prometheus registry is only used when the monitoring server is enabled, filters do not record metrics otherwise
+	var prometheusRegistry *prometheus.Registry
+	var volumeFilterMetrics *plugins.VolumeFilterMetrics
+	if botConfig.MonitoringPort != 0 {
+		prometheusRegistry = prometheus.NewRegistry()
+		volumeFilterMetrics, e = plugins.MakeVolumeFilterMetrics(prometheusRegistry)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make volume filter metrics: %s", e))
+		}
+	}
 	filterFactory := &plugins.FilterFactory{
-		ExchangeName:   botConfig.TradingExchangeName(),
-		TradingPair:    tradingPair,
-		AssetDisplayFn: assetDisplayFn,
-		BaseAsset:      assetBase,
-		QuoteAsset:     assetQuote,
-		DB:             db,
-	}
-	baseString, e := assetDisplayFn(tradingPair.Base)
+		ExchangeName:        botConfig.TradingExchangeName(),
+		TradingPair:         tradingPair,
+		AssetDisplayFn:      assetDisplayFn,
+		BaseAsset:           assetBase,
+		QuoteAsset:          assetQuote,
+		DB:                  db,
+		VolumeFilterMetrics: volumeFilterMetrics,
+	}
+	market, e := plugins.MakeMarketIDFromTradingPair(botConfig.TradingExchangeName(), tradingPair, assetDisplayFn)
 	if e != nil {
-		logger.Fatal(l, fmt.Errorf("could not convert base trading pair to string: %s", e))
+		logger.Fatal(l, fmt.Errorf("could not make market ID: %s", e))
 	}
-	quoteString, e := assetDisplayFn(tradingPair.Quote)
-	if e != nil {
-		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string: %s", e))
+	marketID := market.Hash()
+	alert := makeAlert(botConfig)
+	var countingAlert *monitoring.CountingAlert
+	if alert != nil {
+		countingAlert, e = monitoring.MakeCountingAlert(alert)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make counting alert: %s", e))
+		}
+		alert = countingAlert
+	}
+	if db != nil && botConfig.DbHealthCheckIntervalSeconds > 0 {
+		dbHealthChecker, e := plugins.MakeDBHealthChecker(db, time.Duration(botConfig.DbHealthCheckIntervalSeconds)*time.Second, alert)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make db health checker: %s", e))
+		}
+		dbHealthChecker.Start()
+		addShutdownHook(dbHealthChecker.Stop)
 	}
-	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
 	strategy := makeStrategy(
 		l,
 		network,
@@ -758,6 +830,7 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		db,
 		metricsTracker,
+		alert,
 	)
 	fillTracker := makeFillTracker(
 		l,
@@ -772,6 +845,7 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		botConfig.DbOverrideAccountID,
 		metricsTracker,
+		prometheusRegistry,
 	)
 	bot := makeBot(
 		l,
@@ -788,13 +862,15 @@ func runTradeCmd(options inputs) {
 		options,
 		metricsTracker,
 		botStartTime,
+		alert,
+		makeBotStatsReporter(options, countingAlert),
 	)
 	// --- end initialization of objects ---
 	// --- start initialization of services ---
 	validateTrustlines(l, client, &botConfig)
 	if botConfig.MonitoringPort != 0 {
 		go func() {
-			e := startMonitoringServer(l, botConfig)
+			e := startMonitoringServer(l, botConfig, prometheusRegistry)
 			if e != nil {
 				l.Info("")
 				l.Info("unable to start the monitoring server or problem encountered while running server:")
@@ -820,8 +896,10 @@ func runTradeCmd(options inputs) {
 	}
 	// --- end initialization of services ---
 
+	runShutdownHooksOnSignal(l)
 	l.Info("Starting the trader bot...")
 	bot.Start()
+	runShutdownHooks()
 }
 
 func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
@@ -846,7 +924,7 @@ func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
 	return fmt.Sprint(userIDHashed), nil
 }
 
-func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
+func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig, prometheusRegistry *prometheus.Registry) error {
 	healthMetrics, e := monitoring.MakeMetricsRecorder(map[string]interface{}{"success": true})
 	if e != nil {
 		return fmt.Errorf("unable to make metrics recorder for the /health endpoint: %s", e)
@@ -869,6 +947,11 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 		return fmt.Errorf("unable to make /metrics endpoint: %s", e)
 	}
 
+	prometheusEndpoint, e := monitoring.MakePrometheusEndpoint("/metrics/prometheus", prometheusRegistry, metricsAuth)
+	if e != nil {
+		return fmt.Errorf("unable to make /metrics/prometheus endpoint: %s", e)
+	}
+
 	serverConfig := &networking.Config{
 		GoogleClientID:     botConfig.GoogleClientID,
 		GoogleClientSecret: botConfig.GoogleClientSecret,
@@ -877,7 +960,7 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 	for _, email := range strings.Split(botConfig.AcceptableEmails, ",") {
 		serverConfig.PermittedEmails[email] = true
 	}
-	server, e := networking.MakeServerWithGoogleAuth(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint})
+	server, e := networking.MakeServerWithGoogleAuth(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint, prometheusEndpoint})
 	if e != nil {
 		return fmt.Errorf("unable to initialize the metrics server: %s", e)
 	}
@@ -899,6 +982,7 @@ func makeFillTracker(
 	threadTracker *multithreading.ThreadTracker,
 	accountID string,
 	metricsTracker *plugins.MetricsTracker,
+	prometheusRegistry *prometheus.Registry,
 ) api.FillTracker {
 	strategyFillHandlers, e := strategy.GetFillHandlers()
 	if e != nil {
@@ -938,8 +1022,70 @@ func makeFillTracker(
 
 	fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold, lastCursor)
 	fillLogger := plugins.MakeFillLogger()
+	if botConfig.FillTrackerLogFilePath != "" {
+		fillLogger, e = plugins.MakeFillLoggerWithFile(botConfig.FillTrackerLogFilePath, botConfig.FillTrackerLogJSON)
+		if e != nil {
+			l.Info("")
+			l.Error(fmt.Sprintf("could not make the fill logger: %s", e))
+			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+	} else if botConfig.FillTrackerLogJSON {
+		fillLogger = plugins.MakeJSONFillLogger()
+	}
 	fillTracker.RegisterHandler(fillLogger)
-	if db != nil {
+	if botConfig.FillTrackerPnlEnabled {
+		pnlFillHandler := plugins.MakePnlFillHandler()
+		// serve the P&L on the monitoring server, it is only logged when the monitoring server is disabled
+		if prometheusRegistry != nil {
+			e = prometheusRegistry.Register(pnlFillHandler)
+			if e != nil {
+				logger.Fatal(l, fmt.Errorf("could not register the P&L metrics: %s", e))
+			}
+		}
+		fillTracker.RegisterHandler(pnlFillHandler)
+	}
+	if botConfig.FillTrackerCsvFilePath != "" {
+		csvFillHandler, e := plugins.MakeCsvFillHandler(botConfig.FillTrackerCsvFilePath)
+		if e != nil {
+			l.Info("")
+			l.Error(fmt.Sprintf("could not make the CSV fill handler: %s", e))
+			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+		fillTracker.RegisterHandler(csvFillHandler)
+	}
+	if botConfig.FillTrackerWebhookURL != "" {
+		webhookFillHandler, e := plugins.MakeWebhookFillHandler(botConfig.FillTrackerWebhookURL, botConfig.FillTrackerWebhookStrict)
+		if e != nil {
+			l.Info("")
+			l.Error(fmt.Sprintf("could not make the webhook fill handler: %s", e))
+			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+		fillTracker.RegisterHandler(webhookFillHandler)
+	}
+	if db != nil && botConfig.FillTrackerDbBatchSize > 1 {
+		flushInterval := plugins.DefaultFillDBFlushInterval
+		if botConfig.FillTrackerDbFlushIntervalMillis > 0 {
+			flushInterval = time.Duration(botConfig.FillTrackerDbFlushIntervalMillis) * time.Millisecond
+		}
+		batchedFillDBWriter, e := plugins.MakeBatchedFillDBWriter(db, assetDisplayFn, botConfig.TradingExchangeName(), accountID, botConfig.FillTrackerDbBatchSize, flushInterval)
+		if e != nil {
+			l.Info("")
+			l.Error(fmt.Sprintf("could not make the batched fill db writer: %s", e))
+			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+		// flush the buffered fills on shutdown so they are not lost
+		addShutdownHook(func() {
+			e := batchedFillDBWriter.Close()
+			if e != nil {
+				l.Errorf("could not flush the buffered fills to the db on shutdown: %s", e)
+			}
+		})
+		fillTracker.RegisterHandler(batchedFillDBWriter)
+	} else if db != nil {
 		fillDBWriter := plugins.MakeFillDBWriter(db, assetDisplayFn, botConfig.TradingExchangeName(), accountID)
 		fillTracker.RegisterHandler(fillDBWriter)
 	}
@@ -986,6 +1132,39 @@ func validateTrustlines(l logger.Logger, client *horizonclient.Client, botConfig
 	l.Info("trustlines valid")
 }
 
+// shutdownHooks are run once before the trade command exits so that buffered state, such as fills, is not lost
+var shutdownHooks = []func(){}
+var shutdownHooksLock = &sync.Mutex{}
+var shutdownHooksOnce = &sync.Once{}
+
+func addShutdownHook(hook func()) {
+	shutdownHooksLock.Lock()
+	defer shutdownHooksLock.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+func runShutdownHooks() {
+	shutdownHooksOnce.Do(func() {
+		shutdownHooksLock.Lock()
+		defer shutdownHooksLock.Unlock()
+		for _, hook := range shutdownHooks {
+			hook()
+		}
+	})
+}
+
+// runShutdownHooksOnSignal runs the shutdown hooks and exits when the process is interrupted or terminated
+func runShutdownHooksOnSignal(l logger.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		l.Infof("received signal '%s', running shutdown hooks before exiting...", sig)
+		runShutdownHooks()
+		os.Exit(1)
+	}()
+}
+
 func deleteAllOffersAndExit(
 	l logger.Logger,
 	botConfig trader.BotConfig,
@@ -1008,6 +1187,7 @@ func deleteAllOffersAndExit(
 	threadTracker.Stop(multithreading.StopModeError)
 	threadTracker.Wait()
 	l.Info("...all outstanding threads finished")
+	runShutdownHooks()
 
 	l.Info("")
 	l.Info("deleting all offers and then exiting...")
@@ -1023,7 +1203,11 @@ func deleteAllOffersAndExit(
 	dOps := sdex.DeleteAllOffers(allOffers)
 	l.Infof("created %d operations to delete offers\n", len(dOps))
 
-	if len(dOps) > 0 {
+	// this is also called when the submit mode cannot be parsed, in which case ParseSubmitMode returns SubmitModeBoth so the offers are deleted
+	submitMode, _ := api.ParseSubmitMode(botConfig.SubmitMode)
+	if len(dOps) > 0 && submitMode == api.SubmitModeDryRun {
+		logger.Fatal(l, fmt.Errorf("...not deleting %d offers because the submit mode is dry_run, exiting", len(dOps)))
+	} else if len(dOps) > 0 {
 		// to delete offers the submitMode doesn't matter, so use api.SubmitModeBoth as the default
 		e := exchangeShim.SubmitOpsSynch(api.ConvertOperation2TM(dOps), api.SubmitModeBoth, func(hash string, e error) {
 			if e != nil {
@@ -1,17 +1,21 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/kelp/plugins"
@@ -58,6 +62,9 @@ type APIServer struct {
 	metricsTracker       *plugins.MetricsTracker
 	kelpErrorsByUser     map[string]kelpErrorDataForUser
 	kelpErrorsByUserLock *sync.Mutex
+	kelpCommandTimeout   time.Duration
+	botMetrics           *botMetrics
+	metricsRegistry      *prometheus.Registry
 
 	cachedOptionsMetadata metadata
 	guiConfig			guiconfig.GUIConfig
@@ -79,6 +86,7 @@ func MakeAPIServer(
 	quitFn func(),
 	metricsTracker *plugins.MetricsTracker,
 	guiConfig		guiconfig.GUIConfig,
+	kelpCommandTimeout time.Duration,
 ) (*APIServer, error) {
 	kelpBinPath := kos.GetBinDir().Join(filepath.Base(os.Args[0]))
 
@@ -87,6 +95,13 @@ func MakeAPIServer(
 		return nil, fmt.Errorf("error while loading options metadata when making APIServer: %s", e)
 	}
 
+	botMetrics := makeBotMetrics()
+	metricsRegistry := prometheus.NewRegistry()
+	e = metricsRegistry.Register(botMetrics)
+	if e != nil {
+		return nil, fmt.Errorf("error while registering bot metrics when making APIServer: %s", e)
+	}
+
 	return &APIServer{
 		kelpBinPath:           kelpBinPath,
 		botConfigsPath:        botConfigsPath,
@@ -105,6 +120,9 @@ func MakeAPIServer(
 		metricsTracker:        metricsTracker,
 		kelpErrorsByUser:      map[string]kelpErrorDataForUser{},
 		kelpErrorsByUserLock:  &sync.Mutex{},
+		kelpCommandTimeout:    kelpCommandTimeout,
+		botMetrics:            botMetrics,
+		metricsRegistry:       metricsRegistry,
 		guiConfig:			   guiConfig,
 	}, nil
 }
@@ -117,6 +135,40 @@ func (s *APIServer) botLogsPathForUser(userID string) *kelpos.OSPath {
 	return s.botLogsPath.Join(userID)
 }
 
+// botConfigFilePathForUser returns the path to the config file with the filename in the configs directory of the user. It returns an
+// error if the userID or filename could resolve to a path outside the configs directory.
+func (s *APIServer) botConfigFilePathForUser(userID string, filename string) (*kelpos.OSPath, error) {
+	e := validatePathElement(userID)
+	if e != nil {
+		return nil, fmt.Errorf("invalid userID: %s", e)
+	}
+
+	e = validatePathElement(filename)
+	if e != nil {
+		return nil, fmt.Errorf("invalid config filename: %s", e)
+	}
+
+	return s.botConfigsPathForUser(userID).Join(filename), nil
+}
+
+// validatePathElement checks that the element is a single plain file or directory name that stays within the directory it is joined to
+func validatePathElement(elem string) error {
+	if strings.TrimSpace(elem) == "" {
+		return fmt.Errorf("path element cannot be empty")
+	}
+	if filepath.IsAbs(elem) || path.IsAbs(elem) || filepath.VolumeName(elem) != "" {
+		return fmt.Errorf("path element cannot be an absolute path: %s", elem)
+	}
+	if strings.Contains(elem, "..") {
+		return fmt.Errorf("path element cannot contain '..': %s", elem)
+	}
+	// check for both separators regardless of OS since paths are converted between unix and windows formats
+	if strings.ContainsAny(elem, "/\\") {
+		return fmt.Errorf("path element cannot contain a path separator: %s", elem)
+	}
+	return nil
+}
+
 func (s *APIServer) kelpErrorsForUser(userID string) kelpErrorDataForUser {
 	s.kelpErrorsByUserLock.Lock()
 	defer s.kelpErrorsByUserLock.Unlock()
@@ -235,6 +287,10 @@ func (s *APIServer) addKelpErrorToMap(userData UserData, ke KelpError) {
 	defer kefu.lock.Unlock()
 
 	kefu.errorMap[key] = ke
+
+	if ke.ObjectType == errorTypeBot {
+		s.botMetrics.addError(userData.ID, ke.ObjectName, ke.Level, ke.Date)
+	}
 }
 
 // removeKelpErrorUserDataIfEmpty removes user error data if the underlying map is empty
@@ -285,24 +341,51 @@ func (s *APIServer) writeJsonWithLog(w http.ResponseWriter, v interface{}, doLog
 	w.Write(marshalledJson)
 }
 
-func (s *APIServer) runKelpCommandBlocking(userID string, namespace string, cmd string) ([]byte, error) {
-	// There is a weird issue on windows where the absolute path for the kelp binary does not work on the release GUI
-	// version because of the unzipped directory name but it will work on the released cli version or if we change the
-	// name of the folder in which the GUI version is unzipped.
-	// To avoid these issues we only invoke with the binary name as opposed to the absolute path that contains the
-	// directory name. see start_bot.go for some experimentation with absolute and relative paths
-	cmdString := fmt.Sprintf("%s %s", s.kelpBinPath.Unix(), cmd)
-	return s.kos.Blocking(userID, namespace, cmdString)
-}
-
-func (s *APIServer) runKelpCommandBackground(userID string, namespace string, cmd string) (*kelpos.Process, error) {
-	// There is a weird issue on windows where the absolute path for the kelp binary does not work on the release GUI
-	// version because of the unzipped directory name but it will work on the released cli version or if we change the
-	// name of the folder in which the GUI version is unzipped.
-	// To avoid these issues we only invoke with the binary name as opposed to the absolute path that contains the
-	// directory name. see start_bot.go for some experimentation with absolute and relative paths
-	cmdString := fmt.Sprintf("%s %s", s.kelpBinPath.Unix(), cmd)
-	return s.kos.Background(userID, namespace, cmdString)
+// kelpCommandContext returns the context to run a kelp command with, which has a deadline when kelpCommandTimeout > 0
+func (s *APIServer) kelpCommandContext() (context.Context, context.CancelFunc) {
+	if s.kelpCommandTimeout > 0 {
+		return context.WithTimeout(context.Background(), s.kelpCommandTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// runKelpCommandBlocking runs the kelp binary with the args and blocks. The args are passed directly to the process without
+// going through a shell so values in the args cannot be interpreted as shell syntax. If the command fails then the error is a
+// *kelpos.CommandError, which includes the captured stdout and stderr. The command is killed if it takes longer than the
+// kelpCommandTimeout (when > 0) or if it is cancelled with cancelKelpCommand.
+func (s *APIServer) runKelpCommandBlocking(userID string, namespace string, args ...string) ([]byte, error) {
+	ctx, cancel := s.kelpCommandContext()
+	defer cancel()
+
+	// we invoke the binary with its native path since we do not run under bash anymore, native absolute paths work on windows
+	// when not invoked with bash -c. see start_bot.go for some experimentation with absolute and relative paths
+	return s.kos.BlockingArgsContext(ctx, userID, namespace, s.kelpBinPath.Native(), args...)
+}
+
+// runKelpCommandStreaming runs the kelp binary with the args and invokes the handler with every line of output as soon as it is
+// written, blocking until the command finishes. Use this instead of runKelpCommandBlocking for long-running commands where we want
+// to show progress. The command is subject to the same kelpCommandTimeout and can be cancelled with cancelKelpCommand.
+func (s *APIServer) runKelpCommandStreaming(userID string, namespace string, handler kelpos.LineHandler, args ...string) error {
+	ctx, cancel := s.kelpCommandContext()
+	defer cancel()
+
+	return s.kos.StreamingArgsContext(ctx, userID, namespace, handler, s.kelpBinPath.Native(), args...)
+}
+
+// cancelKelpCommand kills the kelp command running under the namespace for the user
+func (s *APIServer) cancelKelpCommand(userID string, namespace string) error {
+	e := s.kos.Stop(userID, namespace)
+	if e != nil {
+		return fmt.Errorf("could not cancel kelp command for userID '%s' and namespace '%s': %s", userID, namespace, e)
+	}
+	return nil
+}
+
+// runKelpCommandBackground runs the kelp binary with the args in the background, without going through a shell
+func (s *APIServer) runKelpCommandBackground(userID string, namespace string, args ...string) (*kelpos.Process, error) {
+	// we invoke the binary with its native path since we do not run under bash anymore, native absolute paths work on windows
+	// when not invoked with bash -c. see start_bot.go for some experimentation with absolute and relative paths
+	return s.kos.BackgroundArgs(userID, namespace, s.kelpBinPath.Native(), args...)
 }
 
 func (s *APIServer) setupOpsDirectory(userID string) error {
@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotConfigFilePathForUser(t *testing.T) {
+	basePath, e := kelpos.MakeOsPathBase()
+	if !assert.NoError(t, e) {
+		return
+	}
+	s := &APIServer{botConfigsPath: basePath.Join("configs")}
+
+	testCases := []struct {
+		name      string
+		userID    string
+		filename  string
+		wantValid bool
+	}{
+		{
+			name:      "valid",
+			userID:    "user1",
+			filename:  "george_the_friendly_octopus__trader.cfg",
+			wantValid: true,
+		}, {
+			name:      "parent dir",
+			userID:    "user1",
+			filename:  "../../etc/passwd",
+			wantValid: false,
+		}, {
+			name:      "dot dot only",
+			userID:    "user1",
+			filename:  "..",
+			wantValid: false,
+		}, {
+			name:      "embedded dot dot",
+			userID:    "user1",
+			filename:  "bot..cfg",
+			wantValid: false,
+		}, {
+			name:      "windows parent dir",
+			userID:    "user1",
+			filename:  "..\\..\\windows\\system.ini",
+			wantValid: false,
+		}, {
+			name:      "absolute unix path",
+			userID:    "user1",
+			filename:  "/etc/passwd",
+			wantValid: false,
+		}, {
+			name:      "absolute windows path",
+			userID:    "user1",
+			filename:  "C:\\Windows\\system.ini",
+			wantValid: false,
+		}, {
+			name:      "subdirectory",
+			userID:    "user1",
+			filename:  "subdir/bot__trader.cfg",
+			wantValid: false,
+		}, {
+			name:      "empty filename",
+			userID:    "user1",
+			filename:  "",
+			wantValid: false,
+		}, {
+			name:      "traversal in userID",
+			userID:    "../user2",
+			filename:  "bot__trader.cfg",
+			wantValid: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p, e := s.botConfigFilePathForUser(k.userID, k.filename)
+			if !k.wantValid {
+				assert.Error(t, e)
+				return
+			}
+
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, basePath.Join("configs", k.userID, k.filename).Native(), p.Native())
+		})
+	}
+}
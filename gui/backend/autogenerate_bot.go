@@ -77,7 +77,12 @@ func (s *APIServer) autogenerateBot(w http.ResponseWriter, r *http.Request) {
 
 	filenamePair := bot.Filenames()
 	sampleTrader := s.makeSampleTrader(kp.Seed())
-	traderFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Trader)
+	traderFilePath, e := s.botConfigFilePathForUser(userID, filenamePair.Trader)
+	if e != nil {
+		// the bot is not registered at this stage so we don't throw a KelpError here
+		s.writeError(w, fmt.Sprintf("error getting trader config file path: %s\n", e))
+		return
+	}
 	log.Printf("writing autogenerated bot config to file: %s\n", traderFilePath.AsString())
 	e = toml.WriteFile(traderFilePath.Native(), sampleTrader)
 	if e != nil {
@@ -87,7 +92,12 @@ func (s *APIServer) autogenerateBot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sampleBuysell := makeSampleBuysell()
-	strategyFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Strategy)
+	strategyFilePath, e := s.botConfigFilePathForUser(userID, filenamePair.Strategy)
+	if e != nil {
+		// the bot is not registered at this stage so we don't throw a KelpError here
+		s.writeError(w, fmt.Sprintf("error getting strategy config file path: %s\n", e))
+		return
+	}
 	log.Printf("writing autogenerated strategy config to file: %s\n", strategyFilePath.AsString())
 	e = toml.WriteFile(strategyFilePath.Native(), sampleBuysell)
 	if e != nil {
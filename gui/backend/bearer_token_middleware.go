@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// BearerTokenMiddleware returns a middleware that responds with a 401 unless the request has the token as a bearer token
+// in the Authorization header
+func BearerTokenMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isBearerTokenValid(r, token) {
+				log.Printf("rejecting unauthorized request to '%s' from '%s'\n", r.URL.Path, r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isBearerTokenValid(r *http.Request, token string) bool {
+	// an empty token should never authorize a request
+	if token == "" {
+		return false
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return false
+	}
+	requestToken := strings.TrimPrefix(authHeader, bearerPrefix)
+	// use a constant time comparison so the token cannot be guessed using the response time
+	return subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) == 1
+}
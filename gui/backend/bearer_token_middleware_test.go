@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerTokenMiddleware(t *testing.T) {
+	testCases := []struct {
+		name       string
+		token      string
+		authHeader string
+		wantStatus int
+	}{
+		{
+			name:       "valid token",
+			token:      "secret",
+			authHeader: "Bearer secret",
+			wantStatus: http.StatusOK,
+		}, {
+			name:       "invalid token",
+			token:      "secret",
+			authHeader: "Bearer wrong",
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:       "missing header",
+			token:      "secret",
+			authHeader: "",
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:       "not a bearer token",
+			token:      "secret",
+			authHeader: "Basic secret",
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:       "empty token is never valid",
+			token:      "",
+			authHeader: "Bearer ",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			handler := BearerTokenMiddleware(k.token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("POST", "/api/v1/listBots", nil)
+			if k.authHeader != "" {
+				req.Header.Set("Authorization", k.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, k.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package backend
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/stellar/kelp/model"
+)
+
+// botStatsKey identifies a bot across users
+type botStatsKey struct {
+	userID  string
+	botName string
+}
+
+// botStatsEntry is everything we know about the health of a bot
+type botStatsEntry struct {
+	running       bool
+	stats         model.BotStats
+	lastErrorTime time.Time
+	errorsByLevel map[errorLevel]uint64
+}
+
+// botMetrics tracks the health of bots and exposes it as prometheus metrics, it implements prometheus.Collector
+type botMetrics struct {
+	lock       *sync.Mutex
+	statsByBot map[botStatsKey]*botStatsEntry
+
+	runningBotsDesc   *prometheus.Desc
+	lastCycleDesc     *prometheus.Desc
+	lastErrorDesc     *prometheus.Desc
+	errorsDesc        *prometheus.Desc
+	alertTriggersDesc *prometheus.Desc
+}
+
+var _ prometheus.Collector = &botMetrics{}
+
+// makeBotMetrics is a factory method
+func makeBotMetrics() *botMetrics {
+	botLabels := []string{"user_id", "bot_name"}
+	return &botMetrics{
+		lock:       &sync.Mutex{},
+		statsByBot: map[botStatsKey]*botStatsEntry{},
+
+		runningBotsDesc: prometheus.NewDesc(
+			"kelp_gui_running_bots",
+			"number of bots that are currently running",
+			nil,
+			nil,
+		),
+		lastCycleDesc: prometheus.NewDesc(
+			"kelp_gui_bot_last_cycle_timestamp_seconds",
+			"unix timestamp of the last update cycle reported by the bot",
+			botLabels,
+			nil,
+		),
+		lastErrorDesc: prometheus.NewDesc(
+			"kelp_gui_bot_last_error_timestamp_seconds",
+			"unix timestamp of the last error of the bot, the message is available from the fetchKelpErrors endpoint",
+			botLabels,
+			nil,
+		),
+		errorsDesc: prometheus.NewDesc(
+			"kelp_gui_bot_errors_total",
+			"number of errors of the bot by level",
+			append(botLabels, "level"),
+			nil,
+		),
+		alertTriggersDesc: prometheus.NewDesc(
+			"kelp_gui_bot_alert_triggers_total",
+			"number of alerts triggered by the bot since it was started",
+			botLabels,
+			nil,
+		),
+	}
+}
+
+// entry should only be called when holding the lock
+func (m *botMetrics) entry(userID string, botName string) *botStatsEntry {
+	key := botStatsKey{userID: userID, botName: botName}
+	if v, ok := m.statsByBot[key]; ok {
+		return v
+	}
+	v := &botStatsEntry{errorsByLevel: map[errorLevel]uint64{}}
+	m.statsByBot[key] = v
+	return v
+}
+
+// setRunning marks the bot as running or stopped
+func (m *botMetrics) setRunning(userID string, botName string, running bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.entry(userID, botName).running = running
+}
+
+// setStats replaces the stats reported by the bot
+func (m *botMetrics) setStats(userID string, botName string, stats model.BotStats) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.entry(userID, botName).stats = stats
+}
+
+// addError records an error of the bot. The message is not used as a label because it is unbounded, only the level is
+func (m *botMetrics) addError(userID string, botName string, level errorLevel, date time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	e := m.entry(userID, botName)
+	e.errorsByLevel[level]++
+	if date.After(e.lastErrorTime) {
+		e.lastErrorTime = date
+	}
+}
+
+// remove drops all stats of the bot, used when the bot is deleted
+func (m *botMetrics) remove(userID string, botName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.statsByBot, botStatsKey{userID: userID, botName: botName})
+}
+
+// Describe impl.
+func (m *botMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.runningBotsDesc
+	ch <- m.lastCycleDesc
+	ch <- m.lastErrorDesc
+	ch <- m.errorsDesc
+	ch <- m.alertTriggersDesc
+}
+
+// Collect impl.
+func (m *botMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	numRunning := 0
+	for k, v := range m.statsByBot {
+		if v.running {
+			numRunning++
+		}
+		if !v.stats.LastCycleTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(m.lastCycleDesc, prometheus.GaugeValue, float64(v.stats.LastCycleTime.Unix()), k.userID, k.botName)
+		}
+		if !v.lastErrorTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(m.lastErrorDesc, prometheus.GaugeValue, float64(v.lastErrorTime.Unix()), k.userID, k.botName)
+		}
+		for level, count := range v.errorsByLevel {
+			ch <- prometheus.MustNewConstMetric(m.errorsDesc, prometheus.CounterValue, float64(count), k.userID, k.botName, level.String())
+		}
+		ch <- prometheus.MustNewConstMetric(m.alertTriggersDesc, prometheus.CounterValue, float64(v.stats.AlertTriggerCount), k.userID, k.botName)
+	}
+	ch <- prometheus.MustNewConstMetric(m.runningBotsDesc, prometheus.GaugeValue, float64(numRunning))
+}
+
+// RegisterBotStats updates the stats of a bot that are served on the metrics endpoint
+func (s *APIServer) RegisterBotStats(userID string, botName string, stats model.BotStats) {
+	s.botMetrics.setStats(userID, botName, stats)
+}
+
+// readBotStats reads the output of a bot until it is closed and registers the stats that the bot reports in its output
+func (s *APIServer) readBotStats(userID string, botName string, stdout io.Reader) {
+	// use a bufio.Reader instead of a bufio.Scanner because the scanner stops reading on lines that are too long
+	reader := bufio.NewReader(stdout)
+	for {
+		line, e := reader.ReadString('\n')
+		stats, eParse := model.ParseBotStatsLogLine(line)
+		if eParse != nil {
+			log.Printf("could not parse stats reported by bot '%s': %s\n", botName, eParse)
+		} else if stats != nil {
+			s.RegisterBotStats(userID, botName, *stats)
+		}
+
+		if e != nil {
+			if e != io.EOF {
+				log.Printf("stopped reading the output of bot '%s': %s\n", botName, e)
+			}
+			return
+		}
+	}
+}
+
+// metrics serves the health of the bots in the prometheus text format
+func (s *APIServer) metrics(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(s.metricsRegistry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestBotMetrics(t *testing.T) {
+	m := makeBotMetrics()
+	registry := prometheus.NewRegistry()
+	if !assert.NoError(t, registry.Register(m)) {
+		return
+	}
+	s := &APIServer{botMetrics: m, metricsRegistry: registry}
+
+	m.setRunning("user1", "bot_a", true)
+	m.setRunning("user1", "bot_b", true)
+	m.setRunning("user1", "bot_b", false)
+	statsLine, e := model.BotStats{LastCycleTime: time.Unix(1600000000, 0), AlertTriggerCount: 3}.LogLine()
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the output of the bot is read until it is closed, lines without stats and lines that cannot be parsed are skipped
+	s.readBotStats("user1", "bot_a", strings.NewReader(strings.Join([]string{
+		"2020/09/13 12:26:40 time taken for update loop: 1200 millis",
+		"2020/09/13 12:26:40 kelp_bot_stats={not json",
+		statsLine,
+	}, "\n")))
+	m.addError("user1", "bot_b", errorLevelError, time.Unix(1600000100, 0))
+	m.addError("user1", "bot_b", errorLevelError, time.Unix(1600000050, 0))
+	m.addError("user1", "bot_b", errorLevelWarning, time.Unix(1600000010, 0))
+	m.setRunning("user1", "bot_c", true)
+	m.remove("user1", "bot_c")
+
+	w := httptest.NewRecorder()
+	s.metrics(w, httptest.NewRequest("GET", "/api/v1/metrics", nil))
+	if !assert.Equal(t, http.StatusOK, w.Code) {
+		return
+	}
+	body := w.Body.String()
+
+	wantLines := []string{
+		`kelp_gui_running_bots 1`,
+		`kelp_gui_bot_last_cycle_timestamp_seconds{bot_name="bot_a",user_id="user1"} 1.6e+09`,
+		`kelp_gui_bot_last_error_timestamp_seconds{bot_name="bot_b",user_id="user1"} 1.6000001e+09`,
+		`kelp_gui_bot_errors_total{bot_name="bot_b",level="error",user_id="user1"} 2`,
+		`kelp_gui_bot_errors_total{bot_name="bot_b",level="warning",user_id="user1"} 1`,
+		`kelp_gui_bot_alert_triggers_total{bot_name="bot_a",user_id="user1"} 3`,
+		`kelp_gui_bot_alert_triggers_total{bot_name="bot_b",user_id="user1"} 0`,
+	}
+	for _, line := range wantLines {
+		assert.Contains(t, body, line)
+	}
+	// bots that did not report a cycle do not have a last cycle time
+	assert.False(t, strings.Contains(body, `kelp_gui_bot_last_cycle_timestamp_seconds{bot_name="bot_b"`))
+	// removed bots are not reported
+	assert.False(t, strings.Contains(body, "bot_c"))
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -86,18 +87,18 @@ func (s *APIServer) deleteBot(w http.ResponseWriter, r *http.Request) {
 
 	// unregister bot
 	s.kos.BotDataForUser(req.UserData.toUser()).SafeUnregisterBot(botName)
+	s.botMetrics.remove(req.UserData.ID, botName)
 
 	// delete configs
 	botPrefix := model2.GetPrefix(botName)
-	botConfigPath := s.botConfigsPathForUser(req.UserData.ID).Join(botPrefix)
-	_, e = s.kos.Blocking(req.UserData.ID, "rm", fmt.Sprintf("rm %s*", botConfigPath.Unix()))
+	e = s.removeBotConfigs(req.UserData.ID, botPrefix)
 	if e != nil {
 		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
 			errorTypeBot,
 			botName,
 			time.Now().UTC(),
 			errorLevelError,
-			fmt.Sprintf("could not run rm command for bot configs: %s\n", e),
+			fmt.Sprintf("could not remove bot configs: %s\n", e),
 		))
 		return
 	}
@@ -105,3 +106,32 @@ func (s *APIServer) deleteBot(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// removeBotConfigs deletes the config files of the user that start with the prefix, the files are matched and removed directly
+// instead of going through a shell since the prefix comes from the request
+func (s *APIServer) removeBotConfigs(userID string, botPrefix string) error {
+	e := validatePathElement(botPrefix)
+	if e != nil {
+		return fmt.Errorf("invalid bot prefix: %s", e)
+	}
+
+	filenames, e := s.listBotConfigFilenames(userID)
+	if e != nil {
+		return fmt.Errorf("could not list bot configs: %s", e)
+	}
+	for _, filename := range filenames {
+		if !strings.HasPrefix(filename, botPrefix) {
+			continue
+		}
+
+		configPath, e := s.botConfigFilePathForUser(userID, filename)
+		if e != nil {
+			return fmt.Errorf("could not get path of bot config '%s': %s", filename, e)
+		}
+		e = os.Remove(configPath.Native())
+		if e != nil {
+			return fmt.Errorf("could not remove bot config '%s': %s", filename, e)
+		}
+	}
+	return nil
+}
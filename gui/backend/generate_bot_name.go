@@ -79,15 +79,16 @@ func (s *APIServer) doGenerateBotName(userData UserData) (string, error) {
 }
 
 func (s *APIServer) prefixExists(userData UserData, prefix string) (bool, error) {
-	command := fmt.Sprintf("ls %s | grep %s", s.botConfigsPathForUser(userData.ID).Unix(), prefix)
-	_, e := s.kos.Blocking(userData.ID, "prefix", command)
+	filenames, e := s.listBotConfigFilenames(userData.ID)
 	if e != nil {
-		if strings.Contains(e.Error(), "exit status 1") {
-			return false, nil
-		}
 		return false, fmt.Errorf("error checking for prefix '%s': %s", prefix, e)
 	}
-	return true, nil
+	for _, filename := range filenames {
+		if strings.HasPrefix(filename, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // https://www.ssa.gov/oact/babynames/decades/century.html
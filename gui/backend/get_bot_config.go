@@ -46,7 +46,11 @@ func (s *APIServer) getBotConfig(w http.ResponseWriter, r *http.Request) {
 	botName := req.BotName
 
 	filenamePair := model2.GetBotFilenames(botName, "buysell")
-	traderFilePath := s.botConfigsPathForUser(req.UserData.ID).Join(filenamePair.Trader)
+	traderFilePath, e := s.botConfigFilePathForUser(req.UserData.ID, filenamePair.Trader)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting trader config file path for bot '%s': %s", botName, e))
+		return
+	}
 	var botConfig trader.BotConfig
 	e = config.Read(traderFilePath.Native(), &botConfig)
 	if e != nil {
@@ -59,7 +63,11 @@ func (s *APIServer) getBotConfig(w http.ResponseWriter, r *http.Request) {
 		))
 		return
 	}
-	strategyFilePath := s.botConfigsPathForUser(req.UserData.ID).Join(filenamePair.Strategy)
+	strategyFilePath, e := s.botConfigFilePathForUser(req.UserData.ID, filenamePair.Strategy)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting strategy config file path for bot '%s': %s", botName, e))
+		return
+	}
 	var buysellConfig plugins.BuySellConfig
 	e = config.Read(strategyFilePath.Native(), &buysellConfig)
 	if e != nil {
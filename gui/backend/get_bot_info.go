@@ -87,7 +87,11 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, userData UserData
 	}
 
 	filenamePair := model2.GetBotFilenames(botName, buysell)
-	traderFilePath := s.botConfigsPathForUser(userData.ID).Join(filenamePair.Trader)
+	traderFilePath, e := s.botConfigFilePathForUser(userData.ID, filenamePair.Trader)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting trader config file path for bot '%s': %s", botName, e))
+		return
+	}
 	var botConfig trader.BotConfig
 	e = config.Read(traderFilePath.Native(), &botConfig)
 	if e != nil {
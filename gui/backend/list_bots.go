@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/stellar/kelp/gui/model2"
@@ -53,14 +54,12 @@ func (s *APIServer) listBots(w http.ResponseWriter, r *http.Request) {
 
 func (s *APIServer) doListBots(userData UserData) ([]model2.Bot, error) {
 	bots := []model2.Bot{}
-	resultBytes, e := s.kos.Blocking(userData.ID, "ls", fmt.Sprintf("ls %s | sort", s.botConfigsPathForUser(userData.ID).Unix()))
+	files, e := s.listBotConfigFilenames(userData.ID)
 	if e != nil {
 		return bots, fmt.Errorf("error when listing bots: %s", e)
 	}
-	configFiles := string(resultBytes)
-	files := strings.Split(configFiles, "\n")
 
-	// run till one less than length of files because the last name will end in a newline
+	// the strategy config of a bot sorts before its trader config
 	for i := 0; i < len(files)-1; i += 2 {
 		bot := model2.FromFilenames(files[i+1], files[i])
 		bots = append(bots, *bot)
@@ -83,3 +82,32 @@ func (s *APIServer) doListBots(userData UserData) ([]model2.Bot, error) {
 
 	return bots, nil
 }
+
+// listBotConfigFilenames returns the sorted filenames in the configs directory of the user, hidden files and directories are skipped.
+// The directory is read directly instead of going through a shell so the userID cannot be used to inject commands
+func (s *APIServer) listBotConfigFilenames(userID string) ([]string, error) {
+	e := validatePathElement(userID)
+	if e != nil {
+		return nil, fmt.Errorf("invalid userID: %s", e)
+	}
+
+	dirPath := s.botConfigsPathForUser(userID)
+	fileInfos, e := ioutil.ReadDir(dirPath.Native())
+	if e != nil {
+		if os.IsNotExist(e) {
+			// the directory is only created when the first bot is saved
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("could not read the configs directory %s: %s", dirPath.AsString(), e)
+	}
+
+	// ioutil.ReadDir returns the entries sorted by filename
+	filenames := []string{}
+	for _, fi := range fileInfos {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		filenames = append(filenames, fi.Name())
+	}
+	return filenames, nil
+}
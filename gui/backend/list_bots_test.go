@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+func TestBotConfigFiles(t *testing.T) {
+	dir, e := ioutil.TempDir("", "bot_configs")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	basePath, e := kelpos.MakeOsPathBase()
+	if !assert.NoError(t, e) {
+		return
+	}
+	configsPath, e := basePath.MakeFromNativePath(dir)
+	if !assert.NoError(t, e) {
+		return
+	}
+	s := &APIServer{botConfigsPath: configsPath}
+
+	// the configs directory of the user does not exist until the first bot is saved
+	filenames, e := s.listBotConfigFilenames("user1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{}, filenames)
+
+	userDir := filepath.Join(dir, "user1")
+	if !assert.NoError(t, os.MkdirAll(filepath.Join(userDir, "subdir"), 0755)) {
+		return
+	}
+	for _, filename := range []string{
+		"mary_the_calm_whale__trader.cfg",
+		"mary_the_calm_whale__strategy_buysell.cfg",
+		"john_the_brave_shark__trader.cfg",
+		"john_the_brave_shark__strategy_sell.cfg",
+		".hidden",
+	} {
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(userDir, filename), []byte{}, 0644)) {
+			return
+		}
+	}
+
+	filenames, e = s.listBotConfigFilenames("user1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{
+		"john_the_brave_shark__strategy_sell.cfg",
+		"john_the_brave_shark__trader.cfg",
+		"mary_the_calm_whale__strategy_buysell.cfg",
+		"mary_the_calm_whale__trader.cfg",
+	}, filenames)
+
+	exists, e := s.prefixExists(UserData{ID: "user1"}, "mary")
+	assert.NoError(t, e)
+	assert.True(t, exists)
+	exists, e = s.prefixExists(UserData{ID: "user1"}, "patricia")
+	assert.NoError(t, e)
+	assert.False(t, exists)
+
+	// shell syntax in the prefix is matched literally
+	assert.NoError(t, s.removeBotConfigs("user1", "mary_the_calm_whale; rm -rf $(pwd)"))
+	filenames, e = s.listBotConfigFilenames("user1")
+	assert.NoError(t, e)
+	assert.Equal(t, 4, len(filenames))
+
+	assert.NoError(t, s.removeBotConfigs("user1", "mary_the_calm_whale"))
+	filenames, e = s.listBotConfigFilenames("user1")
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"john_the_brave_shark__strategy_sell.cfg", "john_the_brave_shark__trader.cfg"}, filenames)
+
+	assert.Error(t, s.removeBotConfigs("user1", "../user2"))
+	_, e = s.listBotConfigFilenames("../user2")
+	assert.Error(t, e)
+}
@@ -22,6 +22,9 @@ func SetRoutes(r *chi.Mux, s *APIServer) {
 		if s.guiConfig.Auth0Config != nil && s.guiConfig.Auth0Config.Auth0Enabled {
 			// setting the router to use the JWT middleware to handle auth0 style JWT tokens
 			router = r.With(JWTMiddlewareVar.Handler)
+		} else if s.guiConfig.NeedsBearerToken() {
+			// requests need to include the shared secret from the GUI config as a bearer token
+			router = r.With(BearerTokenMiddleware(s.guiConfig.BearerToken))
 		}
 
 		router.Post("/listBots", http.HandlerFunc(s.listBots))
@@ -36,9 +39,11 @@ func SetRoutes(r *chi.Mux, s *APIServer) {
 		router.Post("/getState", http.HandlerFunc(s.getBotState))
 		router.Post("/getBotInfo", http.HandlerFunc(s.getBotInfo))
 		router.Post("/getBotConfig", http.HandlerFunc(s.getBotConfig))
+		router.Post("/selfTest", http.HandlerFunc(s.selfTest))
 		router.Post("/fetchPrice", http.HandlerFunc(s.fetchPrice))
 		router.Post("/upsertBotConfig", http.HandlerFunc(s.upsertBotConfig))
 		router.Post("/sendMetricEvent", http.HandlerFunc(s.sendMetricEvent))
+		router.Get("/metrics", http.HandlerFunc(s.metrics))
 	})
 	r.Get("/ping", http.HandlerFunc(s.ping))
 }
\ No newline at end of file
@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/trader"
+)
+
+// names of the checks in the self-test report
+const (
+	selfTestCheckExchangeReachable = "exchange_reachable"
+	selfTestCheckTradingPair       = "trading_pair_exists"
+	selfTestCheckAPIKey            = "api_key_authenticates"
+)
+
+// ccxtTradingExchangePrefix is the prefix of the TRADING_EXCHANGE values that are traded via CCXT
+const ccxtTradingExchangePrefix = "ccxt-"
+
+// selfTestInstanceNamePrefix is the prefix of the CCXT instance used by the self-test. The instance name is otherwise derived from the API key
+// so the self-test would share (and delete when it is closed) the instance used by a running bot with the same API key
+const selfTestInstanceNamePrefix = "selftest"
+
+type selfTestRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+}
+
+// selfTestCheck is the result of a single check, a skipped check did not run because it does not apply or an earlier check failed
+type selfTestCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped"`
+	Message string `json:"message"`
+}
+
+// selfTestReport is the result of all the checks for a bot, it passes when none of the checks that ran failed
+type selfTestReport struct {
+	BotName     string          `json:"bot_name"`
+	Exchange    string          `json:"exchange"`
+	TradingPair string          `json:"trading_pair"`
+	Passed      bool            `json:"passed"`
+	Checks      []selfTestCheck `json:"checks"`
+}
+
+// selfTestExchange is the part of the CCXT SDK used by the self-test
+type selfTestExchange interface {
+	FetchStatus() (sdk.CcxtStatus, error)
+	SymbolExists(tradingPair string) error
+	FetchBalance() (map[string]sdk.CcxtBalance, error)
+}
+
+// ensure that the CCXT SDK can be used for the self-test
+var _ selfTestExchange = &sdk.Ccxt{}
+
+func (s *APIServer) selfTest(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req selfTestRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+	botName := req.BotName
+
+	report, e := s.doSelfTest(req.UserData, botName)
+	if e != nil {
+		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			botName,
+			time.Now().UTC(),
+			errorLevelError,
+			fmt.Sprintf("unable to run self-test: %s\n", e),
+		))
+		return
+	}
+	s.writeJson(w, report)
+}
+
+// doSelfTest checks that the exchange of the bot is reachable, that the trading pair exists and that the API key authenticates, so
+// misconfiguration is reported before the bot is started. The returned error is only for failures to read the config of the bot
+func (s *APIServer) doSelfTest(userData UserData, botName string) (*selfTestReport, error) {
+	filenamePair := model2.GetBotFilenames(botName, "buysell")
+	traderFilePath, e := s.botConfigFilePathForUser(userData.ID, filenamePair.Trader)
+	if e != nil {
+		return nil, fmt.Errorf("error getting trader config file path for bot '%s': %s", botName, e)
+	}
+	var botConfig trader.BotConfig
+	e = config.Read(traderFilePath.Native(), &botConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath, e)
+	}
+
+	report := &selfTestReport{
+		BotName:     botName,
+		Exchange:    botConfig.TradingExchangeName(),
+		TradingPair: botConfig.TradingPair(),
+	}
+	if !strings.HasPrefix(botConfig.TradingExchange, ccxtTradingExchangePrefix) {
+		report.Checks = skipSelfTestChecks(fmt.Sprintf("the self-test only checks exchanges traded via CCXT, not '%s'", report.Exchange))
+		report.Passed = true
+		return report, nil
+	}
+
+	apiKeys := botConfig.ExchangeAPIKeys.ToExchangeAPIKeys()
+	if len(apiKeys) != 1 {
+		return nil, fmt.Errorf("need exactly 1 ExchangeAPIKey in the config of bot '%s' but found %d", botName, len(apiKeys))
+	}
+	exchangeName := strings.TrimPrefix(botConfig.TradingExchange, ccxtTradingExchangePrefix)
+	c, e := sdk.MakeInitializedCcxtExchange(
+		exchangeName,
+		apiKeys[0],
+		botConfig.ExchangeParams.ToExchangeParams(),
+		botConfig.ExchangeHeaders.ToExchangeHeaders(),
+		sdk.WithInstanceNamePrefix(selfTestInstanceNamePrefix),
+	)
+	if e != nil {
+		report.Checks = unreachableSelfTestChecks(fmt.Sprintf("could not connect to exchange '%s': %s", exchangeName, e))
+		return report, nil
+	}
+	defer func() {
+		if e := c.Close(); e != nil {
+			log.Printf("error closing the CCXT instance used by the self-test of bot '%s': %s\n", botName, e)
+		}
+	}()
+
+	report.Checks = runSelfTestChecks(c, report.TradingPair, hasAPIKey(apiKeys[0]))
+	report.Passed = selfTestPassed(report.Checks)
+	log.Printf("self-test report for bot '%s': %+v\n", botName, *report)
+	return report, nil
+}
+
+// runSelfTestChecks runs the checks in order, the remaining checks are skipped when the exchange is not reachable
+func runSelfTestChecks(c selfTestExchange, tradingPair string, checkAPIKey bool) []selfTestCheck {
+	status, e := c.FetchStatus()
+	if e != nil {
+		return unreachableSelfTestChecks(fmt.Sprintf("could not fetch the status of the exchange: %s", e))
+	}
+	if !status.IsOK() {
+		return unreachableSelfTestChecks(fmt.Sprintf("the exchange reported the status '%s'", status.Status))
+	}
+	checks := []selfTestCheck{{Name: selfTestCheckExchangeReachable, Passed: true}}
+
+	if e := c.SymbolExists(tradingPair); e != nil {
+		checks = append(checks, selfTestCheck{Name: selfTestCheckTradingPair, Message: e.Error()})
+	} else {
+		checks = append(checks, selfTestCheck{Name: selfTestCheckTradingPair, Passed: true})
+	}
+
+	if !checkAPIKey {
+		checks = append(checks, selfTestCheck{Name: selfTestCheckAPIKey, Skipped: true, Message: "no API key is configured"})
+	} else if _, e := c.FetchBalance(); e != nil {
+		checks = append(checks, selfTestCheck{Name: selfTestCheckAPIKey, Message: fmt.Sprintf("could not fetch the balance with the API key: %s", e)})
+	} else {
+		checks = append(checks, selfTestCheck{Name: selfTestCheckAPIKey, Passed: true})
+	}
+	return checks
+}
+
+// skipSelfTestChecks returns all the checks as skipped with the same reason
+func skipSelfTestChecks(reason string) []selfTestCheck {
+	checks := []selfTestCheck{}
+	for _, name := range []string{selfTestCheckExchangeReachable, selfTestCheckTradingPair, selfTestCheckAPIKey} {
+		checks = append(checks, selfTestCheck{Name: name, Skipped: true, Message: reason})
+	}
+	return checks
+}
+
+// unreachableSelfTestChecks returns the exchange check as failed with the message and skips the remaining checks
+func unreachableSelfTestChecks(message string) []selfTestCheck {
+	checks := skipSelfTestChecks("the exchange is not reachable")
+	checks[0] = selfTestCheck{Name: selfTestCheckExchangeReachable, Message: message}
+	return checks
+}
+
+// selfTestPassed returns true when none of the checks that ran failed
+func selfTestPassed(checks []selfTestCheck) bool {
+	for _, c := range checks {
+		if !c.Skipped && !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAPIKey returns true when the key has a value, exchanges that only use public endpoints are configured with an empty key
+func hasAPIKey(apiKey api.ExchangeAPIKey) bool {
+	return strings.TrimSpace(apiKey.Key) != ""
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/sdk"
+)
+
+type fakeSelfTestExchange struct {
+	status        sdk.CcxtStatus
+	statusError   error
+	symbolError   error
+	balanceError  error
+	fetchedStatus bool
+}
+
+func (f *fakeSelfTestExchange) FetchStatus() (sdk.CcxtStatus, error) {
+	f.fetchedStatus = true
+	return f.status, f.statusError
+}
+
+func (f *fakeSelfTestExchange) SymbolExists(tradingPair string) error {
+	return f.symbolError
+}
+
+func (f *fakeSelfTestExchange) FetchBalance() (map[string]sdk.CcxtBalance, error) {
+	return map[string]sdk.CcxtBalance{}, f.balanceError
+}
+
+func TestRunSelfTestChecks(t *testing.T) {
+	okStatus := sdk.CcxtStatus{Status: sdk.CcxtStatusOK}
+	testCases := []struct {
+		name        string
+		exchange    *fakeSelfTestExchange
+		checkAPIKey bool
+		wantPassed  []bool
+		wantSkipped []bool
+		wantResult  bool
+	}{
+		{
+			name:        "all checks pass",
+			exchange:    &fakeSelfTestExchange{status: okStatus},
+			checkAPIKey: true,
+			wantPassed:  []bool{true, true, true},
+			wantSkipped: []bool{false, false, false},
+			wantResult:  true,
+		}, {
+			name:        "exchange not reachable",
+			exchange:    &fakeSelfTestExchange{statusError: fmt.Errorf("connection refused")},
+			checkAPIKey: true,
+			wantPassed:  []bool{false, false, false},
+			wantSkipped: []bool{false, true, true},
+			wantResult:  false,
+		}, {
+			name:        "exchange in maintenance",
+			exchange:    &fakeSelfTestExchange{status: sdk.CcxtStatus{Status: sdk.CcxtStatusMaintenance}},
+			checkAPIKey: true,
+			wantPassed:  []bool{false, false, false},
+			wantSkipped: []bool{false, true, true},
+			wantResult:  false,
+		}, {
+			name:        "trading pair does not exist",
+			exchange:    &fakeSelfTestExchange{status: okStatus, symbolError: fmt.Errorf("trading pair 'XLM/ABC' does not exist")},
+			checkAPIKey: true,
+			wantPassed:  []bool{true, false, true},
+			wantSkipped: []bool{false, false, false},
+			wantResult:  false,
+		}, {
+			name:        "api key does not authenticate",
+			exchange:    &fakeSelfTestExchange{status: okStatus, balanceError: fmt.Errorf("invalid api key")},
+			checkAPIKey: true,
+			wantPassed:  []bool{true, true, false},
+			wantSkipped: []bool{false, false, false},
+			wantResult:  false,
+		}, {
+			name:        "no api key",
+			exchange:    &fakeSelfTestExchange{status: okStatus, balanceError: fmt.Errorf("invalid api key")},
+			checkAPIKey: false,
+			wantPassed:  []bool{true, true, false},
+			wantSkipped: []bool{false, false, true},
+			wantResult:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			checks := runSelfTestChecks(k.exchange, "XLM/USDT", k.checkAPIKey)
+			if !assert.Equal(t, 3, len(checks)) {
+				return
+			}
+			assert.True(t, k.exchange.fetchedStatus)
+			for i, c := range checks {
+				assert.Equal(t, k.wantPassed[i], c.Passed, c.Name)
+				assert.Equal(t, k.wantSkipped[i], c.Skipped, c.Name)
+				if !c.Passed {
+					assert.NotEmpty(t, c.Message, c.Name)
+				}
+			}
+			assert.Equal(t, []string{selfTestCheckExchangeReachable, selfTestCheckTradingPair, selfTestCheckAPIKey}, []string{checks[0].Name, checks[1].Name, checks[2].Name})
+			assert.Equal(t, k.wantResult, selfTestPassed(checks))
+		})
+	}
+}
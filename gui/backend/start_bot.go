@@ -3,6 +3,7 @@ package backend
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -94,12 +95,25 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 	// use relative paths, which is why it seems to work
 	// Note that /mnt/c is unlikely to be valid in windows (but is valid in the linux subsystem) since it's usually prefixed by the
 	// volume (C:\ etc.), which is why relative paths works so well here as it avoids this confusion.
-	traderRelativeConfigPath, e := s.botConfigsPathForUser(userData.ID).Join(filenamePair.Trader).RelFromPath(s.kos.GetDotKelpWorkingDir())
+	traderFilePath, e := s.botConfigFilePathForUser(userData.ID, filenamePair.Trader)
+	if e != nil {
+		return fmt.Errorf("unable to get path of trader config file: %s", e)
+	}
+	strategyFilePath, e := s.botConfigFilePathForUser(userData.ID, filenamePair.Strategy)
+	if e != nil {
+		return fmt.Errorf("unable to get path of strategy config file: %s", e)
+	}
+	e = validatePathElement(logPrefix)
+	if e != nil {
+		return fmt.Errorf("invalid log prefix: %s", e)
+	}
+
+	traderRelativeConfigPath, e := traderFilePath.RelFromPath(s.kos.GetDotKelpWorkingDir())
 	if e != nil {
 		return fmt.Errorf("unable to get relative path of trader config file from basepath: %s", e)
 	}
 
-	stratRelativeConfigPath, e := s.botConfigsPathForUser(userData.ID).Join(filenamePair.Strategy).RelFromPath(s.kos.GetDotKelpWorkingDir())
+	stratRelativeConfigPath, e := strategyFilePath.RelFromPath(s.kos.GetDotKelpWorkingDir())
 	if e != nil {
 		return fmt.Errorf("unable to get relative path of strategy config file from basepath: %s", e)
 	}
@@ -111,10 +125,9 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 
 	// prevent starting pubnet bots if pubnet is disabled
 	var botConfig trader.BotConfig
-	traderLoadReadPath := s.botConfigsPathForUser(userData.ID).Join(filenamePair.Trader)
-	e = config.Read(traderLoadReadPath.Native(), &botConfig)
+	e = config.Read(traderFilePath.Native(), &botConfig)
 	if e != nil {
-		return fmt.Errorf("cannot read bot config at path '%s': %s", traderLoadReadPath.Native(), e)
+		return fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath.Native(), e)
 	}
 	isPubnetBot := botConfig.IsTradingSdex() && strings.TrimSuffix(botConfig.HorizonURL, "/") == strings.TrimSuffix(s.apiPubNet.HorizonURL, "/")
 	if s.disablePubnet && isPubnetBot {
@@ -127,26 +140,27 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 	if s.enableKaas {
 		triggerMode = constants.TriggerKaas
 	}
-	command := fmt.Sprintf("trade -c %s -s %s -f %s -l %s --trigger %s --gui-user-id %s",
-		traderRelativeConfigPath.Unix(),
-		strategy,
-		stratRelativeConfigPath.Unix(),
-		logRelativePrefixPath.Unix(),
-		triggerMode,
-		userData.ID,
-	)
+	args := []string{
+		"trade",
+		"-c", traderRelativeConfigPath.Unix(),
+		"-s", strategy,
+		"-f", stratRelativeConfigPath.Unix(),
+		"-l", logRelativePrefixPath.Unix(),
+		"--trigger", triggerMode,
+		"--gui-user-id", userData.ID,
+	}
 	if iterations != nil {
-		command = fmt.Sprintf("%s --iter %d", command, *iterations)
+		args = append(args, "--iter", fmt.Sprintf("%d", *iterations))
 	}
 	if s.noHeaders {
-		command = fmt.Sprintf("%s --no-headers", command)
+		args = append(args, "--no-headers")
 	}
 	if s.ccxtRestUrl != "" {
-		command = fmt.Sprintf("%s --ccxt-rest-url %s", command, s.ccxtRestUrl)
+		args = append(args, "--ccxt-rest-url", s.ccxtRestUrl)
 	}
-	log.Printf("run command for bot '%s': %s\n", botName, command)
+	log.Printf("run command for bot '%s' with args: %v\n", botName, args)
 
-	p, e := s.runKelpCommandBackground(userData.ID, botName, command)
+	p, e := s.runKelpCommandBackground(userData.ID, botName, args...)
 	if e != nil {
 		return fmt.Errorf("could not start bot %s: %s", botName, e)
 	}
@@ -155,9 +169,13 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 		return fmt.Errorf("kelpCommand (p.Cmd) was nil for bot '%s' with strategy '%s'", botName, strategy)
 	}
 
-	go func(kelpCommand *exec.Cmd, name string) {
+	s.botMetrics.setRunning(userData.ID, botName, true)
+	go func(kelpCommand *exec.Cmd, stdout io.Reader, name string) {
 		defer s.kos.SafeUnregister(userData.ID, name)
+		defer s.botMetrics.setRunning(userData.ID, name, false)
 
+		// the output needs to be read until the bot exits before calling Wait, this also keeps the bot from blocking on a full pipe
+		s.readBotStats(userData.ID, name, stdout)
 		e := kelpCommand.Wait()
 		if e != nil {
 			if strings.Contains(e.Error(), "signal: killed") {
@@ -184,7 +202,7 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 		if maybeFinishCallback != nil {
 			maybeFinishCallback()
 		}
-	}(p.Cmd, botName)
+	}(p.Cmd, p.Stdout, botName)
 
 	return nil
 }
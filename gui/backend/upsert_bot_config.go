@@ -106,7 +106,11 @@ func (s *APIServer) upsertBotConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filenamePair := model2.GetBotFilenames(req.Name, req.Strategy)
-	traderFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Trader)
+	traderFilePath, e := s.botConfigFilePathForUser(userID, filenamePair.Trader)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting trader config file path for bot '%s': %s", req.Name, e))
+		return
+	}
 	botConfig := req.TraderConfig
 	log.Printf("upsert bot config to file: %s\n", traderFilePath.AsString())
 	e = toml.WriteFile(traderFilePath.Native(), &botConfig)
@@ -115,7 +119,11 @@ func (s *APIServer) upsertBotConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	strategyFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Strategy)
+	strategyFilePath, e := s.botConfigFilePathForUser(userID, filenamePair.Strategy)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting strategy config file path for bot '%s': %s", req.Name, e))
+		return
+	}
 	strategyConfig := req.StrategyConfig
 	log.Printf("upsert strategy config to file: %s\n", strategyFilePath.AsString())
 	e = toml.WriteFile(strategyFilePath.Native(), &strategyConfig)
@@ -2,6 +2,14 @@ import fetchIntercept from 'fetch-intercept';
 
 /* this file is not referenced anywhere but still being used because its registering interceptor on javascript fetch function globally */
 
+// when auth0 is not enabled the server opens the GUI with the bearer token in the 'token' query param (it is also logged on startup),
+// store it so it is sent on every request and remove it from the address bar
+const tokenParam = new URLSearchParams(window.location.search).get('token');
+if (tokenParam) {
+    localStorage.setItem('accessToken', tokenParam);
+    window.history.replaceState(null, '', window.location.pathname + window.location.hash);
+}
+
 let AccessToken = localStorage.getItem('accessToken');
 
 export const interceptor = fetchIntercept.register({
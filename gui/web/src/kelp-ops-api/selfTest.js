@@ -0,0 +1,13 @@
+import getUserData from "./getUserData";
+
+export default (baseUrl, botName) => {
+    return fetch(baseUrl + "/api/v1/selfTest", {
+        method: "POST",
+        body: JSON.stringify({
+            user_data: getUserData(),
+            bot_name: botName,
+        }),
+    }).then(resp => {
+        return resp.json();
+    });
+};
\ No newline at end of file
@@ -27,8 +27,20 @@ const SqlTradesIndexCreate3 = "CREATE UNIQUE INDEX IF NOT EXISTS trades_amt ON t
 // SqlMarketsInsertTemplate inserts into the markets table
 const SqlMarketsInsertTemplate = "INSERT INTO markets (market_id, exchange_name, base, quote) VALUES ('%s', '%s', '%s', '%s')"
 
+// SqlTradesInsertPrefix is the start of an insert into the trades table, it is followed by one or more comma separated SqlTradesValuesTemplate
+const SqlTradesInsertPrefix = "INSERT INTO trades (market_id, txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, account_id, order_id) VALUES "
+
+// SqlTradesValuesTemplate is the values of a single row inserted into the trades table
+const SqlTradesValuesTemplate = "('%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f, %.15f, '%s', '%s')"
+
+// SqlTradesIgnoreDuplicateSuffix makes an insert into the trades table do nothing for the rows where the trade (market_id, txid) already exists
+const SqlTradesIgnoreDuplicateSuffix = " ON CONFLICT (market_id, txid) DO NOTHING"
+
 // SqlTradesInsertTemplate inserts into the trades table
-const SqlTradesInsertTemplate = "INSERT INTO trades (market_id, txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, account_id, order_id) VALUES ('%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f, %.15f, '%s', '%s')"
+const SqlTradesInsertTemplate = SqlTradesInsertPrefix + SqlTradesValuesTemplate
+
+// SqlTradesInsertIgnoreDuplicateTemplate inserts into the trades table and does nothing if the trade (market_id, txid) already exists
+const SqlTradesInsertIgnoreDuplicateTemplate = SqlTradesInsertTemplate + SqlTradesIgnoreDuplicateSuffix
 
 // SqlStrategyMirrorTradeTriggersInsertTemplate inserts into the strategy_mirror_trade_triggers table
 const SqlStrategyMirrorTradeTriggersInsertTemplate = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id) VALUES ('%s', '%s', '%s', '%s')"
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// botStatsLogMarker marks the log line that a bot started from the GUI writes at the end of every update cycle
+const botStatsLogMarker = "kelp_bot_stats="
+
+// BotStats are the stats that a running bot reports to the GUI backend
+type BotStats struct {
+	LastCycleTime     time.Time `json:"last_cycle_time"`
+	AlertTriggerCount uint64    `json:"alert_trigger_count"` // total number of alerts triggered since the bot was started
+}
+
+// LogLine returns the line the bot logs to report its stats, this is read by the GUI backend from the output of the bot
+func (s BotStats) LogLine() (string, error) {
+	statsBytes, e := json.Marshal(s)
+	if e != nil {
+		return "", fmt.Errorf("could not marshal bot stats: %s", e)
+	}
+	return botStatsLogMarker + string(statsBytes), nil
+}
+
+// ParseBotStatsLogLine returns the stats in a line logged by the bot, or nil if the line does not contain any stats.
+// The line can have any prefix (such as the timestamp added by the logger)
+func ParseBotStatsLogLine(line string) (*BotStats, error) {
+	idx := strings.Index(line, botStatsLogMarker)
+	if idx == -1 {
+		return nil, nil
+	}
+
+	var stats BotStats
+	e := json.Unmarshal([]byte(strings.TrimSpace(line[idx+len(botStatsLogMarker):])), &stats)
+	if e != nil {
+		return nil, fmt.Errorf("could not unmarshal bot stats from line '%s': %s", line, e)
+	}
+	return &stats, nil
+}
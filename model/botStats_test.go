@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotStatsLogLine(t *testing.T) {
+	stats := BotStats{
+		LastCycleTime:     time.Unix(1600000000, 0).UTC(),
+		AlertTriggerCount: 3,
+	}
+	line, e := stats.LogLine()
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		line      string
+		wantStats *BotStats
+		wantErr   bool
+	}{
+		{
+			line:      line,
+			wantStats: &stats,
+		}, {
+			// the logger adds a timestamp before the line
+			line:      "2020/09/13 12:26:40 " + line + "\n",
+			wantStats: &stats,
+		}, {
+			line:      "2020/09/13 12:26:40 time taken for update loop: 1200 millis",
+			wantStats: nil,
+		}, {
+			line:    "2020/09/13 12:26:40 kelp_bot_stats={not json",
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.line, func(t *testing.T) {
+			parsed, e := ParseBotStatsLogLine(k.line)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantStats, parsed)
+		})
+	}
+}
@@ -242,7 +242,8 @@ type Trade struct {
 	TransactionID *TransactionID
 	OrderID       string
 	Cost          *Number
-	Fee           *Number
+	Fee           *Number // can be nil when the exchange does not report the fee
+	FeeAsset      *Asset  // can be nil when the exchange does not report the asset in which the fee was paid
 }
 
 // TradesByTsID implements sort.Interface for []Trade based on Timestamp and TransactionID
@@ -271,7 +272,11 @@ func (t TradesByTsID) Less(i int, j int) bool {
 }
 
 func (t Trade) String() string {
-	return fmt.Sprintf("Trade[txid: %s, orderId: %s, ts: %s, pair: %s, action: %s, type: %s, counterPrice: %s, baseVolume: %s, counterCost: %s, fee: %s]",
+	feeAsset := "<nil>"
+	if t.FeeAsset != nil {
+		feeAsset = string(*t.FeeAsset)
+	}
+	return fmt.Sprintf("Trade[txid: %s, orderId: %s, ts: %s, pair: %s, action: %s, type: %s, counterPrice: %s, baseVolume: %s, counterCost: %s, fee: %s, feeAsset: %s]",
 		utils.CheckedString(t.TransactionID),
 		t.OrderID,
 		utils.CheckedString(t.Timestamp),
@@ -282,6 +287,7 @@ func (t Trade) String() string {
 		utils.CheckedString(t.Volume),
 		utils.CheckedString(t.Cost),
 		utils.CheckedString(t.Fee),
+		feeAsset,
 	)
 }
 
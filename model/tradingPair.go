@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 )
 
 // TradingPair lists an ordered pair that is understood by the bot and our exchange API.
@@ -46,6 +47,34 @@ func (p TradingPair) ToString(c AssetConverterInterface, delim string) (string,
 	return a + delim + b, nil
 }
 
+// ccxtSymbolDelimiter separates the base and quote assets in a CCXT symbol
+const ccxtSymbolDelimiter = "/"
+
+// ToCcxtSymbol converts the trading pair to the CCXT version of the trading pair (the symbol), such as "XLM/USDT"
+func ToCcxtSymbol(p TradingPair) string {
+	// the CcxtAssetConverter never returns an error
+	s, _ := p.ToString(CcxtAssetConverter, ccxtSymbolDelimiter)
+	return s
+}
+
+// FromCcxtSymbol converts the CCXT version of the trading pair (the symbol), such as "XLM/USDT", to a TradingPair
+func FromCcxtSymbol(symbol string) (TradingPair, error) {
+	parts := strings.Split(symbol, ccxtSymbolDelimiter)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return TradingPair{}, fmt.Errorf("invalid CCXT symbol '%s', expected the format BASE%sQUOTE", symbol, ccxtSymbolDelimiter)
+	}
+
+	base, e := CcxtAssetConverter.FromString(parts[0])
+	if e != nil {
+		return TradingPair{}, fmt.Errorf("could not convert base asset of CCXT symbol '%s': %s", symbol, e)
+	}
+	quote, e := CcxtAssetConverter.FromString(parts[1])
+	if e != nil {
+		return TradingPair{}, fmt.Errorf("could not convert quote asset of CCXT symbol '%s': %s", symbol, e)
+	}
+	return TradingPair{Base: base, Quote: quote}, nil
+}
+
 // TradingPairFromString makes a TradingPair out of a string
 func TradingPairFromString(codeSize int8, c AssetConverterInterface, p string) (*TradingPair, error) {
 	return TradingPairFromString2(codeSize, []AssetConverterInterface{c}, p)
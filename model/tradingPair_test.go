@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCcxtSymbol(t *testing.T) {
+	testCases := []struct {
+		pair       TradingPair
+		wantSymbol string
+	}{
+		{
+			pair:       TradingPair{Base: XLM, Quote: USDT},
+			wantSymbol: "XLM/USDT",
+		}, {
+			// the inverted pair
+			pair:       TradingPair{Base: USDT, Quote: XLM},
+			wantSymbol: "USDT/XLM",
+		}, {
+			pair:       TradingPair{Base: ETHBULL, Quote: BTC},
+			wantSymbol: "ETHBULL/BTC",
+		}, {
+			// assets that are not listed in the model are passed through
+			pair:       TradingPair{Base: Asset("1INCH"), Quote: Asset("USDC")},
+			wantSymbol: "1INCH/USDC",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.wantSymbol, func(t *testing.T) {
+			symbol := ToCcxtSymbol(k.pair)
+			assert.Equal(t, k.wantSymbol, symbol)
+
+			// round trip
+			pair, e := FromCcxtSymbol(symbol)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.pair, pair)
+		})
+	}
+}
+
+func TestFromCcxtSymbolInvalid(t *testing.T) {
+	for _, symbol := range []string{"", "XLMUSDT", "XLM-USDT", "XLM/", "/USDT", "XLM/USDT/BTC"} {
+		t.Run(symbol, func(t *testing.T) {
+			_, e := FromCcxtSymbol(symbol)
+			assert.Error(t, e)
+		})
+	}
+}
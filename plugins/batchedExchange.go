@@ -33,6 +33,7 @@ type BatchedExchange struct {
 }
 
 var _ api.ExchangeShim = BatchedExchange{}
+var _ api.InclusiveTimestampCursor = BatchedExchange{}
 
 // MakeBatchedExchange factory
 func MakeBatchedExchange(
@@ -168,6 +169,11 @@ func (b BatchedExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStar
 	return b.inner.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
 }
 
+// UsesInclusiveTimestampCursor impl, this depends on the inner exchange
+func (b BatchedExchange) UsesInclusiveTimestampCursor() bool {
+	return api.UsesInclusiveTimestampCursor(b.inner)
+}
+
 // GetLatestTradeCursor impl
 func (b BatchedExchange) GetLatestTradeCursor() (interface{}, error) {
 	return b.inner.GetLatestTradeCursor()
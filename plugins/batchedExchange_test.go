@@ -48,3 +48,8 @@ func TestManageOffer2Order(t *testing.T) {
 		assert.Equal(t, k.wantAmount, order.Volume.AsFloat())
 	}
 }
+
+func TestUsesInclusiveTimestampCursor(t *testing.T) {
+	assert.True(t, BatchedExchange{inner: ccxtExchange{}}.UsesInclusiveTimestampCursor())
+	assert.False(t, BatchedExchange{inner: &krakenExchange{}}.UsesInclusiveTimestampCursor())
+}
@@ -0,0 +1,224 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+)
+
+// DefaultFillDBFlushInterval is the interval at which the BatchedFillDBWriter flushes the buffered fills when it is not set
+const DefaultFillDBFlushInterval = time.Second
+
+// maxPendingFills is the most fills the BatchedFillDBWriter buffers while the db cannot be written to (unless the batch size is larger),
+// the oldest fills are dropped beyond this so the buffer does not grow without bounds
+const maxPendingFills = 10000
+
+// BatchedFillDBWriter is a FillHandler that buffers fills and writes them to a SQL database using a single multi-row insert, which is
+// much faster than inserting one row per fill when backfilling fills or when recording a burst of trades. The buffer is flushed once it
+// has batchSize fills and every flushInterval, Close needs to be called on shutdown to flush the remaining fills
+type BatchedFillDBWriter struct {
+	writer        *FillDBWriter
+	exec          func(query string, args ...interface{}) (sql.Result, error)
+	batchSize     int
+	maxPending    int
+	flushInterval time.Duration
+	lock          *sync.Mutex
+	pendingTxids  []string
+	pendingValues []string
+	done          chan struct{}
+	wg            *sync.WaitGroup
+	closed        bool
+}
+
+var _ api.FillHandler = &BatchedFillDBWriter{}
+
+// MakeBatchedFillDBWriter is a factory method, it starts the goroutine that flushes the buffered fills every flushInterval
+func MakeBatchedFillDBWriter(
+	db *sql.DB,
+	assetDisplayFn model.AssetDisplayFn,
+	exchangeName string,
+	accountID string,
+	batchSize int,
+	flushInterval time.Duration,
+) (*BatchedFillDBWriter, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size needs to be greater than 0 but was %d", batchSize)
+	}
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("flush interval needs to be greater than 0 but was %s", flushInterval)
+	}
+
+	maxPending := maxPendingFills
+	if batchSize > maxPending {
+		maxPending = batchSize
+	}
+
+	w := &BatchedFillDBWriter{
+		writer: &FillDBWriter{
+			db:             db,
+			assetDisplayFn: assetDisplayFn,
+			exchangeName:   exchangeName,
+			accountID:      accountID,
+		},
+		exec:          db.Exec,
+		batchSize:     batchSize,
+		maxPending:    maxPending,
+		flushInterval: flushInterval,
+		lock:          &sync.Mutex{},
+		pendingTxids:  []string{},
+		pendingValues: []string{},
+		done:          make(chan struct{}),
+		wg:            &sync.WaitGroup{},
+		closed:        false,
+	}
+	w.wg.Add(1)
+	go w.flushPeriodically()
+	return w, nil
+}
+
+// HandleFill impl.
+func (w *BatchedFillDBWriter) HandleFill(trade model.Trade) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("cannot handle fill because the batched fill db writer is closed")
+	}
+
+	txid, values, e := w.writer.makeTradeValues(trade)
+	if e != nil {
+		return fmt.Errorf("cannot make the values of the trade to insert: %s", e)
+	}
+	w.addPending(txid, values)
+
+	if len(w.pendingValues) < w.batchSize {
+		return nil
+	}
+	e = w.flush()
+	if e != nil {
+		return fmt.Errorf("could not flush fills: %s", e)
+	}
+	return nil
+}
+
+// addPending adds the fill to the buffer, dropping the oldest fill if the buffer is full. Needs the lock to be held
+func (w *BatchedFillDBWriter) addPending(txid string, values string) {
+	if len(w.pendingValues) >= w.maxPending {
+		// the db has not been written to for a while, drop the oldest fill so the buffer stays bounded
+		log.Printf("dropping fill (txid=%s) that was not written to db because there are already %d buffered fills\n", w.pendingTxids[0], w.maxPending)
+		w.pendingTxids = w.pendingTxids[1:]
+		w.pendingValues = w.pendingValues[1:]
+	}
+	w.pendingTxids = append(w.pendingTxids, txid)
+	w.pendingValues = append(w.pendingValues, values)
+}
+
+// Flush writes the buffered fills to the database
+func (w *BatchedFillDBWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.flush()
+}
+
+// Close stops the periodic flush and writes the remaining buffered fills to the database, fills handled after Close return an error
+func (w *BatchedFillDBWriter) Close() error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.lock.Unlock()
+
+	// wait outside the lock since the periodic flush needs the lock to finish
+	w.wg.Wait()
+	return w.Flush()
+}
+
+func (w *BatchedFillDBWriter) flushPeriodically() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			e := w.Flush()
+			if e != nil {
+				// the fills are kept in the buffer (up to maxPending) so they are written on the next flush
+				log.Printf("could not flush fills to db, will try again on the next flush: %s\n", e)
+			}
+		}
+	}
+}
+
+// flush writes the buffered fills in a single insert, falling back to one insert per fill if that fails. Needs the lock to be held
+func (w *BatchedFillDBWriter) flush() error {
+	if len(w.pendingValues) == 0 {
+		return nil
+	}
+
+	// the same trade can be handled more than once (eg after a restart with an older cursor) so we ignore duplicates at the db level
+	sqlInsert := makeBatchedTradesInsert(w.pendingValues)
+	result, e := w.exec(sqlInsert)
+	if e != nil {
+		log.Printf("could not execute sql insert values statement for %d trades, inserting the trades one at a time instead: %s\n", len(w.pendingValues), e)
+		return w.flushEach()
+	}
+
+	numTrades := len(w.pendingValues)
+	rowsAffected, e := result.RowsAffected()
+	if e == nil {
+		log.Printf("wrote %d trades to db in a single insert, ignored %d trades that were already in the db (txids=%v)\n", rowsAffected, int64(numTrades)-rowsAffected, w.pendingTxids)
+	} else {
+		log.Printf("wrote %d trades to db in a single insert (txids=%v)\n", numTrades, w.pendingTxids)
+	}
+	w.pendingTxids = []string{}
+	w.pendingValues = []string{}
+	return nil
+}
+
+// flushEach writes the buffered fills with one insert per fill so a single fill that cannot be inserted does not block the others. Fills
+// that fail are dropped as long as any other fill was written, otherwise the db is most likely unreachable so all the fills are kept in
+// the buffer to be written on the next flush. Needs the lock to be held
+func (w *BatchedFillDBWriter) flushEach() error {
+	errorsByIndex := map[int]error{}
+	for i, values := range w.pendingValues {
+		sqlInsert := makeBatchedTradesInsert([]string{values})
+		_, e := w.exec(sqlInsert)
+		if e != nil {
+			errorsByIndex[i] = fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+		}
+	}
+
+	numWritten := len(w.pendingValues) - len(errorsByIndex)
+	if numWritten == 0 {
+		return fmt.Errorf("could not write any of the %d trades to db, last error: %s", len(w.pendingValues), errorsByIndex[len(w.pendingValues)-1])
+	}
+
+	log.Printf("wrote %d trades to db one at a time\n", numWritten)
+	for i, txid := range w.pendingTxids {
+		if e, failed := errorsByIndex[i]; failed {
+			log.Printf("dropping trade (txid=%s) that could not be written to db while other trades could be written: %s\n", txid, e)
+		}
+	}
+	w.pendingTxids = []string{}
+	w.pendingValues = []string{}
+	return nil
+}
+
+// makeBatchedTradesInsert makes a single insert statement for all the values which ignores the trades that already exist
+func makeBatchedTradesInsert(values []string) string {
+	return kelpdb.SqlTradesInsertPrefix + strings.Join(values, ", ") + kelpdb.SqlTradesIgnoreDuplicateSuffix
+}
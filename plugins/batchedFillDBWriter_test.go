@@ -0,0 +1,162 @@
+package plugins
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestMakeBatchedFillDBWriterInvalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		batchSize     int
+		flushInterval time.Duration
+	}{
+		{
+			name:          "zero batch size",
+			batchSize:     0,
+			flushInterval: time.Second,
+		}, {
+			name:          "zero flush interval",
+			batchSize:     10,
+			flushInterval: 0,
+		}, {
+			name:          "negative flush interval",
+			batchSize:     10,
+			flushInterval: -time.Second,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := MakeBatchedFillDBWriter(nil, nil, "ccxt-binance", "account", k.batchSize, k.flushInterval)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestMakeBatchedTradesInsert(t *testing.T) {
+	values := []string{
+		"('abc', '1', '2020-01-01 00:00:00', 'buy', 'limit', 0.100000000000000, 10.000000000000000, 1.000000000000000, 0.000000000000000, 'account', 'o1')",
+		"('abc', '2', '2020-01-01 00:00:01', 'sell', 'limit', 0.110000000000000, 10.000000000000000, 1.100000000000000, 0.000000000000000, 'account', 'o2')",
+	}
+	assert.Equal(t,
+		"INSERT INTO trades (market_id, txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, account_id, order_id) VALUES "+
+			values[0]+", "+values[1]+" ON CONFLICT (market_id, txid) DO NOTHING",
+		makeBatchedTradesInsert(values),
+	)
+}
+
+func TestBatchedFillDBWriterClose(t *testing.T) {
+	w, e := MakeBatchedFillDBWriter(nil, nil, "ccxt-binance", "account", 10, time.Millisecond)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// nothing is buffered so nothing is written to the db
+	assert.NoError(t, w.Close())
+	// closing twice is a noop
+	assert.NoError(t, w.Close())
+
+	e = w.HandleFill(model.Trade{})
+	assert.Error(t, e)
+}
+
+func TestBatchedFillDBWriterFlush(t *testing.T) {
+	testCases := []struct {
+		name            string
+		failingValues   map[string]bool // values that cannot be inserted, "batch" fails the multi-row insert
+		wantErr         bool
+		wantNumExecs    int
+		wantPendingTxid []string
+	}{
+		{
+			name:            "batch insert",
+			failingValues:   map[string]bool{},
+			wantErr:         false,
+			wantNumExecs:    1,
+			wantPendingTxid: []string{},
+		}, {
+			name:            "bad row is dropped and the other rows are written",
+			failingValues:   map[string]bool{"batch": true, "('2')": true},
+			wantErr:         false,
+			wantNumExecs:    4,
+			wantPendingTxid: []string{},
+		}, {
+			name:            "all rows are kept when none can be written",
+			failingValues:   map[string]bool{"batch": true, "('1')": true, "('2')": true, "('3')": true},
+			wantErr:         true,
+			wantNumExecs:    4,
+			wantPendingTxid: []string{"1", "2", "3"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			w, e := MakeBatchedFillDBWriter(nil, nil, "ccxt-binance", "account", 10, time.Hour)
+			if !assert.NoError(t, e) {
+				return
+			}
+			defer w.Close()
+
+			numExecs := 0
+			w.exec = func(query string, args ...interface{}) (sql.Result, error) {
+				numExecs++
+				if strings.Count(query, "('") > 1 && k.failingValues["batch"] {
+					return nil, fmt.Errorf("batch insert failed")
+				}
+				for values := range k.failingValues {
+					if strings.Contains(query, values) {
+						return nil, fmt.Errorf("insert of %s failed", values)
+					}
+				}
+				return driver.RowsAffected(strings.Count(query, "('")), nil
+			}
+			w.lock.Lock()
+			for _, txid := range []string{"1", "2", "3"} {
+				w.addPending(txid, fmt.Sprintf("('%s')", txid))
+			}
+			w.lock.Unlock()
+
+			e = w.Flush()
+			if k.wantErr {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+			assert.Equal(t, k.wantNumExecs, numExecs)
+			assert.Equal(t, k.wantPendingTxid, w.pendingTxids)
+			assert.Equal(t, len(k.wantPendingTxid), len(w.pendingValues))
+		})
+	}
+}
+
+func TestBatchedFillDBWriterMaxPending(t *testing.T) {
+	w, e := MakeBatchedFillDBWriter(nil, nil, "ccxt-binance", "account", 10, time.Hour)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer func() {
+		w.pendingTxids = []string{}
+		w.pendingValues = []string{}
+		w.Close()
+	}()
+	assert.Equal(t, maxPendingFills, w.maxPending)
+
+	w.maxPending = 2
+	w.lock.Lock()
+	for _, txid := range []string{"1", "2", "3"} {
+		w.addPending(txid, fmt.Sprintf("('%s')", txid))
+	}
+	w.lock.Unlock()
+	// the oldest fill is dropped
+	assert.Equal(t, []string{"2", "3"}, w.pendingTxids)
+	assert.Equal(t, []string{"('2')", "('3')"}, w.pendingValues)
+}
@@ -17,6 +17,7 @@ const ccxtBalancePrecision = 10
 
 // ensure that ccxtExchange conforms to the Exchange interface
 var _ api.Exchange = ccxtExchange{}
+var _ api.InclusiveTimestampCursor = ccxtExchange{}
 
 // ccxtExchangeSpecificParamFactory knows how to create the exchange-specific params for each exchange
 type ccxtExchangeSpecificParamFactory interface {
@@ -77,6 +78,18 @@ func makeCcxtExchange(
 		return nil, fmt.Errorf("error making a ccxt exchange: %s", e)
 	}
 
+	// check the status of the exchange before we start so we fail with a clear message instead of failing mid-cycle
+	status, e := c.FetchStatus()
+	if e != nil {
+		log.Printf("could not fetch the status of exchange '%s', continuing without a status check: %s\n", exchangeName, e)
+	} else if !status.IsOK() {
+		eta := "unknown"
+		if status.Eta != nil {
+			eta = time.Unix(0, *status.Eta*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		}
+		return nil, fmt.Errorf("exchange '%s' is down (status=%s, eta=%s, url=%s)", exchangeName, status.Status, eta, status.URL)
+	}
+
 	ocOverridesHandler := MakeEmptyOrderConstraintsOverridesHandler()
 	if orderConstraintOverrides != nil {
 		ocOverridesHandler = MakeOrderConstraintsOverridesHandler(orderConstraintOverrides)
@@ -101,7 +114,7 @@ func (c ccxtExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.Tradi
 
 	priceResult := map[model.TradingPair]api.Ticker{}
 	for _, p := range pairs {
-		tickerMap, e := c.api.FetchTicker(pairsMap[p])
+		tickerMap, e := c.api.FetchTickerRaw(pairsMap[p])
 		if e != nil {
 			return nil, fmt.Errorf("error while fetching ticker price for trading pair %s: %s", pairsMap[p], e)
 		}
@@ -148,11 +161,18 @@ func (c ccxtExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderC
 	if ccxtMarket == nil {
 		panic(fmt.Errorf("CCXT does not have precision and limit data for the passed in market: %s", pairString))
 	}
-	volumePrecision := ccxtMarket.Precision.Amount
+	pricePrecision := int8(0)
+	if ccxtMarket.Precision.Price != nil {
+		pricePrecision = *ccxtMarket.Precision.Price
+	}
+	volumePrecision := int8(0)
+	if ccxtMarket.Precision.Amount != nil {
+		volumePrecision = *ccxtMarket.Precision.Amount
+	}
 	if volumePrecision == 0 {
-		volumePrecision = ccxtMarket.Precision.Price
+		volumePrecision = pricePrecision
 	}
-	oc := model.MakeOrderConstraintsWithCost(ccxtMarket.Precision.Price, volumePrecision, ccxtMarket.Limits.Amount.Min, ccxtMarket.Limits.Cost.Min)
+	oc := model.MakeOrderConstraintsWithCost(pricePrecision, volumePrecision, ccxtMarket.Limits.Amount.Min, ccxtMarket.Limits.Cost.Min)
 
 	return c.ocOverridesHandler.Apply(pair, oc)
 }
@@ -217,7 +237,7 @@ func (c ccxtExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*mo
 		return nil, fmt.Errorf("error converting pair to string: %s", e)
 	}
 
-	ob, e := c.api.FetchOrderBook(pairString, &fetchLimit)
+	ob, e := c.api.FetchOrderBook(pairString, &fetchLimit, nil)
 	if e != nil {
 		return nil, fmt.Errorf("error while fetching orderbook for trading pair '%s': %s", pairString, e)
 	}
@@ -255,8 +275,8 @@ func (c ccxtExchange) readOrders(orders []sdk.CcxtOrder, pair *model.TradingPair
 			Pair:        pair,
 			OrderAction: orderAction,
 			OrderType:   model.OrderTypeLimit,
-			Price:       model.NumberFromFloat(o.Price, pricePrecision),
-			Volume:      model.NumberFromFloat(o.Amount, volumePrecision),
+			Price:       o.PriceNumber(pricePrecision),
+			Volume:      o.AmountNumber(volumePrecision),
 			Timestamp:   nil,
 		})
 	}
@@ -321,6 +341,11 @@ func (c ccxtExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart i
 	}, nil
 }
 
+// UsesInclusiveTimestampCursor impl, the cursor passed to FetchMyTrades is the "since" timestamp which is inclusive
+func (c ccxtExchange) UsesInclusiveTimestampCursor() bool {
+	return true
+}
+
 // GetLatestTradeCursor impl.
 func (c ccxtExchange) GetLatestTradeCursor() (interface{}, error) {
 	timeNowMillis := time.Now().UnixNano() / int64(time.Millisecond)
@@ -336,7 +361,7 @@ func (c ccxtExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}
 	}
 
 	// TODO use cursor when fetching trades
-	tradesRaw, e := c.api.FetchTrades(pairString)
+	tradesRaw, e := c.api.FetchTrades(pairString, nil)
 	if e != nil {
 		return nil, fmt.Errorf("error while fetching trades for trading pair '%s': %s", pairString, e)
 	}
@@ -406,17 +431,23 @@ func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawT
 	trade := model.Trade{
 		Order: model.Order{
 			Pair:      pair,
-			Price:     model.NumberFromFloat(rawTrade.Price, pricePrecision),
-			Volume:    model.NumberFromFloat(rawTrade.Amount, volumePrecision),
+			Price:     rawTrade.PriceNumber(pricePrecision),
+			Volume:    rawTrade.AmountNumber(volumePrecision),
 			OrderType: model.OrderTypeLimit,
 			Timestamp: model.MakeTimestamp(rawTrade.Timestamp),
 		},
 		TransactionID: model.MakeTransactionID(rawTrade.ID),
-		Cost:          model.NumberFromFloat(rawTrade.Cost, feecCostPrecision),
-		Fee:           model.NumberFromFloat(rawTrade.Fee.Cost, feecCostPrecision),
+		Cost:          rawTrade.CostNumber(feecCostPrecision),
 		// OrderID read by calling function depending on override set for exchange params in "orderId" field of Info object
 	}
 
+	fee, feeAsset, e := readFee(c.assetConverter, rawTrade.Fee, feecCostPrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not read fee of trade: %s", e)
+	}
+	trade.Fee = fee
+	trade.FeeAsset = feeAsset
+
 	useSignToDenoteSide := false
 	if c.esParamFactory != nil {
 		useSignToDenoteSide = c.esParamFactory.useSignToDenoteSideForTrades()
@@ -452,6 +483,24 @@ func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawT
 	return &trade, nil
 }
 
+// readFee converts the fee returned by CCXT, the fee and its asset are nil when the exchange returns a null fee and the asset is also nil
+// when the exchange does not report the currency of the fee
+func readFee(assetConverter model.AssetConverterInterface, rawFee *sdk.CcxtFee, precision int8) (*model.Number, *model.Asset, error) {
+	if rawFee == nil {
+		return nil, nil, nil
+	}
+
+	fee := model.NumberFromFloat(rawFee.Cost, precision)
+	if rawFee.Currency == "" {
+		return fee, nil, nil
+	}
+	feeAsset, e := assetConverter.FromString(rawFee.Currency)
+	if e != nil {
+		return nil, nil, fmt.Errorf("could not convert fee currency '%s' to an asset: %s", rawFee.Currency, e)
+	}
+	return fee, &feeAsset, nil
+}
+
 // GetOpenOrders impl
 func (c ccxtExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
 	pairStrings := []string{}
@@ -507,14 +556,14 @@ func (c ccxtExchange) convertOpenOrderFromCcxt(pair *model.TradingPair, o sdk.Cc
 			Pair:        pair,
 			OrderAction: orderAction,
 			OrderType:   model.OrderTypeLimit,
-			Price:       model.NumberFromFloat(o.Price, c.GetOrderConstraints(pair).PricePrecision),
-			Volume:      model.NumberFromFloat(o.Amount, c.GetOrderConstraints(pair).VolumePrecision),
+			Price:       o.PriceNumber(c.GetOrderConstraints(pair).PricePrecision),
+			Volume:      o.AmountNumber(c.GetOrderConstraints(pair).VolumePrecision),
 			Timestamp:   ts,
 		},
 		ID:             o.ID,
 		StartTime:      ts,
 		ExpireTime:     nil,
-		VolumeExecuted: model.NumberFromFloat(o.Filled, c.GetOrderConstraints(pair).VolumePrecision),
+		VolumeExecuted: o.FilledNumber(c.GetOrderConstraints(pair).VolumePrecision),
 	}, nil
 }
 
@@ -20,7 +20,7 @@ func (f *ccxtExchangeSpecificParamFactoryCoinbasepro) getParamsForGetOrderBook()
 }
 
 func (f *ccxtExchangeSpecificParamFactoryCoinbasepro) getParamsForAddOrder(submitMode api.SubmitMode) interface{} {
-	if submitMode == api.SubmitModeMakerOnly {
+	if submitMode == api.SubmitModeMakerOnly || submitMode == api.SubmitModePostOnly {
 		return map[string]interface{}{
 			"post_only": true,
 		}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
 )
 
 type exchangeAuthData struct {
@@ -612,3 +613,41 @@ func TestGetOrderConstraints_Ccxt_Precision(t *testing.T) {
 		})
 	}
 }
+
+func TestReadFee(t *testing.T) {
+	btc := model.BTC
+	testCases := []struct {
+		name         string
+		rawFee       *sdk.CcxtFee
+		wantFee      *model.Number
+		wantFeeAsset *model.Asset
+	}{
+		{
+			name:         "null fee",
+			rawFee:       nil,
+			wantFee:      nil,
+			wantFeeAsset: nil,
+		}, {
+			name:         "fee with currency",
+			rawFee:       &sdk.CcxtFee{Cost: 0.00012345, Currency: "BTC"},
+			wantFee:      model.NumberFromFloat(0.00012345, 8),
+			wantFeeAsset: &btc,
+		}, {
+			name:         "fee without currency",
+			rawFee:       &sdk.CcxtFee{Cost: 0.5},
+			wantFee:      model.NumberFromFloat(0.5, 8),
+			wantFeeAsset: nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			fee, feeAsset, e := readFee(model.CcxtAssetConverter, k.rawFee, 8)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantFee, fee)
+			assert.Equal(t, k.wantFeeAsset, feeAsset)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// ccxtTickerFetcher is the part of the CCXT SDK used by the CcxtPriceFeed
+type ccxtTickerFetcher interface {
+	FetchTicker(tradingPair string) (*sdk.CcxtTicker, error)
+}
+
+// ensure that the CCXT SDK can be used by the CcxtPriceFeed
+var _ ccxtTickerFetcher = &sdk.Ccxt{}
+
+// CcxtPriceFeed is a PairPriceFeed that uses the ticker of an exchange on CCXT. The price is the mid price of the best bid and ask, falling
+// back to the price of the last trade when the ticker does not have both a bid and an ask
+type CcxtPriceFeed struct {
+	c ccxtTickerFetcher
+}
+
+// ensure that it implements PairPriceFeed
+var _ api.PairPriceFeed = &CcxtPriceFeed{}
+
+// MakeCcxtPriceFeed is a factory method
+func MakeCcxtPriceFeed(c *sdk.Ccxt) *CcxtPriceFeed {
+	return &CcxtPriceFeed{c: c}
+}
+
+// GetPrice impl
+func (f *CcxtPriceFeed) GetPrice(pair model.TradingPair) (float64, error) {
+	symbol := model.ToCcxtSymbol(pair)
+	ticker, e := f.c.FetchTicker(symbol)
+	if e != nil {
+		return 0, fmt.Errorf("error while fetching ticker for '%s' from CCXT price feed: %w", symbol, e)
+	}
+
+	// CCXT returns null for missing values which are decoded as 0
+	if ticker.Bid > 0 && ticker.Ask > 0 {
+		midPrice := (ticker.Bid + ticker.Ask) / 2
+		log.Printf("price from CCXT price feed for '%s': bidPrice=%.10f, askPrice=%.10f; midPrice=%.10f\n", symbol, ticker.Bid, ticker.Ask, midPrice)
+		return midPrice, nil
+	}
+	if ticker.Last > 0 {
+		log.Printf("price from CCXT price feed for '%s' uses the last price because the ticker does not have both a bid and an ask: bidPrice=%.10f, askPrice=%.10f; lastPrice=%.10f\n", symbol, ticker.Bid, ticker.Ask, ticker.Last)
+		return ticker.Last, nil
+	}
+	return 0, fmt.Errorf("ticker for '%s' from CCXT price feed does not have a bid and ask or a last price", symbol)
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// fakeTickerFetcher returns the ticker and error, and records the symbol it was called with
+type fakeTickerFetcher struct {
+	ticker *sdk.CcxtTicker
+	err    error
+	symbol string
+}
+
+func (f *fakeTickerFetcher) FetchTicker(tradingPair string) (*sdk.CcxtTicker, error) {
+	f.symbol = tradingPair
+	return f.ticker, f.err
+}
+
+func TestCcxtPriceFeed(t *testing.T) {
+	testCases := []struct {
+		name      string
+		ticker    *sdk.CcxtTicker
+		err       error
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			name:      "mid price",
+			ticker:    &sdk.CcxtTicker{Bid: 0.10, Ask: 0.12, Last: 0.5},
+			wantPrice: 0.11,
+		}, {
+			name:      "no bid falls back to last",
+			ticker:    &sdk.CcxtTicker{Ask: 0.12, Last: 0.5},
+			wantPrice: 0.5,
+		}, {
+			name:      "no ask falls back to last",
+			ticker:    &sdk.CcxtTicker{Bid: 0.10, Last: 0.5},
+			wantPrice: 0.5,
+		}, {
+			name:      "no prices",
+			ticker:    &sdk.CcxtTicker{},
+			wantError: true,
+		}, {
+			name:      "error",
+			err:       fmt.Errorf("exchange is down"),
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			fetcher := &fakeTickerFetcher{ticker: k.ticker, err: k.err}
+			f := &CcxtPriceFeed{c: fetcher}
+
+			price, e := f.GetPrice(*model.MakeTradingPair(model.XLM, model.USDT))
+			assert.Equal(t, "XLM/USDT", fetcher.symbol)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package plugins
+
+import (
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// ensure that CcxtTradeFetcher conforms to the TradeFetcher interface
+var _ api.TradeFetcher = &CcxtTradeFetcher{}
+var _ api.InclusiveTimestampCursor = &CcxtTradeFetcher{}
+
+// CcxtTradeFetcher adapts the Ccxt SDK to the api.TradeFetcher interface so components that only need the trade history, such as
+// the pendulum level provider, can use a CCXT exchange without constructing a full api.Exchange
+type CcxtTradeFetcher struct {
+	exchange ccxtExchange
+}
+
+// MakeCcxtTradeFetcher is a factory method, it uses the exchange-specific params for the exchange of the Ccxt instance if there are any
+func MakeCcxtTradeFetcher(c *sdk.Ccxt) *CcxtTradeFetcher {
+	// maybeEsParamFactory can be nil
+	maybeEsParamFactory := ccxtExchangeSpecificParamFactoryMap["ccxt-"+c.GetExchangeName()]
+	return &CcxtTradeFetcher{
+		exchange: ccxtExchange{
+			assetConverter:     model.CcxtAssetConverter,
+			delimiter:          "/",
+			ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+			api:                c,
+			esParamFactory:     maybeEsParamFactory,
+		},
+	}
+}
+
+// GetTradeHistory impl, this calls FetchMyTrades on CCXT and converts the trades using the precision of the market. The returned cursor
+// is the timestamp after the last trade so it can be passed back in to fetch the next page of trades
+func (f *CcxtTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	return f.exchange.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+}
+
+// UsesInclusiveTimestampCursor impl, the cursor passed to FetchMyTrades is the "since" timestamp which is inclusive
+func (f *CcxtTradeFetcher) UsesInclusiveTimestampCursor() bool {
+	return f.exchange.UsesInclusiveTimestampCursor()
+}
@@ -0,0 +1,14 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestCcxtTradeFetcherUsesInclusiveTimestampCursor(t *testing.T) {
+	// the pendulum level provider relies on this to increment the cursor after the last trade
+	assert.True(t, api.UsesInclusiveTimestampCursor(&CcxtTradeFetcher{}))
+}
@@ -0,0 +1,162 @@
+package plugins
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+var csvFillHeader = []string{"timestamp", "pair", "side", "price", "base_amount", "quote_amount", "order_id", "fee"}
+
+// CsvFillHandler is a FillHandler that appends fills as rows to a CSV file
+type CsvFillHandler struct {
+	filePath string
+	lock     *sync.Mutex
+
+	// uninitialized
+	file *os.File
+}
+
+var _ api.FillHandler = &CsvFillHandler{}
+
+// MakeCsvFillHandler is a factory method
+func MakeCsvFillHandler(filePath string) (*CsvFillHandler, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("the file path for the CSV fill handler cannot be empty")
+	}
+
+	h := &CsvFillHandler{
+		filePath: filePath,
+		lock:     &sync.Mutex{},
+	}
+	// open the file up front so we fail early if the path is not writable
+	e := h.ensureFileOpen()
+	if e != nil {
+		return nil, fmt.Errorf("unable to open CSV file for fills: %s", e)
+	}
+	return h, nil
+}
+
+// HandleFill impl.
+func (h *CsvFillHandler) HandleFill(trade model.Trade) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	e := h.ensureFileOpen()
+	if e != nil {
+		return fmt.Errorf("unable to open CSV file for fills: %s", e)
+	}
+
+	e = h.writeRow(makeCsvFillRow(trade))
+	if e != nil {
+		return fmt.Errorf("unable to write fill to CSV file '%s': %s", h.filePath, e)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (h *CsvFillHandler) Close() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+	e := h.file.Close()
+	h.file = nil
+	return e
+}
+
+// ensureFileOpen (re)opens the file if it is not open yet or if it was moved or deleted since we opened it (eg by logrotate),
+// writing the header when the file is new
+func (h *CsvFillHandler) ensureFileOpen() error {
+	if h.file != nil {
+		pathInfo, e := os.Stat(h.filePath)
+		if e == nil {
+			fileInfo, e := h.file.Stat()
+			if e == nil && os.SameFile(pathInfo, fileInfo) {
+				return nil
+			}
+		}
+
+		log.Printf("CSV fill file '%s' was rotated or removed, reopening\n", h.filePath)
+		_ = h.file.Close()
+		h.file = nil
+	}
+
+	f, e := os.OpenFile(h.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if e != nil {
+		return fmt.Errorf("could not open file '%s': %s", h.filePath, e)
+	}
+	info, e := f.Stat()
+	if e != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not stat file '%s': %s", h.filePath, e)
+	}
+	h.file = f
+
+	if info.Size() == 0 {
+		e = h.writeRow(csvFillHeader)
+		if e != nil {
+			return fmt.Errorf("could not write header: %s", e)
+		}
+	}
+	return nil
+}
+
+// writeRow writes the row and flushes it to disk
+func (h *CsvFillHandler) writeRow(row []string) error {
+	w := csv.NewWriter(h.file)
+	e := w.Write(row)
+	if e != nil {
+		return fmt.Errorf("could not write row: %s", e)
+	}
+	w.Flush()
+	e = w.Error()
+	if e != nil {
+		return fmt.Errorf("could not flush row: %s", e)
+	}
+	return h.file.Sync()
+}
+
+func makeCsvFillRow(trade model.Trade) []string {
+	return []string{
+		fillTimestampString(trade),
+		fillPairString(trade),
+		trade.OrderAction.String(),
+		csvNumber(trade.Price),
+		csvNumber(trade.Volume),
+		csvNumber(trade.Cost),
+		trade.OrderID,
+		csvNumber(trade.Fee),
+	}
+}
+
+// fillTimestampString formats the timestamp of the trade as RFC3339 in UTC, or empty if missing
+func fillTimestampString(trade model.Trade) string {
+	if trade.Timestamp == nil {
+		return ""
+	}
+	return time.Unix(0, trade.Timestamp.AsInt64()*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
+
+// fillPairString formats the trading pair of the trade, or empty if missing
+func fillPairString(trade model.Trade) string {
+	if trade.Pair == nil {
+		return ""
+	}
+	return trade.Pair.String()
+}
+
+func csvNumber(n *model.Number) string {
+	if n == nil {
+		return ""
+	}
+	return n.AsString()
+}
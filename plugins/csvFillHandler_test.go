@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func makeTestCsvTrade(orderID string) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			Pair:        model.MakeTradingPair(model.XLM, model.USD),
+			OrderAction: model.OrderActionSell,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(0.1, 4),
+			Volume:      model.NumberFromFloat(100, 2),
+			Timestamp:   model.MakeTimestamp(1577836800000),
+		},
+		OrderID: orderID,
+		Cost:    model.NumberFromFloat(10, 2),
+		Fee:     nil,
+	}
+}
+
+func TestCsvFillHandler(t *testing.T) {
+	dir, e := ioutil.TempDir("", "csv_fill_handler")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "fills.csv")
+
+	h, e := MakeCsvFillHandler(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer h.Close()
+
+	if !assert.NoError(t, h.HandleFill(makeTestCsvTrade("order1"))) {
+		return
+	}
+	header := "timestamp,pair,side,price,base_amount,quote_amount,order_id,fee\n"
+	row1 := "2020-01-01T00:00:00Z,XLM/USD,sell,0.1000,100.00,10.00,order1,\n"
+	data, e := ioutil.ReadFile(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, header+row1, string(data))
+
+	// a file that is reopened is appended to without writing the header again
+	h2, e := MakeCsvFillHandler(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer h2.Close()
+	if !assert.NoError(t, h2.HandleFill(makeTestCsvTrade("order2"))) {
+		return
+	}
+	data, e = ioutil.ReadFile(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	row2 := "2020-01-01T00:00:00Z,XLM/USD,sell,0.1000,100.00,10.00,order2,\n"
+	assert.Equal(t, header+row1+row2, string(data))
+
+	// rotating the file causes the handler to write to a new file with a header
+	if !assert.NoError(t, os.Rename(filePath, filePath+".1")) {
+		return
+	}
+	if !assert.NoError(t, h.HandleFill(makeTestCsvTrade("order3"))) {
+		return
+	}
+	data, e = ioutil.ReadFile(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, header+"2020-01-01T00:00:00Z,XLM/USD,sell,0.1000,100.00,10.00,order3,\n", string(data))
+}
@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// dbHealthCheckPingTimeout is how long a health check waits for the db to respond before it is considered unreachable
+const dbHealthCheckPingTimeout = 5 * time.Second
+
+// DBHealthChecker pings the db in the background and triggers an alert when the db becomes unreachable, the alert is resolved once the
+// db is reachable again. This surfaces a db that goes away mid-run before the volume filter queries fail inside an update cycle
+type DBHealthChecker struct {
+	interval time.Duration
+	alert    api.Alert // can be nil
+	pingFn   func() error
+
+	// uninitialized
+	isAlertTriggered bool
+	alertDedupKey    string
+	done             chan struct{}
+	wg               *sync.WaitGroup
+	stopOnce         *sync.Once
+}
+
+// MakeDBHealthChecker is a factory method, call Start to begin the health checks
+func MakeDBHealthChecker(db *sql.DB, interval time.Duration, alert api.Alert) (*DBHealthChecker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("health check interval needs to be greater than 0 but was %s", interval)
+	}
+
+	return &DBHealthChecker{
+		interval: interval,
+		alert:    alert,
+		pingFn: func() error {
+			return postgresdb.Ping(db, dbHealthCheckPingTimeout)
+		},
+		done:     make(chan struct{}),
+		wg:       &sync.WaitGroup{},
+		stopOnce: &sync.Once{},
+	}, nil
+}
+
+// Start runs the health checks every interval in a goroutine until Stop is called
+func (c *DBHealthChecker) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				c.check()
+			}
+		}
+	}()
+}
+
+// Stop stops the health checks and waits for the running check to finish, it is safe to call more than once
+func (c *DBHealthChecker) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+}
+
+// check pings the db and triggers the alert when the db becomes unreachable or resolves it when the db is reachable again
+func (c *DBHealthChecker) check() {
+	e := c.pingFn()
+	if e == nil {
+		if c.isAlertTriggered {
+			log.Printf("db health check succeeded, the db is reachable again\n")
+			if c.alert != nil && c.alertDedupKey != "" {
+				if e := c.alert.Resolve(c.alertDedupKey); e != nil {
+					log.Printf("could not resolve the db health check alert: %s\n", e)
+				}
+			}
+			c.isAlertTriggered = false
+			c.alertDedupKey = ""
+		}
+		return
+	}
+
+	log.Printf("db health check failed: %s\n", e)
+	if c.isAlertTriggered {
+		return
+	}
+	c.isAlertTriggered = true
+	if c.alert == nil {
+		return
+	}
+	dedupKey, e := c.alert.TriggerWithSeverity(api.AlertSeverityCritical, "db is unreachable, volume filters and fill tracking will fail until it is reachable again", map[string]interface{}{
+		"error": e.Error(),
+	})
+	if e != nil {
+		log.Printf("could not trigger the db health check alert: %s\n", e)
+		return
+	}
+	c.alertDedupKey = dedupKey
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeDBHealthCheckerInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		db       *sql.DB
+		interval time.Duration
+	}{
+		{
+			name:     "nil db",
+			db:       nil,
+			interval: time.Minute,
+		}, {
+			name:     "zero interval",
+			db:       &sql.DB{},
+			interval: 0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := MakeDBHealthChecker(k.db, k.interval, nil)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestDBHealthCheckerCheck(t *testing.T) {
+	unreachable := fmt.Errorf("connection refused")
+	testCases := []struct {
+		name         string
+		pingErrors   []error
+		wantTriggers int
+		wantResolved []string
+	}{
+		{
+			name:         "healthy",
+			pingErrors:   []error{nil, nil},
+			wantTriggers: 0,
+			wantResolved: nil,
+		}, {
+			name:         "unreachable alerts once",
+			pingErrors:   []error{unreachable, unreachable, unreachable},
+			wantTriggers: 1,
+			wantResolved: nil,
+		}, {
+			name:         "recovers",
+			pingErrors:   []error{nil, unreachable, unreachable, nil, nil},
+			wantTriggers: 1,
+			wantResolved: []string{"key-1"},
+		}, {
+			name:         "goes away again",
+			pingErrors:   []error{unreachable, nil, unreachable},
+			wantTriggers: 2,
+			wantResolved: []string{"key-1"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			alert := &recordingAlert{}
+			c, e := MakeDBHealthChecker(&sql.DB{}, time.Minute, alert)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			for _, pingError := range k.pingErrors {
+				pingError := pingError
+				c.pingFn = func() error {
+					return pingError
+				}
+				c.check()
+			}
+			assert.Equal(t, k.wantTriggers, len(alert.triggers))
+			assert.Equal(t, k.wantResolved, alert.resolved)
+			assert.Equal(t, k.pingErrors[len(k.pingErrors)-1] != nil, c.isAlertTriggered)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+type dryRunFilter struct {
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+}
+
+var _ SubmitFilter = &dryRunFilter{}
+
+// Name impl.
+func (f *dryRunFilter) Name() string {
+	return "dryRunFilter"
+}
+
+// Describe impl.
+func (f *dryRunFilter) Describe() string {
+	return "logs every operation and drops it so nothing is submitted (submit mode dry_run)"
+}
+
+// MakeFilterDryRun makes a submit filter for the dry run submit mode, it logs every operation and then drops it so nothing is submitted.
+// It should be the last filter so it logs the operations after they were modified by all the other filters.
+func MakeFilterDryRun(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) SubmitFilter {
+	return &dryRunFilter{
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+	}
+}
+
+// Apply impl.
+func (f *dryRunFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	log.Printf("dryRunFilter: not submitting %d operations because the submit mode is dry_run\n", len(ops))
+	for i, op := range ops {
+		log.Printf("dryRunFilter: op %d: %s\n", i+1, f.describe(op))
+	}
+	return []txnbuild.Operation{}, nil
+}
+
+// describe returns a human readable description of what the operation would have done
+func (f *dryRunFilter) describe(op txnbuild.Operation) string {
+	o, ok := op.(*txnbuild.ManageSellOffer)
+	if !ok {
+		return fmt.Sprintf("would have submitted operation of type %T: %+v", op, op)
+	}
+
+	change := "create new offer"
+	if o.OfferID != 0 && utils.AmountStringAsFloat(o.Amount) == 0 {
+		return fmt.Sprintf("would delete offer %d", o.OfferID)
+	} else if o.OfferID != 0 {
+		change = fmt.Sprintf("modify offer %d", o.OfferID)
+	}
+
+	side := "buy"
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, o.Selling, o.Buying)
+	if e != nil {
+		side = "unknown side"
+	} else if isSell {
+		side = "sell"
+	}
+
+	sellingCode := utils.Asset2CodeString(utils.Asset2Asset2(o.Selling))
+	buyingCode := utils.Asset2CodeString(utils.Asset2Asset2(o.Buying))
+	return fmt.Sprintf("would %s (%s): selling %s %s for %s at a price of %s %s per %s", change, side, o.Amount, sellingCode, buyingCode, o.Price, buyingCode, sellingCode)
+}
@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestDryRunFilter(t *testing.T) {
+	base := utils.NativeAsset
+	quote := *utils.MustParseAsset("USD", "GBQXIRXCQX3ESN43MT4C6P5Y5PHPFTZKMALXL2U7SJMT2GJFBA5XTHM4")
+	xlm := utils.Asset2Asset(base)
+	usd := utils.Asset2Asset(quote)
+
+	testCases := []struct {
+		name string
+		op   *txnbuild.ManageSellOffer
+		want string
+	}{
+		{
+			name: "create sell",
+			op:   &txnbuild.ManageSellOffer{Selling: xlm, Buying: usd, Amount: "10.0000000", Price: "0.1000000"},
+			want: "would create new offer (sell): selling 10.0000000 XLM for USD at a price of 0.1000000 USD per XLM",
+		}, {
+			name: "modify buy",
+			op:   &txnbuild.ManageSellOffer{Selling: usd, Buying: xlm, Amount: "1.0000000", Price: "11.0000000", OfferID: 42},
+			want: "would modify offer 42 (buy): selling 1.0000000 USD for XLM at a price of 11.0000000 XLM per USD",
+		}, {
+			name: "delete",
+			op:   &txnbuild.ManageSellOffer{Selling: xlm, Buying: usd, Amount: "0", Price: "0.1000000", OfferID: 42},
+			want: "would delete offer 42",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &dryRunFilter{baseAsset: base, quoteAsset: quote}
+			assert.Equal(t, k.want, f.describe(k.op))
+
+			ops, e := f.Apply([]txnbuild.Operation{k.op}, nil, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			// nothing is ever submitted
+			assert.Equal(t, 0, len(ops))
+		})
+	}
+}
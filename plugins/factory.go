@@ -28,6 +28,7 @@ type strategyFactoryData struct {
 	isTradingSdex   bool
 	filterFactory   *FilterFactory
 	db              *sql.DB
+	alert           api.Alert // can be nil
 }
 
 // StrategyContainer contains the strategy factory method along with some metadata
@@ -139,8 +140,9 @@ var strategies = map[string]StrategyContainer{
 				&cfg,
 				strategyFactoryData.tradeFetcher,
 				strategyFactoryData.tradingPair,
-				!strategyFactoryData.isTradingSdex,
-			), nil
+				api.UsesInclusiveTimestampCursor(strategyFactoryData.tradeFetcher),
+				strategyFactoryData.alert,
+			)
 		},
 	},
 	"sell_twap": {
@@ -212,6 +214,7 @@ func MakeStrategy(
 	isTradingSdex bool,
 	filterFactory *FilterFactory,
 	db *sql.DB,
+	alert api.Alert, // can be nil
 ) (api.Strategy, error) {
 	log.Printf("Making strategy: %s\n", strategy)
 	if s, ok := strategies[strategy]; ok {
@@ -233,6 +236,7 @@ func MakeStrategy(
 			isTradingSdex:   isTradingSdex,
 			filterFactory:   filterFactory,
 			db:              db,
+			alert:           alert,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("cannot make '%s' strategy: %s", strategy, e)
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -48,7 +47,7 @@ type FillDBWriter struct {
 	market *tradingMarket
 }
 
-// MakeMarketID generates a universal marketID
+// MakeMarketID generates a universal marketID, prefer using the constructors of MarketID which normalize the assets
 func MakeMarketID(exchangeName string, baseAsset string, quoteAsset string) string {
 	idString := fmt.Sprintf("%s_%s_%s", exchangeName, baseAsset, quoteAsset)
 	h := sha256.New()
@@ -91,18 +90,14 @@ func (f *FillDBWriter) fetchOrRegisterMarket(trade model.Trade) (*tradingMarket,
 	}
 
 	txid := utils.CheckedString(trade.TransactionID)
-	baseAssetString, e := f.assetDisplayFn(trade.Pair.Base)
+	marketID, e := MakeMarketIDFromTradingPair(f.exchangeName, trade.Pair, f.assetDisplayFn)
 	if e != nil {
-		return nil, fmt.Errorf("bot is not configured to recognize the base asset from this trade (txid=%s), base asset = %s, error: %s", txid, string(trade.Pair.Base), e)
-	}
-	quoteAssetString, e := f.assetDisplayFn(trade.Pair.Quote)
-	if e != nil {
-		return nil, fmt.Errorf("bot is not configured to recognize the quote asset from this trade (txid=%s), quote asset = %s, error: %s", txid, string(trade.Pair.Quote), e)
+		return nil, fmt.Errorf("bot is not configured to recognize the assets from this trade (txid=%s), trading pair = %s, error: %s", txid, trade.Pair, e)
 	}
 
-	market, e := fetchOrRegisterMarketByDetails(f.db, f.exchangeName, baseAssetString, quoteAssetString)
+	market, e := fetchOrRegisterMarketByDetails(f.db, marketID.ExchangeName, marketID.BaseAsset, marketID.QuoteAsset)
 	if e != nil {
-		return nil, fmt.Errorf("error while calling fetchOrRegisterMarketByDetails (exchangeName=%s, baseAsset=%s, quoteAsset=%s): %s", f.exchangeName, baseAssetString, quoteAssetString, e)
+		return nil, fmt.Errorf("error while calling fetchOrRegisterMarketByDetails (marketID=%s): %s", marketID, e)
 	}
 
 	f.market = market
@@ -179,6 +174,30 @@ func registerMarket(db *sql.DB, market *tradingMarket) error {
 
 // HandleFill impl.
 func (f *FillDBWriter) HandleFill(trade model.Trade) error {
+	txid, values, e := f.makeTradeValues(trade)
+	if e != nil {
+		return fmt.Errorf("cannot make the values of the trade to insert: %s", e)
+	}
+
+	// the same trade can be handled more than once (eg after a restart with an older cursor) so we ignore duplicates at the db level
+	sqlInsert := kelpdb.SqlTradesInsertPrefix + values + kelpdb.SqlTradesIgnoreDuplicateSuffix
+	result, e := f.db.Exec(sqlInsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+	}
+
+	rowsAffected, e := result.RowsAffected()
+	if e == nil && rowsAffected == 0 {
+		log.Printf("trying to reinsert trade (txid=%s) to db, ignore and continue\n", txid)
+		return nil
+	}
+
+	log.Printf("wrote trade (txid=%s) to db\n", txid)
+	return nil
+}
+
+// makeTradeValues returns the txid and the values of the row for the trade in the format of kelpdb.SqlTradesValuesTemplate
+func (f *FillDBWriter) makeTradeValues(trade model.Trade) (string, string, error) {
 	txid := utils.CheckedString(trade.TransactionID)
 	timeSeconds := trade.Timestamp.AsInt64() / 1000
 	date := time.Unix(timeSeconds, 0).UTC()
@@ -186,10 +205,16 @@ func (f *FillDBWriter) HandleFill(trade model.Trade) error {
 
 	market, e := f.fetchOrRegisterMarket(trade)
 	if e != nil {
-		return fmt.Errorf("cannot fetch or register market for trade (txid=%s): %s", txid, e)
+		return "", "", fmt.Errorf("cannot fetch or register market for trade (txid=%s): %s", txid, e)
 	}
 
-	sqlInsert := fmt.Sprintf(kelpdb.SqlTradesInsertTemplate,
+	// the fee column is not nullable and not all exchanges report a fee with the trade
+	fee := trade.Fee
+	if fee == nil {
+		fee = model.NumberConstants.Zero
+	}
+
+	values := fmt.Sprintf(kelpdb.SqlTradesValuesTemplate,
 		market.ID,
 		txid,
 		dateString,
@@ -198,23 +223,11 @@ func (f *FillDBWriter) HandleFill(trade model.Trade) error {
 		f.checkedFloat(trade.Price),
 		f.checkedFloat(trade.Volume),
 		f.checkedFloat(trade.Cost),
-		f.checkedFloat(trade.Fee),
+		f.checkedFloat(fee),
 		f.accountID,
 		trade.OrderID,
 	)
-	_, e = f.db.Exec(sqlInsert)
-	if e != nil {
-		if strings.Contains(e.Error(), "duplicate key value violates unique constraint \"trades_pkey\"") {
-			log.Printf("trying to reinsert trade (txid=%s) to db, ignore and continue\n", txid)
-			return nil
-		}
-
-		// return an error on any other errors
-		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
-	}
-
-	log.Printf("wrote trade (txid=%s) to db\n", txid)
-	return nil
+	return txid, values, nil
 }
 
 func (f *FillDBWriter) checkedFloat(n *model.Number) interface{} {
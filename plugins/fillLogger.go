@@ -1,24 +1,145 @@
 package plugins
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"sync"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 )
 
-// FillLogger is a FillHandler that logs fills
-type FillLogger struct{}
+// jsonFill is the single-line JSON object logged for each fill by the JSON FillLogger, the field names should not be changed because
+// log aggregators depend on them
+type jsonFill struct {
+	Pair      string `json:"pair"`
+	Side      string `json:"side"`
+	Price     string `json:"price"`
+	Amount    string `json:"amount"`
+	Cost      string `json:"cost"`
+	OrderID   string `json:"order_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+// FillLogger is a FillHandler that logs fills, either to the standard log or to a dedicated writer
+type FillLogger struct {
+	jsonFormat bool
+	lock       *sync.Mutex
+	writer     io.Writer // nil to use the standard log
+	file       *os.File  // set when the FillLogger opened the file, so it can be closed
+}
 
 var _ api.FillHandler = &FillLogger{}
 
 // MakeFillLogger is a factory method
 func MakeFillLogger() api.FillHandler {
-	return &FillLogger{}
+	return &FillLogger{lock: &sync.Mutex{}}
+}
+
+// MakeJSONFillLogger is a factory method for a FillLogger that logs each fill as a single-line JSON object so it can be parsed by log aggregators
+func MakeJSONFillLogger() api.FillHandler {
+	return &FillLogger{jsonFormat: true, lock: &sync.Mutex{}}
+}
+
+// MakeFillLoggerWithWriter is a factory method for a FillLogger that writes each fill as a line to w instead of the standard log, the
+// writer is flushed after each fill if it supports flushing (such as a bufio.Writer or an os.File)
+func MakeFillLoggerWithWriter(w io.Writer, jsonFormat bool) (*FillLogger, error) {
+	if w == nil {
+		return nil, fmt.Errorf("the writer for the fill logger cannot be nil")
+	}
+	return &FillLogger{
+		jsonFormat: jsonFormat,
+		lock:       &sync.Mutex{},
+		writer:     w,
+	}, nil
+}
+
+// MakeFillLoggerWithFile is a factory method for a FillLogger that appends each fill as a line to the file, the file is opened in append
+// mode so multiple bots can write to the same file without overwriting each other's fills
+func MakeFillLoggerWithFile(filePath string, jsonFormat bool) (*FillLogger, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("the file path for the fill logger cannot be empty")
+	}
+
+	f, e := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if e != nil {
+		return nil, fmt.Errorf("could not open file '%s' for the fill logger: %s", filePath, e)
+	}
+	return &FillLogger{
+		jsonFormat: jsonFormat,
+		lock:       &sync.Mutex{},
+		writer:     f,
+		file:       f,
+	}, nil
 }
 
 // HandleFill impl.
 func (f *FillLogger) HandleFill(trade model.Trade) error {
-	log.Printf("received fill: %s\n", trade)
+	line := fmt.Sprintf("received fill: %s", trade)
+	if f.jsonFormat {
+		jsonLine, e := json.Marshal(makeJSONFill(trade))
+		if e != nil {
+			return fmt.Errorf("could not marshal fill to JSON: %s", e)
+		}
+		line = string(jsonLine)
+	}
+
+	if f.writer == nil {
+		log.Printf("%s\n", line)
+		return nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	// write the line with a single call so appends from concurrent writers are not interleaved
+	_, e := f.writer.Write([]byte(line + "\n"))
+	if e != nil {
+		return fmt.Errorf("could not write fill: %s", e)
+	}
+	return f.flush()
+}
+
+// flush flushes the writer to make sure the fill is not lost if the bot crashes
+func (f *FillLogger) flush() error {
+	if w, ok := f.writer.(interface{ Flush() error }); ok {
+		e := w.Flush()
+		if e != nil {
+			return fmt.Errorf("could not flush fill: %s", e)
+		}
+	}
+	if w, ok := f.writer.(interface{ Sync() error }); ok {
+		e := w.Sync()
+		if e != nil {
+			return fmt.Errorf("could not sync fill: %s", e)
+		}
+	}
 	return nil
 }
+
+// Close closes the file if the FillLogger opened it, writers passed in by the caller are not closed
+func (f *FillLogger) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	e := f.file.Close()
+	f.file = nil
+	return e
+}
+
+func makeJSONFill(trade model.Trade) jsonFill {
+	return jsonFill{
+		Pair:      fillPairString(trade),
+		Side:      trade.OrderAction.String(),
+		Price:     csvNumber(trade.Price),
+		Amount:    csvNumber(trade.Volume),
+		Cost:      csvNumber(trade.Cost),
+		OrderID:   trade.OrderID,
+		Timestamp: fillTimestampString(trade),
+	}
+}
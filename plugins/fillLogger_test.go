@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestMakeJSONFill(t *testing.T) {
+	testCases := []struct {
+		name  string
+		trade model.Trade
+		want  string
+	}{
+		{
+			name:  "all fields",
+			trade: makeTestCsvTrade("order1"),
+			want:  `{"pair":"XLM/USD","side":"sell","price":"0.1000","amount":"100.00","cost":"10.00","order_id":"order1","timestamp":"2020-01-01T00:00:00Z"}`,
+		}, {
+			name: "missing fields",
+			trade: model.Trade{
+				Order: model.Order{OrderAction: model.OrderActionBuy},
+			},
+			want: `{"pair":"","side":"buy","price":"","amount":"","cost":"","order_id":"","timestamp":""}`,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			line, e := json.Marshal(makeJSONFill(k.trade))
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, string(line))
+		})
+	}
+}
+
+func TestFillLoggerWithWriter(t *testing.T) {
+	_, e := MakeFillLoggerWithWriter(nil, false)
+	assert.Error(t, e)
+
+	buf := &bytes.Buffer{}
+	// the bufio.Writer would hold on to the lines if the fill logger did not flush it
+	l, e := MakeFillLoggerWithWriter(bufio.NewWriter(buf), true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.NoError(t, l.HandleFill(makeTestCsvTrade("order1"))) {
+		return
+	}
+	if !assert.NoError(t, l.HandleFill(makeTestCsvTrade("order2"))) {
+		return
+	}
+	line1 := `{"pair":"XLM/USD","side":"sell","price":"0.1000","amount":"100.00","cost":"10.00","order_id":"order1","timestamp":"2020-01-01T00:00:00Z"}`
+	line2 := `{"pair":"XLM/USD","side":"sell","price":"0.1000","amount":"100.00","cost":"10.00","order_id":"order2","timestamp":"2020-01-01T00:00:00Z"}`
+	assert.Equal(t, line1+"\n"+line2+"\n", buf.String())
+}
+
+func TestFillLoggerWithFile(t *testing.T) {
+	dir, e := ioutil.TempDir("", "fill_logger")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "fills.log")
+
+	// two loggers (such as two bots) append to the same file without overwriting each other
+	l1, e := MakeFillLoggerWithFile(filePath, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer l1.Close()
+	l2, e := MakeFillLoggerWithFile(filePath, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer l2.Close()
+
+	trade1 := makeTestCsvTrade("order1")
+	trade2 := makeTestCsvTrade("order2")
+	if !assert.NoError(t, l1.HandleFill(trade1)) {
+		return
+	}
+	if !assert.NoError(t, l2.HandleFill(trade2)) {
+		return
+	}
+	if !assert.NoError(t, l1.HandleFill(trade1)) {
+		return
+	}
+
+	data, e := ioutil.ReadFile(filePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	line1 := "received fill: " + trade1.String() + "\n"
+	line2 := "received fill: " + trade2.String() + "\n"
+	assert.Equal(t, line1+line2+line1, string(data))
+
+	_, e = MakeFillLoggerWithFile("", false)
+	assert.Error(t, e)
+}
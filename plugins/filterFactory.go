@@ -36,6 +36,8 @@ type FilterFactory struct {
 	BaseAsset      hProtocol.Asset
 	QuoteAsset     hProtocol.Asset
 	DB             *sql.DB
+	// VolumeFilterMetrics is optional, volume filters record their state to it when it is non-nil
+	VolumeFilterMetrics *VolumeFilterMetrics
 }
 
 // MakeFilter is the function that makes the required filters
@@ -54,10 +56,18 @@ func (f *FilterFactory) MakeFilter(configInput string) (SubmitFilter, error) {
 	return factoryMethod(f, configInput)
 }
 
+// volumeFilterTierDelimiter separates the volume filters that are combined into a single filter with tiered caps,
+// eg. "volume/hourly/sell/base/150.0/exact|volume/daily/sell/base/3500.0/exact"
+const volumeFilterTierDelimiter = "|"
+
 func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
-	config, e := makeVolumeFilterConfig(configInput)
-	if e != nil {
-		return nil, fmt.Errorf("could not make VolumeFilterConfig for configInput (%s): %s", configInput, e)
+	configs := []*VolumeFilterConfig{}
+	for _, tierInput := range strings.Split(configInput, volumeFilterTierDelimiter) {
+		config, e := makeVolumeFilterConfig(strings.TrimSpace(tierInput))
+		if e != nil {
+			return nil, fmt.Errorf("could not make VolumeFilterConfig for configInput (%s): %s", configInput, e)
+		}
+		configs = append(configs, config)
 	}
 
 	return makeFilterVolume(
@@ -68,7 +78,8 @@ func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
 		f.BaseAsset,
 		f.QuoteAsset,
 		f.DB,
-		config,
+		configs,
+		f.VolumeFilterMetrics,
 	)
 }
 
@@ -77,16 +88,20 @@ func makeRawVolumeFilterConfig(
 	baseAssetCapInQuoteUnits *float64,
 	action queries.DailyVolumeAction,
 	mode volumeFilterMode,
+	window queries.VolumeWindow,
 	additionalMarketIDs []string,
 	optionalAccountIDs []string,
+	excludedAccountIDs []string,
 ) *VolumeFilterConfig {
 	return &VolumeFilterConfig{
 		BaseAssetCapInBaseUnits:  baseAssetCapInBaseUnits,
 		BaseAssetCapInQuoteUnits: baseAssetCapInQuoteUnits,
 		action:                   action,
 		mode:                     mode,
+		window:                   window,
 		additionalMarketIDs:      additionalMarketIDs,
 		optionalAccountIDs:       optionalAccountIDs,
+		excludedAccountIDs:       excludedAccountIDs,
 	}
 }
 
@@ -96,16 +111,23 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 		return nil, fmt.Errorf("invalid input (%s), needs 6 parts separated by the delimiter (/)", configInput)
 	}
 
-	mode, e := parseVolumeFilterMode(parts[5])
+	modeParts := strings.Split(parts[5], ":")
+	mode, e := parseVolumeFilterMode(modeParts[0])
 	if e != nil {
 		return nil, fmt.Errorf("could not parse volume filter mode from input (%s): %s", configInput, e)
 	}
 	config := &VolumeFilterConfig{mode: mode}
+	e = addSoftCapParametersToConfig(config, modeParts[1:])
+	if e != nil {
+		return nil, fmt.Errorf("invalid input (%s), the mode can have parameters only when it is \"soft\" like so 'soft', 'soft:0.8' or 'soft:0.8:quadratic': %s", configInput, e)
+	}
 
 	limitWindowParts := strings.Split(parts[1], ":")
-	if limitWindowParts[0] != "daily" {
-		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"daily\"", configInput)
+	window, e := queries.ParseVolumeWindow(limitWindowParts[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"hourly\", \"daily\", \"weekly\", or \"rolling24h\": %s", configInput, e)
 	}
+	config.window = window
 
 	action, e := queries.ParseDailyVolumeAction(parts[2])
 	if e != nil {
@@ -113,24 +135,15 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	}
 	config.action = action
 
-	errInvalid := fmt.Errorf("invalid input (%s), the modifier for \"daily\" can be either \"market_ids\" or \"account_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]' or 'daily:account_ids=[account1,account2]' or 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]'", configInput)
-	if len(limitWindowParts) == 2 {
-		e = addModifierToConfig(config, limitWindowParts[1])
-		if e != nil {
-			return nil, fmt.Errorf("%s: could not addModifierToConfig for %s: %s", errInvalid, limitWindowParts[1], e)
-		}
-	} else if len(limitWindowParts) == 3 {
-		e = addModifierToConfig(config, limitWindowParts[1])
-		if e != nil {
-			return nil, fmt.Errorf("%s: could not addModifierToConfig for %s: %s", errInvalid, limitWindowParts[1], e)
-		}
-
-		e = addModifierToConfig(config, limitWindowParts[2])
+	errInvalid := fmt.Errorf("invalid input (%s), the modifier for \"daily\" can be either \"market_ids\", \"account_ids\" or \"excluded_account_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]' or 'daily:account_ids=[account1,account2]' or 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]' or 'daily:market_ids=[4c19915f47,db4531d586]:excluded_account_ids=[account3]'", configInput)
+	if len(limitWindowParts) > 4 {
+		return nil, fmt.Errorf("invalid input (%s), the second part can have at most three modifiers \"market_ids\", \"account_ids\" and \"excluded_account_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]:excluded_account_ids=[account3]'", configInput)
+	}
+	for _, modifierMapping := range limitWindowParts[1:] {
+		e = addModifierToConfig(config, modifierMapping)
 		if e != nil {
-			return nil, fmt.Errorf("%s: could not addModifierToConfig for %s: %s", errInvalid, limitWindowParts[2], e)
+			return nil, fmt.Errorf("%s: could not addModifierToConfig for %s: %s", errInvalid, modifierMapping, e)
 		}
-	} else if len(limitWindowParts) != 1 {
-		return nil, fmt.Errorf("invalid input (%s), the second part needs to be \"daily\" and can have only one modifier \"market_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]'", configInput)
 	}
 
 	limit, e := strconv.ParseFloat(parts[4], 64)
@@ -151,6 +164,37 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	return config, nil
 }
 
+// addSoftCapParametersToConfig sets the optional start and curve parameters of the soft mode, falling back to the defaults when not specified
+func addSoftCapParametersToConfig(config *VolumeFilterConfig, params []string) error {
+	if config.mode != volumeFilterModeSoft {
+		if len(params) > 0 {
+			return fmt.Errorf("mode '%s' does not accept parameters", config.mode)
+		}
+		return nil
+	}
+
+	if len(params) > 2 {
+		return fmt.Errorf("soft mode accepts at most two parameters but had %d", len(params))
+	}
+	config.softCapStart = defaultSoftCapStart
+	config.softCapCurve = defaultSoftCapCurve
+	if len(params) > 0 {
+		start, e := strconv.ParseFloat(params[0], 64)
+		if e != nil {
+			return fmt.Errorf("could not parse soft cap start (%s) as a float: %s", params[0], e)
+		}
+		config.softCapStart = start
+	}
+	if len(params) > 1 {
+		curve, e := parseSoftCapCurve(params[1])
+		if e != nil {
+			return fmt.Errorf("could not parse soft cap curve: %s", e)
+		}
+		config.softCapCurve = curve
+	}
+	return nil
+}
+
 func addModifierToConfig(config *VolumeFilterConfig, modifierMapping string) error {
 	ids, modifierType, e := parseVolumeFilterModifier(modifierMapping)
 	if e != nil {
@@ -163,6 +207,9 @@ func addModifierToConfig(config *VolumeFilterConfig, modifierMapping string) err
 	} else if modifierType == "account_ids" {
 		config.optionalAccountIDs = ids
 		return nil
+	} else if modifierType == "excluded_account_ids" {
+		config.excludedAccountIDs = ids
+		return nil
 	}
 	return fmt.Errorf("programmer error? invalid modifier type '%s', should have thrown an error above when calling parseVolumeFilterModifier", modifierType)
 }
@@ -192,6 +239,8 @@ func parseVolumeFilterModifier(modifierMapping string) ([]string, string, error)
 		return ids, "market_ids", nil
 	} else if strings.HasPrefix(modifierMapping, "account_ids=") {
 		return ids, "account_ids", nil
+	} else if strings.HasPrefix(modifierMapping, "excluded_account_ids=") {
+		return ids, "excluded_account_ids", nil
 	}
 
 	return nil, "", fmt.Errorf("invalid prefix for volume filter modifier '%s'", modifierMapping)
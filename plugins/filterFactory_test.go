@@ -91,6 +91,11 @@ func TestParseVolumeFilterModifier(t *testing.T) {
 			wantIds:          []string{},
 			wantModifierType: "account_ids",
 			wantError:        nil,
+		}, {
+			modifierMapping:  "excluded_account_ids=[account3]",
+			wantIds:          []string{"account3"},
+			wantModifierType: "excluded_account_ids",
+			wantError:        nil,
 		},
 	}
 
@@ -115,6 +120,9 @@ func TestAddModifierToConfig(t *testing.T) {
 		}, {
 			modifierMapping: "account_ids=[accountX]",
 			wantConfig:      &VolumeFilterConfig{optionalAccountIDs: []string{"accountX"}},
+		}, {
+			modifierMapping: "excluded_account_ids=[accountY]",
+			wantConfig:      &VolumeFilterConfig{excludedAccountIDs: []string{"accountY"}},
 		},
 	}
 
@@ -143,6 +151,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      nil,
 				optionalAccountIDs:       nil,
 			},
@@ -151,6 +160,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  nil,
 				BaseAssetCapInQuoteUnits: pointy.Float64(4000.0),
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      nil,
 				optionalAccountIDs:       nil,
 			},
@@ -160,6 +170,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      nil,
 				optionalAccountIDs:       nil,
 			},
@@ -168,6 +179,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  nil,
 				BaseAssetCapInQuoteUnits: pointy.Float64(1000.0),
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      nil,
 				optionalAccountIDs:       nil,
 			},
@@ -176,6 +188,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
 				optionalAccountIDs:       nil,
 			},
@@ -184,6 +197,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      nil,
 				optionalAccountIDs:       []string{"account1", "account2"},
 			},
@@ -192,21 +206,69 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 			wantConfig: &VolumeFilterConfig{
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
 				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
 				optionalAccountIDs:       []string{"account1", "account2"},
 			},
+		}, {
+			configInput: "volume/daily:market_ids=[4c19915f47]:excluded_account_ids=[account3]/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
+				additionalMarketIDs:      []string{"4c19915f47"},
+				optionalAccountIDs:       nil,
+				excludedAccountIDs:       []string{"account3"},
+			},
+		}, {
+			configInput: "volume/daily:market_ids=[4c19915f47]:account_ids=[account1,account2]:excluded_account_ids=[account2]/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowDaily,
+				additionalMarketIDs:      []string{"4c19915f47"},
+				optionalAccountIDs:       []string{"account1", "account2"},
+				excludedAccountIDs:       []string{"account2"},
+			},
+		}, {
+			configInput: "volume/hourly/%s/base/100.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  pointy.Float64(100.0),
+				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowHourly,
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       nil,
+			},
+		}, {
+			configInput: "volume/weekly:market_ids=[4c19915f47]/%s/quote/25000.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  nil,
+				BaseAssetCapInQuoteUnits: pointy.Float64(25000.0),
+				window:                   queries.VolumeWindowWeekly,
+				additionalMarketIDs:      []string{"4c19915f47"},
+				optionalAccountIDs:       nil,
+			},
+		}, {
+			configInput: "volume/rolling24h/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				window:                   queries.VolumeWindowRolling24h,
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       nil,
+			},
 		},
 	}
 
 	modes := []volumeFilterMode{volumeFilterModeExact, volumeFilterModeIgnore}
-	actions := []queries.DailyVolumeAction{queries.DailyVolumeActionBuy, queries.DailyVolumeActionSell}
+	actions := []queries.DailyVolumeAction{queries.DailyVolumeActionBuy, queries.DailyVolumeActionSell, queries.DailyVolumeActionBoth}
 	for _, k := range testCases {
 		// loop over both modes, and inject the desired mode in the config
 		for _, m := range modes {
 			wantConfig := k.wantConfig
 			wantConfig.mode = m
 
-			// loop over both actions, and inject the desired action in the config
+			// loop over all the actions, and inject the desired action in the config
 			for _, a := range actions {
 				wantConfig.action = a
 				configInput := fmt.Sprintf(k.configInput, a, m)
@@ -233,7 +295,63 @@ func assertVolumeFilterConfigEqual(t *testing.T, want *VolumeFilterConfig, actua
 		assert.Equal(t, want.BaseAssetCapInQuoteUnits, actual.BaseAssetCapInQuoteUnits)
 		assert.Equal(t, want.action, actual.action)
 		assert.Equal(t, want.mode, actual.mode)
+		assert.Equal(t, want.window, actual.window)
 		assert.Equal(t, want.additionalMarketIDs, actual.additionalMarketIDs)
 		assert.Equal(t, want.optionalAccountIDs, actual.optionalAccountIDs)
+		assert.Equal(t, want.excludedAccountIDs, actual.excludedAccountIDs)
+	}
+}
+
+func TestMakeVolumeFilterConfigSoftMode(t *testing.T) {
+	testCases := []struct {
+		configInput string
+		wantError   bool
+		wantStart   float64
+		wantCurve   softCapCurve
+	}{
+		{
+			configInput: "volume/daily/sell/base/3500.0/soft",
+			wantStart:   defaultSoftCapStart,
+			wantCurve:   defaultSoftCapCurve,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/soft:0.5",
+			wantStart:   0.5,
+			wantCurve:   defaultSoftCapCurve,
+		}, {
+			configInput: "volume/hourly/buy/quote/100.0/soft:0.25:quadratic",
+			wantStart:   0.25,
+			wantCurve:   softCapCurveQuadratic,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/soft:1.0",
+			wantError:   true,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/soft:abc",
+			wantError:   true,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/soft:0.5:cubic",
+			wantError:   true,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/soft:0.5:linear:1",
+			wantError:   true,
+		}, {
+			configInput: "volume/daily/sell/base/3500.0/exact:0.5",
+			wantError:   true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			actual, e := makeVolumeFilterConfig(k.configInput)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, volumeFilterModeSoft, actual.mode)
+			assert.Equal(t, k.wantStart, actual.softCapStart)
+			assert.Equal(t, k.wantCurve, actual.softCapCurve)
+		})
 	}
 }
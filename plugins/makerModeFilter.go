@@ -12,8 +12,13 @@ import (
 	"github.com/stellar/kelp/support/utils"
 )
 
+// submitFilterOrderbookDepth is the depth of the orderbook fetched by the maker and taker mode filters on each call to Apply.
+// A depth of 1 is not enough since the top of the book can be made up of the trader's own offers which are excluded from the top price
+const submitFilterOrderbookDepth = 50
+
 type makerModeFilter struct {
 	name         string
+	submitMode   api.SubmitMode // only used to describe the filter, maker_only and post_only behave the same
 	tradingPair  *model.TradingPair
 	exchangeShim api.ExchangeShim
 	sdex         *SDEX
@@ -21,8 +26,19 @@ type makerModeFilter struct {
 
 // MakeFilterMakerMode makes a submit filter based on the passed in submitMode
 func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+	return makeFilterMakerMode(api.SubmitModeMakerOnly, exchangeShim, sdex, tradingPair)
+}
+
+// MakeFilterPostOnlyMode makes a submit filter for the post only submit mode, which is an alias of the maker only submit mode since the
+// maker mode filter already drops operations that would cross the orderbook and never reprices them
+func MakeFilterPostOnlyMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+	return makeFilterMakerMode(api.SubmitModePostOnly, exchangeShim, sdex, tradingPair)
+}
+
+func makeFilterMakerMode(submitMode api.SubmitMode, exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
 	return &makerModeFilter{
 		name:         "makeModeFilter",
+		submitMode:   submitMode,
 		tradingPair:  tradingPair,
 		exchangeShim: exchangeShim,
 		sdex:         sdex,
@@ -31,8 +47,19 @@ func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair
 
 var _ SubmitFilter = &makerModeFilter{}
 
+// Name impl.
+func (f *makerModeFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *makerModeFilter) Describe() string {
+	return fmt.Sprintf("drops operations that would cross the orderbook so that only maker orders are placed, operations are never repriced (submit mode %s)", f.submitMode.String())
+}
+
 func (f *makerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	ob, e := f.exchangeShim.GetOrderBook(f.tradingPair, 50)
+	// fetch the orderbook once and reuse it for all the operations filtered in this call
+	ob, e := f.exchangeShim.GetOrderBook(f.tradingPair, submitFilterOrderbookDepth)
 	if e != nil {
 		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
 	}
@@ -42,22 +69,29 @@ func (f *makerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProto
 		return nil, fmt.Errorf("could not get assets: %s", e)
 	}
 
+	oc := f.exchangeShim.GetOrderConstraints(f.tradingPair)
+	numCrossingDropped := 0
 	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
-		topBidPrice, e := f.topOrderPriceExcludingTrader(ob.Bids(), buyingOffers, false)
+		topBidPrice, e := topOrderPriceExcludingTrader(oc, ob.Bids(), buyingOffers, false)
 		if e != nil {
 			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for bids: %s", e)
 		}
-		topAskPrice, e := f.topOrderPriceExcludingTrader(ob.Asks(), sellingOffers, true)
+		topAskPrice, e := topOrderPriceExcludingTrader(oc, ob.Asks(), sellingOffers, true)
 		if e != nil {
 			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for asks: %s", e)
 		}
 
-		return f.transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, op)
+		newOp, e := f.transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, op)
+		if e == nil && newOp == nil {
+			numCrossingDropped++
+		}
+		return newOp, e
 	}
 	ops, e = filterOps(f.name, baseAsset, quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
+	log.Printf("%s: dropped %d operations that would have crossed the orderbook in this cycle\n", f.name, numCrossingDropped)
 	return ops, nil
 }
 
@@ -72,9 +106,7 @@ func isNewLevel(lastPrice *model.Number, priceNumber *model.Number, isSell bool)
 	return false
 }
 
-func (f *makerModeFilter) collateOffers(traderOffers []hProtocol.Offer, isSell bool) ([]api.Level, error) {
-	oc := f.exchangeShim.GetOrderConstraints(f.tradingPair)
-
+func collateOffers(oc *model.OrderConstraints, traderOffers []hProtocol.Offer, isSell bool) ([]api.Level, error) {
 	levels := []api.Level{}
 	var lastPrice *model.Number
 	for _, tOffer := range traderOffers {
@@ -102,8 +134,9 @@ func (f *makerModeFilter) collateOffers(traderOffers []hProtocol.Offer, isSell b
 	return levels, nil
 }
 
-func (f *makerModeFilter) topOrderPriceExcludingTrader(obSide []model.Order, traderOffers []hProtocol.Offer, isSell bool) (*model.Number, error) {
-	traderLevels, e := f.collateOffers(traderOffers, isSell)
+// topOrderPriceExcludingTrader returns the price of the top order on the orderbook side that does not belong to the trader, nil if there is no such order
+func topOrderPriceExcludingTrader(oc *model.OrderConstraints, obSide []model.Order, traderOffers []hProtocol.Offer, isSell bool) (*model.Number, error) {
+	traderLevels, e := collateOffers(oc, traderOffers, isSell)
 	if e != nil {
 		return nil, fmt.Errorf("unable to collate offers: %s", e)
 	}
@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// orderbookCountingShim is an api.ExchangeShim that returns a fixed orderbook and counts the calls to GetOrderBook
+type orderbookCountingShim struct {
+	api.ExchangeShim
+	ob             *model.OrderBook
+	getOrderBookNs []int32
+}
+
+func (s *orderbookCountingShim) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	s.getOrderBookNs = append(s.getOrderBookNs, maxCount)
+	return s.ob, nil
+}
+
+func (s *orderbookCountingShim) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	return model.MakeOrderConstraints(7, 7, 0.1)
+}
+
+func makeTestFilterSdexAndShim() (*model.TradingPair, *SDEX, *orderbookCountingShim) {
+	pair := &model.TradingPair{Base: "XLM", Quote: "QUOTE"}
+	sdex := &SDEX{
+		pair: pair,
+		assetMap: map[model.Asset]hProtocol.Asset{
+			model.Asset("XLM"):   utils.Asset2Asset2(testBaseAsset),
+			model.Asset("QUOTE"): utils.Asset2Asset2(testQuoteAsset),
+		},
+	}
+	shim := &orderbookCountingShim{
+		ob: model.MakeOrderBook(
+			pair,
+			[]model.Order{{Price: model.NumberFromFloat(1.2, 7), Volume: model.NumberFromFloat(100, 7)}},
+			[]model.Order{{Price: model.NumberFromFloat(1.0, 7), Volume: model.NumberFromFloat(100, 7)}},
+		),
+	}
+	return pair, sdex, shim
+}
+
+func TestMakerModeFilterFetchesOrderBookOncePerApply(t *testing.T) {
+	pair, sdex, shim := makeTestFilterSdexAndShim()
+	f := MakeFilterMakerMode(shim, sdex, pair)
+
+	ops := []txnbuild.Operation{
+		makeSellOpAmtPrice(10.0, 1.1),
+		makeSellOpAmtPrice(10.0, 0.9),
+		makeSellOpAmtPrice(10.0, 1.3),
+	}
+	filteredOps, e := f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.Equal(t, []int32{submitFilterOrderbookDepth}, shim.getOrderBookNs)
+	assert.Equal(t, []txnbuild.Operation{ops[0], ops[2]}, filteredOps)
+}
+
+func TestTransformOfferMakerMode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		topBidPrice *model.Number
+		topAskPrice *model.Number
+		op          *txnbuild.ManageSellOffer
+		wantKeep    bool
+	}{
+		{
+			name:        "sell above top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 1.1),
+			wantKeep:    true,
+		}, {
+			name:        "sell at top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 1.0),
+			wantKeep:    false,
+		}, {
+			name:        "sell below top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 0.9),
+			wantKeep:    false,
+		}, {
+			name:        "sell with no bids",
+			topBidPrice: nil,
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 0.9),
+			wantKeep:    true,
+		}, {
+			name:        "buy below top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 1.0),
+			wantKeep:    true,
+		}, {
+			name:        "buy at top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 1.25),
+			wantKeep:    false,
+		}, {
+			name:        "buy above top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 2.0),
+			wantKeep:    false,
+		}, {
+			name:        "buy with no asks",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: nil,
+			op:          makeBuyOpAmtPrice(10.0, 2.0),
+			wantKeep:    true,
+		}, {
+			name:        "delete op is always kept",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          &txnbuild.ManageSellOffer{Buying: testQuoteAsset, Selling: testBaseAsset, Amount: "0", Price: "0.5", OfferID: 1},
+			wantKeep:    true,
+		},
+	}
+
+	f := &makerModeFilter{}
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			baseAsset := utils.Asset2Asset2(testBaseAsset)
+			quoteAsset := utils.Asset2Asset2(testQuoteAsset)
+
+			actual, e := f.transformOfferMakerMode(baseAsset, quoteAsset, k.topBidPrice, k.topAskPrice, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			if k.wantKeep {
+				assert.Equal(t, k.op, actual)
+			} else {
+				assert.Nil(t, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// MarketID identifies a market on an exchange. The canonical form of a MarketID is "<exchangeName>_<baseAsset>_<quoteAsset>" where
+// the assets are represented as follows:
+//   - SDEX: "native" for XLM and "CODE:ISSUER" for all other assets (the same as utils.Asset2String), eg "sdex_native_USD:GDUKMG..."
+//   - centralized exchanges: the uppercase asset codes of the CCXT symbol, eg "ccxt-binance_XLM_USDT" for the symbol "XLM/USDT"
+//
+// The hash of the canonical form (see Hash) is the market_id that is used in the database to key fills and volume queries, so
+// two code paths that construct a MarketID for the same market using the constructors below will always match
+type MarketID struct {
+	ExchangeName string
+	BaseAsset    string
+	QuoteAsset   string
+}
+
+// MakeSdexMarketID makes a MarketID for a market on SDEX
+func MakeSdexMarketID(exchangeName string, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) (MarketID, error) {
+	return makeMarketID(exchangeName, utils.Asset2String(baseAsset), utils.Asset2String(quoteAsset))
+}
+
+// MakeCcxtMarketID makes a MarketID for a market on a centralized exchange from the CCXT symbol, eg "XLM/USDT"
+func MakeCcxtMarketID(exchangeName string, symbol string) (MarketID, error) {
+	pair, e := model.FromCcxtSymbol(symbol)
+	if e != nil {
+		return MarketID{}, fmt.Errorf("could not parse CCXT symbol: %s", e)
+	}
+	return makeMarketID(exchangeName, string(pair.Base), string(pair.Quote))
+}
+
+// MakeMarketIDFromTradingPair makes a MarketID for the trading pair using the assetDisplayFn to convert the assets, the display
+// function is issuer independent for non-SDEX exchanges which keeps the MarketID consistent across bots on those exchanges
+func MakeMarketIDFromTradingPair(exchangeName string, tradingPair *model.TradingPair, assetDisplayFn model.AssetDisplayFn) (MarketID, error) {
+	baseAssetString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		return MarketID{}, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	}
+	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		return MarketID{}, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	}
+	return makeMarketID(exchangeName, baseAssetString, quoteAssetString)
+}
+
+// ParseMarketID parses the canonical form of a MarketID, it is the inverse of MarketID.String
+func ParseMarketID(s string) (MarketID, error) {
+	parts := strings.Split(s, "_")
+	if len(parts) < 3 {
+		return MarketID{}, fmt.Errorf("invalid market ID '%s', expected the format exchangeName_baseAsset_quoteAsset", s)
+	}
+	// the exchange name is the only component that could contain an underscore
+	n := len(parts)
+	return makeMarketID(strings.Join(parts[:n-2], "_"), parts[n-2], parts[n-1])
+}
+
+// makeMarketID validates and normalizes the components of a MarketID
+func makeMarketID(exchangeName string, baseAsset string, quoteAsset string) (MarketID, error) {
+	exchangeName = strings.TrimSpace(exchangeName)
+	if exchangeName == "" {
+		return MarketID{}, fmt.Errorf("exchange name of a market ID cannot be empty")
+	}
+
+	base, e := normalizeMarketAsset(baseAsset)
+	if e != nil {
+		return MarketID{}, fmt.Errorf("invalid base asset: %s", e)
+	}
+	quote, e := normalizeMarketAsset(quoteAsset)
+	if e != nil {
+		return MarketID{}, fmt.Errorf("invalid quote asset: %s", e)
+	}
+	return MarketID{
+		ExchangeName: exchangeName,
+		BaseAsset:    base,
+		QuoteAsset:   quote,
+	}, nil
+}
+
+// normalizeMarketAsset converts the asset to its canonical form: "native", "CODE:ISSUER" or "CODE" (uppercase)
+func normalizeMarketAsset(asset string) (string, error) {
+	asset = strings.TrimSpace(asset)
+	if asset == "" {
+		return "", fmt.Errorf("asset cannot be empty")
+	}
+	if strings.Contains(asset, "_") {
+		return "", fmt.Errorf("asset '%s' cannot contain an underscore", asset)
+	}
+	if strings.ToLower(asset) == utils.Native {
+		return utils.Native, nil
+	}
+
+	parts := strings.Split(asset, ":")
+	if len(parts) > 2 || (len(parts) == 2 && (parts[0] == "" || parts[1] == "")) {
+		return "", fmt.Errorf("asset '%s' needs to be in the format CODE or CODE:ISSUER", asset)
+	}
+	if len(parts) == 2 {
+		// asset codes on SDEX are case sensitive so we cannot change the case
+		return asset, nil
+	}
+	return strings.ToUpper(asset), nil
+}
+
+// String returns the canonical form of the MarketID
+func (m MarketID) String() string {
+	return fmt.Sprintf("%s_%s_%s", m.ExchangeName, m.BaseAsset, m.QuoteAsset)
+}
+
+// Hash returns the market_id that is used in the database
+func (m MarketID) Hash() string {
+	return MakeMarketID(m.ExchangeName, m.BaseAsset, m.QuoteAsset)
+}
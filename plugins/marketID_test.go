@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+const testIssuer = "GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX"
+
+func TestMakeSdexMarketID(t *testing.T) {
+	m, e := MakeSdexMarketID("sdex", utils.NativeAsset, utils.String2Asset("USD", testIssuer))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "sdex_native_USD:"+testIssuer, m.String())
+
+	// asset codes on SDEX are case sensitive
+	m, e = MakeSdexMarketID("sdex", utils.String2Asset("usd", testIssuer), utils.NativeAsset)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "sdex_usd:"+testIssuer+"_native", m.String())
+}
+
+func TestMakeCcxtMarketID(t *testing.T) {
+	testCases := []struct {
+		symbol    string
+		want      string
+		wantError bool
+	}{
+		{symbol: "XLM/USDT", want: "ccxt-binance_XLM_USDT"},
+		{symbol: "xlm/usdt", want: "ccxt-binance_XLM_USDT"},
+		{symbol: "XLM-USDT", wantError: true},
+		{symbol: "XLM/", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.symbol, func(t *testing.T) {
+			m, e := MakeCcxtMarketID("ccxt-binance", k.symbol)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, m.String())
+		})
+	}
+}
+
+func TestMarketIDMatchesAcrossConstructors(t *testing.T) {
+	fromSymbol, e := MakeCcxtMarketID("kraken", "XLM/USD")
+	if !assert.NoError(t, e) {
+		return
+	}
+	fromPair, e := MakeMarketIDFromTradingPair("kraken", model.MakeTradingPair(model.XLM, model.USD), model.MakePassthroughAssetDisplayFn())
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, fromSymbol, fromPair)
+	// the hash is unchanged from the values in the database
+	assert.Equal(t, "96eda0a6ec", fromPair.Hash())
+}
+
+func TestParseMarketID(t *testing.T) {
+	testCases := []struct {
+		input     string
+		want      MarketID
+		wantError bool
+	}{
+		{
+			input: "ccxt-binance_XLM_USDT",
+			want:  MarketID{ExchangeName: "ccxt-binance", BaseAsset: "XLM", QuoteAsset: "USDT"},
+		}, {
+			input: "sdex_native_USD:" + testIssuer,
+			want:  MarketID{ExchangeName: "sdex", BaseAsset: "native", QuoteAsset: "USD:" + testIssuer},
+		}, {
+			input: "some_exchange_XLM_BTC",
+			want:  MarketID{ExchangeName: "some_exchange", BaseAsset: "XLM", QuoteAsset: "BTC"},
+		}, {
+			input:     "kraken_XLM",
+			wantError: true,
+		}, {
+			input:     "_XLM_USD",
+			wantError: true,
+		}, {
+			input:     "sdex_native_USD:",
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.input, func(t *testing.T) {
+			m, e := ParseMarketID(k.input)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, m)
+
+			// round trip
+			assert.Equal(t, k.input, m.String())
+		})
+	}
+}
@@ -34,6 +34,16 @@ func MakeFilterMaxPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, c
 
 var _ SubmitFilter = &maxPriceFilter{}
 
+// Name impl.
+func (f *maxPriceFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *maxPriceFilter) Describe() string {
+	return fmt.Sprintf("drops operations with a price above the max price: %s", f.config)
+}
+
 // Validate ensures validity
 func (c *MaxPriceFilterConfig) Validate() error {
 	if c.MaxPrice == nil {
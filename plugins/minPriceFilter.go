@@ -34,6 +34,16 @@ func MakeFilterMinPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, c
 
 var _ SubmitFilter = &minPriceFilter{}
 
+// Name impl.
+func (f *minPriceFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *minPriceFilter) Describe() string {
+	return fmt.Sprintf("drops operations with a price below the min price: %s", f.config)
+}
+
 // Validate ensures validity
 func (c *MinPriceFilterConfig) Validate() error {
 	if c.MinPrice == nil {
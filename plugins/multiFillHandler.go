@@ -0,0 +1,41 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// MultiFillHandler is a FillHandler that fans out each fill to all of its handlers
+type MultiFillHandler struct {
+	handlers []api.FillHandler
+}
+
+var _ api.FillHandler = &MultiFillHandler{}
+
+// MakeMultiFillHandler is a factory method
+func MakeMultiFillHandler(handlers []api.FillHandler) api.FillHandler {
+	return &MultiFillHandler{
+		handlers: handlers,
+	}
+}
+
+// HandleFill impl.
+func (f *MultiFillHandler) HandleFill(trade model.Trade) error {
+	errs := []string{}
+	for i, h := range f.handlers {
+		e := h.HandleFill(trade)
+		if e != nil {
+			// we do NOT want to return immediately after encountering an error
+			// because we want to give all handlers a chance to get called for the trade
+			errs = append(errs, fmt.Sprintf("handler %d (%T): %s", i, h, e))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d fill handlers failed: [%s]", len(errs), len(f.handlers), strings.Join(errs, "; "))
+	}
+	return nil
+}
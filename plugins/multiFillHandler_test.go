@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+type countingFillHandler struct {
+	count int
+	e     error
+}
+
+func (h *countingFillHandler) HandleFill(trade model.Trade) error {
+	h.count++
+	return h.e
+}
+
+func TestMultiFillHandler(t *testing.T) {
+	h1 := &countingFillHandler{}
+	h2 := &countingFillHandler{e: fmt.Errorf("some error")}
+	h3 := &countingFillHandler{}
+	multi := MakeMultiFillHandler([]api.FillHandler{h1, h2, h3})
+
+	e := multi.HandleFill(model.Trade{})
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.Equal(t, "1 of 3 fill handlers failed: [handler 1 (*plugins.countingFillHandler): some error]", e.Error())
+	// all handlers are called even when one of them fails
+	assert.Equal(t, 1, h1.count)
+	assert.Equal(t, 1, h2.count)
+	assert.Equal(t, 1, h3.count)
+
+	h2.e = nil
+	assert.NoError(t, multi.HandleFill(model.Trade{}))
+	assert.Equal(t, 2, h3.count)
+}
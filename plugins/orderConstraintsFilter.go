@@ -19,6 +19,16 @@ type orderConstraintsFilter struct {
 
 var _ SubmitFilter = &orderConstraintsFilter{}
 
+// Name impl.
+func (f *orderConstraintsFilter) Name() string {
+	return "orderConstraintsFilter"
+}
+
+// Describe impl.
+func (f *orderConstraintsFilter) Describe() string {
+	return fmt.Sprintf("drops operations that do not meet the order constraints of the exchange: %s", f.oc)
+}
+
 // MakeFilterOrderConstraints makes a submit filter based on the passed in orderConstraints
 func MakeFilterOrderConstraints(
 	oc *model.OrderConstraints,
@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// passthroughFilter keeps all operations unchanged, it is used in place of a nil filter so callers do not need nil checks
+type passthroughFilter struct {
+	name        string
+	description string
+}
+
+var _ SubmitFilter = &passthroughFilter{}
+
+// MakeFilterSubmitMode makes the submit filter that enforces the submitMode. The submit modes both and dry_run do not restrict
+// which orders are placed so they return a passthrough filter (the dry_run filter is added separately as the last filter)
+func MakeFilterSubmitMode(submitMode api.SubmitMode, exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+	switch submitMode {
+	case api.SubmitModeMakerOnly:
+		return MakeFilterMakerMode(exchangeShim, sdex, tradingPair)
+	case api.SubmitModePostOnly:
+		return MakeFilterPostOnlyMode(exchangeShim, sdex, tradingPair)
+	case api.SubmitModeTakerOnly:
+		return MakeFilterTakerMode(exchangeShim, sdex, tradingPair)
+	}
+	return &passthroughFilter{
+		name:        "submitModeBothFilter",
+		description: fmt.Sprintf("keeps all operations unchanged because maker and taker orders are both allowed (submit mode %s)", submitMode.String()),
+	}
+}
+
+// Apply impl.
+func (f *passthroughFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	return ops, nil
+}
+
+// Name impl.
+func (f *passthroughFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *passthroughFilter) Describe() string {
+	return f.description
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func TestMakeFilterSubmitMode(t *testing.T) {
+	testCases := []struct {
+		submitMode api.SubmitMode
+		wantName   string
+	}{
+		{submitMode: api.SubmitModeMakerOnly, wantName: "makeModeFilter"},
+		{submitMode: api.SubmitModePostOnly, wantName: "makeModeFilter"},
+		{submitMode: api.SubmitModeTakerOnly, wantName: "takerModeFilter"},
+		{submitMode: api.SubmitModeBoth, wantName: "submitModeBothFilter"},
+		{submitMode: api.SubmitModeDryRun, wantName: "submitModeBothFilter"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.submitMode.String(), func(t *testing.T) {
+			f := MakeFilterSubmitMode(k.submitMode, nil, nil, model.MakeTradingPair(model.XLM, model.USDT))
+			if !assert.NotNil(t, f) {
+				return
+			}
+			assert.Equal(t, k.wantName, f.Name())
+			assert.Contains(t, f.Describe(), k.submitMode.String())
+		})
+	}
+}
+
+func TestPassthroughFilterApply(t *testing.T) {
+	f := MakeFilterSubmitMode(api.SubmitModeBoth, nil, nil, model.MakeTradingPair(model.XLM, model.USDT))
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Amount: "10", Price: "0.1", OfferID: 1},
+		&txnbuild.ManageSellOffer{Amount: "0", Price: "0.2", OfferID: 2},
+	}
+
+	filtered, e := f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, filtered)
+}
@@ -1,18 +1,64 @@
 package plugins
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math"
+	"math/rand"
+	"os"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 )
 
-// use a global variable for now so it is common across both instances (buy and sell side)
-var price2LastPrice map[float64]float64 = map[float64]float64{}
+// pendulumState is the state shared between both instances (buy and sell side) of the pendulumLevelProvider for a single strategy
+type pendulumState struct {
+	lock            *sync.Mutex
+	price2LastPrice map[float64]float64
+	stateFilePath   string // empty if the state should not be saved
+	savedSides      map[string]pendulumSavedSide
+}
+
+// pendulumSavedSide is the state of one side that is saved to the state file so we can continue from the last trade after a restart
+type pendulumSavedSide struct {
+	LastTradeCursor string  `json:"last_trade_cursor"`
+	LastTradePrice  float64 `json:"last_trade_price"`
+}
+
+// makePendulumState is the factory method, it loads the saved state from stateFilePath if the file exists
+func makePendulumState(stateFilePath string) (*pendulumState, error) {
+	s := &pendulumState{
+		lock:            &sync.Mutex{},
+		price2LastPrice: map[float64]float64{},
+		stateFilePath:   stateFilePath,
+		savedSides:      map[string]pendulumSavedSide{},
+	}
+	if stateFilePath == "" {
+		return s, nil
+	}
+
+	data, e := ioutil.ReadFile(stateFilePath)
+	if e != nil {
+		if os.IsNotExist(e) {
+			log.Printf("pendulum state file '%s' does not exist, starting without any saved state\n", stateFilePath)
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read pendulum state file '%s': %s", stateFilePath, e)
+	}
+
+	e = json.Unmarshal(data, &s.savedSides)
+	if e != nil {
+		return nil, fmt.Errorf("could not unmarshal pendulum state file '%s': %s", stateFilePath, e)
+	}
+	log.Printf("loaded pendulum state from file '%s': %+v\n", stateFilePath, s.savedSides)
+	return s, nil
+}
 
 // the keys in price2LastPrice should have a larger precision than the exchange's market supports because we use the same map for
 // storing prices of both buy and sell orders which could hold prices at the same level and we want the map to allow both (instead
@@ -24,17 +70,73 @@ type pendulumLevelProvider struct {
 	spread                        float64
 	offsetSpread                  float64
 	amountBase                    float64
-	useMaxQuoteInTargetAmountCalc bool // else use maxBase
+	amountMultiplier              float64 // the amount of each level is the amount of the previous level times this, 1.0 for the same amount on every level
+	minFillFraction               float64 // fraction of amountBase that needs to be filled at a level before we update the last trade price
+	filledAmountByLevel           map[string]float64
+	amountByLevelPrice            map[float64]float64 // amount of each level placed in the last cycle keyed by its price in units of the real quote asset
+	useMaxQuoteInTargetAmountCalc bool                // else use maxBase
 	maxLevels                     int16
+	maxOffersPerTransaction       int // max number of levels emitted per cycle so they fit in a single transaction, lower than maxLevels to bind
 	lastTradePrice                float64
 	priceLimit                    float64 // last price for which to place order
 	minBase                       float64
+	minQuoteValue                 float64 // min value of a level in units of the quote asset, levels below this are skipped, 0 to disable
+	minLevelAmount                float64 // min amount of a level in units of the base asset, levels below this are skipped, 0 to disable
+	maxQuoteExposure              float64 // max quote committed across all levels on the buy side, 0 to disable
 	tradeFetcher                  api.TradeFetcher
+	maxTradeHistoryPages          int // max pages of trade history fetched per cycle, the remaining trades are fetched in the next cycles
+	maxConsecutiveFetchErrors     int // consecutive errors fetching the trade history that are tolerated before GetLevels fails, 0 to disable
+	consecutiveFetchErrors        int
+	startupJitterFn               func() time.Duration // random delay before the first cycle, nil once it has been applied or if it is disabled
+	sleepFn                       func(time.Duration)
+	staleTradeThreshold           time.Duration // alert when no trade advances the cursor within this duration, 0 to disable
+	alert                         api.Alert     // can be nil
+	lastCursorAdvanceTime         time.Time
+	staleAlertDedupKey            string
+	isStaleAlertTriggered         bool
+	nowFn                         func() time.Time
 	tradingPair                   *model.TradingPair
+	state                         *pendulumState
 	lastTradeCursor               interface{}
 	isFirstTradeHistoryRun        bool
 	incrementTimestampCursor      bool
 	orderConstraints              *model.OrderConstraints
+	debugLogging                  bool // logs every level and the price2LastPrice map on each cycle
+}
+
+// termination reasons of the level creation loop
+const (
+	pendulumTerminationMaxLevels        = "maxLevels reached"
+	pendulumTerminationMaxOffersPerTx   = "maxOffersPerTransaction reached"
+	pendulumTerminationMinBase          = "minBase hit"
+	pendulumTerminationMaxQuoteExposure = "maxQuoteExposure hit"
+	pendulumTerminationPriceLimit       = "priceLimit crossed"
+)
+
+// pendulumLevelsSummary summarizes the levels created in a single cycle, prices are always in units of the real quote asset
+type pendulumLevelsSummary struct {
+	numLevels         int
+	baseExposed       float64 // in units of the real quote asset on the buy side
+	lowestPrice       float64
+	highestPrice      float64
+	terminationReason string
+}
+
+// String is the stringer function
+func (s pendulumLevelsSummary) String() string {
+	return fmt.Sprintf("numLevels=%d, baseExposed=%.10f, lowestPrice=%.10f, highestPrice=%.10f, terminationReason=%s",
+		s.numLevels, s.baseExposed, s.lowestPrice, s.highestPrice, s.terminationReason)
+}
+
+// addLevel updates the summary with a level at the price (in units of the real quote asset)
+func (s *pendulumLevelsSummary) addLevel(price float64) {
+	if s.numLevels == 0 || price < s.lowestPrice {
+		s.lowestPrice = price
+	}
+	if s.numLevels == 0 || price > s.highestPrice {
+		s.highestPrice = price
+	}
+	s.numLevels++
 }
 
 // ensure it implements LevelProvider
@@ -46,47 +148,181 @@ func makePendulumLevelProvider(
 	offsetSpread float64,
 	useMaxQuoteInTargetAmountCalc bool,
 	amountBase float64,
+	amountMultiplier float64,
+	minFillFraction float64,
 	maxLevels int16,
+	maxOffersPerTransaction int,
 	lastTradePrice float64,
 	priceLimit float64,
 	minBase float64,
+	minQuoteValue float64,
+	minLevelAmount float64,
+	maxQuoteExposure float64,
 	tradeFetcher api.TradeFetcher,
+	maxTradeHistoryPages int,
+	maxConsecutiveFetchErrors int,
+	maxStartupJitterMillis int64,
+	staleTradeThreshold time.Duration,
+	alert api.Alert, // can be nil
 	tradingPair *model.TradingPair,
+	state *pendulumState,
 	lastTradeCursor interface{},
 	incrementTimestampCursor bool,
 	orderConstraints *model.OrderConstraints,
+	debugLogging bool,
 ) *pendulumLevelProvider {
+	// only do the first run special casing when we don't have any saved state
+	isFirstTradeHistoryRun := true
+	if saved, ok := state.getSavedSide(pendulumSideName(useMaxQuoteInTargetAmountCalc)); ok {
+		log.Printf("restoring saved state for pendulum side '%s': lastTradeCursor=%s, lastTradePrice=%.10f\n", pendulumSideName(useMaxQuoteInTargetAmountCalc), saved.LastTradeCursor, saved.LastTradePrice)
+		lastTradeCursor = saved.LastTradeCursor
+		lastTradePrice = saved.LastTradePrice
+		isFirstTradeHistoryRun = false
+	}
+
+	// spread out the first fetch of the trade history across bots that are started at the same time
+	var startupJitterFn func() time.Duration
+	if maxStartupJitterMillis > 0 {
+		randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
+		startupJitterFn = makeRandomDelayMillisFn(maxStartupJitterMillis, randGen)
+	}
+
 	return &pendulumLevelProvider{
 		spread:                        spread,
 		offsetSpread:                  offsetSpread,
 		useMaxQuoteInTargetAmountCalc: useMaxQuoteInTargetAmountCalc,
 		amountBase:                    amountBase,
+		amountMultiplier:              amountMultiplier,
+		minFillFraction:               minFillFraction,
+		filledAmountByLevel:           map[string]float64{},
+		amountByLevelPrice:            map[float64]float64{},
 		maxLevels:                     maxLevels,
+		maxOffersPerTransaction:       maxOffersPerTransaction,
 		lastTradePrice:                lastTradePrice,
 		priceLimit:                    priceLimit,
 		minBase:                       minBase,
+		minQuoteValue:                 minQuoteValue,
+		minLevelAmount:                minLevelAmount,
+		maxQuoteExposure:              maxQuoteExposure,
 		tradeFetcher:                  tradeFetcher,
+		maxTradeHistoryPages:          maxTradeHistoryPages,
+		maxConsecutiveFetchErrors:     maxConsecutiveFetchErrors,
+		consecutiveFetchErrors:        0,
+		startupJitterFn:               startupJitterFn,
+		sleepFn:                       time.Sleep,
+		staleTradeThreshold:           staleTradeThreshold,
+		alert:                         alert,
+		lastCursorAdvanceTime:         time.Now(),
+		staleAlertDedupKey:            "",
+		isStaleAlertTriggered:         false,
+		nowFn:                         time.Now,
 		tradingPair:                   tradingPair,
+		state:                         state,
 		lastTradeCursor:               lastTradeCursor,
-		isFirstTradeHistoryRun:        true,
+		isFirstTradeHistoryRun:        isFirstTradeHistoryRun,
 		incrementTimestampCursor:      incrementTimestampCursor,
 		orderConstraints:              orderConstraints,
+		debugLogging:                  debugLogging,
+	}
+}
+
+// pendulumSideName is the key used for the side in the saved state
+func pendulumSideName(isBuy bool) string {
+	if isBuy {
+		return "buy"
+	}
+	return "sell"
+}
+
+// getLastPrice looks up the last price for the trade price in the shared map
+func (s *pendulumState) getLastPrice(tradePrice float64, lastTradeIsBuy bool) (lastTradePrice float64, lastPrice float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.price2LastPrice) == 0 {
+		// this happens when we restored from saved state and trades happened before we placed any levels in this run
+		log.Printf("getLastPrice, price2LastPrice map is empty so using tradePrice (%.8f) as the last price\n", tradePrice)
+		return tradePrice, tradePrice
 	}
+	return getLastPriceFromMap(s.price2LastPrice, tradePrice, lastTradeIsBuy)
 }
 
-func printPrice2LastPriceMap() {
+// getSavedSide returns the saved state for the side, if any
+func (s *pendulumState) getSavedSide(side string) (pendulumSavedSide, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	saved, ok := s.savedSides[side]
+	return saved, ok
+}
+
+// saveSide saves the state for the side to the state file, it is a no-op if there is no state file
+func (s *pendulumState) saveSide(side string, lastTradeCursor interface{}, lastTradePrice float64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stateFilePath == "" {
+		return nil
+	}
+
+	cursorString := ""
+	if lastTradeCursor != nil {
+		cursorString = fmt.Sprintf("%v", lastTradeCursor)
+	}
+	s.savedSides[side] = pendulumSavedSide{
+		LastTradeCursor: cursorString,
+		LastTradePrice:  lastTradePrice,
+	}
+
+	data, e := json.MarshalIndent(s.savedSides, "", "    ")
+	if e != nil {
+		return fmt.Errorf("could not marshal pendulum state: %s", e)
+	}
+	// write to a temp file and rename it so we never leave a partially written state file behind
+	tempFilePath := s.stateFilePath + ".tmp"
+	e = ioutil.WriteFile(tempFilePath, data, 0644)
+	if e != nil {
+		return fmt.Errorf("could not write pendulum state to file '%s': %s", tempFilePath, e)
+	}
+	e = os.Rename(tempFilePath, s.stateFilePath)
+	if e != nil {
+		return fmt.Errorf("could not rename pendulum state file '%s' to '%s': %s", tempFilePath, s.stateFilePath, e)
+	}
+	return nil
+}
+
+// setLastPrice sets the last price for the offer price in the shared map
+func (s *pendulumState) setLastPrice(offerPrice float64, lastPrice float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.price2LastPrice[offerPrice] = lastPrice
+}
+
+// print logs the shared map
+func (s *pendulumState) print() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.printPrice2LastPriceMap()
+}
+
+// printPrice2LastPriceMap should only be called when holding the lock
+func (s *pendulumState) printPrice2LastPriceMap() {
 	keys := []float64{}
-	for k, _ := range price2LastPrice {
+	for k, _ := range s.price2LastPrice {
 		keys = append(keys, k)
 	}
 	sort.Float64s(keys)
 
-	log.Printf("price2LastPrice map (%d elements):\n", len(price2LastPrice))
+	log.Printf("price2LastPrice map (%d elements):\n", len(s.price2LastPrice))
 	for _, k := range keys {
-		log.Printf("    %.8f -> %.8f\n", k, price2LastPrice[k])
+		log.Printf("    %.8f -> %.8f\n", k, s.price2LastPrice[k])
 	}
 }
 
+// getLastPriceFromMap returns the offer price and last price for the trade price. When the trade price is not in the map (or has an unexpected
+// last price) it uses the closest offer price on the same side, preferring the lower offer price when two offer prices are equidistant
 func getLastPriceFromMap(price2LastPriceMap map[float64]float64, tradePrice float64, lastTradeIsBuy bool) (lastTradePrice float64, lastPrice float64) {
 	if lp, ok := price2LastPriceMap[tradePrice]; ok {
 		if lastTradeIsBuy {
@@ -129,7 +365,9 @@ func getLastPriceFromMap(price2LastPriceMap map[float64]float64, tradePrice floa
 			continue
 		}
 
-		if d < diff {
+		// map iteration order is random so break ties between equidistant offer prices by preferring the lower offer price,
+		// otherwise the result could change from one cycle to the next
+		if d < diff || (d == diff && offerPrice < closestOfferPrice) {
 			closestOfferPrice = offerPrice
 			diff = d
 		}
@@ -147,66 +385,196 @@ func (p *pendulumLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
 
 // GetLevels impl.
 func (p *pendulumLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	if p.startupJitterFn != nil {
+		jitter := p.startupJitterFn()
+		// only applies to the first cycle
+		p.startupJitterFn = nil
+		log.Printf("sleeping for a startup jitter of %s before the first cycle of pendulum side '%s'\n", jitter, pendulumSideName(p.useMaxQuoteInTargetAmountCalc))
+		p.sleepFn(jitter)
+	}
+
 	if maxAssetBase <= p.minBase {
 		return []api.Level{}, nil
 	}
 
-	lastPrice, lastCursor, lastIsBuy, e := p.fetchLatestTradePrice()
+	lastPrice, lastCursor, lastIsBuy, hasFilledLevel, reachedEnd, e := p.fetchLatestTradePrice()
 	if e != nil {
-		return nil, fmt.Errorf("error in fetchLatestTradePrice: %s", e)
+		p.consecutiveFetchErrors++
+		if p.consecutiveFetchErrors > p.maxConsecutiveFetchErrors {
+			return nil, fmt.Errorf("error in fetchLatestTradePrice (consecutiveErrors=%d): %s", p.consecutiveFetchErrors, e)
+		}
+		// the error may be transient (such as a network blip) so we keep the last trade price for this cycle instead of failing the update
+		// cycle, which would delete all the offers. The trades are picked up in the next cycle since the cursor is unchanged
+		log.Printf("error in fetchLatestTradePrice for pendulum side '%s', keeping lastTradePrice=%.10f for this cycle (consecutiveErrors=%d, maxConsecutiveFetchErrors=%d): %s\n",
+			pendulumSideName(p.useMaxQuoteInTargetAmountCalc), p.lastTradePrice, p.consecutiveFetchErrors, p.maxConsecutiveFetchErrors, e)
+		return p.makeLevelsAndLog(maxAssetBase), nil
 	}
+	p.consecutiveFetchErrors = 0
+	p.checkStaleTrades(lastCursor != p.lastTradeCursor)
 
 	// update it only if there's no error
-	if p.isFirstTradeHistoryRun {
+	if p.isFirstTradeHistoryRun && !reachedEnd {
+		// the history can take more than one cycle to catch up on when it has more than maxTradeHistoryPages pages, we stay in the first run
+		// until we reach the end so the older trades are never treated as fills. The state is not saved so a restart starts the catch up again
+		p.lastTradeCursor = lastCursor
+		log.Printf("isFirstTradeHistoryRun and did not reach the end of the trade history so updated lastTradeCursor=%v, leaving unchanged lastTradePrice=%.10f", p.lastTradeCursor, p.lastTradePrice)
+	} else if p.isFirstTradeHistoryRun {
 		p.isFirstTradeHistoryRun = false
 		p.lastTradeCursor = lastCursor
 		log.Printf("isFirstTradeHistoryRun so updated lastTradeCursor=%v, leaving unchanged lastTradePrice=%.10f", p.lastTradeCursor, p.lastTradePrice)
+		e = p.state.saveSide(pendulumSideName(p.useMaxQuoteInTargetAmountCalc), p.lastTradeCursor, p.lastTradePrice)
+		if e != nil {
+			return nil, fmt.Errorf("could not save pendulum state: %s", e)
+		}
 	} else if lastCursor == p.lastTradeCursor {
 		log.Printf("lastCursor == p.lastTradeCursor leaving lastTradeCursor=%v and lastTradePrice=%.10f", p.lastTradeCursor, p.lastTradePrice)
+	} else if !hasFilledLevel {
+		p.lastTradeCursor = lastCursor
+		log.Printf("no level was filled beyond minFillFraction=%.4f so updated lastTradeCursor=%v, leaving unchanged lastTradePrice=%.10f", p.minFillFraction, p.lastTradeCursor, p.lastTradePrice)
+		e = p.state.saveSide(pendulumSideName(p.useMaxQuoteInTargetAmountCalc), p.lastTradeCursor, p.lastTradePrice)
+		if e != nil {
+			return nil, fmt.Errorf("could not save pendulum state: %s", e)
+		}
 	} else {
 		p.lastTradeCursor = lastCursor
 		mapKey := model.NumberFromFloat(lastPrice, p.orderConstraints.PricePrecision)
-		printPrice2LastPriceMap()
-		_, p.lastTradePrice = getLastPriceFromMap(price2LastPrice, mapKey.AsFloat(), lastIsBuy)
+		if p.debugLogging {
+			p.state.print()
+		}
+		_, p.lastTradePrice = p.state.getLastPrice(mapKey.AsFloat(), lastIsBuy)
 		log.Printf("updated lastTradeCursor=%v and lastTradePrice=%.10f (converted=%.10f)", p.lastTradeCursor, lastPrice, p.lastTradePrice)
+		e = p.state.saveSide(pendulumSideName(p.useMaxQuoteInTargetAmountCalc), p.lastTradeCursor, p.lastTradePrice)
+		if e != nil {
+			return nil, fmt.Errorf("could not save pendulum state: %s", e)
+		}
+	}
+
+	return p.makeLevelsAndLog(maxAssetBase), nil
+}
+
+// makeLevelsAndLog makes the levels from the lastTradePrice and logs a summary of the levels
+func (p *pendulumLevelProvider) makeLevelsAndLog(maxAssetBase float64) []api.Level {
+	levels, summary := p.makeLevels(maxAssetBase)
+	if p.debugLogging {
+		p.state.print()
+	}
+	log.Printf("pendulum levels summary (sideIsBuy=%v): %s\n", p.useMaxQuoteInTargetAmountCalc, summary)
+	return levels
+}
+
+// checkStaleTrades triggers an alert when no trade has advanced the cursor within the staleTradeThreshold because the market may have
+// died or the trade feed may be broken, in which case the last trade price is stale. The alert is triggered once and resolved when a trade
+// advances the cursor again
+func (p *pendulumLevelProvider) checkStaleTrades(cursorAdvanced bool) {
+	if p.staleTradeThreshold <= 0 {
+		return
+	}
+
+	now := p.nowFn()
+	sideName := pendulumSideName(p.useMaxQuoteInTargetAmountCalc)
+	if cursorAdvanced {
+		p.lastCursorAdvanceTime = now
+		if p.isStaleAlertTriggered {
+			log.Printf("a trade advanced the cursor of pendulum side '%s' so the trade history is no longer stale\n", sideName)
+			if p.alert != nil && p.staleAlertDedupKey != "" {
+				if e := p.alert.Resolve(p.staleAlertDedupKey); e != nil {
+					log.Printf("could not resolve the stale trades alert for pendulum side '%s': %s\n", sideName, e)
+				}
+			}
+			p.isStaleAlertTriggered = false
+			p.staleAlertDedupKey = ""
+		}
+		return
+	}
+
+	elapsed := now.Sub(p.lastCursorAdvanceTime)
+	if elapsed < p.staleTradeThreshold || p.isStaleAlertTriggered {
+		return
+	}
+	description := fmt.Sprintf("no new trades for pendulum side '%s' on %s in the last %s, the last trade price may be stale", sideName, p.tradingPair, elapsed.Round(time.Second))
+	log.Printf("%s (staleTradeThreshold=%s, lastTradeCursor=%v, lastTradePrice=%.10f)\n", description, p.staleTradeThreshold, p.lastTradeCursor, p.lastTradePrice)
+	p.isStaleAlertTriggered = true
+	if p.alert == nil {
+		return
+	}
+	dedupKey, e := p.alert.TriggerWithSeverity(api.AlertSeverityWarning, description, map[string]interface{}{
+		"side":                sideName,
+		"staleTradeThreshold": p.staleTradeThreshold.String(),
+		"lastTradeCursor":     fmt.Sprintf("%v", p.lastTradeCursor),
+		"lastTradePrice":      p.lastTradePrice,
+	})
+	if e != nil {
+		log.Printf("could not trigger the stale trades alert for pendulum side '%s': %s\n", sideName, e)
+		return
 	}
+	p.staleAlertDedupKey = dedupKey
+}
 
+// makeLevels creates the levels starting from the last trade price and updates the last price map for each level
+func (p *pendulumLevelProvider) makeLevels(maxAssetBase float64) ([]api.Level, pendulumLevelsSummary) {
 	levels := []api.Level{}
+	summary := pendulumLevelsSummary{terminationReason: pendulumTerminationMaxLevels}
 	newPrice := p.lastTradePrice
 	if p.useMaxQuoteInTargetAmountCalc {
 		// invert lastTradePrice here -- it's always kept in the actual quote price at all other times
 		newPrice = 1 / newPrice
 	}
 	baseExposed := 0.0
+	p.amountByLevelPrice = map[float64]float64{}
 	for i := 0; i < int(p.maxLevels); i++ {
+		// maxLevels only limits the number of iterations, this limits the number of levels that are actually placed
+		if len(levels) >= p.maxOffersPerTransaction {
+			summary.terminationReason = fmt.Sprintf("%s (maxOffersPerTransaction=%d, maxLevels=%d)", pendulumTerminationMaxOffersPerTx, p.maxOffersPerTransaction, p.maxLevels)
+			log.Printf("pendulum levels (sideIsBuy=%v) were limited by maxOffersPerTransaction=%d instead of maxLevels=%d\n", p.useMaxQuoteInTargetAmountCalc, p.maxOffersPerTransaction, p.maxLevels)
+			break
+		}
 		newPrice = newPrice * (1 + p.spread/2)
 		priceToUse := newPrice * (1 + p.offsetSpread/2)
+		actualPrice := priceToUse
+		if p.useMaxQuoteInTargetAmountCalc {
+			actualPrice = 1 / priceToUse
+		}
+		// the amount grows (or shrinks) geometrically from the first level, skipped levels are included so the amount only depends on the price
+		amount := p.amountBase * math.Pow(p.amountMultiplier, float64(i))
 
 		// check what the balance would be if we were to place this level, ensuring it will still be within the limits
-		expectedBaseUsage := p.amountBase
+		expectedBaseUsage := amount
 		if p.useMaxQuoteInTargetAmountCalc {
 			expectedBaseUsage = expectedBaseUsage / priceToUse
 		}
 		expectedEndingBase := maxAssetBase - baseExposed - expectedBaseUsage
 		if expectedEndingBase <= p.minBase {
-			log.Printf("early exiting level creation loop (sideIsBuy=%v), expectedEndingBase=%.10f, minBase=%.10f\n", p.useMaxQuoteInTargetAmountCalc, expectedEndingBase, p.minBase)
+			summary.terminationReason = fmt.Sprintf("%s (expectedEndingBase=%.10f, minBase=%.10f)", pendulumTerminationMinBase, expectedEndingBase, p.minBase)
 			break
 		}
 
-		if p.useMaxQuoteInTargetAmountCalc && 1/priceToUse < p.priceLimit {
-			log.Printf("early exiting level creation loop (buy side) because we crossed minPrice, priceLimit=%.10f, current price=%.10f\n", p.priceLimit, 1/priceToUse)
+		// on the buy side the base asset is the real quote asset so baseExposed is the quote committed across all levels
+		if p.useMaxQuoteInTargetAmountCalc && p.maxQuoteExposure > 0 && baseExposed+expectedBaseUsage > p.maxQuoteExposure {
+			summary.terminationReason = fmt.Sprintf("%s (expectedQuoteExposure=%.10f, maxQuoteExposure=%.10f)", pendulumTerminationMaxQuoteExposure, baseExposed+expectedBaseUsage, p.maxQuoteExposure)
 			break
 		}
 
-		if !p.useMaxQuoteInTargetAmountCalc && priceToUse > p.priceLimit {
-			log.Printf("early exiting level creation loop (sell side) because we crossed maxPrice, priceLimit=%.10f, current price=%.10f\n", p.priceLimit, priceToUse)
+		// the price limit is the min price on the buy side and the max price on the sell side
+		if (p.useMaxQuoteInTargetAmountCalc && actualPrice < p.priceLimit) || (!p.useMaxQuoteInTargetAmountCalc && actualPrice > p.priceLimit) {
+			summary.terminationReason = fmt.Sprintf("%s (price=%.10f, priceLimit=%.10f)", pendulumTerminationPriceLimit, actualPrice, p.priceLimit)
 			break
 		}
 
-		levels = append(levels, api.Level{
+		level := api.Level{
 			Price:  *model.NumberFromFloat(priceToUse, p.orderConstraints.PricePrecision),
-			Amount: *model.NumberFromFloat(p.amountBase, p.orderConstraints.VolumePrecision),
-		})
+			Amount: *model.NumberFromFloat(amount, p.orderConstraints.VolumePrecision),
+		}
+		// a level that is too small fails the submission of the whole transaction so we drop it here
+		e := p.validateLevel(level)
+		if e != nil {
+			log.Printf("skipping level (sideIsBuy=%v) because it is too small to be placed, price=%.10f, amount=%.10f: %s\n", p.useMaxQuoteInTargetAmountCalc, level.Price.AsFloat(), level.Amount.AsFloat(), e)
+			continue
+		}
+
+		levels = append(levels, level)
+		if p.debugLogging {
+			log.Printf("added level (sideIsBuy=%v), price=%.10f, amount=%.10f, expectedBaseUsage=%.10f\n", p.useMaxQuoteInTargetAmountCalc, actualPrice, amount, expectedBaseUsage)
+		}
 
 		// update last price map here
 		// the keys in price2LastPrice should have a larger precision than the exchange's market supports because we use the same map for
@@ -218,29 +586,66 @@ func (p *pendulumLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote fl
 			mapKey = model.NumberFromFloat(1/priceToUse, offerPriceLargePrecision)
 			mapValue = 1 / newPrice
 		}
-		price2LastPrice[mapKey.AsFloat()] = mapValue
+		p.state.setLastPrice(mapKey.AsFloat(), mapValue)
 
+		p.amountByLevelPrice[model.NumberFromFloat(actualPrice, p.orderConstraints.PricePrecision).AsFloat()] = amount
 		baseExposed += expectedBaseUsage
+		summary.addLevel(actualPrice)
+	}
+	summary.baseExposed = baseExposed
+
+	return levels, summary
+}
+
+// validateLevel returns an error when the level is below the minimum amount or minimum value of a level. This is checked on the level
+// after it is rounded to the precision of the exchange since that is what is submitted. It is distinct from minBase, which protects the
+// remaining balance rather than the size of each offer
+func (p *pendulumLevelProvider) validateLevel(level api.Level) error {
+	price := level.Price.AsFloat()
+	amount := level.Amount.AsFloat()
+	if price <= 0 {
+		return fmt.Errorf("price rounds to %.10f at a precision of %d", price, p.orderConstraints.PricePrecision)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount rounds to %.10f at a precision of %d", amount, p.orderConstraints.VolumePrecision)
+	}
+	if p.minLevelAmount > 0 && amount < p.minLevelAmount {
+		return fmt.Errorf("amount (%.10f) is below minLevelAmount (%.10f)", amount, p.minLevelAmount)
 	}
-	printPrice2LastPriceMap()
 
-	return levels, nil
+	// the amount is always in units of the real base asset but the price is inverted on the buy side
+	quoteValue := amount * price
+	if p.useMaxQuoteInTargetAmountCalc {
+		quoteValue = amount / price
+	}
+	if p.minQuoteValue > 0 && quoteValue < p.minQuoteValue {
+		return fmt.Errorf("value (%.10f) is below minQuoteValue (%.10f)", quoteValue, p.minQuoteValue)
+	}
+	return nil
 }
 
-func (p *pendulumLevelProvider) fetchLatestTradePrice() (float64, interface{}, bool, error) {
+// fetchLatestTradePrice returns the price of the last trade that completed the fill of a level, the cursor, whether that trade was a buy,
+// whether any level was filled, and whether it reached the end of the trade history. It fetches at most maxTradeHistoryPages pages so a
+// large backlog of trades does not block the cycle, the returned cursor is after the last trade processed so the remaining trades are
+// picked up in the next cycle. Trades are not counted as fills on the first run since they happened before the bot was started
+func (p *pendulumLevelProvider) fetchLatestTradePrice() (float64, interface{}, bool, bool, bool, error) {
 	lastPrice := p.lastTradePrice
 	lastCursor := p.lastTradeCursor
 	lastIsBuy := false
-	for {
+	hasFilledLevel := false
+	for page := 0; ; page++ {
+		if page >= p.maxTradeHistoryPages {
+			log.Printf("fetched the max number of trade history pages (%d) for this cycle, continuing from cursor %v in the next cycle\n", p.maxTradeHistoryPages, lastCursor)
+			return lastPrice, lastCursor, lastIsBuy, hasFilledLevel, false, nil
+		}
+
 		tradeHistoryResult, e := p.tradeFetcher.GetTradeHistory(*p.tradingPair, lastCursor, nil)
 		if e != nil {
-			return 0, "", false, fmt.Errorf("error in tradeFetcher.GetTradeHistory: %s", e)
+			return 0, "", false, false, false, fmt.Errorf("error in tradeFetcher.GetTradeHistory: %s", e)
 		}
 
-		// TODO need to check for volume here too at some point (if full lot is not taken then we don't want to update last price)
-
 		if len(tradeHistoryResult.Trades) == 0 {
-			return lastPrice, tradeHistoryResult.Cursor, lastIsBuy, nil
+			return lastPrice, tradeHistoryResult.Cursor, lastIsBuy, hasFilledLevel, true, nil
 		}
 
 		log.Printf("listing %d trades since last cycle", len(tradeHistoryResult.Trades))
@@ -249,18 +654,90 @@ func (p *pendulumLevelProvider) fetchLatestTradePrice() (float64, interface{}, b
 		}
 
 		lastTrade := tradeHistoryResult.Trades[len(tradeHistoryResult.Trades)-1]
+		var nextCursor string
 		if p.incrementTimestampCursor {
 			i64Cursor, e := strconv.Atoi(lastTrade.Order.Timestamp.String())
 			if e != nil {
-				return 0, "", false, fmt.Errorf("unable to convert order timestamp to integer for binance cursor: %s", e)
+				return 0, "", false, false, false, fmt.Errorf("unable to convert order timestamp to integer for binance cursor: %s", e)
 			}
-			// increment last timestamp cursor for binance because it's inclusive
-			lastCursor = strconv.FormatInt(int64(i64Cursor)+1, 10)
+			// increment last timestamp cursor because it's inclusive (ccxt)
+			nextCursor = strconv.FormatInt(int64(i64Cursor)+1, 10)
 		} else {
-			lastCursor = lastTrade.TransactionID.String()
+			nextCursor = lastTrade.TransactionID.String()
+		}
+		// a cursor that does not advance means the exchange returned trades we have already processed, continuing would replay them
+		// (double-counting the filled amounts) or loop on the same page forever so we bail without advancing the cursor
+		e = validateCursorAdvance(lastCursor, nextCursor)
+		if e != nil {
+			return 0, "", false, false, false, fmt.Errorf("invalid trade history cursor (incrementTimestampCursor=%v): %s", p.incrementTimestampCursor, e)
 		}
-		lastIsBuy = lastTrade.Order.OrderAction == model.OrderActionBuy
-		price := lastTrade.Order.Price.AsFloat()
-		lastPrice = price
+		lastCursor = nextCursor
+
+		if p.isFirstTradeHistoryRun {
+			continue
+		}
+
+		for _, t := range tradeHistoryResult.Trades {
+			if !p.updateFilledAmount(t) {
+				continue
+			}
+			hasFilledLevel = true
+			lastIsBuy = t.Order.OrderAction == model.OrderActionBuy
+			lastPrice = t.Order.Price.AsFloat()
+		}
+	}
+}
+
+// validateCursorAdvance returns an error if the next cursor is not strictly after the previous cursor. Numeric cursors (timestamps and
+// numeric trade IDs) are compared numerically, other cursors cannot be ordered so they only need to be different from the previous cursor
+func validateCursorAdvance(prevCursor interface{}, nextCursor string) error {
+	if prevCursor == nil {
+		return nil
+	}
+	prevCursorString := fmt.Sprintf("%v", prevCursor)
+	if prevCursorString == "" {
+		return nil
+	}
+
+	if nextCursor == prevCursorString {
+		return fmt.Errorf("cursor did not advance from '%s', the trades were already processed", prevCursorString)
+	}
+
+	prevInt, e := strconv.ParseInt(prevCursorString, 10, 64)
+	if e != nil {
+		return nil
+	}
+	nextInt, e := strconv.ParseInt(nextCursor, 10, 64)
+	if e != nil {
+		return nil
 	}
+	if nextInt <= prevInt {
+		return fmt.Errorf("cursor went backward from '%s' to '%s', the trades were returned out of order", prevCursorString, nextCursor)
+	}
+	return nil
+}
+
+// updateFilledAmount adds the volume of the trade to the amount filled at its level and returns true if the level is now filled,
+// so partial fills that are smaller than minFillFraction of a lot do not move the last trade price
+func (p *pendulumLevelProvider) updateFilledAmount(t model.Trade) bool {
+	levelKey := fmt.Sprintf("%s@%s", t.Order.OrderAction, t.Order.Price.AsString())
+	filledAmount := p.filledAmountByLevel[levelKey] + t.Order.Volume.AsFloat()
+
+	// the lot is the amount of the level at the trade price when we placed it, otherwise (trades on the other side) it is the amountBase
+	lotAmount := p.amountBase
+	if amount, ok := p.amountByLevelPrice[model.NumberFromFloat(t.Order.Price.AsFloat(), p.orderConstraints.PricePrecision).AsFloat()]; ok {
+		lotAmount = amount
+	}
+
+	// round to the volume precision so filling the full lot is not missed because of floating point errors
+	minFillAmount := model.NumberFromFloat(p.minFillFraction*lotAmount, p.orderConstraints.VolumePrecision).AsFloat()
+	if model.NumberFromFloat(filledAmount, p.orderConstraints.VolumePrecision).AsFloat() < minFillAmount {
+		log.Printf("level %s is partially filled, filledAmount=%.8f < minFillAmount=%.8f, not updating last trade price\n", levelKey, filledAmount, minFillAmount)
+		p.filledAmountByLevel[levelKey] = filledAmount
+		return false
+	}
+
+	log.Printf("level %s is filled, filledAmount=%.8f >= minFillAmount=%.8f\n", levelKey, filledAmount, minFillAmount)
+	delete(p.filledAmountByLevel, levelKey)
+	return true
 }
@@ -2,9 +2,18 @@ package plugins
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
 )
 
 func TestGetLastPriceFromMap(t *testing.T) {
@@ -72,3 +81,1175 @@ func TestGetLastPriceFromMap(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLastPriceFromMapEquidistant(t *testing.T) {
+	// the values are exactly representable as floats so the offer prices are exactly equidistant from the trade prices
+	price2LastPriceMap := map[float64]float64{
+		0.5:   0.25,  // sell side
+		1.0:   0.75,  // sell side
+		0.25:  0.5,   // buy side
+		0.375: 0.625, // buy side
+		0.125: 0.25,  // buy side
+	}
+
+	testCases := []struct {
+		tradePrice     float64
+		isBuy          bool
+		wantTradePrice float64
+		wantLastPrice  float64
+	}{
+		{
+			tradePrice:     0.75,
+			isBuy:          false,
+			wantTradePrice: 0.5,
+			wantLastPrice:  0.25,
+		}, {
+			tradePrice:     0.1875,
+			isBuy:          true,
+			wantTradePrice: 0.125,
+			wantLastPrice:  0.25,
+		}, {
+			tradePrice:     0.3125,
+			isBuy:          true,
+			wantTradePrice: 0.25,
+			wantLastPrice:  0.5,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.4f/%v", k.tradePrice, k.isBuy), func(t *testing.T) {
+			// map iteration order is random so repeat the lookup to make sure the result does not depend on it
+			for i := 0; i < 100; i++ {
+				lastTradePrice, lastPrice := getLastPriceFromMap(price2LastPriceMap, k.tradePrice, k.isBuy)
+				if !assert.Equal(t, k.wantTradePrice, lastTradePrice) {
+					return
+				}
+				if !assert.Equal(t, k.wantLastPrice, lastPrice) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestPendulumStateIsNotShared(t *testing.T) {
+	s1, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	s2, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	s1.setLastPrice(0.075, 0.070)
+	assert.Equal(t, map[float64]float64{0.075: 0.070}, s1.price2LastPrice)
+	assert.Equal(t, map[float64]float64{}, s2.price2LastPrice)
+
+	lastTradePrice, lastPrice := s1.getLastPrice(0.075, false)
+	assert.Equal(t, 0.075, lastTradePrice)
+	assert.Equal(t, 0.070, lastPrice)
+}
+
+func TestPendulumStateSaveAndLoad(t *testing.T) {
+	dir, e := ioutil.TempDir("", "pendulum_state")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	stateFilePath := filepath.Join(dir, "state.json")
+
+	// no saved state when the file does not exist yet
+	s, e := makePendulumState(stateFilePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, ok := s.getSavedSide("sell")
+	assert.False(t, ok)
+
+	e = s.saveSide("sell", "1594668000001", 0.066)
+	if !assert.NoError(t, e) {
+		return
+	}
+	e = s.saveSide("buy", nil, 0.065)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// a new state (i.e. after a restart) reloads the values from the file
+	reloaded, e := makePendulumState(stateFilePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	saved, ok := reloaded.getSavedSide("sell")
+	assert.True(t, ok)
+	assert.Equal(t, pendulumSavedSide{LastTradeCursor: "1594668000001", LastTradePrice: 0.066}, saved)
+	saved, ok = reloaded.getSavedSide("buy")
+	assert.True(t, ok)
+	assert.Equal(t, pendulumSavedSide{LastTradeCursor: "", LastTradePrice: 0.065}, saved)
+
+	// the level provider skips the first run special casing when restored
+	p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.050, 1.0, 0.0, 0.0, 0.0, 0.0, nil, 10, 0, 0, 0, nil, nil, reloaded, "cursorFromConfig", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+	assert.False(t, p.isFirstTradeHistoryRun)
+	assert.Equal(t, "1594668000001", p.lastTradeCursor)
+	assert.Equal(t, 0.066, p.lastTradePrice)
+}
+
+func TestPendulumStateGetLastPriceEmptyMap(t *testing.T) {
+	s, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	lastTradePrice, lastPrice := s.getLastPrice(0.075, true)
+	assert.Equal(t, 0.075, lastTradePrice)
+	assert.Equal(t, 0.075, lastPrice)
+}
+
+func TestUpdateFilledAmount(t *testing.T) {
+	makeTrade := func(action model.OrderAction, price float64, volume float64) model.Trade {
+		return model.Trade{Order: model.Order{
+			OrderAction: action,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+		}}
+	}
+
+	testCases := []struct {
+		name            string
+		minFillFraction float64
+		trades          []model.Trade
+		wantFilled      []bool
+	}{
+		{
+			name:            "full lot in one trade",
+			minFillFraction: 1.0,
+			trades:          []model.Trade{makeTrade(model.OrderActionSell, 0.066, 10.0)},
+			wantFilled:      []bool{true},
+		}, {
+			name:            "dust trade does not fill the level",
+			minFillFraction: 1.0,
+			trades:          []model.Trade{makeTrade(model.OrderActionSell, 0.066, 0.01)},
+			wantFilled:      []bool{false},
+		}, {
+			name:            "partial fills accumulate at the same level",
+			minFillFraction: 1.0,
+			trades: []model.Trade{
+				makeTrade(model.OrderActionSell, 0.066, 4.0),
+				makeTrade(model.OrderActionSell, 0.066, 6.0),
+				makeTrade(model.OrderActionSell, 0.066, 4.0),
+			},
+			wantFilled: []bool{false, true, false},
+		}, {
+			name:            "partial fills do not accumulate across levels",
+			minFillFraction: 1.0,
+			trades: []model.Trade{
+				makeTrade(model.OrderActionSell, 0.066, 6.0),
+				makeTrade(model.OrderActionBuy, 0.066, 6.0),
+				makeTrade(model.OrderActionSell, 0.067, 6.0),
+			},
+			wantFilled: []bool{false, false, false},
+		}, {
+			name:            "partial fill above the min fill fraction",
+			minFillFraction: 0.5,
+			trades:          []model.Trade{makeTrade(model.OrderActionBuy, 0.065, 5.0)},
+			wantFilled:      []bool{true},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, k.minFillFraction, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, nil, 10, 0, 0, 0, nil, nil, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			for i, trade := range k.trades {
+				assert.Equal(t, k.wantFilled[i], p.updateFilledAmount(trade), fmt.Sprintf("trade at index %d", i))
+			}
+		})
+	}
+}
+
+// emptyTradeFetcher is a TradeFetcher that never returns any trades
+type emptyTradeFetcher struct{}
+
+func (f emptyTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+}
+
+// pagedTradeFetcher is a TradeFetcher that returns one trade per page, the cursor is the index of the next trade
+type pagedTradeFetcher struct {
+	trades   []model.Trade
+	numCalls int
+}
+
+func (f *pagedTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	f.numCalls++
+	i := 0
+	if maybeCursorStart != nil && maybeCursorStart != "" {
+		n, e := strconv.Atoi(fmt.Sprintf("%v", maybeCursorStart))
+		if e != nil {
+			return nil, e
+		}
+		i = n
+	}
+	if i >= len(f.trades) {
+		return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+	}
+	return &api.TradeHistoryResult{Cursor: strconv.Itoa(i + 1), Trades: f.trades[i : i+1]}, nil
+}
+
+func TestFetchLatestTradePriceMaxPages(t *testing.T) {
+	trades := []model.Trade{}
+	for i, price := range []float64{0.066, 0.067, 0.068, 0.069, 0.070} {
+		trades = append(trades, model.Trade{
+			Order: model.Order{
+				OrderAction: model.OrderActionSell,
+				Price:       model.NumberFromFloat(price, 7),
+				Volume:      model.NumberFromFloat(10.0, 7),
+			},
+			// the cursor of the next page
+			TransactionID: model.MakeTransactionID(strconv.Itoa(i + 1)),
+		})
+	}
+
+	s, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	fetcher := &pagedTradeFetcher{trades: trades}
+	p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, fetcher, 2, 0, 0, 0, nil, nil, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+	// the trades are fills of our levels, see TestGetLevelsFirstRunMaxPages for the history that is fetched on the first run
+	p.isFirstTradeHistoryRun = false
+
+	// the first cycle stops after 2 pages
+	lastPrice, lastCursor, _, hasFilledLevel, reachedEnd, e := p.fetchLatestTradePrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2, fetcher.numCalls)
+	assert.Equal(t, "2", lastCursor)
+	assert.True(t, hasFilledLevel)
+	assert.False(t, reachedEnd)
+	assert.Equal(t, 0.067, lastPrice)
+
+	// the next cycles continue from where the previous cycle stopped
+	p.lastTradeCursor = lastCursor
+	lastPrice, lastCursor, _, _, _, e = p.fetchLatestTradePrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "4", lastCursor)
+	assert.Equal(t, 0.069, lastPrice)
+
+	p.lastTradeCursor = lastCursor
+	lastPrice, lastCursor, _, _, _, e = p.fetchLatestTradePrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "5", lastCursor)
+	assert.Equal(t, 0.070, lastPrice)
+	assert.Equal(t, 6, fetcher.numCalls)
+}
+
+func TestGetLevelsFirstRunMaxPages(t *testing.T) {
+	trades := []model.Trade{}
+	for i, price := range []float64{0.060, 0.061, 0.062, 0.063, 0.064} {
+		trades = append(trades, model.Trade{
+			Order: model.Order{
+				OrderAction: model.OrderActionSell,
+				Price:       model.NumberFromFloat(price, 7),
+				Volume:      model.NumberFromFloat(10.0, 7),
+			},
+			// the cursor of the next page
+			TransactionID: model.MakeTransactionID(strconv.Itoa(i + 1)),
+		})
+	}
+
+	dir, e := ioutil.TempDir("", "pendulum_state")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	s, e := makePendulumState(filepath.Join(dir, "state.json"))
+	if !assert.NoError(t, e) {
+		return
+	}
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	fetcher := &pagedTradeFetcher{trades: trades}
+	p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, fetcher, 2, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+	// the history has more pages than we fetch per cycle so the first run takes 3 cycles to reach the end of it
+	for cycle, wantCursor := range []string{"2", "4", "5"} {
+		_, e = p.GetLevels(1000.0, 1000.0)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, wantCursor, p.lastTradeCursor, "cycle %d", cycle)
+		// the trades from before the bot was started are not fills so they don't move the last trade price
+		assert.Equal(t, 0.066, p.lastTradePrice, "cycle %d", cycle)
+		assert.Equal(t, 0, len(p.filledAmountByLevel), "cycle %d", cycle)
+		// the state is only saved once the end of the history was reached
+		_, saved := s.getSavedSide("sell")
+		assert.Equal(t, cycle == 2, saved, "cycle %d", cycle)
+	}
+	assert.False(t, p.isFirstTradeHistoryRun)
+}
+
+// scriptedTradeFetcher is a TradeFetcher that returns the pages in order regardless of the cursor and no trades after the last page
+type scriptedTradeFetcher struct {
+	pages    [][]model.Trade
+	numCalls int
+}
+
+func (f *scriptedTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	call := f.numCalls
+	f.numCalls++
+	if call >= len(f.pages) {
+		return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+	}
+	return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: f.pages[call]}, nil
+}
+
+func TestValidateCursorAdvance(t *testing.T) {
+	testCases := []struct {
+		name       string
+		prevCursor interface{}
+		nextCursor string
+		wantError  bool
+	}{
+		{
+			name:       "nil previous cursor",
+			prevCursor: nil,
+			nextCursor: "100",
+			wantError:  false,
+		}, {
+			name:       "empty previous cursor",
+			prevCursor: "",
+			nextCursor: "100",
+			wantError:  false,
+		}, {
+			name:       "numeric advances",
+			prevCursor: "100",
+			nextCursor: "101",
+			wantError:  false,
+		}, {
+			name:       "numeric advances past a shorter cursor",
+			prevCursor: "99",
+			nextCursor: "100",
+			wantError:  false,
+		}, {
+			name:       "numeric unchanged",
+			prevCursor: "100",
+			nextCursor: "100",
+			wantError:  true,
+		}, {
+			name:       "numeric goes backward",
+			prevCursor: "100",
+			nextCursor: "99",
+			wantError:  true,
+		}, {
+			name:       "non-string previous cursor",
+			prevCursor: 100,
+			nextCursor: "99",
+			wantError:  true,
+		}, {
+			name:       "non-numeric changed",
+			prevCursor: "107449584845914113-0",
+			nextCursor: "107449584845914113-1",
+			wantError:  false,
+		}, {
+			name:       "non-numeric unchanged",
+			prevCursor: "TXID-ABC",
+			nextCursor: "TXID-ABC",
+			wantError:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := validateCursorAdvance(k.prevCursor, k.nextCursor)
+			if k.wantError {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
+
+func TestFetchLatestTradePriceCursorNotAdvancing(t *testing.T) {
+	makeTrade := func(txID string, timestamp int64) model.Trade {
+		return model.Trade{
+			Order: model.Order{
+				OrderAction: model.OrderActionSell,
+				Price:       model.NumberFromFloat(0.067, 7),
+				Volume:      model.NumberFromFloat(10.0, 7),
+				Timestamp:   model.MakeTimestamp(timestamp),
+			},
+			TransactionID: model.MakeTransactionID(txID),
+		}
+	}
+
+	testCases := []struct {
+		name                     string
+		incrementTimestampCursor bool
+		cursor                   string
+		pages                    [][]model.Trade
+		wantError                bool
+		wantCursor               string
+	}{
+		{
+			name:                     "timestamp advances",
+			incrementTimestampCursor: true,
+			cursor:                   "1000",
+			pages:                    [][]model.Trade{{makeTrade("5", 1000)}, {makeTrade("6", 1500)}},
+			wantError:                false,
+			wantCursor:               "1501",
+		}, {
+			name:                     "timestamp replays the last trade",
+			incrementTimestampCursor: true,
+			cursor:                   "1000",
+			pages:                    [][]model.Trade{{makeTrade("5", 1500)}, {makeTrade("5", 1500)}},
+			wantError:                true,
+		}, {
+			name:                     "timestamp goes backward",
+			incrementTimestampCursor: true,
+			cursor:                   "2000",
+			pages:                    [][]model.Trade{{makeTrade("5", 1500)}},
+			wantError:                true,
+		}, {
+			name:                     "transaction id advances",
+			incrementTimestampCursor: false,
+			cursor:                   "4",
+			pages:                    [][]model.Trade{{makeTrade("5", 1000)}, {makeTrade("6", 1000)}},
+			wantError:                false,
+			wantCursor:               "6",
+		}, {
+			name:                     "transaction id replays the last trade",
+			incrementTimestampCursor: false,
+			cursor:                   "4",
+			pages:                    [][]model.Trade{{makeTrade("5", 1000)}, {makeTrade("5", 1000)}},
+			wantError:                true,
+		}, {
+			name:                     "transaction id goes backward",
+			incrementTimestampCursor: false,
+			cursor:                   "4",
+			pages:                    [][]model.Trade{{makeTrade("5", 1000)}, {makeTrade("3", 1000)}},
+			wantError:                true,
+		}, {
+			name:                     "non-numeric transaction id unchanged",
+			incrementTimestampCursor: false,
+			cursor:                   "TXID-A",
+			pages:                    [][]model.Trade{{makeTrade("TXID-A", 1000)}},
+			wantError:                true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			fetcher := &scriptedTradeFetcher{pages: k.pages}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, fetcher, 10, 0, 0, 0, nil, nil, s, k.cursor, k.incrementTimestampCursor, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			_, lastCursor, _, _, _, e := p.fetchLatestTradePrice()
+			if k.wantError {
+				assert.Error(t, e)
+				// the cursor is not advanced so the trades are not replayed
+				assert.Equal(t, k.cursor, p.lastTradeCursor)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantCursor, lastCursor)
+		})
+	}
+}
+
+func TestGetLevelsMinQuoteValue(t *testing.T) {
+	testCases := []struct {
+		name          string
+		isBuy         bool
+		priceLimit    float64
+		minQuoteValue float64
+		wantNumLevels int
+	}{
+		{
+			name:          "sell side disabled",
+			isBuy:         false,
+			priceLimit:    1.0,
+			minQuoteValue: 0.0,
+			wantNumLevels: 2,
+		}, {
+			// 10 units at ~0.06650 and ~0.06683 are worth ~0.6650 and ~0.6683 units of quote
+			name:          "sell side skips levels below min value",
+			isBuy:         false,
+			priceLimit:    1.0,
+			minQuoteValue: 0.666,
+			wantNumLevels: 1,
+		}, {
+			name:          "sell side skips all levels",
+			isBuy:         false,
+			priceLimit:    1.0,
+			minQuoteValue: 1.0,
+			wantNumLevels: 0,
+		}, {
+			// 10 units at ~0.06551 and ~0.06518 are worth ~0.6551 and ~0.6518 units of quote
+			name:          "buy side skips levels below min value",
+			isBuy:         true,
+			priceLimit:    0.0,
+			minQuoteValue: 0.653,
+			wantNumLevels: 1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, k.isBuy, 10.0, 1.0, 1.0, 2, 100, 0.066, k.priceLimit, 0.0, k.minQuoteValue, 0.0, 0.0, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			levels, e := p.GetLevels(1000.0, 1000.0)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNumLevels, len(levels))
+		})
+	}
+}
+
+func TestGetLevelsMinLevelAmount(t *testing.T) {
+	testCases := []struct {
+		name            string
+		isBuy           bool
+		priceLimit      float64
+		amountBase      float64
+		minLevelAmount  float64
+		volumePrecision int8
+		wantNumLevels   int
+		wantFirstAmount float64
+	}{
+		{
+			name:            "disabled",
+			isBuy:           false,
+			priceLimit:      1.0,
+			amountBase:      10.0,
+			minLevelAmount:  0.0,
+			volumePrecision: 7,
+			wantNumLevels:   2,
+			wantFirstAmount: 10.0,
+		}, {
+			name:            "sell side skips levels below min amount",
+			isBuy:           false,
+			priceLimit:      1.0,
+			amountBase:      10.0,
+			minLevelAmount:  10.5,
+			volumePrecision: 7,
+			wantNumLevels:   0,
+		}, {
+			name:            "buy side skips levels below min amount",
+			isBuy:           true,
+			priceLimit:      0.0,
+			amountBase:      10.0,
+			minLevelAmount:  10.5,
+			volumePrecision: 7,
+			wantNumLevels:   0,
+		}, {
+			name:            "amount at the min amount is kept",
+			isBuy:           false,
+			priceLimit:      1.0,
+			amountBase:      10.0,
+			minLevelAmount:  10.0,
+			volumePrecision: 7,
+			wantNumLevels:   2,
+			wantFirstAmount: 10.0,
+		}, {
+			// the amount of 0.4 rounds to 0 at a volume precision of 0 so the exchange would reject the offer
+			name:            "skips levels that round to zero",
+			isBuy:           false,
+			priceLimit:      1.0,
+			amountBase:      0.4,
+			minLevelAmount:  0.0,
+			volumePrecision: 0,
+			wantNumLevels:   0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, k.isBuy, k.amountBase, 1.0, 1.0, 2, 100, 0.066, k.priceLimit, 0.0, 0.0, k.minLevelAmount, 0.0, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, k.volumePrecision, 0.1), false)
+
+			levels, e := p.GetLevels(1000.0, 1000.0)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, k.wantNumLevels, len(levels)) || len(levels) == 0 {
+				return
+			}
+			assert.Equal(t, k.wantFirstAmount, levels[0].Amount.AsFloat())
+		})
+	}
+}
+
+func TestValidateLevel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		isBuy          bool
+		price          float64
+		amount         float64
+		minQuoteValue  float64
+		minLevelAmount float64
+		wantError      bool
+	}{
+		{
+			name:      "valid",
+			price:     0.066,
+			amount:    10.0,
+			wantError: false,
+		}, {
+			name:      "zero price",
+			price:     0.0,
+			amount:    10.0,
+			wantError: true,
+		}, {
+			name:      "zero amount",
+			price:     0.066,
+			amount:    0.0,
+			wantError: true,
+		}, {
+			name:           "below min amount",
+			price:          0.066,
+			amount:         10.0,
+			minLevelAmount: 10.1,
+			wantError:      true,
+		}, {
+			name:          "below min value on the sell side",
+			price:         0.066,
+			amount:        10.0,
+			minQuoteValue: 0.67,
+			wantError:     true,
+		}, {
+			// the price is inverted on the buy side so the value is 10 / 15.0 = 0.6667
+			name:          "above min value on the buy side",
+			isBuy:         true,
+			price:         15.0,
+			amount:        10.0,
+			minQuoteValue: 0.66,
+			wantError:     false,
+		}, {
+			name:          "below min value on the buy side",
+			isBuy:         true,
+			price:         15.0,
+			amount:        10.0,
+			minQuoteValue: 0.67,
+			wantError:     true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p := &pendulumLevelProvider{
+				useMaxQuoteInTargetAmountCalc: k.isBuy,
+				minQuoteValue:                 k.minQuoteValue,
+				minLevelAmount:                k.minLevelAmount,
+				orderConstraints:              model.MakeOrderConstraints(7, 7, 0.1),
+			}
+			e := p.validateLevel(api.Level{
+				Price:  *model.NumberFromFloat(k.price, 7),
+				Amount: *model.NumberFromFloat(k.amount, 7),
+			})
+			assert.Equal(t, k.wantError, e != nil, fmt.Sprintf("%v", e))
+		})
+	}
+}
+
+func TestGetLevelsMaxQuoteExposure(t *testing.T) {
+	testCases := []struct {
+		name             string
+		isBuy            bool
+		maxQuoteExposure float64
+		wantNumLevels    int
+	}{
+		{
+			name:             "disabled",
+			isBuy:            true,
+			maxQuoteExposure: 0.0,
+			wantNumLevels:    3,
+		}, {
+			// each level of 10 units commits ~0.65 units of quote
+			name:             "stops before exceeding the max exposure",
+			isBuy:            true,
+			maxQuoteExposure: 1.5,
+			wantNumLevels:    2,
+		}, {
+			name:             "less than one level",
+			isBuy:            true,
+			maxQuoteExposure: 0.5,
+			wantNumLevels:    0,
+		}, {
+			name:             "ignored on the sell side",
+			isBuy:            false,
+			maxQuoteExposure: 0.5,
+			wantNumLevels:    3,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			// the price limit is the max price on the sell side and the min price on the buy side
+			priceLimit := 1.0
+			if k.isBuy {
+				priceLimit = 0.0
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, k.isBuy, 10.0, 1.0, 1.0, 3, 100, 0.066, priceLimit, 0.0, 0.0, 0.0, k.maxQuoteExposure, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			levels, e := p.GetLevels(1000.0, 1000.0)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNumLevels, len(levels))
+		})
+	}
+}
+
+func TestMakeLevelsSummary(t *testing.T) {
+	testCases := []struct {
+		name             string
+		isBuy            bool
+		maxAssetBase     float64
+		priceLimit       float64
+		maxQuoteExposure float64
+		wantNumLevels    int
+		wantReason       string
+	}{
+		{
+			name:          "max levels",
+			isBuy:         false,
+			maxAssetBase:  1000.0,
+			priceLimit:    1.0,
+			wantNumLevels: 3,
+			wantReason:    pendulumTerminationMaxLevels,
+		}, {
+			// each level uses 10 units of base so the third level would leave us with a negative balance
+			name:          "min base",
+			isBuy:         false,
+			maxAssetBase:  25.0,
+			priceLimit:    1.0,
+			wantNumLevels: 2,
+			wantReason:    pendulumTerminationMinBase,
+		}, {
+			// the second level is at ~0.06683
+			name:          "price limit on the sell side",
+			isBuy:         false,
+			maxAssetBase:  1000.0,
+			priceLimit:    0.0667,
+			wantNumLevels: 1,
+			wantReason:    pendulumTerminationPriceLimit,
+		}, {
+			// the second level is at ~0.06518
+			name:          "price limit on the buy side",
+			isBuy:         true,
+			maxAssetBase:  1000.0,
+			priceLimit:    0.0652,
+			wantNumLevels: 1,
+			wantReason:    pendulumTerminationPriceLimit,
+		}, {
+			// each level of 10 units commits ~0.65 units of quote
+			name:             "max quote exposure",
+			isBuy:            true,
+			maxAssetBase:     1000.0,
+			priceLimit:       0.0,
+			maxQuoteExposure: 1.5,
+			wantNumLevels:    2,
+			wantReason:       pendulumTerminationMaxQuoteExposure,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, k.isBuy, 10.0, 1.0, 1.0, 3, 100, 0.066, k.priceLimit, 0.0, 0.0, 0.0, k.maxQuoteExposure, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			levels, summary := p.makeLevels(k.maxAssetBase)
+			assert.Equal(t, k.wantNumLevels, len(levels))
+			assert.Equal(t, k.wantNumLevels, summary.numLevels)
+			assert.True(t, strings.HasPrefix(summary.terminationReason, k.wantReason), summary.terminationReason)
+			if len(levels) == 0 {
+				return
+			}
+			// the prices in the summary are always in units of the real quote asset and the levels are ordered away from the last trade price
+			firstPrice := levels[0].Price.AsFloat()
+			lastPrice := levels[len(levels)-1].Price.AsFloat()
+			if k.isBuy {
+				firstPrice = 1 / firstPrice
+				lastPrice = 1 / lastPrice
+				assert.InDelta(t, lastPrice, summary.lowestPrice, 1e-6)
+				assert.InDelta(t, firstPrice, summary.highestPrice, 1e-6)
+			} else {
+				assert.InDelta(t, firstPrice, summary.lowestPrice, 1e-6)
+				assert.InDelta(t, lastPrice, summary.highestPrice, 1e-6)
+				assert.InDelta(t, 10.0*float64(len(levels)), summary.baseExposed, 1e-9)
+			}
+		})
+	}
+}
+
+func TestMakeLevelsAmountMultiplier(t *testing.T) {
+	testCases := []struct {
+		name             string
+		isBuy            bool
+		amountMultiplier float64
+		maxAssetBase     float64
+		wantAmounts      []float64
+	}{
+		{
+			name:             "same amount on every level",
+			isBuy:            false,
+			amountMultiplier: 1.0,
+			maxAssetBase:     1000.0,
+			wantAmounts:      []float64{10.0, 10.0, 10.0},
+		}, {
+			name:             "larger amounts deeper in the book",
+			isBuy:            false,
+			amountMultiplier: 2.0,
+			maxAssetBase:     1000.0,
+			wantAmounts:      []float64{10.0, 20.0, 40.0},
+		}, {
+			name:             "smaller amounts deeper in the book",
+			isBuy:            false,
+			amountMultiplier: 0.5,
+			maxAssetBase:     1000.0,
+			wantAmounts:      []float64{10.0, 5.0, 2.5},
+		}, {
+			name:             "buy side",
+			isBuy:            true,
+			amountMultiplier: 2.0,
+			maxAssetBase:     1000.0,
+			wantAmounts:      []float64{10.0, 20.0, 40.0},
+		}, {
+			// the second level would use 20 units of base and leave us with a negative balance
+			name:             "min base uses the multiplied amount",
+			isBuy:            false,
+			amountMultiplier: 2.0,
+			maxAssetBase:     25.0,
+			wantAmounts:      []float64{10.0},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			// the price limit is the max price on the sell side and the min price on the buy side
+			priceLimit := 1.0
+			if k.isBuy {
+				priceLimit = 0.0
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, k.isBuy, 10.0, k.amountMultiplier, 1.0, 3, 100, 0.066, priceLimit, 0.0, 0.0, 0.0, 0.0, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			levels, _ := p.makeLevels(k.maxAssetBase)
+			amounts := []float64{}
+			for _, l := range levels {
+				amounts = append(amounts, l.Amount.AsFloat())
+			}
+			assert.Equal(t, k.wantAmounts, amounts)
+		})
+	}
+}
+
+func TestUpdateFilledAmountWithAmountMultiplier(t *testing.T) {
+	s, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 0.5, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, nil, 10, 0, 0, 0, nil, nil, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+	levels, _ := p.makeLevels(1000.0)
+	if !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+
+	// the second level only has 5 units so a trade of 5 units fills it
+	makeLevelTrade := func(price float64, volume float64) model.Trade {
+		return model.Trade{Order: model.Order{
+			OrderAction: model.OrderActionSell,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+		}}
+	}
+	assert.False(t, p.updateFilledAmount(makeLevelTrade(levels[0].Price.AsFloat(), 5.0)))
+	assert.True(t, p.updateFilledAmount(makeLevelTrade(levels[1].Price.AsFloat(), 5.0)))
+	// trades at prices that do not match a level use the amountBase
+	assert.False(t, p.updateFilledAmount(makeLevelTrade(0.05, 5.0)))
+}
+
+func TestMakeLevelsMaxOffersPerTransaction(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		maxOffersPerTransaction int
+		minQuoteValue           float64
+		wantNumLevels           int
+		wantReason              string
+	}{
+		{
+			name:                    "max levels is the binding constraint",
+			maxOffersPerTransaction: 5,
+			wantNumLevels:           3,
+			wantReason:              pendulumTerminationMaxLevels,
+		}, {
+			name:                    "max offers per transaction is the binding constraint",
+			maxOffersPerTransaction: 2,
+			wantNumLevels:           2,
+			wantReason:              pendulumTerminationMaxOffersPerTx,
+		}, {
+			// 10 units at ~0.06650 are worth ~0.6650 units of quote so the first level is skipped and does not count
+			name:                    "skipped levels do not count",
+			maxOffersPerTransaction: 2,
+			minQuoteValue:           0.666,
+			wantNumLevels:           2,
+			wantReason:              pendulumTerminationMaxLevels,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 3, k.maxOffersPerTransaction, 0.066, 1.0, 0.0, k.minQuoteValue, 0.0, 0.0, emptyTradeFetcher{}, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			levels, summary := p.makeLevels(1000.0)
+			assert.Equal(t, k.wantNumLevels, len(levels))
+			assert.True(t, strings.HasPrefix(summary.terminationReason, k.wantReason), summary.terminationReason)
+		})
+	}
+}
+
+func TestGetLevelsStartupJitter(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		maxStartupJitterMillis int64
+		wantSleeps             int
+	}{
+		{
+			name:                   "disabled",
+			maxStartupJitterMillis: 0,
+			wantSleeps:             0,
+		}, {
+			name:                   "enabled",
+			maxStartupJitterMillis: 5000,
+			wantSleeps:             1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, emptyTradeFetcher{}, 10, 0, k.maxStartupJitterMillis, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+			sleeps := []time.Duration{}
+			p.sleepFn = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			// the jitter is only applied on the first cycle
+			for i := 0; i < 3; i++ {
+				_, e = p.GetLevels(1000.0, 1000.0)
+				if !assert.NoError(t, e) {
+					return
+				}
+			}
+			if !assert.Equal(t, k.wantSleeps, len(sleeps)) {
+				return
+			}
+			for _, d := range sleeps {
+				assert.True(t, d >= 0 && d < time.Duration(k.maxStartupJitterMillis)*time.Millisecond, d.String())
+			}
+		})
+	}
+}
+
+// recordingAlert records the alerts that are triggered and resolved
+type recordingAlert struct {
+	triggers []string
+	resolved []string
+}
+
+var _ api.Alert = &recordingAlert{}
+
+func (r *recordingAlert) Trigger(description string, details interface{}) (string, error) {
+	return r.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+func (r *recordingAlert) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	r.triggers = append(r.triggers, description)
+	return fmt.Sprintf("key-%d", len(r.triggers)), nil
+}
+
+func (r *recordingAlert) Resolve(dedupKey string) error {
+	r.resolved = append(r.resolved, dedupKey)
+	return nil
+}
+
+func TestCheckStaleTrades(t *testing.T) {
+	type step struct {
+		elapsed        time.Duration // since the previous step
+		cursorAdvanced bool
+	}
+	testCases := []struct {
+		name                string
+		staleTradeThreshold time.Duration
+		steps               []step
+		wantTriggers        int
+		wantResolved        []string
+	}{
+		{
+			name:                "disabled",
+			staleTradeThreshold: 0,
+			steps:               []step{{elapsed: 24 * time.Hour}, {elapsed: 24 * time.Hour}},
+			wantTriggers:        0,
+			wantResolved:        nil,
+		}, {
+			name:                "within threshold",
+			staleTradeThreshold: time.Hour,
+			steps:               []step{{elapsed: 40 * time.Minute}, {elapsed: 40 * time.Minute, cursorAdvanced: true}, {elapsed: 40 * time.Minute}},
+			wantTriggers:        0,
+			wantResolved:        nil,
+		}, {
+			name:                "stale triggers once",
+			staleTradeThreshold: time.Hour,
+			steps:               []step{{elapsed: 40 * time.Minute}, {elapsed: 40 * time.Minute}, {elapsed: 40 * time.Minute}},
+			wantTriggers:        1,
+			wantResolved:        nil,
+		}, {
+			name:                "resolved when a trade is seen",
+			staleTradeThreshold: time.Hour,
+			steps:               []step{{elapsed: 2 * time.Hour}, {elapsed: time.Minute, cursorAdvanced: true}, {elapsed: 2 * time.Hour}},
+			wantTriggers:        2,
+			wantResolved:        []string{"key-1"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			alert := &recordingAlert{}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, emptyTradeFetcher{}, 10, 0, 0, k.staleTradeThreshold, alert, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+			now := time.Unix(1600000000, 0)
+			p.lastCursorAdvanceTime = now
+			p.nowFn = func() time.Time {
+				return now
+			}
+
+			for _, st := range k.steps {
+				now = now.Add(st.elapsed)
+				p.checkStaleTrades(st.cursorAdvanced)
+			}
+			assert.Equal(t, k.wantTriggers, len(alert.triggers))
+			assert.Equal(t, k.wantResolved, alert.resolved)
+		})
+	}
+}
+
+// failingTradeFetcher is a TradeFetcher that returns an error on the calls where fail is true and no trades otherwise
+type failingTradeFetcher struct {
+	fail     []bool
+	numCalls int
+}
+
+func (f *failingTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	call := f.numCalls
+	f.numCalls++
+	if call < len(f.fail) && f.fail[call] {
+		return nil, fmt.Errorf("connection reset by peer")
+	}
+	return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+}
+
+func TestGetLevelsTransientFetchErrors(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		maxConsecutiveFetchErrors int
+		fail                      []bool
+		wantErrors                []bool
+	}{
+		{
+			name:                      "disabled",
+			maxConsecutiveFetchErrors: 0,
+			fail:                      []bool{true, false},
+			wantErrors:                []bool{true, false},
+		}, {
+			name:                      "tolerates errors up to the max",
+			maxConsecutiveFetchErrors: 2,
+			fail:                      []bool{true, true, true, true},
+			wantErrors:                []bool{false, false, true, true},
+		}, {
+			name:                      "success resets the count",
+			maxConsecutiveFetchErrors: 1,
+			fail:                      []bool{true, false, true, false},
+			wantErrors:                []bool{false, false, false, false},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s, e := makePendulumState("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			fetcher := &failingTradeFetcher{fail: k.fail}
+			p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, fetcher, 10, k.maxConsecutiveFetchErrors, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+			for i, wantError := range k.wantErrors {
+				levels, e := p.GetLevels(1000.0, 1000.0)
+				if wantError {
+					assert.Error(t, e, "cycle %d", i)
+					continue
+				}
+				if !assert.NoError(t, e, "cycle %d", i) {
+					return
+				}
+				// the levels are made from the last trade price whether or not the fetch failed
+				if assert.Equal(t, 2, len(levels), "cycle %d", i) {
+					assert.InDelta(t, 0.0664958, levels[0].Price.AsFloat(), 1e-7, "cycle %d", i)
+				}
+			}
+			assert.Equal(t, 0.066, p.lastTradePrice)
+		})
+	}
+}
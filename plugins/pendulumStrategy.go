@@ -1,6 +1,9 @@
 package plugins
 
 import (
+	"fmt"
+	"time"
+
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
@@ -9,18 +12,32 @@ import (
 
 // pendulumConfig contains the configuration params for this Strategy
 type pendulumConfig struct {
-	PriceTolerance     float64 `valid:"-" toml:"PRICE_TOLERANCE"`
-	AmountTolerance    float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
-	AmountBaseBuy      float64 `valid:"-" toml:"AMOUNT_BASE_BUY"`
-	AmountBaseSell     float64 `valid:"-" toml:"AMOUNT_BASE_SELL"`
-	Spread             float64 `valid:"-" toml:"SPREAD"`                // this is the bid-ask spread (i.e. it is not the spread from the center price)
-	MaxLevels          int16   `valid:"-" toml:"MAX_LEVELS"`            // max number of levels to have on either side
-	SeedLastTradePrice float64 `valid:"-" toml:"SEED_LAST_TRADE_PRICE"` // price with which to start off as the last trade price (i.e. initial center price)
-	MaxPrice           float64 `valid:"-" toml:"MAX_PRICE"`             // max price for which to place an order
-	MinPrice           float64 `valid:"-" toml:"MIN_PRICE"`             // min price for which to place an order
-	MinBase            float64 `valid:"-" toml:"MIN_BASE"`
-	MinQuote           float64 `valid:"-" toml:"MIN_QUOTE"`
-	LastTradeCursor    string  `valid:"-" toml:"LAST_TRADE_CURSOR"`
+	PriceTolerance          float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance         float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	AmountBaseBuy           float64 `valid:"-" toml:"AMOUNT_BASE_BUY"`
+	AmountBaseSell          float64 `valid:"-" toml:"AMOUNT_BASE_SELL"`
+	AmountMultiplier        float64 `valid:"-" toml:"AMOUNT_MULTIPLIER"`          // the amount of each level is the amount of the previous level times this, defaults to 1.0
+	Spread                  float64 `valid:"-" toml:"SPREAD"`                     // this is the bid-ask spread (i.e. it is not the spread from the center price)
+	MaxLevels               int16   `valid:"-" toml:"MAX_LEVELS"`                 // max number of levels to have on either side
+	MaxOffersPerTransaction int     `valid:"-" toml:"MAX_OFFERS_PER_TRANSACTION"` // max number of levels placed on either side, defaults to 50 so both sides fit in a single transaction
+	SeedLastTradePrice      float64 `valid:"-" toml:"SEED_LAST_TRADE_PRICE"`      // price with which to start off as the last trade price (i.e. initial center price)
+	MaxPrice                float64 `valid:"-" toml:"MAX_PRICE"`                  // max price for which to place an order
+	MinPrice                float64 `valid:"-" toml:"MIN_PRICE"`                  // min price for which to place an order
+	MinBase                 float64 `valid:"-" toml:"MIN_BASE"`
+	MinQuote                float64 `valid:"-" toml:"MIN_QUOTE"`
+	MinQuoteValue           float64 `valid:"-" toml:"MIN_QUOTE_VALUE"`           // min value of a level in units of the quote asset, smaller levels are skipped so the exchange does not reject them
+	MinLevelAmount          float64 `valid:"-" toml:"MIN_LEVEL_AMOUNT"`          // min amount of a level in units of the base asset, smaller levels are skipped so the exchange does not reject them
+	MaxQuoteExposure        float64 `valid:"-" toml:"MAX_QUOTE_EXPOSURE"`        // max amount of quote committed across all buy levels, 0 for no limit
+	PricePrecisionOverride  *int8   `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`  // number of decimals for prices, defaults to the precision of the trading exchange
+	VolumePrecisionOverride *int8   `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"` // number of decimals for amounts, defaults to the precision of the trading exchange
+	LastTradeCursor         string  `valid:"-" toml:"LAST_TRADE_CURSOR"`
+	MaxTradeHistoryPages    int     `valid:"-" toml:"MAX_TRADE_HISTORY_PAGES"`   // max pages of trades fetched per cycle, defaults to 10
+	MaxTradeFetchErrors     int     `valid:"-" toml:"MAX_TRADE_FETCH_ERRORS"`    // consecutive errors fetching trades that keep the last trade price instead of failing the cycle, 0 to disable
+	MaxStartupJitterMillis  int64   `valid:"-" toml:"MAX_STARTUP_JITTER_MILLIS"` // max random delay before the first cycle on each side, 0 to disable
+	StaleTradeSeconds       int64   `valid:"-" toml:"STALE_TRADE_SECONDS"`       // alert when no new trade is seen within this many seconds, 0 to disable
+	MinFillFraction         float64 `valid:"-" toml:"MIN_FILL_FRACTION"`         // fraction of a level's amount that needs to be filled before we update the last trade price, defaults to 1.0
+	StateFilePath           string  `valid:"-" toml:"STATE_FILE_PATH"`           // file where the last trade cursor and price are saved, ignores LAST_TRADE_CURSOR and SEED_LAST_TRADE_PRICE once it has been written
+	DebugLogging            bool    `valid:"-" toml:"DEBUG_LOGGING"`             // logs every level and the price2LastPrice map on each cycle instead of only a summary
 }
 
 /*
@@ -46,6 +63,15 @@ func (c pendulumConfig) String() string {
 	return utils.StructString(c, 0, nil)
 }
 
+// defaultPendulumMaxTradeHistoryPages is the number of pages of trades fetched per cycle when MAX_TRADE_HISTORY_PAGES is not set
+const defaultPendulumMaxTradeHistoryPages = 10
+
+// stellarMaxOpsPerTransaction is the max number of operations in a single Stellar transaction, shared by the buy and sell sides
+const stellarMaxOpsPerTransaction = 100
+
+// defaultPendulumMaxOffersPerTransaction is the number of levels placed on either side when MAX_OFFERS_PER_TRANSACTION is not set
+const defaultPendulumMaxOffersPerTransaction = stellarMaxOpsPerTransaction / 2
+
 // makePendulumStrategy is a factory method for pendulumStrategy
 func makePendulumStrategy(
 	sdex *SDEX,
@@ -56,27 +82,98 @@ func makePendulumStrategy(
 	config *pendulumConfig,
 	tradeFetcher api.TradeFetcher,
 	tradingPair *model.TradingPair,
-	incrementTimestampCursor bool, // only do this if we are on ccxt
-) api.Strategy {
+	incrementTimestampCursor bool, // only do this if the tradeFetcher uses an inclusive timestamp cursor (ccxt)
+	alert api.Alert, // can be nil
+) (api.Strategy, error) {
 	if config.AmountTolerance != 1.0 {
 		panic("pendulum strategy needs to be configured with AMOUNT_TOLERANCE = 1.0")
 	}
 
-	orderConstraints := exchangeShim.GetOrderConstraints(tradingPair)
+	minFillFraction := config.MinFillFraction
+	if minFillFraction == 0 {
+		minFillFraction = 1.0
+	}
+	if minFillFraction < 0 || minFillFraction > 1.0 {
+		return nil, fmt.Errorf("MIN_FILL_FRACTION needs to be greater than 0 and less than or equal to 1.0 but was %f", config.MinFillFraction)
+	}
+
+	amountMultiplier := config.AmountMultiplier
+	if amountMultiplier == 0 {
+		amountMultiplier = 1.0
+	}
+	if amountMultiplier < 0 {
+		return nil, fmt.Errorf("AMOUNT_MULTIPLIER needs to be greater than 0 but was %f", config.AmountMultiplier)
+	}
+
+	maxTradeHistoryPages := config.MaxTradeHistoryPages
+	if maxTradeHistoryPages == 0 {
+		maxTradeHistoryPages = defaultPendulumMaxTradeHistoryPages
+	}
+	if maxTradeHistoryPages < 0 {
+		return nil, fmt.Errorf("MAX_TRADE_HISTORY_PAGES needs to be greater than 0 but was %d", config.MaxTradeHistoryPages)
+	}
+
+	if config.MinLevelAmount < 0 {
+		return nil, fmt.Errorf("MIN_LEVEL_AMOUNT cannot be negative but was %f", config.MinLevelAmount)
+	}
+
+	if config.MaxTradeFetchErrors < 0 {
+		return nil, fmt.Errorf("MAX_TRADE_FETCH_ERRORS cannot be negative but was %d", config.MaxTradeFetchErrors)
+	}
+
+	if config.MaxStartupJitterMillis < 0 {
+		return nil, fmt.Errorf("MAX_STARTUP_JITTER_MILLIS cannot be negative but was %d", config.MaxStartupJitterMillis)
+	}
+
+	if config.StaleTradeSeconds < 0 {
+		return nil, fmt.Errorf("STALE_TRADE_SECONDS cannot be negative but was %d", config.StaleTradeSeconds)
+	}
+	staleTradeThreshold := time.Duration(config.StaleTradeSeconds) * time.Second
+
+	maxOffersPerTransaction := config.MaxOffersPerTransaction
+	if maxOffersPerTransaction == 0 {
+		maxOffersPerTransaction = defaultPendulumMaxOffersPerTransaction
+	}
+	if maxOffersPerTransaction < 0 || maxOffersPerTransaction > stellarMaxOpsPerTransaction/2 {
+		return nil, fmt.Errorf("MAX_OFFERS_PER_TRANSACTION needs to be greater than 0 and less than or equal to %d (the max for each side) but was %d", stellarMaxOpsPerTransaction/2, config.MaxOffersPerTransaction)
+	}
+
+	orderConstraints, e := makePendulumOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), config)
+	if e != nil {
+		return nil, fmt.Errorf("could not make order constraints: %s", e)
+	}
+	// the state is shared between the buy side and sell side level providers so they can coordinate the last price of each level
+	state, e := makePendulumState(config.StateFilePath)
+	if e != nil {
+		return nil, fmt.Errorf("could not make pendulum state: %s", e)
+	}
 	sellLevelProvider := makePendulumLevelProvider(
 		config.Spread,
 		config.Spread/2,
 		false,
 		config.AmountBaseSell,
+		amountMultiplier,
+		minFillFraction,
 		config.MaxLevels,
+		maxOffersPerTransaction,
 		config.SeedLastTradePrice,
 		config.MaxPrice,
 		config.MinBase,
+		config.MinQuoteValue,
+		config.MinLevelAmount,
+		0, // maxQuoteExposure only applies to the buy side
 		tradeFetcher,
+		maxTradeHistoryPages,
+		config.MaxTradeFetchErrors,
+		config.MaxStartupJitterMillis,
+		staleTradeThreshold,
+		alert,
 		tradingPair,
+		state,
 		config.LastTradeCursor,
 		incrementTimestampCursor,
 		orderConstraints,
+		config.DebugLogging,
 	)
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
@@ -94,15 +191,28 @@ func makePendulumStrategy(
 		config.Spread/2,
 		true, // real base is passed in as quote so pass in true
 		config.AmountBaseBuy,
+		amountMultiplier,
+		minFillFraction,
 		config.MaxLevels,
+		maxOffersPerTransaction,
 		config.SeedLastTradePrice, // we don't invert seed last trade price for the buy side because it's handeld in the pendulumLevelProvider
 		config.MinPrice,           // use minPrice for buy side
 		config.MinQuote,           // use minQuote for buying side
+		config.MinQuoteValue,      // minQuoteValue is always in units of the real quote asset so it is the same for both sides
+		config.MinLevelAmount,     // the amount of a level is always in units of the real base asset so it is the same for both sides
+		config.MaxQuoteExposure,
 		tradeFetcher,
+		maxTradeHistoryPages,
+		config.MaxTradeFetchErrors,
+		config.MaxStartupJitterMillis,
+		staleTradeThreshold,
+		alert,
 		tradingPair,
+		state,
 		config.LastTradeCursor,
 		incrementTimestampCursor,
 		orderConstraints,
+		config.DebugLogging,
 	)
 	// switch sides of base/quote here for buy side
 	buySideStrategy := makeSellSideStrategy(
@@ -122,5 +232,22 @@ func makePendulumStrategy(
 		assetQuote,
 		buySideStrategy,
 		sellSideStrategy,
-	)
+	), nil
+}
+
+// makePendulumOrderConstraints applies the precision overrides from the config to a copy of the exchange's order constraints
+func makePendulumOrderConstraints(oc *model.OrderConstraints, config *pendulumConfig) (*model.OrderConstraints, error) {
+	if config.PricePrecisionOverride != nil && *config.PricePrecisionOverride < 0 {
+		return nil, fmt.Errorf("need to specify non-negative PRICE_PRECISION_OVERRIDE config param in pendulum strategy config file")
+	}
+	if config.VolumePrecisionOverride != nil && *config.VolumePrecisionOverride < 0 {
+		return nil, fmt.Errorf("need to specify non-negative VOLUME_PRECISION_OVERRIDE config param in pendulum strategy config file")
+	}
+
+	return model.MakeOrderConstraintsWithOverride(*oc, model.MakeOrderConstraintsOverride(
+		config.PricePrecisionOverride,
+		config.VolumePrecisionOverride,
+		nil,
+		nil,
+	)), nil
 }
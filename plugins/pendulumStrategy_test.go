@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestMakePendulumOrderConstraints(t *testing.T) {
+	int8Ptr := func(v int8) *int8 {
+		return &v
+	}
+
+	testCases := []struct {
+		name                string
+		config              *pendulumConfig
+		wantPricePrecision  int8
+		wantVolumePrecision int8
+		wantErr             bool
+	}{
+		{
+			name:                "no overrides",
+			config:              &pendulumConfig{},
+			wantPricePrecision:  7,
+			wantVolumePrecision: 7,
+		}, {
+			name:                "price precision override",
+			config:              &pendulumConfig{PricePrecisionOverride: int8Ptr(4)},
+			wantPricePrecision:  4,
+			wantVolumePrecision: 7,
+		}, {
+			name:                "both overrides",
+			config:              &pendulumConfig{PricePrecisionOverride: int8Ptr(4), VolumePrecisionOverride: int8Ptr(0)},
+			wantPricePrecision:  4,
+			wantVolumePrecision: 0,
+		}, {
+			name:    "negative price precision",
+			config:  &pendulumConfig{PricePrecisionOverride: int8Ptr(-1)},
+			wantErr: true,
+		}, {
+			name:    "negative volume precision",
+			config:  &pendulumConfig{VolumePrecisionOverride: int8Ptr(-1)},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			exchangeConstraints := model.MakeOrderConstraints(7, 7, 0.1)
+			oc, e := makePendulumOrderConstraints(exchangeConstraints, k.config)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, k.wantPricePrecision, oc.PricePrecision)
+			assert.Equal(t, k.wantVolumePrecision, oc.VolumePrecision)
+			// the exchange's constraints are not modified
+			assert.Equal(t, int8(7), exchangeConstraints.PricePrecision)
+			assert.Equal(t, int8(7), exchangeConstraints.VolumePrecision)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// pnlEpsilon is the volume below which a lot or the remainder of a fill is treated as 0, so that floating point dust from
+// closing out lots does not leave behind an open position
+const pnlEpsilon = 1e-9
+
+// PnlSnapshot is the position and realized P&L of a trading pair
+type PnlSnapshot struct {
+	Position    float64 // net position in units of the base asset, negative when short
+	AverageCost float64 // average price of the open position in units of the quote asset, 0 when the position is flat
+	RealizedPnl float64 // realized P&L in units of the quote asset, excluding fees
+}
+
+// String is the stringer function
+func (s PnlSnapshot) String() string {
+	return fmt.Sprintf("PnlSnapshot[position=%.8f, averageCost=%.8f, realizedPnl=%.8f]", s.Position, s.AverageCost, s.RealizedPnl)
+}
+
+// pnlLot is an open lot of a position, volume is positive for longs and negative for shorts
+type pnlLot struct {
+	volume float64
+	price  float64
+}
+
+// pnlPairState is the open lots (oldest first) and realized P&L of a trading pair
+type pnlPairState struct {
+	lots        []pnlLot
+	realizedPnl float64
+}
+
+// PnlFillHandler is a FillHandler that tracks the running position and realized P&L of each trading pair by matching fills
+// against the open lots in FIFO order. It implements prometheus.Collector so the snapshots can be queried on the monitoring server
+type PnlFillHandler struct {
+	lock   *sync.Mutex
+	states map[string]*pnlPairState
+
+	positionDesc    *prometheus.Desc
+	averageCostDesc *prometheus.Desc
+	realizedPnlDesc *prometheus.Desc
+}
+
+var _ api.FillHandler = &PnlFillHandler{}
+var _ prometheus.Collector = &PnlFillHandler{}
+
+// MakePnlFillHandler is a factory method
+func MakePnlFillHandler() *PnlFillHandler {
+	pairLabels := []string{"pair"}
+	return &PnlFillHandler{
+		lock:   &sync.Mutex{},
+		states: map[string]*pnlPairState{},
+
+		positionDesc: prometheus.NewDesc(
+			"kelp_pnl_position_base_units",
+			"net position in units of the base asset, negative when short",
+			pairLabels,
+			nil,
+		),
+		averageCostDesc: prometheus.NewDesc(
+			"kelp_pnl_average_cost_quote_units",
+			"average price of the open position in units of the quote asset, 0 when the position is flat",
+			pairLabels,
+			nil,
+		),
+		realizedPnlDesc: prometheus.NewDesc(
+			"kelp_pnl_realized_quote_units",
+			"realized P&L in units of the quote asset, excluding fees",
+			pairLabels,
+			nil,
+		),
+	}
+}
+
+// HandleFill impl.
+func (h *PnlFillHandler) HandleFill(trade model.Trade) error {
+	if trade.Pair == nil || trade.Price == nil || trade.Volume == nil {
+		// P&L tracking is informational so we don't want a trade that it cannot use to count as an error in the fill tracker
+		log.Printf("skipping P&L for trade with a missing pair, price or volume: %s\n", trade)
+		return nil
+	}
+
+	pair := trade.Pair.String()
+	volume := trade.Volume.AsFloat()
+	if trade.OrderAction.IsSell() {
+		volume = -volume
+	}
+
+	h.lock.Lock()
+	state, ok := h.states[pair]
+	if !ok {
+		state = &pnlPairState{lots: []pnlLot{}}
+		h.states[pair] = state
+	}
+	state.apply(volume, trade.Price.AsFloat())
+	snapshot := state.snapshot()
+	h.lock.Unlock()
+
+	log.Printf("P&L for pair %s after %s fill: %s\n", pair, trade.OrderAction, snapshot)
+	return nil
+}
+
+// Snapshot returns the current position and realized P&L of the trading pair, the snapshot is empty if there were no fills
+func (h *PnlFillHandler) Snapshot(pair *model.TradingPair) PnlSnapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	state, ok := h.states[pair.String()]
+	if !ok {
+		return PnlSnapshot{}
+	}
+	return state.snapshot()
+}
+
+// Describe impl.
+func (h *PnlFillHandler) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.positionDesc
+	ch <- h.averageCostDesc
+	ch <- h.realizedPnlDesc
+}
+
+// Collect impl.
+func (h *PnlFillHandler) Collect(ch chan<- prometheus.Metric) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for pair, state := range h.states {
+		snapshot := state.snapshot()
+		ch <- prometheus.MustNewConstMetric(h.positionDesc, prometheus.GaugeValue, snapshot.Position, pair)
+		ch <- prometheus.MustNewConstMetric(h.averageCostDesc, prometheus.GaugeValue, snapshot.AverageCost, pair)
+		ch <- prometheus.MustNewConstMetric(h.realizedPnlDesc, prometheus.GaugeValue, snapshot.RealizedPnl, pair)
+	}
+}
+
+// apply closes out the opposing lots in FIFO order, realizing the P&L on the closed volume, and opens a lot with any remaining volume.
+// volume is positive for buys and negative for sells, partial fills are handled by closing out lots partially
+func (s *pnlPairState) apply(volume float64, price float64) {
+	for math.Abs(volume) > pnlEpsilon && len(s.lots) > 0 && (s.lots[0].volume > 0) != (volume > 0) {
+		lot := &s.lots[0]
+		closed := math.Min(math.Abs(volume), math.Abs(lot.volume))
+		if lot.volume > 0 {
+			// selling against a long lot
+			s.realizedPnl += closed * (price - lot.price)
+			lot.volume -= closed
+			volume += closed
+		} else {
+			// buying against a short lot
+			s.realizedPnl += closed * (lot.price - price)
+			lot.volume += closed
+			volume -= closed
+		}
+
+		if math.Abs(lot.volume) <= pnlEpsilon {
+			s.lots = s.lots[1:]
+		}
+	}
+
+	if math.Abs(volume) > pnlEpsilon {
+		s.lots = append(s.lots, pnlLot{volume: volume, price: price})
+	}
+}
+
+// snapshot converts the state into a PnlSnapshot
+func (s *pnlPairState) snapshot() PnlSnapshot {
+	position := 0.0
+	cost := 0.0
+	for _, lot := range s.lots {
+		position += lot.volume
+		cost += math.Abs(lot.volume) * lot.price
+	}
+
+	averageCost := 0.0
+	if math.Abs(position) > pnlEpsilon {
+		averageCost = cost / math.Abs(position)
+	}
+	return PnlSnapshot{
+		Position:    position,
+		AverageCost: averageCost,
+		RealizedPnl: s.realizedPnl,
+	}
+}
@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func makeTestPnlTrade(action model.OrderAction, price float64, volume float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			Pair:        model.MakeTradingPair(model.XLM, model.USD),
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, 4),
+			Volume:      model.NumberFromFloat(volume, 4),
+			Timestamp:   model.MakeTimestamp(1577836800000),
+		},
+	}
+}
+
+func TestPnlFillHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		trades []model.Trade
+		want   PnlSnapshot
+	}{
+		{
+			name:   "no fills",
+			trades: []model.Trade{},
+			want:   PnlSnapshot{},
+		}, {
+			name: "open long",
+			trades: []model.Trade{
+				makeTestPnlTrade(model.OrderActionBuy, 0.10, 100),
+				makeTestPnlTrade(model.OrderActionBuy, 0.20, 100),
+			},
+			want: PnlSnapshot{Position: 200, AverageCost: 0.15, RealizedPnl: 0},
+		}, {
+			name: "partial close of long uses oldest lot first",
+			trades: []model.Trade{
+				makeTestPnlTrade(model.OrderActionBuy, 0.10, 100),
+				makeTestPnlTrade(model.OrderActionBuy, 0.20, 100),
+				makeTestPnlTrade(model.OrderActionSell, 0.30, 150),
+			},
+			// 100 * (0.30 - 0.10) + 50 * (0.30 - 0.20)
+			want: PnlSnapshot{Position: 50, AverageCost: 0.20, RealizedPnl: 25},
+		}, {
+			name: "sell through a long into a short",
+			trades: []model.Trade{
+				makeTestPnlTrade(model.OrderActionBuy, 0.10, 100),
+				makeTestPnlTrade(model.OrderActionSell, 0.12, 300),
+			},
+			want: PnlSnapshot{Position: -200, AverageCost: 0.12, RealizedPnl: 2},
+		}, {
+			name: "close a short at a loss",
+			trades: []model.Trade{
+				makeTestPnlTrade(model.OrderActionSell, 0.10, 100),
+				makeTestPnlTrade(model.OrderActionBuy, 0.15, 100),
+			},
+			want: PnlSnapshot{Position: 0, AverageCost: 0, RealizedPnl: -5},
+		}, {
+			name: "close a long opened in fractional lots",
+			trades: []model.Trade{
+				makeTestPnlTrade(model.OrderActionBuy, 0.10, 0.1),
+				makeTestPnlTrade(model.OrderActionBuy, 0.10, 0.2),
+				makeTestPnlTrade(model.OrderActionSell, 0.20, 0.3),
+			},
+			want: PnlSnapshot{Position: 0, AverageCost: 0, RealizedPnl: 0.03},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			h := MakePnlFillHandler()
+			for _, trade := range k.trades {
+				if !assert.NoError(t, h.HandleFill(trade)) {
+					return
+				}
+			}
+
+			got := h.Snapshot(model.MakeTradingPair(model.XLM, model.USD))
+			assert.InDelta(t, k.want.Position, got.Position, 1e-9)
+			assert.InDelta(t, k.want.AverageCost, got.AverageCost, 1e-9)
+			assert.InDelta(t, k.want.RealizedPnl, got.RealizedPnl, 1e-9)
+		})
+	}
+}
+
+func TestPnlFillHandlerSkipsIncompleteTrade(t *testing.T) {
+	h := MakePnlFillHandler()
+	trade := makeTestPnlTrade(model.OrderActionBuy, 0.10, 100)
+	trade.Price = nil
+	// an incomplete trade should not count as an error in the fill tracker
+	assert.NoError(t, h.HandleFill(trade))
+	assert.Equal(t, PnlSnapshot{}, h.Snapshot(model.MakeTradingPair(model.XLM, model.USD)))
+}
+
+func TestPnlFillHandlerMetrics(t *testing.T) {
+	h := MakePnlFillHandler()
+	registry := prometheus.NewRegistry()
+	if !assert.NoError(t, registry.Register(h)) {
+		return
+	}
+	assert.NoError(t, h.HandleFill(makeTestPnlTrade(model.OrderActionBuy, 0.10, 100)))
+	assert.NoError(t, h.HandleFill(makeTestPnlTrade(model.OrderActionSell, 0.12, 40)))
+
+	families, e := registry.Gather()
+	if !assert.NoError(t, e) {
+		return
+	}
+	got := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			assert.Equal(t, "XLM/USD", m.GetLabel()[0].GetValue())
+			got[f.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+	assert.InDelta(t, 60.0, got["kelp_pnl_position_base_units"], 1e-9)
+	assert.InDelta(t, 0.10, got["kelp_pnl_average_cost_quote_units"], 1e-9)
+	assert.InDelta(t, 0.8, got["kelp_pnl_realized_quote_units"], 1e-9)
+}
+
+func TestPnlFillHandlerTracksPairsSeparately(t *testing.T) {
+	h := MakePnlFillHandler()
+	trade := makeTestPnlTrade(model.OrderActionBuy, 0.10, 100)
+	trade.Pair = model.MakeTradingPair(model.XLM, model.BTC)
+	assert.NoError(t, h.HandleFill(trade))
+
+	assert.Equal(t, PnlSnapshot{}, h.Snapshot(model.MakeTradingPair(model.XLM, model.USD)))
+	assert.InDelta(t, 100.0, h.Snapshot(model.MakeTradingPair(model.XLM, model.BTC)).Position, 1e-9)
+}
+
+func TestPnlPairStateApplyLeavesNoDustLots(t *testing.T) {
+	s := &pnlPairState{lots: []pnlLot{}}
+	s.apply(0.1, 0.10)
+	s.apply(0.2, 0.10)
+	// 0.3 - 0.1 - 0.2 is not exactly 0 in floating point
+	s.apply(-0.3, 0.20)
+
+	assert.Equal(t, 0, len(s.lots))
+	assert.Equal(t, 0.0, s.snapshot().Position)
+	assert.Equal(t, 0.0, s.snapshot().AverageCost)
+}
@@ -66,6 +66,19 @@ func MakeFilterPriceFeed(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset,
 
 var _ SubmitFilter = &priceFeedFilter{}
 
+// Name impl.
+func (f *priceFeedFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *priceFeedFilter) Describe() string {
+	if f.cm == comparisonModeOutsideInclude {
+		return "drops sell operations priced below and buy operations priced above the price feed (comparison mode outside-include)"
+	}
+	return "drops sell operations priced at or below and buy operations priced at or above the price feed (comparison mode outside-exclude)"
+}
+
 func (f *priceFeedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
 	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.priceFeedFilterFn)
 	if e != nil {
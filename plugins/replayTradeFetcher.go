@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// ensure that ReplayTradeFetcher conforms to the TradeFetcher interface
+var _ api.TradeFetcher = &ReplayTradeFetcher{}
+
+// ReplayTradeFetcher is a TradeFetcher that replays a fixed, ordered list of trades from memory so strategies such as the pendulum strategy
+// can be backtested without an exchange. The cursor is the TransactionID of the last trade that was returned, which is how the pendulum
+// level provider advances its cursor, and an empty list of trades is returned once all the trades have been replayed
+type ReplayTradeFetcher struct {
+	trades    []model.Trade
+	indexByID map[string]int
+	batchSize int
+}
+
+// MakeReplayTradeFetcher is a factory method, every trade needs a unique TransactionID and at most batchSize trades are returned per call
+func MakeReplayTradeFetcher(trades []model.Trade, batchSize int) (*ReplayTradeFetcher, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize needs to be greater than 0 but was %d", batchSize)
+	}
+
+	indexByID := map[string]int{}
+	for i, t := range trades {
+		if t.TransactionID == nil {
+			return nil, fmt.Errorf("trade at index %d does not have a TransactionID", i)
+		}
+		id := t.TransactionID.String()
+		if _, ok := indexByID[id]; ok {
+			return nil, fmt.Errorf("trade at index %d has a duplicate TransactionID '%s'", i, id)
+		}
+		indexByID[id] = i
+	}
+
+	return &ReplayTradeFetcher{
+		// copy so the caller cannot modify the trades being replayed
+		trades:    append([]model.Trade{}, trades...),
+		indexByID: indexByID,
+		batchSize: batchSize,
+	}, nil
+}
+
+// GetTradeHistory impl, returns the trades of the pair after maybeCursorStart (exclusive) up to maybeCursorEnd (inclusive). A nil or empty
+// maybeCursorStart starts from the first trade and a nil maybeCursorEnd continues until the last trade. Trades without a pair are
+// treated as trades of the requested pair
+func (f *ReplayTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	start := 0
+	if maybeCursorStart != nil && fmt.Sprintf("%v", maybeCursorStart) != "" {
+		i, e := f.indexOf(maybeCursorStart)
+		if e != nil {
+			return nil, fmt.Errorf("invalid start cursor: %s", e)
+		}
+		start = i + 1
+	}
+	end := len(f.trades) - 1
+	if maybeCursorEnd != nil {
+		i, e := f.indexOf(maybeCursorEnd)
+		if e != nil {
+			return nil, fmt.Errorf("invalid end cursor: %s", e)
+		}
+		end = i
+	}
+
+	trades := []model.Trade{}
+	cursor := maybeCursorStart
+	for i := start; i <= end && len(trades) < f.batchSize; i++ {
+		t := f.trades[i]
+		// advance the cursor past trades of other pairs so they are not scanned again
+		cursor = t.TransactionID.String()
+		if t.Pair != nil && *t.Pair != pair {
+			continue
+		}
+		trades = append(trades, t)
+	}
+
+	return &api.TradeHistoryResult{
+		Cursor: cursor,
+		Trades: trades,
+	}, nil
+}
+
+// indexOf returns the index of the trade with the TransactionID of the cursor
+func (f *ReplayTradeFetcher) indexOf(cursor interface{}) (int, error) {
+	id := fmt.Sprintf("%v", cursor)
+	i, ok := f.indexByID[id]
+	if !ok {
+		return 0, fmt.Errorf("no trade with TransactionID '%s'", id)
+	}
+	return i, nil
+}
@@ -0,0 +1,174 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func makeReplayTrade(id string, pair *model.TradingPair, action model.OrderAction, price float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(10.0, 7),
+		},
+		TransactionID: model.MakeTransactionID(id),
+	}
+}
+
+func TestMakeReplayTradeFetcherInvalid(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.BTC)
+	noID := makeReplayTrade("1", pair, model.OrderActionSell, 0.066)
+	noID.TransactionID = nil
+
+	testCases := []struct {
+		name      string
+		trades    []model.Trade
+		batchSize int
+	}{
+		{
+			name:      "batch size",
+			trades:    []model.Trade{},
+			batchSize: 0,
+		}, {
+			name:      "missing TransactionID",
+			trades:    []model.Trade{noID},
+			batchSize: 1,
+		}, {
+			name: "duplicate TransactionID",
+			trades: []model.Trade{
+				makeReplayTrade("1", pair, model.OrderActionSell, 0.066),
+				makeReplayTrade("1", pair, model.OrderActionSell, 0.067),
+			},
+			batchSize: 1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := MakeReplayTradeFetcher(k.trades, k.batchSize)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestReplayTradeFetcher(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.BTC)
+	otherPair := model.MakeTradingPair(model.XLM, model.USD)
+	trades := []model.Trade{
+		makeReplayTrade("1", pair, model.OrderActionSell, 0.066),
+		makeReplayTrade("2", otherPair, model.OrderActionSell, 0.10),
+		makeReplayTrade("3", pair, model.OrderActionBuy, 0.065),
+		makeReplayTrade("4", nil, model.OrderActionSell, 0.067),
+		makeReplayTrade("5", pair, model.OrderActionSell, 0.068),
+	}
+
+	testCases := []struct {
+		cursorStart interface{}
+		cursorEnd   interface{}
+		wantIDs     []string
+		wantCursor  interface{}
+	}{
+		{
+			cursorStart: nil,
+			wantIDs:     []string{"1", "3"},
+			wantCursor:  "3",
+		}, {
+			cursorStart: "",
+			wantIDs:     []string{"1", "3"},
+			wantCursor:  "3",
+		}, {
+			cursorStart: "3",
+			wantIDs:     []string{"4", "5"},
+			wantCursor:  "5",
+		}, {
+			cursorStart: model.MakeTransactionID("4"),
+			wantIDs:     []string{"5"},
+			wantCursor:  "5",
+		}, {
+			cursorStart: "1",
+			cursorEnd:   "3",
+			wantIDs:     []string{"3"},
+			wantCursor:  "3",
+		}, {
+			// end of data
+			cursorStart: "5",
+			wantIDs:     []string{},
+			wantCursor:  "5",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%v/%v", k.cursorStart, k.cursorEnd), func(t *testing.T) {
+			f, e := MakeReplayTradeFetcher(trades, 2)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			result, e := f.GetTradeHistory(*pair, k.cursorStart, k.cursorEnd)
+			if !assert.NoError(t, e) {
+				return
+			}
+			ids := []string{}
+			for _, trade := range result.Trades {
+				ids = append(ids, trade.TransactionID.String())
+			}
+			assert.Equal(t, k.wantIDs, ids)
+			assert.Equal(t, k.wantCursor, result.Cursor)
+		})
+	}
+
+	f, e := MakeReplayTradeFetcher(trades, 2)
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = f.GetTradeHistory(*pair, "unknown", nil)
+	assert.Error(t, e)
+}
+
+func TestGetLevelsWithReplayTradeFetcher(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.BTC)
+	s, e := makePendulumState("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	noTrades, e := MakeReplayTradeFetcher([]model.Trade{}, 10)
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := makePendulumLevelProvider(0.01, 0.005, false, 10.0, 1.0, 1.0, 2, 100, 0.066, 1.0, 0.0, 0.0, 0.0, 0.0, noTrades, 10, 0, 0, 0, nil, pair, s, "", false, model.MakeOrderConstraints(7, 7, 0.1), false)
+
+	// the first level is at 0.066 * 1.005 * 1.0025
+	levels, e := p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.InDelta(t, 0.0664958, levels[0].Price.AsFloat(), 1e-7)
+
+	// the first level is filled so the pendulum swings up by one level
+	p.tradeFetcher, e = MakeReplayTradeFetcher([]model.Trade{
+		makeReplayTrade("1", pair, model.OrderActionSell, levels[0].Price.AsFloat()),
+	}, 10)
+	if !assert.NoError(t, e) {
+		return
+	}
+	levels, e = p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.Equal(t, "1", p.lastTradeCursor)
+	assert.InDelta(t, 0.06633, p.lastTradePrice, 1e-9)
+	assert.InDelta(t, 0.0668283, levels[0].Price.AsFloat(), 1e-7)
+}
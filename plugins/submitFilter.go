@@ -17,6 +17,12 @@ type SubmitFilter interface {
 		sellingOffers []hProtocol.Offer, // quoted quote/base
 		buyingOffers []hProtocol.Offer, // quoted base/quote
 	) ([]txnbuild.Operation, error)
+
+	// Name returns the name of the filter which is used in the logs
+	Name() string
+
+	// Describe returns a human readable description of what the filter does with its configured values
+	Describe() string
 }
 
 // filterFn returns a non-nil op to indicate the op that we want to append to the update. the newOp can do one of the following:
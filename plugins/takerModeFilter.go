@@ -0,0 +1,124 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+type takerModeFilter struct {
+	name         string
+	tradingPair  *model.TradingPair
+	exchangeShim api.ExchangeShim
+	sdex         *SDEX
+}
+
+// MakeFilterTakerMode makes a submit filter that only keeps operations that cross the orderbook
+func MakeFilterTakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+	return &takerModeFilter{
+		name:         "takerModeFilter",
+		tradingPair:  tradingPair,
+		exchangeShim: exchangeShim,
+		sdex:         sdex,
+	}
+}
+
+var _ SubmitFilter = &takerModeFilter{}
+
+// Name impl.
+func (f *takerModeFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *takerModeFilter) Describe() string {
+	return "drops operations that would not cross the orderbook so that only taker orders are placed (submit mode taker_only)"
+}
+
+func (f *takerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	// fetch the orderbook once and reuse it for all the operations filtered in this call
+	ob, e := f.exchangeShim.GetOrderBook(f.tradingPair, submitFilterOrderbookDepth)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+
+	baseAsset, quoteAsset, e := f.sdex.Assets()
+	if e != nil {
+		return nil, fmt.Errorf("could not get assets: %s", e)
+	}
+
+	oc := f.exchangeShim.GetOrderConstraints(f.tradingPair)
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		topBidPrice, e := topOrderPriceExcludingTrader(oc, ob.Bids(), buyingOffers, false)
+		if e != nil {
+			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for bids: %s", e)
+		}
+		topAskPrice, e := topOrderPriceExcludingTrader(oc, ob.Asks(), sellingOffers, true)
+		if e != nil {
+			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for asks: %s", e)
+		}
+
+		return f.transformOfferTakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, op)
+	}
+	ops, e = filterOps(f.name, baseAsset, quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func (f *takerModeFilter) transformOfferTakerMode(
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	topBidPrice *model.Number,
+	topAskPrice *model.Number,
+	op *txnbuild.ManageSellOffer,
+) (*txnbuild.ManageSellOffer, error) {
+	// delete operations should never be dropped
+	if op.Amount == "0" {
+		return op, nil
+	}
+
+	isSell, e := utils.IsSelling(baseAsset, quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	sellPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+
+	var keep bool
+	if !isSell && topAskPrice != nil {
+		// invert price when buying
+		keep = 1/sellPrice >= topAskPrice.AsFloat()
+		log.Printf("takerModeFilter:  buying, keep = (op price) %.7f >= %.7f (topAskPrice): keep = %v", 1/sellPrice, topAskPrice.AsFloat(), keep)
+	} else if isSell && topBidPrice != nil {
+		keep = sellPrice <= topBidPrice.AsFloat()
+		log.Printf("takerModeFilter: selling, keep = (op price) %.7f <= %.7f (topBidPrice): keep = %v", sellPrice, topBidPrice.AsFloat(), keep)
+	} else {
+		price := sellPrice
+		action := "selling"
+		if !isSell {
+			price = 1 / price
+			action = " buying"
+		}
+		// there is nothing to take when there is no market on the other side
+		keep = false
+		log.Printf("takerModeFilter: %s, no market (op price = %.7f): keep = %v", action, price, keep)
+	}
+
+	if keep {
+		return op, nil
+	}
+
+	// we don't want to keep it so return the dropped command
+	return nil, nil
+}
@@ -0,0 +1,143 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestTransformOfferTakerMode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		topBidPrice *model.Number
+		topAskPrice *model.Number
+		op          *txnbuild.ManageSellOffer
+		wantKeep    bool
+	}{
+		// empty book
+		{
+			name:        "sell with empty book",
+			topBidPrice: nil,
+			topAskPrice: nil,
+			op:          makeSellOpAmtPrice(10.0, 1.0),
+			wantKeep:    false,
+		}, {
+			name:        "buy with empty book",
+			topBidPrice: nil,
+			topAskPrice: nil,
+			op:          makeBuyOpAmtPrice(10.0, 1.0),
+			wantKeep:    false,
+		},
+		// crossing
+		{
+			name:        "sell below top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 0.9),
+			wantKeep:    true,
+		}, {
+			name:        "sell at top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 1.0),
+			wantKeep:    true,
+		}, {
+			name:        "buy above top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 2.0),
+			wantKeep:    true,
+		}, {
+			name:        "buy at top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 1.25),
+			wantKeep:    true,
+		},
+		// non-crossing
+		{
+			name:        "sell above top bid",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 1.1),
+			wantKeep:    false,
+		}, {
+			name:        "sell with no bids",
+			topBidPrice: nil,
+			topAskPrice: model.NumberFromFloat(1.2, 7),
+			op:          makeSellOpAmtPrice(10.0, 0.9),
+			wantKeep:    false,
+		}, {
+			name:        "buy below top ask",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: model.NumberFromFloat(1.25, 7),
+			op:          makeBuyOpAmtPrice(10.0, 1.0),
+			wantKeep:    false,
+		}, {
+			name:        "buy with no asks",
+			topBidPrice: model.NumberFromFloat(1.0, 7),
+			topAskPrice: nil,
+			op:          makeBuyOpAmtPrice(10.0, 2.0),
+			wantKeep:    false,
+		},
+		// delete ops
+		{
+			name:        "delete op is always kept",
+			topBidPrice: nil,
+			topAskPrice: nil,
+			op:          &txnbuild.ManageSellOffer{Buying: testQuoteAsset, Selling: testBaseAsset, Amount: "0", Price: "0.5", OfferID: 1},
+			wantKeep:    true,
+		},
+	}
+
+	f := &takerModeFilter{}
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			baseAsset := utils.Asset2Asset2(testBaseAsset)
+			quoteAsset := utils.Asset2Asset2(testQuoteAsset)
+
+			actual, e := f.transformOfferTakerMode(baseAsset, quoteAsset, k.topBidPrice, k.topAskPrice, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			if k.wantKeep {
+				assert.Equal(t, k.op, actual)
+			} else {
+				assert.Nil(t, actual)
+			}
+		})
+	}
+}
+
+func TestTopOrderPriceExcludingTraderEmptyBook(t *testing.T) {
+	oc := model.MakeOrderConstraints(7, 7, 0.1)
+	price, e := topOrderPriceExcludingTrader(oc, []model.Order{}, []hProtocol.Offer{}, true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Nil(t, price)
+}
+
+func TestTakerModeFilterFetchesOrderBookOncePerApply(t *testing.T) {
+	pair, sdex, shim := makeTestFilterSdexAndShim()
+	f := MakeFilterTakerMode(shim, sdex, pair)
+
+	ops := []txnbuild.Operation{
+		makeSellOpAmtPrice(10.0, 1.1),
+		makeSellOpAmtPrice(10.0, 0.9),
+		makeSellOpAmtPrice(10.0, 1.0),
+	}
+	filteredOps, e := f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.Equal(t, []int32{submitFilterOrderbookDepth}, shim.getOrderBookNs)
+	assert.Equal(t, []txnbuild.Operation{ops[1], ops[2]}, filteredOps)
+}
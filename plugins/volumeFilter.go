@@ -4,10 +4,12 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/model"
@@ -16,12 +18,22 @@ import (
 	"github.com/stellar/kelp/support/utils"
 )
 
+// volumeFilterDBPingTimeout is how long makeFilterVolume waits for the db to respond before failing
+const volumeFilterDBPingTimeout = 5 * time.Second
+
+// pingVolumeFilterDB verifies the db used by the volume filter queries is reachable, it is a variable so tests can stub it
+var pingVolumeFilterDB = func(db *sql.DB) error {
+	return postgresdb.Ping(db, volumeFilterDBPingTimeout)
+}
+
 type volumeFilterMode string
 
 // type of volumeFilterMode
 const (
 	volumeFilterModeExact  volumeFilterMode = "exact"
 	volumeFilterModeIgnore volumeFilterMode = "ignore"
+	// volumeFilterModeSoft progressively scales down the offered amount as the traded volume approaches the cap, see softCapScale
+	volumeFilterModeSoft volumeFilterMode = "soft"
 )
 
 // String is the Stringer method
@@ -34,24 +46,94 @@ func parseVolumeFilterMode(mode string) (volumeFilterMode, error) {
 		return volumeFilterModeExact, nil
 	} else if mode == string(volumeFilterModeIgnore) {
 		return volumeFilterModeIgnore, nil
+	} else if mode == string(volumeFilterModeSoft) {
+		return volumeFilterModeSoft, nil
 	}
 	return volumeFilterModeExact, fmt.Errorf("invalid input mode '%s'", mode)
 }
 
+type softCapCurve string
+
+// type of softCapCurve
+const (
+	softCapCurveLinear    softCapCurve = "linear"
+	softCapCurveQuadratic softCapCurve = "quadratic"
+)
+
+// defaults for the parameters of volumeFilterModeSoft
+const (
+	defaultSoftCapStart              = 0.8
+	defaultSoftCapCurve softCapCurve = softCapCurveLinear
+)
+
+// String is the Stringer method
+func (c softCapCurve) String() string {
+	return string(c)
+}
+
+func parseSoftCapCurve(curve string) (softCapCurve, error) {
+	if curve == string(softCapCurveLinear) {
+		return softCapCurveLinear, nil
+	} else if curve == string(softCapCurveQuadratic) {
+		return softCapCurveQuadratic, nil
+	}
+	return defaultSoftCapCurve, fmt.Errorf("invalid soft cap curve '%s'", curve)
+}
+
+// apply maps the fraction of the soft cap region that is still available (1.0 at the start of the region, 0.0 at the cap) to the fraction of
+// the offer amount that should be kept, the quadratic curve scales down more aggressively than the linear curve
+func (c softCapCurve) apply(remainingFraction float64) float64 {
+	if c == softCapCurveQuadratic {
+		return remainingFraction * remainingFraction
+	}
+	return remainingFraction
+}
+
+// softCapScale returns the fraction of the offer amount to keep given the volume used so far: offers are not scaled until the used volume
+// reaches start * cap, after which they are scaled down along the curve until nothing is offered at the cap
+func softCapScale(used float64, cap float64, start float64, curve softCapCurve) float64 {
+	softStart := start * cap
+	if used <= softStart {
+		return 1.0
+	}
+	if used >= cap {
+		return 0.0
+	}
+	return curve.apply((cap - used) / (cap - softStart))
+}
+
 // VolumeFilterConfig ensures that any one constraint that is hit will result in deleting all offers and pausing until limits are no longer constrained
 type VolumeFilterConfig struct {
 	BaseAssetCapInBaseUnits  *float64
 	BaseAssetCapInQuoteUnits *float64
 	action                   queries.DailyVolumeAction
 	mode                     volumeFilterMode
+	softCapStart             float64      // only used with volumeFilterModeSoft, fraction of the cap at which offers start to be scaled down
+	softCapCurve             softCapCurve // only used with volumeFilterModeSoft
+	window                   queries.VolumeWindow
 	additionalMarketIDs      []string // can be nil
 	optionalAccountIDs       []string // can be nil
+	excludedAccountIDs       []string // can be nil, volume from these accounts is never counted even if they are in optionalAccountIDs
 }
 
 type limitParameters struct {
 	baseAssetCapInBaseUnits  *float64
 	baseAssetCapInQuoteUnits *float64
 	mode                     volumeFilterMode
+	softCapStart             float64
+	softCapCurve             softCapCurve
+}
+
+// volumeFilterLimit is a single cap enforced by volumeFilterFn along with the on-the-books values for the window of that cap
+type volumeFilterLimit struct {
+	dailyOTB *VolumeFilterConfig
+	lp       limitParameters
+}
+
+// volumeFilterTier is one of the caps of a volumeFilter along with the query that fetches the volume for the window of the cap
+type volumeFilterTier struct {
+	config                 *VolumeFilterConfig
+	dailyVolumeByDateQuery *queries.DailyVolumeByDate
 }
 
 type volumeFilter struct {
@@ -61,9 +143,17 @@ type volumeFilter struct {
 	quoteAsset             hProtocol.Asset
 	config                 *VolumeFilterConfig
 	dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	additionalTiers        []volumeFilterTier // can be nil, these caps are enforced in addition to config
+	marketID               string
+	metrics                *VolumeFilterMetrics // can be nil
+
+	// uninitialized
+	lastTbbWindowKey string               // window of lastTbb, empty until the first call to Apply
+	lastTbb          *queries.DailyVolume // to-be-booked values computed by the latest call to Apply
 }
 
-// makeFilterVolume makes a submit filter that limits orders placed based on the daily volume traded
+// makeFilterVolume makes a submit filter that limits orders placed based on the volume traded in the configured windows. When more than one
+// config is passed in (tiered caps, eg. an hourly and a daily cap) an operation needs to pass every config, the first config is the primary one
 func makeFilterVolume(
 	configValue string,
 	exchangeName string,
@@ -72,43 +162,91 @@ func makeFilterVolume(
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
 	db *sql.DB,
-	config *VolumeFilterConfig,
+	configs []*VolumeFilterConfig,
+	metrics *VolumeFilterMetrics, // can be nil
 ) (SubmitFilter, error) {
-	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
-	baseAssetString, e := assetDisplayFn(tradingPair.Base)
-	if e != nil {
-		return nil, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("invalid config: need at least one config")
 	}
-	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
-	if e != nil {
-		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	for i, config := range configs {
+		e := config.Validate()
+		if e != nil {
+			return nil, fmt.Errorf("invalid config at index %d: %s", i, e)
+		}
+		// the to-be-booked values are shared by all the tiers so they need to constrain the same operations
+		if config.action != configs[0].action {
+			return nil, fmt.Errorf("invalid config at index %d: action (%s) needs to match the action of the first config (%s)", i, config.action, configs[0].action)
+		}
 	}
 
-	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
-	// note that append(s, nil) is valid
-	marketIDs := utils.Dedupe(append([]string{marketID}, config.additionalMarketIDs...))
-	dailyVolumeByDateQuery, e := queries.MakeDailyVolumeByDateForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
+	// use assetDisplayFn to make the marketID because it is issuer independent for non-sdex exchanges keeping a consistent marketID
+	market, e := MakeMarketIDFromTradingPair(exchangeName, tradingPair, assetDisplayFn)
 	if e != nil {
-		return nil, fmt.Errorf("could not make daily volume by date Query: %s", e)
+		return nil, fmt.Errorf("could not make market ID: %s", e)
+	}
+	marketID := market.Hash()
+
+	// tiers that select the same volume share a query so it only runs once per call to Apply
+	queriesByKey := map[string]*queries.DailyVolumeByDate{}
+	tiers := []volumeFilterTier{}
+	for _, config := range configs {
+		// note that append(s, nil) is valid
+		marketIDs := utils.Dedupe(append([]string{marketID}, config.additionalMarketIDs...))
+		queryKey := fmt.Sprintf("%s|%v|%v|%v", config.window, marketIDs, config.optionalAccountIDs, config.excludedAccountIDs)
+		query, ok := queriesByKey[queryKey]
+		if !ok {
+			query, e = queries.MakeVolumeByWindowForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs, config.excludedAccountIDs, config.window)
+			if e != nil {
+				return nil, fmt.Errorf("could not make %s volume by date Query: %s", config.window, e)
+			}
+			queriesByKey[queryKey] = query
+		}
+		tiers = append(tiers, volumeFilterTier{
+			config:                 config,
+			dailyVolumeByDateQuery: query,
+		})
 	}
 
-	e = config.Validate()
+	// fail fast when the db is unreachable instead of failing the first time the queries run inside an update cycle
+	e = pingVolumeFilterDB(db)
 	if e != nil {
-		return nil, fmt.Errorf("invalid config: %s", e)
+		utils.PrintErrorHintf("check that the POSTGRES_DB config in the trader.cfg file points to a running postgres instance")
+		return nil, fmt.Errorf("could not reach the db used by the volume filter: %s", e)
 	}
 
+	var additionalTiers []volumeFilterTier
+	if len(tiers) > 1 {
+		additionalTiers = tiers[1:]
+	}
 	return &volumeFilter{
 		name:                   "volumeFilter",
 		configValue:            configValue,
 		baseAsset:              baseAsset,
 		quoteAsset:             quoteAsset,
-		config:                 config,
-		dailyVolumeByDateQuery: dailyVolumeByDateQuery,
+		config:                 tiers[0].config,
+		dailyVolumeByDateQuery: tiers[0].dailyVolumeByDateQuery,
+		additionalTiers:        additionalTiers,
+		marketID:               marketID,
+		metrics:                metrics,
 	}, nil
 }
 
 var _ SubmitFilter = &volumeFilter{}
 
+// Name impl.
+func (f *volumeFilter) Name() string {
+	return f.name
+}
+
+// Describe impl.
+func (f *volumeFilter) Describe() string {
+	configs := []string{}
+	for _, tier := range f.tiers() {
+		configs = append(configs, tier.config.String())
+	}
+	return fmt.Sprintf("limits operations to the volume caps of the config '%s': %s", f.configValue, strings.Join(configs, ", "))
+}
+
 // Validate ensures validity
 func (c *VolumeFilterConfig) Validate() error {
 	if c.BaseAssetCapInBaseUnits != nil && c.BaseAssetCapInQuoteUnits != nil {
@@ -119,58 +257,230 @@ func (c *VolumeFilterConfig) Validate() error {
 		return fmt.Errorf("invalid asset caps: only one asset cap can be non-nil, but both are nil")
 	}
 
+	if c.BaseAssetCapInBaseUnits != nil && *c.BaseAssetCapInBaseUnits < 0 {
+		return fmt.Errorf("invalid asset caps: BaseAssetCapInBaseUnits cannot be negative but was %f", *c.BaseAssetCapInBaseUnits)
+	}
+
+	if c.BaseAssetCapInQuoteUnits != nil && *c.BaseAssetCapInQuoteUnits < 0 {
+		return fmt.Errorf("invalid asset caps: BaseAssetCapInQuoteUnits cannot be negative but was %f", *c.BaseAssetCapInQuoteUnits)
+	}
+
 	if _, e := parseVolumeFilterMode(string(c.mode)); e != nil {
 		return fmt.Errorf("could not parse mode: %s", e)
 	}
 
+	if c.mode == volumeFilterModeSoft {
+		if c.softCapStart < 0 || c.softCapStart >= 1 {
+			return fmt.Errorf("invalid soft cap start: needs to be in the range [0.0, 1.0) but was %f", c.softCapStart)
+		}
+
+		if _, e := parseSoftCapCurve(string(c.softCapCurve)); e != nil {
+			return fmt.Errorf("could not parse soft cap curve: %s", e)
+		}
+	}
+
 	if _, e := queries.ParseDailyVolumeAction(string(c.action)); e != nil {
 		return fmt.Errorf("could not parse action: %s", e)
 	}
 
+	if _, e := queries.ParseVolumeWindow(string(c.window)); e != nil {
+		return fmt.Errorf("could not parse window: %s", e)
+	}
+
 	return nil
 }
 
 // String is the stringer method
 func (c *VolumeFilterConfig) String() string {
-	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, additionalMarketIDs=%v, optionalAccountIDs=%v]",
-		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), c.mode, c.action, c.additionalMarketIDs, c.optionalAccountIDs)
+	mode := c.mode.String()
+	if c.mode == volumeFilterModeSoft {
+		mode = fmt.Sprintf("%s(start=%.4f, curve=%s)", c.mode, c.softCapStart, c.softCapCurve)
+	}
+	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, window=%s, additionalMarketIDs=%v, optionalAccountIDs=%v, excludedAccountIDs=%v]",
+		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), mode, c.action, c.window, c.additionalMarketIDs, c.optionalAccountIDs, c.excludedAccountIDs)
 }
 
 func (f *volumeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	dateString := time.Now().UTC().Format(postgresdb.DateFormatString)
-	// TODO for flipped marketIDs
-	queryResult, e := f.dailyVolumeByDateQuery.QueryRow(dateString)
+	// the to-be-booked values below are accumulated for a single call to Apply so they always fall in the same window as the query
+	now := time.Now()
+	tiers := f.tiers()
+	// the to-be-booked values are kept for the narrowest window since anything booked in that window also falls in the wider windows
+	windowKey := narrowestVolumeWindow(tiers).Key(now)
+
+	limits, e := f.loadLimits(now, tiers)
 	if e != nil {
-		return nil, fmt.Errorf("could not load dailyValuesByDate for today (%s): %s", dateString, e)
+		return nil, fmt.Errorf("could not load limits: %s", e)
 	}
-	dailyValuesBaseSold, ok := queryResult.(*queries.DailyVolume)
-	if !ok {
-		return nil, fmt.Errorf("incorrect type returned from DailyVolumeByDate query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+	for i, l := range limits {
+		log.Printf("%s dailyValuesByDate for the current window (%s): baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
+			tiers[i].config.window, tiers[i].config.window.QueryArg(now), *l.dailyOTB.BaseAssetCapInBaseUnits, utils.Asset2String(f.baseAsset), *l.dailyOTB.BaseAssetCapInQuoteUnits, utils.Asset2String(f.quoteAsset), tiers[i].config)
 	}
 
-	log.Printf("dailyValuesByDate for today (%s): baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
-		dateString, dailyValuesBaseSold.BaseVol, utils.Asset2String(f.baseAsset), dailyValuesBaseSold.QuoteVol, utils.Asset2String(f.quoteAsset), f.config)
+	// daily to-be-booked starts out as empty and accumulates the values of the operations and the existing offers. Nothing is carried over
+	// from a previous run: the existing offers are recounted here on every call and the volume of any offer that was taken is already
+	// included in the on-the-books values, so the cap is honored across restarts without saving the to-be-booked values
+	ops, dailyTBB, numTrimmed, e := f.applyLimits(ops, sellingOffers, buyingOffers, limits)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+
+	if f.metrics != nil {
+		for i, l := range limits {
+			otb, tbb, cap, e := extractAllCaps(l.dailyOTB, dailyTBB, l.lp)
+			if e != nil {
+				return nil, fmt.Errorf("could not extract caps for metrics: %s", e)
+			}
+			labels := prometheus.Labels{"market_id": f.marketID, "action": f.config.action.String(), "window": tiers[i].config.window.String()}
+			f.metrics.observeCycle(
+				labels,
+				*l.dailyOTB.BaseAssetCapInBaseUnits+*dailyTBB.BaseAssetCapInBaseUnits,
+				*l.dailyOTB.BaseAssetCapInQuoteUnits+*dailyTBB.BaseAssetCapInQuoteUnits,
+				cap-otb-tbb,
+				numTrimmed,
+			)
+		}
+	}
+
+	f.lastTbbWindowKey = windowKey
+	f.lastTbb = &queries.DailyVolume{BaseVol: *dailyTBB.BaseAssetCapInBaseUnits, QuoteVol: *dailyTBB.BaseAssetCapInQuoteUnits}
+	return ops, nil
+}
 
-	// daily on-the-books
-	dailyOTB := makeIntermediateVolumeFilterConfig(&dailyValuesBaseSold.BaseVol, &dailyValuesBaseSold.QuoteVol)
-	// daily to-be-booked starts out as empty and accumulates the values of the operations
+// applyLimits runs the ops and the existing offers through volumeFilterFn, it returns the filtered ops, the to-be-booked values once all
+// the ops and offers are counted, and the number of ops that were trimmed or dropped
+func (f *volumeFilter) applyLimits(
+	ops []txnbuild.Operation,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+	limits []volumeFilterLimit,
+) ([]txnbuild.Operation, *VolumeFilterConfig, int, error) {
 	dailyTbbBase := 0.0
 	dailyTbbSellQuote := 0.0
 	dailyTBB := makeIntermediateVolumeFilterConfig(&dailyTbbBase, &dailyTbbSellQuote)
 
+	numTrimmed := 0
 	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
-		limitParameters := limitParameters{
-			baseAssetCapInBaseUnits:  f.config.BaseAssetCapInBaseUnits,
-			baseAssetCapInQuoteUnits: f.config.BaseAssetCapInQuoteUnits,
-			mode:                     f.config.mode,
+		// volumeFilterFn updates the amount of the op in place so we need to save it before calling it
+		originalAmount := op.Amount
+		newOp, e := volumeFilterFn(f.config.action, limits, dailyTBB, op, f.baseAsset, f.quoteAsset)
+		if e == nil && (newOp == nil || newOp.Amount != originalAmount) {
+			numTrimmed++
 		}
-		return volumeFilterFn(f.config.action, dailyOTB, dailyTBB, op, f.baseAsset, f.quoteAsset, limitParameters)
+		return newOp, e
 	}
-	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	filteredOps, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
 	if e != nil {
-		return nil, fmt.Errorf("could not apply filter: %s", e)
+		return nil, nil, 0, e
 	}
-	return ops, nil
+	return filteredOps, dailyTBB, numTrimmed, nil
+}
+
+// loadLimits fetches the on-the-books values for the current window of each tier and returns the limits in the same order as the tiers
+func (f *volumeFilter) loadLimits(now time.Time, tiers []volumeFilterTier) ([]volumeFilterLimit, error) {
+	limits := []volumeFilterLimit{}
+	// tiers sharing a query also share the window so the result of the query can be reused
+	resultsByQuery := map[*queries.DailyVolumeByDate]*queries.DailyVolume{}
+	for _, tier := range tiers {
+		dailyValuesBaseSold, ok := resultsByQuery[tier.dailyVolumeByDateQuery]
+		if !ok {
+			dateString := tier.config.window.QueryArg(now)
+			// TODO for flipped marketIDs
+			queryResult, e := tier.dailyVolumeByDateQuery.QueryRow(dateString)
+			if e != nil {
+				return nil, fmt.Errorf("could not load %s dailyValuesByDate for the current window (%s): %s", tier.config.window, dateString, e)
+			}
+			dailyValuesBaseSold, ok = queryResult.(*queries.DailyVolume)
+			if !ok {
+				return nil, fmt.Errorf("incorrect type returned from DailyVolumeByDate query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+			}
+			resultsByQuery[tier.dailyVolumeByDateQuery] = dailyValuesBaseSold
+		}
+
+		limits = append(limits, volumeFilterLimit{
+			// daily on-the-books
+			dailyOTB: makeIntermediateVolumeFilterConfig(&dailyValuesBaseSold.BaseVol, &dailyValuesBaseSold.QuoteVol),
+			lp: limitParameters{
+				baseAssetCapInBaseUnits:  tier.config.BaseAssetCapInBaseUnits,
+				baseAssetCapInQuoteUnits: tier.config.BaseAssetCapInQuoteUnits,
+				mode:                     tier.config.mode,
+				softCapStart:             tier.config.softCapStart,
+				softCapCurve:             tier.config.softCapCurve,
+			},
+		})
+	}
+	return limits, nil
+}
+
+// RemainingCapacity returns how much of the base asset can still be traded in the current window before a cap is reached, denominated in
+// units of the base asset and in units of the quote asset. This is the cap minus the volume already traded and the volume booked by the
+// offers placed in the latest call to Apply, taking the minimum across all the caps of the filter. Caps are denominated in either the base
+// or the quote asset so the value for a unit without any caps is +Inf
+func (f *volumeFilter) RemainingCapacity() (float64 /*baseRemaining*/, float64 /*quoteRemaining*/, error) {
+	now := time.Now()
+	tiers := f.tiers()
+	limits, e := f.loadLimits(now, tiers)
+	if e != nil {
+		return 0, 0, fmt.Errorf("could not load limits: %s", e)
+	}
+
+	windowKey := narrowestVolumeWindow(tiers).Key(now)
+	tbb := &queries.DailyVolume{BaseVol: 0.0, QuoteVol: 0.0}
+	if f.lastTbbWindowKey == windowKey {
+		// values from a previous window are discarded because nothing booked by the latest call to Apply is counted there yet
+		tbb = f.lastTbb
+	}
+	dailyTBB := makeIntermediateVolumeFilterConfig(&tbb.BaseVol, &tbb.QuoteVol)
+	return remainingCapacity(limits, dailyTBB)
+}
+
+// remainingCapacity returns the minimum remaining capacity across the limits in units of the base asset and the quote asset, never less than 0
+func remainingCapacity(limits []volumeFilterLimit, dailyTBB *VolumeFilterConfig) (float64 /*baseRemaining*/, float64 /*quoteRemaining*/, error) {
+	baseRemaining := math.Inf(1)
+	quoteRemaining := math.Inf(1)
+	for _, l := range limits {
+		otb, tbb, cap, e := extractAllCaps(l.dailyOTB, dailyTBB, l.lp)
+		if e != nil {
+			return 0, 0, fmt.Errorf("could not extract caps: %s", e)
+		}
+
+		remaining := math.Max(cap-otb-tbb, 0)
+		if l.lp.baseAssetCapInBaseUnits != nil {
+			baseRemaining = math.Min(baseRemaining, remaining)
+		} else {
+			quoteRemaining = math.Min(quoteRemaining, remaining)
+		}
+	}
+	return baseRemaining, quoteRemaining, nil
+}
+
+// tiers returns all the caps of the filter starting with the primary config
+func (f *volumeFilter) tiers() []volumeFilterTier {
+	primary := volumeFilterTier{
+		config:                 f.config,
+		dailyVolumeByDateQuery: f.dailyVolumeByDateQuery,
+	}
+	return append([]volumeFilterTier{primary}, f.additionalTiers...)
+}
+
+// narrowestVolumeWindow returns the shortest window across the tiers, preferring the earlier tier when two windows are equally narrow
+func narrowestVolumeWindow(tiers []volumeFilterTier) queries.VolumeWindow {
+	rank := func(w queries.VolumeWindow) int {
+		if w == queries.VolumeWindowHourly || w == queries.VolumeWindowRolling24h {
+			// both use hourly keys, see VolumeWindow.Key
+			return 0
+		} else if w == queries.VolumeWindowWeekly {
+			return 2
+		}
+		return 1
+	}
+
+	narrowest := tiers[0].config.window
+	for _, tier := range tiers[1:] {
+		if rank(tier.config.window) < rank(narrowest) {
+			narrowest = tier.config.window
+		}
+	}
+	return narrowest
 }
 
 func makeIntermediateVolumeFilterConfig(baseCapBaseUnits *float64, baseCapQuoteUnits *float64) *VolumeFilterConfig {
@@ -180,15 +490,17 @@ func makeIntermediateVolumeFilterConfig(baseCapBaseUnits *float64, baseCapQuoteU
 	}
 }
 
-func volumeFilterFn(action queries.DailyVolumeAction, dailyOTB *VolumeFilterConfig, dailyTBBAccumulator *VolumeFilterConfig, op *txnbuild.ManageSellOffer, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, lp limitParameters) (*txnbuild.ManageSellOffer, error) {
-	isFilterApplicable, e := offerSameTypeAsFilter(action, op, baseAsset, quoteAsset)
+// volumeFilterFn reduces the amount of the op to the minimum amount allowed across all the limits, or drops the op when any one of the
+// limits does not allow it. The to-be-booked values are shared by all the limits because they all constrain the same operations
+func volumeFilterFn(action queries.DailyVolumeAction, limits []volumeFilterLimit, dailyTBBAccumulator *VolumeFilterConfig, op *txnbuild.ManageSellOffer, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) (*txnbuild.ManageSellOffer, error) {
+	opIsSelling, isFilterApplicable, e := offerSameTypeAsFilter(action, op, baseAsset, quoteAsset)
 	if e != nil {
 		return nil, fmt.Errorf("could not compare offer and filter: %s", e)
 	}
 
 	if !isFilterApplicable {
 		// ignore filter so return op directly
-		log.Printf("volumeFilter: isSell=%v, isFilterApplicable=false; keep=true", action.IsSell())
+		log.Printf("volumeFilter: isSell=%v, isFilterApplicable=false; keep=true", opIsSelling)
 		return op, nil
 	}
 
@@ -204,45 +516,71 @@ func volumeFilterFn(action queries.DailyVolumeAction, dailyOTB *VolumeFilterConf
 	// A "buy" op has amount = sellAmount * sellPrice, and price = 1/sellPrice
 	// So, we adjust the offer variables by "undoing" those adjustments
 	// We can then use the same computations as sell orders on buy orders
-	if action.IsBuy() {
+	// the side of the op is used instead of the action since a filter on both sides applies to buy and sell ops
+	if !opIsSelling {
 		offerAmount = offerAmount * offerPrice
 		offerPrice = 1 / offerPrice
 	}
 
-	// capPrice is used when computing amounts to sell or buy
-	// it's the offer price when capping on quote, and 1.0 when capping on base
-	capPrice := offerPrice
-	if lp.baseAssetCapInBaseUnits != nil {
-		capPrice = 1.0
-	}
+	newOfferAmount := offerAmount
+	for _, l := range limits {
+		// capPrice is used when computing amounts to sell or buy
+		// it's the offer price when capping on quote, and 1.0 when capping on base
+		capPrice := offerPrice
+		if l.lp.baseAssetCapInBaseUnits != nil {
+			capPrice = 1.0
+		}
 
-	// extracts from base or quote side, depending on filter
-	otb, tbb, cap, e := extractAllCaps(dailyOTB, dailyTBBAccumulator, lp)
-	if e != nil {
-		return nil, fmt.Errorf("could not extract filter inputs from filter: %s", e)
-	}
+		// extracts from base or quote side, depending on filter
+		otb, tbb, cap, e := extractAllCaps(l.dailyOTB, dailyTBBAccumulator, l.lp)
+		if e != nil {
+			return nil, fmt.Errorf("could not extract filter inputs from filter: %s", e)
+		}
 
-	// if projected is under the cap, update the tbb and return the original op
-	projected := otb + tbb + offerAmount*capPrice
-	if projected <= cap {
-		dailyTBBAccumulator = updateTBB(dailyTBBAccumulator, offerAmount, offerPrice)
-		log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, projected (%.10f) <= cap (%.10f); keep=true", action.IsSell(), offerPrice, projected, cap)
-		return op, nil
-	}
+		// soft limits scale down the amount as the used volume approaches the cap, before also trimming it to the cap like exact limits
+		limitOfferAmount := offerAmount
+		if l.lp.mode == volumeFilterModeSoft {
+			scale := softCapScale(otb+tbb, cap, l.lp.softCapStart, l.lp.softCapCurve)
+			limitOfferAmount = offerAmount * scale
+			if limitOfferAmount <= 0 {
+				log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, lp.mode=%s, scale=%.10f; keep=false", opIsSelling, offerPrice, l.lp.mode.String(), scale)
+				return nil, nil
+			}
+			log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, lp.mode=%s, scale=%.10f, scaledOfferAmount=%.10f", opIsSelling, offerPrice, l.lp.mode.String(), scale, limitOfferAmount)
+		}
 
-	// for ignore type of filters we want to drop the operations when the cap is exceeded
-	if lp.mode == volumeFilterModeIgnore {
-		log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f; lp.mode=%s, keep=false", action.IsSell(), offerPrice, lp.mode.String())
-		return nil, nil
-	}
+		// if projected is under the cap then this limit allows the op
+		projected := otb + tbb + limitOfferAmount*capPrice
+		if projected <= cap {
+			log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, projected (%.10f) <= cap (%.10f); keep=true", opIsSelling, offerPrice, projected, cap)
+			if limitOfferAmount < newOfferAmount {
+				newOfferAmount = limitOfferAmount
+			}
+			continue
+		}
+
+		// for ignore type of filters we want to drop the operations when the cap is exceeded
+		if l.lp.mode == volumeFilterModeIgnore {
+			log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f; lp.mode=%s, keep=false", opIsSelling, offerPrice, l.lp.mode.String())
+			return nil, nil
+		}
 
-	// if exact mode and with remaining capacity, update the op amount and return the op otherwise return nil
-	newOfferAmount := (cap - otb - tbb) / capPrice
-	if newOfferAmount <= 0 {
-		log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, newOfferAmount (%.10f) <= 0; keep=false", action.IsSell(), offerPrice, newOfferAmount)
-		return nil, nil
+		// if exact or soft mode and with remaining capacity, reduce the amount to the remaining capacity otherwise drop the op
+		allowedOfferAmount := (cap - otb - tbb) / capPrice
+		if allowedOfferAmount <= 0 {
+			log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, newOfferAmount (%.10f) <= 0; keep=false", opIsSelling, offerPrice, allowedOfferAmount)
+			return nil, nil
+		}
+		if allowedOfferAmount < newOfferAmount {
+			newOfferAmount = allowedOfferAmount
+		}
 	}
+
 	dailyTBBAccumulator = updateTBB(dailyTBBAccumulator, newOfferAmount, offerPrice)
+	if newOfferAmount == offerAmount {
+		return op, nil
+	}
+
 	// if we have a buy operation, we want to make sure buy ops have the same relationship between price and amount
 	// to do this, we apply the same amount adjustment as `makeBuyOpAmtPrice`
 	// The following conversion is done above on input:
@@ -253,23 +591,29 @@ func volumeFilterFn(action queries.DailyVolumeAction, dailyOTB *VolumeFilterConf
 	// newOpAmount = newOpAmount * sellOfferPrice
 	// newOpAmount => newOpAmount * 1 / buyOfferPrice
 	newOpAmount := newOfferAmount
-	if action.IsBuy() {
+	if !opIsSelling {
 		newOpAmount = newOpAmount * offerPrice
 	}
 	op.Amount = fmt.Sprintf("%.7f", newOpAmount)
 
-	log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, newOpAmount=%s; keep=true", action.IsSell(), offerPrice, op.Amount)
+	log.Printf("volumeFilter: isSell=%v, offerPrice=%.10f, newOpAmount=%s; keep=true", opIsSelling, offerPrice, op.Amount)
 	return op, nil
 }
 
-func offerSameTypeAsFilter(action queries.DailyVolumeAction, op *txnbuild.ManageSellOffer, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) (bool, error) {
+// offerSameTypeAsFilter returns whether the op sells the base asset and whether the filter applies to the op, a filter on both sides
+// applies to every op
+func offerSameTypeAsFilter(action queries.DailyVolumeAction, op *txnbuild.ManageSellOffer, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) (bool /* opIsSelling */, bool /* isSame */, error) {
 	opIsSelling, e := utils.IsSelling(baseAsset, quoteAsset, op.Selling, op.Buying)
 	if e != nil {
-		return false, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+		return false, false, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+	if action.IsBoth() {
+		log.Printf("volumeFilter: opIsSelling (%v), filter.action is %s; isSame = true", opIsSelling, action)
+		return opIsSelling, true, nil
 	}
 	isSame := opIsSelling == action.IsSell()
 	log.Printf("volumeFilter: opIsSelling (%v) == filter.action.IsSell() (%v); isSame = %v", opIsSelling, action.IsSell(), isSame)
-	return isSame, nil
+	return opIsSelling, isSame, nil
 }
 
 // extractAllCaps will extract caps from both filters and the limit parameters
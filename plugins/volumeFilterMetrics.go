@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// VolumeFilterMetrics exposes how close each volume filter is to its cap via a prometheus registry.
+// A nil *VolumeFilterMetrics is valid and does not record anything so filters don't pay for metrics unless a registry is wired in.
+type VolumeFilterMetrics struct {
+	tradedBase   *prometheus.GaugeVec
+	tradedQuote  *prometheus.GaugeVec
+	remainingCap *prometheus.GaugeVec
+	trimmedOps   *prometheus.CounterVec
+}
+
+// volumeFilterMetricsLabels are the labels used to distinguish the volume filters of a bot
+var volumeFilterMetricsLabels = []string{"market_id", "action", "window"}
+
+// MakeVolumeFilterMetrics is a factory method that registers the volume filter metrics with the registerer
+func MakeVolumeFilterMetrics(registerer prometheus.Registerer) (*VolumeFilterMetrics, error) {
+	m := &VolumeFilterMetrics{
+		tradedBase: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kelp",
+			Subsystem: "volume_filter",
+			Name:      "traded_base_units",
+			Help:      "amount of the base asset traded and to be traded in the current window of the volume filter",
+		}, volumeFilterMetricsLabels),
+		tradedQuote: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kelp",
+			Subsystem: "volume_filter",
+			Name:      "traded_quote_units",
+			Help:      "amount of the quote asset traded and to be traded in the current window of the volume filter",
+		}, volumeFilterMetricsLabels),
+		remainingCap: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kelp",
+			Subsystem: "volume_filter",
+			Name:      "remaining_cap_units",
+			Help:      "amount remaining before the cap of the volume filter is hit, in the units of the cap",
+		}, volumeFilterMetricsLabels),
+		trimmedOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kelp",
+			Subsystem: "volume_filter",
+			Name:      "trimmed_operations_total",
+			Help:      "number of operations that were dropped or reduced by the volume filter",
+		}, volumeFilterMetricsLabels),
+	}
+
+	for _, c := range []prometheus.Collector{m.tradedBase, m.tradedQuote, m.remainingCap, m.trimmedOps} {
+		e := registerer.Register(c)
+		if e != nil {
+			return nil, fmt.Errorf("could not register volume filter metric: %s", e)
+		}
+	}
+	return m, nil
+}
+
+// observeCycle records the state of the volume filter at the end of a call to Apply
+func (m *VolumeFilterMetrics) observeCycle(labels prometheus.Labels, tradedBase float64, tradedQuote float64, remainingCap float64, numTrimmed int) {
+	if m == nil {
+		return
+	}
+
+	m.tradedBase.With(labels).Set(tradedBase)
+	m.tradedQuote.With(labels).Set(tradedQuote)
+	m.remainingCap.With(labels).Set(remainingCap)
+	m.trimmedOps.With(labels).Add(float64(numTrimmed))
+}
@@ -0,0 +1,37 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeFilterMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, e := MakeVolumeFilterMetrics(registry)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	labels := prometheus.Labels{"market_id": "abcde12345", "action": "sell", "window": "daily"}
+	m.observeCycle(labels, 100.0, 10.0, 900.0, 2)
+	m.observeCycle(labels, 150.0, 15.0, 850.0, 1)
+
+	assert.Equal(t, 150.0, testutil.ToFloat64(m.tradedBase.With(labels)))
+	assert.Equal(t, 15.0, testutil.ToFloat64(m.tradedQuote.With(labels)))
+	assert.Equal(t, 850.0, testutil.ToFloat64(m.remainingCap.With(labels)))
+	// trimmed operations accumulate across cycles
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.trimmedOps.With(labels)))
+
+	// registering the metrics twice on the same registry fails
+	_, e = MakeVolumeFilterMetrics(registry)
+	assert.Error(t, e)
+}
+
+func TestVolumeFilterMetricsNil(t *testing.T) {
+	var m *VolumeFilterMetrics
+	// does not panic
+	m.observeCycle(prometheus.Labels{"market_id": "abcde12345", "action": "sell", "window": "daily"}, 100.0, 10.0, 900.0, 2)
+}
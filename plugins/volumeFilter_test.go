@@ -3,6 +3,7 @@ package plugins
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"strings"
 	"testing"
 
@@ -19,8 +20,8 @@ import (
 var testBaseAsset = txnbuild.NativeAsset{}
 var testQuoteAsset txnbuild.CreditAsset = txnbuild.CreditAsset{Code: "QUOTE", Issuer: "GBGQAGAMK6W6FH6AGGZ2BI2MY5TA5VJEHU2DQRFXACMAZHNRD3SXEV6Z"}
 
-func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accountIDs []string, action queries.DailyVolumeAction) *volumeFilter {
-	query, e := queries.MakeDailyVolumeByDateForMarketIdsAction(&sql.DB{}, marketIDs, action, accountIDs)
+func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accountIDs []string, excludedAccountIDs []string, action queries.DailyVolumeAction) *volumeFilter {
+	query, e := queries.MakeVolumeByWindowForMarketIdsAction(&sql.DB{}, marketIDs, action, accountIDs, excludedAccountIDs, config.window)
 	if e != nil {
 		panic(e)
 	}
@@ -32,20 +33,34 @@ func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accoun
 		quoteAsset:             utils.NativeAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: query,
+		marketID:               marketIDs[0],
+	}
+}
+
+// stubVolumeFilterDBPing replaces the db ping used by makeFilterVolume since the tests do not connect to a db, call the returned function to restore it
+func stubVolumeFilterDBPing(pingError error) func() {
+	original := pingVolumeFilterDB
+	pingVolumeFilterDB = func(db *sql.DB) error {
+		return pingError
+	}
+	return func() {
+		pingVolumeFilterDB = original
 	}
 }
 
 func TestMakeFilterVolume(t *testing.T) {
+	defer stubVolumeFilterDBPing(nil)()
 	testAssetDisplayFn := model.MakeSdexMappedAssetDisplayFn(map[model.Asset]hProtocol.Asset{model.Asset("XLM"): utils.NativeAsset})
 	configValue := ""
 	tradingPair := &model.TradingPair{Base: "XLM", Quote: "XLM"}
 	testCases := []struct {
-		name          string
-		exchangeName  string
-		marketIDs     []string
-		accountIDs    []string
-		wantMarketIDs []string
-		wantFilter    *volumeFilter
+		name               string
+		exchangeName       string
+		marketIDs          []string
+		accountIDs         []string
+		excludedAccountIDs []string
+		wantMarketIDs      []string
+		wantFilter         *volumeFilter
 	}{
 		{
 			name:          "0 market id or account id",
@@ -96,6 +111,22 @@ func TestMakeFilterVolume(t *testing.T) {
 			accountIDs:    []string{"accountID"},
 			wantMarketIDs: []string{"9db20cdd56", "marketID"},
 		},
+		{
+			name:               "market ids and excluded account ids",
+			exchangeName:       "exchange 1",
+			marketIDs:          []string{"marketID"},
+			accountIDs:         []string{},
+			excludedAccountIDs: []string{"accountID"},
+			wantMarketIDs:      []string{"6d9862b0e2", "marketID"},
+		},
+		{
+			name:               "account ids and excluded account ids",
+			exchangeName:       "exchange 2",
+			marketIDs:          []string{},
+			accountIDs:         []string{"accountID1", "accountID2"},
+			excludedAccountIDs: []string{"accountID2"},
+			wantMarketIDs:      []string{"9db20cdd56"},
+		},
 	}
 
 	caseNo := 1
@@ -110,16 +141,20 @@ func TestMakeFilterVolume(t *testing.T) {
 					nil,
 					action,
 					m,
+					queries.VolumeWindowDaily,
 					k.marketIDs,
 					k.accountIDs,
+					k.excludedAccountIDs,
 				)
 				baseCapInQuoteConfig := makeRawVolumeFilterConfig(
 					nil,
 					pointy.Float64(1.0),
 					action,
 					m,
+					queries.VolumeWindowDaily,
 					k.marketIDs,
 					k.accountIDs,
+					k.excludedAccountIDs,
 				)
 				for _, config := range []*VolumeFilterConfig{baseCapInBaseConfig, baseCapInQuoteConfig} {
 					// configType is used to represent the type of config when printing test name
@@ -128,7 +163,7 @@ func TestMakeFilterVolume(t *testing.T) {
 						configType = "base"
 					}
 
-					wantFilter := makeWantVolumeFilter(config, k.wantMarketIDs, k.accountIDs, action)
+					wantFilter := makeWantVolumeFilter(config, k.wantMarketIDs, k.accountIDs, k.excludedAccountIDs, action)
 					testCaseInstanceName := fmt.Sprintf("%d. %s,%s,%s,%s", caseNo, k.name, configType, m, action.String())
 					caseNo++
 					t.Run(testCaseInstanceName, func(t *testing.T) {
@@ -140,7 +175,8 @@ func TestMakeFilterVolume(t *testing.T) {
 							utils.NativeAsset,
 							utils.NativeAsset,
 							&sql.DB{},
-							config,
+							[]*VolumeFilterConfig{config},
+							nil,
 						)
 
 						if !assert.Nil(t, e) {
@@ -168,7 +204,8 @@ func TestMakeFilterVolume_FailsOnEmptyConfig(t *testing.T) {
 		utils.NativeAsset,
 		utils.NativeAsset,
 		&sql.DB{},
-		configUnderTest,
+		[]*VolumeFilterConfig{configUnderTest},
+		nil,
 	)
 	if !assert.Error(t, e) {
 		return
@@ -177,6 +214,279 @@ func TestMakeFilterVolume_FailsOnEmptyConfig(t *testing.T) {
 	assert.True(t, strings.HasPrefix(e.Error(), "invalid config"), e.Error())
 }
 
+func TestMakeFilterVolume_Tiers(t *testing.T) {
+	defer stubVolumeFilterDBPing(nil)()
+	testAssetDisplayFn := model.MakeSdexMappedAssetDisplayFn(map[model.Asset]hProtocol.Asset{model.Asset("XLM"): utils.NativeAsset})
+	tradingPair := &model.TradingPair{Base: "XLM", Quote: "XLM"}
+	makeConfig := func(cap float64, action queries.DailyVolumeAction, window queries.VolumeWindow) *VolumeFilterConfig {
+		return makeRawVolumeFilterConfig(pointy.Float64(cap), nil, action, volumeFilterModeExact, window, nil, nil, nil)
+	}
+
+	testCases := []struct {
+		name           string
+		configs        []*VolumeFilterConfig
+		wantError      bool
+		wantNumTiers   int
+		wantNumQueries int
+		wantNarrowest  queries.VolumeWindow
+	}{
+		{
+			name:      "no configs",
+			configs:   []*VolumeFilterConfig{},
+			wantError: true,
+		}, {
+			name: "mismatched actions",
+			configs: []*VolumeFilterConfig{
+				makeConfig(150.0, queries.DailyVolumeActionSell, queries.VolumeWindowHourly),
+				makeConfig(3500.0, queries.DailyVolumeActionBuy, queries.VolumeWindowDaily),
+			},
+			wantError: true,
+		}, {
+			name: "hourly and daily",
+			configs: []*VolumeFilterConfig{
+				makeConfig(3500.0, queries.DailyVolumeActionSell, queries.VolumeWindowDaily),
+				makeConfig(150.0, queries.DailyVolumeActionSell, queries.VolumeWindowHourly),
+			},
+			wantNumTiers:   2,
+			wantNumQueries: 2,
+			wantNarrowest:  queries.VolumeWindowHourly,
+		}, {
+			name: "same window shares the query",
+			configs: []*VolumeFilterConfig{
+				makeConfig(3500.0, queries.DailyVolumeActionSell, queries.VolumeWindowDaily),
+				makeConfig(150.0, queries.DailyVolumeActionSell, queries.VolumeWindowWeekly),
+				makeConfig(2000.0, queries.DailyVolumeActionSell, queries.VolumeWindowDaily),
+			},
+			wantNumTiers:   3,
+			wantNumQueries: 2,
+			wantNarrowest:  queries.VolumeWindowDaily,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := makeFilterVolume(
+				"someConfigValue",
+				"someExchangeName",
+				tradingPair,
+				testAssetDisplayFn,
+				utils.NativeAsset,
+				utils.NativeAsset,
+				&sql.DB{},
+				k.configs,
+				nil,
+			)
+			if k.wantError {
+				if assert.Error(t, e) {
+					assert.True(t, strings.HasPrefix(e.Error(), "invalid config"), e.Error())
+				}
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			f := actual.(*volumeFilter)
+			assert.Equal(t, k.configs[0], f.config)
+			tiers := f.tiers()
+			if !assert.Equal(t, k.wantNumTiers, len(tiers)) {
+				return
+			}
+			uniqueQueries := map[*queries.DailyVolumeByDate]bool{}
+			for i, tier := range tiers {
+				assert.Equal(t, k.configs[i], tier.config)
+				uniqueQueries[tier.dailyVolumeByDateQuery] = true
+			}
+			assert.Equal(t, k.wantNumQueries, len(uniqueQueries))
+			assert.Equal(t, k.wantNarrowest, narrowestVolumeWindow(tiers))
+		})
+	}
+}
+
+func TestMakeFilterVolume_FailsWhenDBUnreachable(t *testing.T) {
+	defer stubVolumeFilterDBPing(fmt.Errorf("dial tcp 127.0.0.1:5432: connect: connection refused"))()
+	testAssetDisplayFn := model.MakeSdexMappedAssetDisplayFn(map[model.Asset]hProtocol.Asset{model.Asset("XLM"): utils.NativeAsset})
+	tradingPair := &model.TradingPair{Base: "XLM", Quote: "XLM"}
+
+	config := makeRawVolumeFilterConfig(pointy.Float64(1.0), nil, queries.DailyVolumeActionSell, volumeFilterModeExact, queries.VolumeWindowDaily, nil, nil, nil)
+	_, e := makeFilterVolume(
+		"someConfigValue",
+		"someExchangeName",
+		tradingPair,
+		testAssetDisplayFn,
+		utils.NativeAsset,
+		utils.NativeAsset,
+		&sql.DB{},
+		[]*VolumeFilterConfig{config},
+		nil,
+	)
+	if !assert.Error(t, e) {
+		return
+	}
+
+	assert.True(t, strings.HasPrefix(e.Error(), "could not reach the db used by the volume filter"), e.Error())
+	assert.Contains(t, e.Error(), "connection refused")
+}
+
+func TestVolumeFilterFn_MultipleLimits(t *testing.T) {
+	makeLimit := func(baseCap *float64, quoteCap *float64, mode volumeFilterMode, otbBase float64, otbQuote float64) volumeFilterLimit {
+		return volumeFilterLimit{
+			dailyOTB: makeIntermediateVolumeFilterConfig(pointy.Float64(otbBase), pointy.Float64(otbQuote)),
+			lp: limitParameters{
+				baseAssetCapInBaseUnits:  baseCap,
+				baseAssetCapInQuoteUnits: quoteCap,
+				mode:                     mode,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		action       queries.DailyVolumeAction
+		limits       []volumeFilterLimit
+		inputOp      *txnbuild.ManageSellOffer
+		wantOp       *txnbuild.ManageSellOffer
+		wantTbbBase  float64
+		wantTbbQuote float64
+	}{
+		{
+			name:   "all limits allow the op",
+			action: queries.DailyVolumeActionSell,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeExact, 1.0, 2.0),
+				makeLimit(pointy.Float64(100.0), nil, volumeFilterModeExact, 50.0, 100.0),
+			},
+			inputOp:      makeSellOpAmtPrice(8.0, 2.0),
+			wantOp:       makeSellOpAmtPrice(8.0, 2.0),
+			wantTbbBase:  8.0,
+			wantTbbQuote: 16.0,
+		}, {
+			name:   "reduced to the smaller of the allowed amounts",
+			action: queries.DailyVolumeActionSell,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeExact, 5.0, 10.0),
+				makeLimit(pointy.Float64(100.0), nil, volumeFilterModeExact, 97.0, 194.0),
+			},
+			inputOp:      makeSellOpAmtPrice(8.0, 2.0),
+			wantOp:       makeSellOpAmtPrice(3.0, 2.0),
+			wantTbbBase:  3.0,
+			wantTbbQuote: 6.0,
+		}, {
+			name:   "base and quote caps",
+			action: queries.DailyVolumeActionSell,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeExact, 5.0, 10.0),
+				makeLimit(nil, pointy.Float64(10.0), volumeFilterModeExact, 3.0, 6.0),
+			},
+			inputOp:      makeSellOpAmtPrice(8.0, 2.0),
+			wantOp:       makeSellOpAmtPrice(2.0, 2.0),
+			wantTbbBase:  2.0,
+			wantTbbQuote: 4.0,
+		}, {
+			name:   "buy op reduced to the smaller of the allowed amounts",
+			action: queries.DailyVolumeActionBuy,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(100.0), nil, volumeFilterModeExact, 96.0, 192.0),
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeExact, 5.0, 10.0),
+			},
+			inputOp:      makeBuyOpAmtPrice(8.0, 2.0),
+			wantOp:       makeBuyOpAmtPrice(4.0, 2.0),
+			wantTbbBase:  4.0,
+			wantTbbQuote: 8.0,
+		}, {
+			name:   "dropped when an ignore limit is exceeded",
+			action: queries.DailyVolumeActionSell,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(100.0), nil, volumeFilterModeExact, 0.0, 0.0),
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeIgnore, 5.0, 10.0),
+			},
+			inputOp:      makeSellOpAmtPrice(8.0, 2.0),
+			wantOp:       nil,
+			wantTbbBase:  0.0,
+			wantTbbQuote: 0.0,
+		}, {
+			name:   "dropped when a limit is used up",
+			action: queries.DailyVolumeActionSell,
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(10.0), nil, volumeFilterModeExact, 5.0, 10.0),
+				makeLimit(pointy.Float64(100.0), nil, volumeFilterModeExact, 100.0, 200.0),
+			},
+			inputOp:      makeSellOpAmtPrice(8.0, 2.0),
+			wantOp:       nil,
+			wantTbbBase:  0.0,
+			wantTbbQuote: 0.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			dailyTBBAccumulator := makeIntermediateVolumeFilterConfig(pointy.Float64(0.0), pointy.Float64(0.0))
+			base := utils.Asset2Asset2(testBaseAsset)
+			quote := utils.Asset2Asset2(testQuoteAsset)
+			actual, e := volumeFilterFn(k.action, k.limits, dailyTBBAccumulator, k.inputOp, base, quote)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOp, actual)
+			assert.InDelta(t, k.wantTbbBase, *dailyTBBAccumulator.BaseAssetCapInBaseUnits, 1e-7)
+			assert.InDelta(t, k.wantTbbQuote, *dailyTBBAccumulator.BaseAssetCapInQuoteUnits, 1e-7)
+		})
+	}
+}
+
+func TestVolumeFilterFn_BothSides(t *testing.T) {
+	limits := []volumeFilterLimit{{
+		dailyOTB: makeIntermediateVolumeFilterConfig(pointy.Float64(2.0), pointy.Float64(4.0)),
+		lp: limitParameters{
+			baseAssetCapInBaseUnits:  pointy.Float64(10.0),
+			baseAssetCapInQuoteUnits: nil,
+			mode:                     volumeFilterModeExact,
+		},
+	}}
+	dailyTBBAccumulator := makeIntermediateVolumeFilterConfig(pointy.Float64(0.0), pointy.Float64(0.0))
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+
+	// the ops are applied in order and draw down the same cap, regardless of their side
+	testCases := []struct {
+		name         string
+		inputOp      *txnbuild.ManageSellOffer
+		wantOp       *txnbuild.ManageSellOffer
+		wantTbbBase  float64
+		wantTbbQuote float64
+	}{
+		{
+			name:         "buy op within the cap",
+			inputOp:      makeBuyOpAmtPrice(5.0, 2.0),
+			wantOp:       makeBuyOpAmtPrice(5.0, 2.0),
+			wantTbbBase:  5.0,
+			wantTbbQuote: 10.0,
+		}, {
+			name:         "sell op reduced by the volume of the buy op",
+			inputOp:      makeSellOpAmtPrice(5.0, 2.0),
+			wantOp:       makeSellOpAmtPrice(3.0, 2.0),
+			wantTbbBase:  8.0,
+			wantTbbQuote: 16.0,
+		}, {
+			name:         "buy op dropped after the cap is used up",
+			inputOp:      makeBuyOpAmtPrice(1.0, 2.0),
+			wantOp:       nil,
+			wantTbbBase:  8.0,
+			wantTbbQuote: 16.0,
+		},
+	}
+
+	for _, k := range testCases {
+		actual, e := volumeFilterFn(queries.DailyVolumeActionBoth, limits, dailyTBBAccumulator, k.inputOp, base, quote)
+		if !assert.NoError(t, e, k.name) {
+			return
+		}
+		assert.Equal(t, k.wantOp, actual, k.name)
+		assert.InDelta(t, k.wantTbbBase, *dailyTBBAccumulator.BaseAssetCapInBaseUnits, 1e-7, k.name)
+		assert.InDelta(t, k.wantTbbQuote, *dailyTBBAccumulator.BaseAssetCapInQuoteUnits, 1e-7, k.name)
+	}
+}
+
 // volumeFilterFnTestCase is the input that will be reused across all tests of type TestVolumeFilterFn*
 type volumeFilterFnTestCase struct {
 	name         string
@@ -1239,7 +1549,7 @@ func runTestVolumeFilterFn(
 
 		base := utils.Asset2Asset2(testBaseAsset)
 		quote := utils.Asset2Asset2(testQuoteAsset)
-		actual, e := volumeFilterFn(action, dailyOTB, dailyTBBAccumulator, inputOp, base, quote, lp)
+		actual, e := volumeFilterFn(action, []volumeFilterLimit{{dailyOTB: dailyOTB, lp: lp}}, dailyTBBAccumulator, inputOp, base, quote)
 		if !assert.Nil(t, e) {
 			return
 		}
@@ -1288,6 +1598,7 @@ func TestValidateConfig(t *testing.T) {
 		baseCapQuote *float64
 		mode         volumeFilterMode
 		action       queries.DailyVolumeAction
+		window       queries.VolumeWindow
 		marketIDs    []string
 		accountIDs   []string
 		wantErr      error
@@ -1298,6 +1609,7 @@ func TestValidateConfig(t *testing.T) {
 			baseCapQuote: nil,
 			mode:         volumeFilterModeExact,
 			action:       queries.DailyVolumeActionSell,
+			window:       queries.VolumeWindowDaily,
 			marketIDs:    nil,
 			accountIDs:   nil,
 			wantErr:      nil,
@@ -1308,6 +1620,7 @@ func TestValidateConfig(t *testing.T) {
 			baseCapQuote: pointy.Float64(1.0),
 			mode:         volumeFilterModeExact,
 			action:       queries.DailyVolumeActionBuy,
+			window:       queries.VolumeWindowDaily,
 			marketIDs:    nil,
 			accountIDs:   nil,
 			wantErr:      nil,
@@ -1330,12 +1643,46 @@ func TestValidateConfig(t *testing.T) {
 			accountIDs:   nil,
 			wantErr:      fmt.Errorf("invalid asset caps: only one asset cap can be non-nil, but both are non-nil"),
 		},
+		{
+			name:         "success - zero cap",
+			baseCapBase:  pointy.Float64(0.0),
+			baseCapQuote: nil,
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionSell,
+			window:       queries.VolumeWindowDaily,
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      nil,
+		},
+		{
+			name:         "failure - negative base cap",
+			baseCapBase:  pointy.Float64(-1.0),
+			baseCapQuote: nil,
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionSell,
+			window:       queries.VolumeWindowDaily,
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      fmt.Errorf("invalid asset caps: BaseAssetCapInBaseUnits cannot be negative but was -1.000000"),
+		},
+		{
+			name:         "failure - negative quote cap",
+			baseCapBase:  nil,
+			baseCapQuote: pointy.Float64(-0.5),
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionBuy,
+			window:       queries.VolumeWindowDaily,
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      fmt.Errorf("invalid asset caps: BaseAssetCapInQuoteUnits cannot be negative but was -0.500000"),
+		},
 		{
 			name:         "failure - invalid mode",
 			baseCapBase:  pointy.Float64(1.0),
 			baseCapQuote: nil,
 			mode:         volumeFilterMode("hello"),
 			action:       queries.DailyVolumeActionSell,
+			window:       queries.VolumeWindowDaily,
 			marketIDs:    nil,
 			accountIDs:   nil,
 			wantErr:      fmt.Errorf("could not parse mode: invalid input mode 'hello'"),
@@ -1346,17 +1693,292 @@ func TestValidateConfig(t *testing.T) {
 			baseCapQuote: nil,
 			mode:         volumeFilterModeExact,
 			action:       queries.DailyVolumeAction("hello"),
+			window:       queries.VolumeWindowDaily,
 			marketIDs:    nil,
 			accountIDs:   nil,
 			wantErr:      fmt.Errorf("could not parse action: invalid action value 'hello'"),
 		},
+		{
+			name:         "failure - invalid window",
+			baseCapBase:  pointy.Float64(1.0),
+			baseCapQuote: nil,
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionSell,
+			window:       queries.VolumeWindow("monthly"),
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      fmt.Errorf("could not parse window: invalid window value 'monthly'"),
+		},
 	}
 
 	for _, k := range testCases {
 		t.Run(k.name, func(t *testing.T) {
-			c := makeRawVolumeFilterConfig(k.baseCapBase, k.baseCapQuote, k.action, k.mode, k.marketIDs, k.accountIDs)
+			c := makeRawVolumeFilterConfig(k.baseCapBase, k.baseCapQuote, k.action, k.mode, k.window, k.marketIDs, k.accountIDs, nil)
 			gotErr := c.Validate()
 			assert.Equal(t, k.wantErr, gotErr)
 		})
 	}
 }
+
+func TestSoftCapScale(t *testing.T) {
+	testCases := []struct {
+		used  float64
+		start float64
+		curve softCapCurve
+		want  float64
+	}{
+		{used: 0.0, start: 0.5, curve: softCapCurveLinear, want: 1.0},
+		{used: 5.0, start: 0.5, curve: softCapCurveLinear, want: 1.0},
+		{used: 6.0, start: 0.5, curve: softCapCurveLinear, want: 0.8},
+		{used: 6.0, start: 0.5, curve: softCapCurveQuadratic, want: 0.64},
+		{used: 9.0, start: 0.0, curve: softCapCurveLinear, want: 0.1},
+		{used: 10.0, start: 0.5, curve: softCapCurveLinear, want: 0.0},
+		{used: 12.0, start: 0.5, curve: softCapCurveQuadratic, want: 0.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.1f/%.1f/%s", k.used, k.start, k.curve), func(t *testing.T) {
+			assert.InDelta(t, k.want, softCapScale(k.used, 10.0, k.start, k.curve), 1e-9)
+		})
+	}
+}
+
+func TestVolumeFilterFn_Soft(t *testing.T) {
+	testCases := []struct {
+		name         string
+		start        float64
+		curve        softCapCurve
+		otb          float64
+		inputAmount  float64
+		wantAmount   *float64
+		wantTbbBase  float64
+		wantTbbQuote float64
+	}{
+		{
+			name:         "below the start of the soft cap",
+			start:        0.5,
+			curve:        softCapCurveLinear,
+			otb:          4.0,
+			inputAmount:  1.0,
+			wantAmount:   pointy.Float64(1.0),
+			wantTbbBase:  1.0,
+			wantTbbQuote: 2.0,
+		}, {
+			name:         "scaled linearly",
+			start:        0.5,
+			curve:        softCapCurveLinear,
+			otb:          6.0,
+			inputAmount:  2.0,
+			wantAmount:   pointy.Float64(1.6),
+			wantTbbBase:  1.6,
+			wantTbbQuote: 3.2,
+		}, {
+			name:         "scaled quadratically",
+			start:        0.5,
+			curve:        softCapCurveQuadratic,
+			otb:          6.0,
+			inputAmount:  2.0,
+			wantAmount:   pointy.Float64(1.28),
+			wantTbbBase:  1.28,
+			wantTbbQuote: 2.56,
+		}, {
+			name:         "scaled and trimmed to the cap",
+			start:        0.9,
+			curve:        softCapCurveLinear,
+			otb:          9.5,
+			inputAmount:  4.0,
+			wantAmount:   pointy.Float64(0.5),
+			wantTbbBase:  0.5,
+			wantTbbQuote: 1.0,
+		}, {
+			name:         "dropped at the cap",
+			start:        0.5,
+			curve:        softCapCurveLinear,
+			otb:          10.0,
+			inputAmount:  2.0,
+			wantAmount:   nil,
+			wantTbbBase:  0.0,
+			wantTbbQuote: 0.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			limit := volumeFilterLimit{
+				dailyOTB: makeIntermediateVolumeFilterConfig(pointy.Float64(k.otb), pointy.Float64(k.otb*2.0)),
+				lp: limitParameters{
+					baseAssetCapInBaseUnits: pointy.Float64(10.0),
+					mode:                    volumeFilterModeSoft,
+					softCapStart:            k.start,
+					softCapCurve:            k.curve,
+				},
+			}
+			dailyTBBAccumulator := makeIntermediateVolumeFilterConfig(pointy.Float64(0.0), pointy.Float64(0.0))
+			base := utils.Asset2Asset2(testBaseAsset)
+			quote := utils.Asset2Asset2(testQuoteAsset)
+			actual, e := volumeFilterFn(queries.DailyVolumeActionSell, []volumeFilterLimit{limit}, dailyTBBAccumulator, makeSellOpAmtPrice(k.inputAmount, 2.0), base, quote)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			if k.wantAmount == nil {
+				assert.Nil(t, actual)
+			} else {
+				assert.Equal(t, makeSellOpAmtPrice(*k.wantAmount, 2.0), actual)
+			}
+			assert.InDelta(t, k.wantTbbBase, *dailyTBBAccumulator.BaseAssetCapInBaseUnits, 1e-7)
+			assert.InDelta(t, k.wantTbbQuote, *dailyTBBAccumulator.BaseAssetCapInQuoteUnits, 1e-7)
+		})
+	}
+}
+
+func TestRemainingCapacity(t *testing.T) {
+	makeLimit := func(baseCap *float64, quoteCap *float64, otbBase float64, otbQuote float64) volumeFilterLimit {
+		return volumeFilterLimit{
+			dailyOTB: makeIntermediateVolumeFilterConfig(pointy.Float64(otbBase), pointy.Float64(otbQuote)),
+			lp: limitParameters{
+				baseAssetCapInBaseUnits:  baseCap,
+				baseAssetCapInQuoteUnits: quoteCap,
+				mode:                     volumeFilterModeExact,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		limits    []volumeFilterLimit
+		tbbBase   float64
+		tbbQuote  float64
+		wantBase  float64
+		wantQuote float64
+	}{
+		{
+			name:      "base cap",
+			limits:    []volumeFilterLimit{makeLimit(pointy.Float64(100.0), nil, 40.0, 80.0)},
+			tbbBase:   10.0,
+			tbbQuote:  20.0,
+			wantBase:  50.0,
+			wantQuote: math.Inf(1),
+		}, {
+			name:      "quote cap",
+			limits:    []volumeFilterLimit{makeLimit(nil, pointy.Float64(100.0), 40.0, 80.0)},
+			tbbBase:   5.0,
+			tbbQuote:  10.0,
+			wantBase:  math.Inf(1),
+			wantQuote: 10.0,
+		}, {
+			name: "minimum across tiers",
+			limits: []volumeFilterLimit{
+				makeLimit(pointy.Float64(1000.0), nil, 400.0, 800.0),
+				makeLimit(pointy.Float64(100.0), nil, 40.0, 80.0),
+				makeLimit(nil, pointy.Float64(500.0), 400.0, 800.0),
+			},
+			tbbBase:   0.0,
+			tbbQuote:  0.0,
+			wantBase:  60.0,
+			wantQuote: 0.0,
+		}, {
+			name:      "never negative",
+			limits:    []volumeFilterLimit{makeLimit(pointy.Float64(100.0), nil, 95.0, 190.0)},
+			tbbBase:   10.0,
+			tbbQuote:  20.0,
+			wantBase:  0.0,
+			wantQuote: math.Inf(1),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			dailyTBB := makeIntermediateVolumeFilterConfig(pointy.Float64(k.tbbBase), pointy.Float64(k.tbbQuote))
+			baseRemaining, quoteRemaining, e := remainingCapacity(k.limits, dailyTBB)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBase, baseRemaining)
+			assert.Equal(t, k.wantQuote, quoteRemaining)
+		})
+	}
+}
+
+func TestApplyLimits_AfterRestart(t *testing.T) {
+	// the previous run booked 8 units with an offer, after the restart the to-be-booked values always start from 0
+	openOffer := hProtocol.Offer{
+		ID:      1,
+		Seller:  "GBGQAGAMK6W6FH6AGGZ2BI2MY5TA5VJEHU2DQRFXACMAZHNRD3SXEV6Z",
+		Selling: utils.Asset2Asset2(testBaseAsset),
+		Buying:  utils.Asset2Asset2(testQuoteAsset),
+		Amount:  "8.0000000",
+		Price:   "2.0000000",
+	}
+
+	testCases := []struct {
+		name          string
+		otbBase       float64
+		otbQuote      float64
+		sellingOffers []hProtocol.Offer
+		ops           []txnbuild.Operation
+		wantOps       []txnbuild.Operation
+		wantTbbBase   float64
+		wantTbbQuote  float64
+		wantTrimmed   int
+	}{
+		{
+			name:          "offer still open is counted once and kept",
+			otbBase:       0.0,
+			otbQuote:      0.0,
+			sellingOffers: []hProtocol.Offer{openOffer},
+			ops:           []txnbuild.Operation{},
+			wantOps:       []txnbuild.Operation{},
+			wantTbbBase:   8.0,
+			wantTbbQuote:  16.0,
+			wantTrimmed:   0,
+		}, {
+			name:          "offer filled while stopped is only counted as traded",
+			otbBase:       8.0,
+			otbQuote:      16.0,
+			sellingOffers: []hProtocol.Offer{},
+			ops:           []txnbuild.Operation{makeSellOpAmtPrice(8.0, 2.0)},
+			wantOps:       []txnbuild.Operation{makeSellOpAmtPrice(2.0, 2.0)},
+			wantTbbBase:   2.0,
+			wantTbbQuote:  4.0,
+			wantTrimmed:   1,
+		}, {
+			name:          "offer cancelled while stopped does not use up the cap",
+			otbBase:       0.0,
+			otbQuote:      0.0,
+			sellingOffers: []hProtocol.Offer{},
+			ops:           []txnbuild.Operation{makeSellOpAmtPrice(8.0, 2.0)},
+			wantOps:       []txnbuild.Operation{makeSellOpAmtPrice(8.0, 2.0)},
+			wantTbbBase:   8.0,
+			wantTbbQuote:  16.0,
+			wantTrimmed:   0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &volumeFilter{
+				name:       "volumeFilter",
+				baseAsset:  utils.Asset2Asset2(testBaseAsset),
+				quoteAsset: utils.Asset2Asset2(testQuoteAsset),
+				config:     &VolumeFilterConfig{action: queries.DailyVolumeActionSell},
+			}
+			limits := []volumeFilterLimit{{
+				dailyOTB: makeIntermediateVolumeFilterConfig(pointy.Float64(k.otbBase), pointy.Float64(k.otbQuote)),
+				lp: limitParameters{
+					baseAssetCapInBaseUnits: pointy.Float64(10.0),
+					mode:                    volumeFilterModeExact,
+				},
+			}}
+
+			ops, dailyTBB, numTrimmed, e := f.applyLimits(k.ops, k.sellingOffers, []hProtocol.Offer{}, limits)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOps, ops)
+			assert.InDelta(t, k.wantTbbBase, *dailyTBB.BaseAssetCapInBaseUnits, 1e-7)
+			assert.InDelta(t, k.wantTbbQuote, *dailyTBB.BaseAssetCapInQuoteUnits, 1e-7)
+			assert.Equal(t, k.wantTrimmed, numTrimmed)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+const webhookFillTimeout = 5 * time.Second
+const webhookFillMaxAttempts = 3
+const webhookFillRetryDelay = 500 * time.Millisecond
+
+// webhookFill is the JSON body that is sent to the webhook for each fill
+type webhookFill struct {
+	TransactionID string `json:"txid"`
+	Timestamp     string `json:"timestamp"`
+	Pair          string `json:"pair"`
+	Action        string `json:"action"`
+	Type          string `json:"type"`
+	Price         string `json:"price"`
+	BaseVolume    string `json:"base_volume"`
+	CounterCost   string `json:"counter_cost"`
+	Fee           string `json:"fee"`
+	OrderID       string `json:"order_id"`
+}
+
+// WebhookFillHandler is a FillHandler that POSTs fills as JSON to a URL
+type WebhookFillHandler struct {
+	url         string
+	strict      bool
+	maxAttempts int
+	retryDelay  time.Duration
+	httpClient  *http.Client
+}
+
+var _ api.FillHandler = &WebhookFillHandler{}
+
+// MakeWebhookFillHandler is a factory method, when strict is false any failures are logged instead of being returned as an
+// error so an unreachable endpoint does not affect the bot
+func MakeWebhookFillHandler(url string, strict bool) (*WebhookFillHandler, error) {
+	if url == "" {
+		return nil, fmt.Errorf("the URL for the webhook fill handler cannot be empty")
+	}
+
+	return &WebhookFillHandler{
+		url:         url,
+		strict:      strict,
+		maxAttempts: webhookFillMaxAttempts,
+		retryDelay:  webhookFillRetryDelay,
+		// the timeout makes sure that a slow endpoint cannot stall the fill tracker
+		httpClient: &http.Client{Timeout: webhookFillTimeout},
+	}, nil
+}
+
+// HandleFill impl.
+func (h *WebhookFillHandler) HandleFill(trade model.Trade) error {
+	body, e := json.Marshal(makeWebhookFill(trade))
+	if e != nil {
+		return h.handleError(fmt.Errorf("could not marshal fill: %s", e))
+	}
+
+	for attempt := 1; attempt <= h.maxAttempts; attempt++ {
+		retryable, e := h.post(body)
+		if e == nil {
+			return nil
+		}
+
+		if !retryable || attempt == h.maxAttempts {
+			return h.handleError(fmt.Errorf("could not send fill (txid=%s) to webhook after %d attempt(s): %s", utils.CheckedString(trade.TransactionID), attempt, e))
+		}
+		log.Printf("attempt %d of %d to send fill to webhook failed, retrying in %s: %s\n", attempt, h.maxAttempts, h.retryDelay, e)
+		time.Sleep(h.retryDelay)
+	}
+	return nil
+}
+
+// post returns whether the request can be retried when it returns an error
+func (h *WebhookFillHandler) post(body []byte) (bool, error) {
+	resp, e := h.httpClient.Post(h.url, "application/json", bytes.NewReader(body))
+	if e != nil {
+		// network errors and timeouts are transient
+		return true, fmt.Errorf("error making request: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("webhook responded with a non-2xx status code (%d): %s", resp.StatusCode, string(respBody))
+}
+
+func (h *WebhookFillHandler) handleError(e error) error {
+	if h.strict {
+		return e
+	}
+	log.Printf("error in webhook fill handler, ignoring because strict mode is disabled: %s\n", e)
+	return nil
+}
+
+func makeWebhookFill(trade model.Trade) webhookFill {
+	txid := ""
+	if trade.TransactionID != nil {
+		txid = trade.TransactionID.String()
+	}
+	return webhookFill{
+		TransactionID: txid,
+		Timestamp:     fillTimestampString(trade),
+		Pair:          fillPairString(trade),
+		Action:        trade.OrderAction.String(),
+		Type:          trade.OrderType.String(),
+		Price:         csvNumber(trade.Price),
+		BaseVolume:    csvNumber(trade.Volume),
+		CounterCost:   csvNumber(trade.Cost),
+		Fee:           csvNumber(trade.Fee),
+		OrderID:       trade.OrderID,
+	}
+}
@@ -0,0 +1,93 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookFillHandler(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statuses      []int
+		strict        bool
+		wantAttempts  int
+		errorExpected bool
+	}{
+		{
+			name:          "success",
+			statuses:      []int{http.StatusOK},
+			strict:        true,
+			wantAttempts:  1,
+			errorExpected: false,
+		}, {
+			name:          "retries transient failures",
+			statuses:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			strict:        true,
+			wantAttempts:  2,
+			errorExpected: false,
+		}, {
+			name:          "does not retry client errors",
+			statuses:      []int{http.StatusBadRequest},
+			strict:        true,
+			wantAttempts:  1,
+			errorExpected: true,
+		}, {
+			name:          "gives up after max attempts",
+			statuses:      []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			strict:        true,
+			wantAttempts:  3,
+			errorExpected: true,
+		}, {
+			name:          "non-strict mode ignores errors",
+			statuses:      []int{http.StatusBadRequest},
+			strict:        false,
+			wantAttempts:  1,
+			errorExpected: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			attempts := 0
+			var gotFill webhookFill
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotFill)
+				w.WriteHeader(k.statuses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			h, e := MakeWebhookFillHandler(server.URL, k.strict)
+			if !assert.NoError(t, e) {
+				return
+			}
+			h.retryDelay = 0
+
+			e = h.HandleFill(makeTestCsvTrade("order1"))
+			if k.errorExpected {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+			assert.Equal(t, k.wantAttempts, attempts)
+			assert.Equal(t, webhookFill{
+				TransactionID: "",
+				Timestamp:     "2020-01-01T00:00:00Z",
+				Pair:          "XLM/USD",
+				Action:        "sell",
+				Type:          "limit",
+				Price:         "0.1000",
+				BaseVolume:    "100.00",
+				CounterCost:   "10.00",
+				Fee:           "",
+				OrderID:       "order1",
+			}, gotFill)
+		})
+	}
+}
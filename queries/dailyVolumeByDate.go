@@ -4,24 +4,109 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/postgresdb"
 	"github.com/stellar/kelp/support/utils"
 )
 
-// sqlQueryDailyValuesTemplateAllAccounts queries the trades table to get the values for a given day
-const sqlQueryDailyValuesTemplateAllAccounts = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s) AND DATE(date_utc) = $1 and action = $2 group by DATE(date_utc)"
+// sqlQueryDailyValuesTemplate queries the trades table to get the values for a given day, the second param is the filter on accounts and
+// the third param is the filter on the action
+const sqlQueryDailyValuesTemplate = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s)%s AND DATE(date_utc) = $1%s group by DATE(date_utc)"
 
-// sqlQueryDailyValuesTemplateSpecificAccounts queries the trades table to get the values for a given day filtered by specific accounts
-const sqlQueryDailyValuesTemplateSpecificAccounts = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s) AND account_id IN (%s) AND DATE(date_utc) = $1 and action = $2 group by DATE(date_utc)"
+// sqlQueryWindowValuesTemplate queries the trades table to get the values for the time bucket (hour, week) that contains the given timestamp,
+// the second param is the filter on accounts and the fifth param is the filter on the action
+const sqlQueryWindowValuesTemplate = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s)%s AND date_trunc('%s', date_utc) = date_trunc('%s', $1::timestamp)%s group by date_trunc('%s', date_utc)"
 
-// DailyVolumeAction represents either a sell or a buy
+// sqlQueryRollingValuesTemplate queries the trades table to get the values for trades after the given timestamp, the second param is the
+// filter on accounts and the third param is the filter on the action followed by the grouping
+//
+// unlike the calendar windows this cannot group on a fixed bucket, so every call sums over the raw trades in the trailing window.
+// The trades_mdd index can only narrow this down by market_id so the cost grows with the number of trades in the market.
+const sqlQueryRollingValuesTemplate = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s)%s AND date_utc > $1::timestamp%s"
+
+// sqlActionFilter is the condition on action that is added to the WHERE clause of the queries for a single side
+const sqlActionFilter = " and action = $2"
+
+// rollingWindowDuration is the length of the trailing window used by VolumeWindowRolling24h
+const rollingWindowDuration = 24 * time.Hour
+
+// VolumeWindow is the time bucket over which the volume is summed
+type VolumeWindow string
+
+// type of VolumeWindow
+const (
+	VolumeWindowHourly VolumeWindow = "hourly"
+	VolumeWindowDaily  VolumeWindow = "daily"
+	VolumeWindowWeekly VolumeWindow = "weekly"
+	// VolumeWindowRolling24h is the trailing 24 hours, it does not reset at a calendar boundary
+	VolumeWindowRolling24h VolumeWindow = "rolling24h"
+)
+
+// String is the Stringer method impl
+func (w VolumeWindow) String() string {
+	return string(w)
+}
+
+// ParseVolumeWindow converts a string to a VolumeWindow
+func ParseVolumeWindow(window string) (VolumeWindow, error) {
+	if window == VolumeWindowHourly.String() {
+		return VolumeWindowHourly, nil
+	} else if window == VolumeWindowDaily.String() {
+		return VolumeWindowDaily, nil
+	} else if window == VolumeWindowWeekly.String() {
+		return VolumeWindowWeekly, nil
+	} else if window == VolumeWindowRolling24h.String() {
+		return VolumeWindowRolling24h, nil
+	}
+	return VolumeWindowDaily, fmt.Errorf("invalid window value '%s'", window)
+}
+
+// QueryArg formats the time as the arg to be passed to QueryRow so it selects the window that contains the time
+func (w VolumeWindow) QueryArg(t time.Time) string {
+	if w == VolumeWindowDaily {
+		return t.UTC().Format(postgresdb.DateFormatString)
+	} else if w == VolumeWindowRolling24h {
+		// the rolling query selects trades after the start of the window
+		return t.UTC().Add(-rollingWindowDuration).Format(postgresdb.TimestampFormatString)
+	}
+	return t.UTC().Format(postgresdb.TimestampFormatString)
+}
+
+// Key returns a string that identifies the window that contains the time, this is used to detect state saved for a window that has rolled over
+func (w VolumeWindow) Key(t time.Time) string {
+	t = t.UTC()
+	if w == VolumeWindowHourly || w == VolumeWindowRolling24h {
+		// rolling windows do not have a fixed bucket so we only reuse saved state within the same hour
+		return fmt.Sprintf("%s:%s", w, t.Format("2006/01/02 15"))
+	} else if w == VolumeWindowWeekly {
+		// weeks start on Monday, which matches date_trunc('week', ...) in postgres
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return fmt.Sprintf("%s:%s", w, t.AddDate(0, 0, -daysSinceMonday).Format(postgresdb.DateFormatString))
+	}
+	return fmt.Sprintf("%s:%s", w, t.Format(postgresdb.DateFormatString))
+}
+
+// postgresUnit returns the unit accepted by the date_trunc function in postgres
+func (w VolumeWindow) postgresUnit() string {
+	if w == VolumeWindowHourly {
+		return "hour"
+	} else if w == VolumeWindowWeekly {
+		return "week"
+	}
+	return "day"
+}
+
+// DailyVolumeAction represents either a sell or a buy, or both sides together
 type DailyVolumeAction string
 
 // type of DailyVolumeAction
 const (
 	DailyVolumeActionBuy  DailyVolumeAction = "buy"
 	DailyVolumeActionSell DailyVolumeAction = "sell"
+	// DailyVolumeActionBoth sums the volume of buys and sells together
+	DailyVolumeActionBoth DailyVolumeAction = "both"
 )
 
 // String is the Stringer method impl
@@ -39,21 +124,29 @@ func (a DailyVolumeAction) IsBuy() bool {
 	return a == DailyVolumeActionBuy
 }
 
+// IsBoth returns whether the action includes both buys and sells
+func (a DailyVolumeAction) IsBoth() bool {
+	return a == DailyVolumeActionBoth
+}
+
 // ParseDailyVolumeAction converts a string to a DailyVolumeAction
 func ParseDailyVolumeAction(action string) (DailyVolumeAction, error) {
 	if action == DailyVolumeActionBuy.String() {
 		return DailyVolumeActionBuy, nil
 	} else if action == DailyVolumeActionSell.String() {
 		return DailyVolumeActionSell, nil
+	} else if action == DailyVolumeActionBoth.String() {
+		return DailyVolumeActionBoth, nil
 	}
 	return DailyVolumeActionSell, fmt.Errorf("invalid action value '%s'", action)
 }
 
-// DailyVolumeByDate is a query that fetches the daily volume of sales
+// DailyVolumeByDate is a query that fetches the volume of sales in a window, which is daily unless specified otherwise
 type DailyVolumeByDate struct {
 	db       *sql.DB
 	sqlQuery string
 	action   DailyVolumeAction
+	window   VolumeWindow
 }
 
 var _ api.Query = &DailyVolumeByDate{}
@@ -64,26 +157,54 @@ type DailyVolume struct {
 	QuoteVol float64
 }
 
-// MakeDailyVolumeByDateForMarketIdsAction makes the DailyVolumeByDate query for a set of marketIds and an action
+// MakeDailyVolumeByDateForMarketIdsAction makes the DailyVolumeByDate query for a set of marketIds and an action. The volume is limited to
+// the optionalAccountIDs when specified and never includes the excludedAccountIDs, either of which can be nil
 func MakeDailyVolumeByDateForMarketIdsAction(
 	db *sql.DB,
 	marketIDs []string,
 	action DailyVolumeAction,
 	optionalAccountIDs []string,
+	excludedAccountIDs []string,
+) (*DailyVolumeByDate, error) {
+	return MakeVolumeByWindowForMarketIdsAction(db, marketIDs, action, optionalAccountIDs, excludedAccountIDs, VolumeWindowDaily)
+}
+
+// MakeVolumeByWindowForMarketIdsAction makes the DailyVolumeByDate query for a set of marketIds and an action over the given window,
+// the arg to QueryRow should be formatted using window.QueryArg
+func MakeVolumeByWindowForMarketIdsAction(
+	db *sql.DB,
+	marketIDs []string,
+	action DailyVolumeAction,
+	optionalAccountIDs []string,
+	excludedAccountIDs []string,
+	window VolumeWindow,
 ) (*DailyVolumeByDate, error) {
 	if db == nil {
 		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
 		return nil, fmt.Errorf("the provided db should be non-nil")
 	}
 
-	sqlQuery := makeSQLQueryDailyVolume(marketIDs, optionalAccountIDs)
+	var sqlQuery string
+	if window == VolumeWindowDaily {
+		sqlQuery = makeSQLQueryDailyVolume(marketIDs, optionalAccountIDs, excludedAccountIDs, action)
+	} else if window == VolumeWindowRolling24h {
+		sqlQuery = makeSQLQueryRollingVolume(marketIDs, optionalAccountIDs, excludedAccountIDs, action)
+	} else {
+		sqlQuery = makeSQLQueryWindowVolume(marketIDs, optionalAccountIDs, excludedAccountIDs, window, action)
+	}
 	return &DailyVolumeByDate{
 		db:       db,
 		sqlQuery: sqlQuery,
 		action:   action,
+		window:   window,
 	}, nil
 }
 
+// Window returns the window over which this query sums the volume
+func (q *DailyVolumeByDate) Window() VolumeWindow {
+	return q.window
+}
+
 // Name impl.
 func (q *DailyVolumeByDate) Name() string {
 	return "DailyVolumeByDate"
@@ -92,12 +213,17 @@ func (q *DailyVolumeByDate) Name() string {
 // QueryRow impl.
 func (q *DailyVolumeByDate) QueryRow(args ...interface{}) (interface{}, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("expected 1 arg (dateUTC string), but got args %v", args)
+		return nil, fmt.Errorf("expected 1 arg (dateUTC or timestampUTC string), but got args %v", args)
 	} else if _, ok := args[0].(string); !ok {
 		return nil, fmt.Errorf("input arg needs to be of type 'string', but was of type '%T'", args[0])
 	}
 
-	row := q.db.QueryRow(q.sqlQuery, args[0], q.action.String())
+	queryArgs := []interface{}{args[0]}
+	if !q.action.IsBoth() {
+		// the query for both sides does not filter on the action
+		queryArgs = append(queryArgs, q.action.String())
+	}
+	row := q.db.QueryRow(q.sqlQuery, queryArgs...)
 
 	var baseVol sql.NullFloat64
 	var quoteVol sql.NullFloat64
@@ -125,26 +251,54 @@ func (q *DailyVolumeByDate) QueryRow(args ...interface{}) (interface{}, error) {
 	}, nil
 }
 
-func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string) string {
-	// add filter on marketIDs
-	marketsInClauseParts := []string{}
-	for _, mid := range marketIDs {
-		marketsInValue := fmt.Sprintf("'%s'", mid)
-		marketsInClauseParts = append(marketsInClauseParts, marketsInValue)
+func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string, excludedAccountIDs []string, action DailyVolumeAction) string {
+	marketsInClause := makeInClause(marketIDs)
+	return fmt.Sprintf(sqlQueryDailyValuesTemplate, marketsInClause, makeAccountsFilter(optionalAccountIDs, excludedAccountIDs), makeActionFilter(action))
+}
+
+func makeSQLQueryWindowVolume(marketIDs []string, optionalAccountIDs []string, excludedAccountIDs []string, window VolumeWindow, action DailyVolumeAction) string {
+	marketsInClause := makeInClause(marketIDs)
+	unit := window.postgresUnit()
+	return fmt.Sprintf(sqlQueryWindowValuesTemplate, marketsInClause, makeAccountsFilter(optionalAccountIDs, excludedAccountIDs), unit, unit, makeActionFilter(action), unit)
+}
+
+func makeSQLQueryRollingVolume(marketIDs []string, optionalAccountIDs []string, excludedAccountIDs []string, action DailyVolumeAction) string {
+	marketsInClause := makeInClause(marketIDs)
+	// the rolling window has no bucket to group on. Grouping by the single action returns no rows (instead of a NULL sum) when there are
+	// no trades, and the having clause does the same for both sides which would otherwise be split into one row per action
+	suffix := makeActionFilter(action) + " group by action"
+	if action.IsBoth() {
+		suffix = " having count(*) > 0"
 	}
-	marketsInClause := strings.Join(marketsInClauseParts, ", ")
+	return fmt.Sprintf(sqlQueryRollingValuesTemplate, marketsInClause, makeAccountsFilter(optionalAccountIDs, excludedAccountIDs), suffix)
+}
 
+// makeActionFilter makes the condition on action to be added to the WHERE clause, it is empty for DailyVolumeActionBoth
+func makeActionFilter(action DailyVolumeAction) string {
+	if action.IsBoth() {
+		return ""
+	}
+	return sqlActionFilter
+}
+
+// makeAccountsFilter makes the conditions on account_id to be added to the WHERE clause, it is empty when there are no accounts to filter on
+func makeAccountsFilter(optionalAccountIDs []string, excludedAccountIDs []string) string {
+	filter := ""
 	// len(a), where a is a nil array, is valid and returns 0
-	if len(optionalAccountIDs) == 0 {
-		return fmt.Sprintf(sqlQueryDailyValuesTemplateAllAccounts, marketsInClause)
+	if len(optionalAccountIDs) > 0 {
+		filter += fmt.Sprintf(" AND account_id IN (%s)", makeInClause(optionalAccountIDs))
 	}
+	if len(excludedAccountIDs) > 0 {
+		filter += fmt.Sprintf(" AND account_id NOT IN (%s)", makeInClause(excludedAccountIDs))
+	}
+	return filter
+}
 
-	// include filter on account_id
-	accountsInClauseParts := []string{}
-	for _, aid := range optionalAccountIDs {
-		accountsInValue := fmt.Sprintf("'%s'", aid)
-		accountsInClauseParts = append(accountsInClauseParts, accountsInValue)
+// makeInClause quotes the values and joins them so they can be used in an IN clause
+func makeInClause(values []string) string {
+	inClauseParts := []string{}
+	for _, v := range values {
+		inClauseParts = append(inClauseParts, fmt.Sprintf("'%s'", v))
 	}
-	accountsInClause := strings.Join(accountsInClauseParts, ", ")
-	return fmt.Sprintf(sqlQueryDailyValuesTemplateSpecificAccounts, marketsInClause, accountsInClause)
+	return strings.Join(inClauseParts, ", ")
 }
@@ -41,6 +41,7 @@ func TestDailyVolumeByDate_QueryRow(t *testing.T) {
 	testCases := []struct {
 		action                    DailyVolumeAction
 		queryByOptionalAccountIDs []string
+		queryByExcludedAccountIDs []string
 		wantYesterdayBase         float64
 		wantYesterdayQuote        float64
 		wantTodayBase             float64
@@ -111,6 +112,36 @@ func TestDailyVolumeByDate_QueryRow(t *testing.T) {
 			wantTodayQuote:            0.0,
 			wantTomorrowBase:          0.0,
 			wantTomorrowQuote:         0.0,
+		}, {
+			action:                    DailyVolumeActionSell,
+			queryByOptionalAccountIDs: nil,
+			queryByExcludedAccountIDs: []string{"accountID2"}, // excluding accountID2 should return the same as only including accountID1
+			wantYesterdayBase:         100.0,
+			wantYesterdayQuote:        10.0,
+			wantTodayBase:             107.0,
+			wantTodayQuote:            11.83,
+			wantTomorrowBase:          102.0,
+			wantTomorrowQuote:         12.24,
+		}, {
+			action:                    DailyVolumeActionSell,
+			queryByOptionalAccountIDs: []string{"accountID1", "accountID2"},
+			queryByExcludedAccountIDs: []string{"accountID1"}, // excluded accounts are removed from the included accounts
+			wantYesterdayBase:         0.0,
+			wantYesterdayQuote:        0.0,
+			wantTodayBase:             100.0,
+			wantTodayQuote:            10.0,
+			wantTomorrowBase:          0.0,
+			wantTomorrowQuote:         0.0,
+		}, {
+			action:                    DailyVolumeActionSell,
+			queryByOptionalAccountIDs: []string{"accountID2"},
+			queryByExcludedAccountIDs: []string{"accountID2"}, // exclusion takes precedence when an account is both included and excluded
+			wantYesterdayBase:         0.0,
+			wantYesterdayQuote:        0.0,
+			wantTodayBase:             0.0,
+			wantTodayQuote:            0.0,
+			wantTomorrowBase:          0.0,
+			wantTomorrowQuote:         0.0,
 		},
 		{
 			action:                    DailyVolumeActionBuy,
@@ -182,6 +213,26 @@ func TestDailyVolumeByDate_QueryRow(t *testing.T) {
 			wantTomorrowBase:          0,
 			wantTomorrowQuote:         0,
 		},
+		{
+			action:                    DailyVolumeActionBoth,
+			queryByOptionalAccountIDs: []string{"accountID1"}, // sums the sells of accountID1 with its only buy, which is tomorrow
+			wantYesterdayBase:         100.0,
+			wantYesterdayQuote:        10.0,
+			wantTodayBase:             107.0,
+			wantTodayQuote:            11.83,
+			wantTomorrowBase:          204.0,
+			wantTomorrowQuote:         24.48,
+		},
+		{
+			action:                    DailyVolumeActionBoth,
+			queryByOptionalAccountIDs: []string{"accountID3"}, // accountID3 does not exist
+			wantYesterdayBase:         0,
+			wantYesterdayQuote:        0,
+			wantTodayBase:             0,
+			wantTodayQuote:            0,
+			wantTomorrowBase:          0,
+			wantTomorrowQuote:         0,
+		},
 	}
 
 	// setup db
@@ -324,13 +375,14 @@ func TestDailyVolumeByDate_QueryRow(t *testing.T) {
 	}
 
 	for _, k := range testCases {
-		t.Run(strings.Replace(fmt.Sprintf("%v/%s", k.queryByOptionalAccountIDs, k.action), " ", "_", -1), func(t *testing.T) {
+		t.Run(strings.Replace(fmt.Sprintf("%v/%v/%s", k.queryByOptionalAccountIDs, k.queryByExcludedAccountIDs, k.action), " ", "_", -1), func(t *testing.T) {
 			// make query being tested
 			dailyVolumeByDateQuery, e := MakeDailyVolumeByDateForMarketIdsAction(
 				db,
 				[]string{"market1"},
 				k.action,
 				k.queryByOptionalAccountIDs,
+				k.queryByExcludedAccountIDs,
 			)
 			if !assert.NoError(t, e) {
 				return
@@ -358,3 +410,121 @@ func runQueryAndVerifyValues(t *testing.T, query api.Query, inputDate time.Time,
 	assert.Equal(t, wantBaseVol, dailyVolume.BaseVol)
 	assert.Equal(t, wantQuoteVol, dailyVolume.QuoteVol)
 }
+
+func TestMakeSQLQueryWindowVolume(t *testing.T) {
+	testCases := []struct {
+		window     VolumeWindow
+		action     DailyVolumeAction
+		accountIDs []string
+		want       string
+	}{
+		{
+			window:     VolumeWindowHourly,
+			action:     DailyVolumeActionSell,
+			accountIDs: nil,
+			want:       "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1', 'market2') AND date_trunc('hour', date_utc) = date_trunc('hour', $1::timestamp) and action = $2 group by date_trunc('hour', date_utc)",
+		}, {
+			window:     VolumeWindowWeekly,
+			action:     DailyVolumeActionSell,
+			accountIDs: []string{"accountID1"},
+			want:       "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1', 'market2') AND account_id IN ('accountID1') AND date_trunc('week', date_utc) = date_trunc('week', $1::timestamp) and action = $2 group by date_trunc('week', date_utc)",
+		}, {
+			window:     VolumeWindowHourly,
+			action:     DailyVolumeActionBoth,
+			accountIDs: nil,
+			want:       "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1', 'market2') AND date_trunc('hour', date_utc) = date_trunc('hour', $1::timestamp) group by date_trunc('hour', date_utc)",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.window.String()+"/"+k.action.String(), func(t *testing.T) {
+			actual := makeSQLQueryWindowVolume([]string{"market1", "market2"}, k.accountIDs, nil, k.window, k.action)
+			assert.Equal(t, k.want, actual)
+		})
+	}
+}
+
+func TestMakeSQLQueryDailyVolume(t *testing.T) {
+	testCases := []struct {
+		name               string
+		optionalAccountIDs []string
+		excludedAccountIDs []string
+		wantAccountsFilter string
+	}{
+		{
+			name:               "all accounts",
+			wantAccountsFilter: "",
+		}, {
+			name:               "included accounts",
+			optionalAccountIDs: []string{"accountID1"},
+			wantAccountsFilter: " AND account_id IN ('accountID1')",
+		}, {
+			name:               "excluded accounts",
+			excludedAccountIDs: []string{"accountID2", "accountID3"},
+			wantAccountsFilter: " AND account_id NOT IN ('accountID2', 'accountID3')",
+		}, {
+			name:               "included and excluded accounts",
+			optionalAccountIDs: []string{"accountID1", "accountID2"},
+			excludedAccountIDs: []string{"accountID2"},
+			wantAccountsFilter: " AND account_id IN ('accountID1', 'accountID2') AND account_id NOT IN ('accountID2')",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual := makeSQLQueryDailyVolume([]string{"market1"}, k.optionalAccountIDs, k.excludedAccountIDs, DailyVolumeActionSell)
+			want := "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1')" +
+				k.wantAccountsFilter +
+				" AND DATE(date_utc) = $1 and action = $2 group by DATE(date_utc)"
+			assert.Equal(t, want, actual)
+		})
+	}
+}
+
+func TestVolumeWindowQueryArg(t *testing.T) {
+	input := time.Date(2021, 3, 4, 15, 16, 17, 0, time.UTC)
+	assert.Equal(t, "2021/03/04", VolumeWindowDaily.QueryArg(input))
+	assert.Equal(t, "2021/03/04 15:16:17 UTC", VolumeWindowHourly.QueryArg(input))
+	assert.Equal(t, "2021/03/04 15:16:17 UTC", VolumeWindowWeekly.QueryArg(input))
+}
+
+func TestMakeSQLQueryRollingVolume(t *testing.T) {
+	actual := makeSQLQueryRollingVolume([]string{"market1"}, nil, nil, DailyVolumeActionSell)
+	assert.Equal(t, "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1') AND date_utc > $1::timestamp and action = $2 group by action", actual)
+
+	actual = makeSQLQueryRollingVolume([]string{"market1"}, []string{"accountID1", "accountID2"}, nil, DailyVolumeActionSell)
+	assert.Equal(t, "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1') AND account_id IN ('accountID1', 'accountID2') AND date_utc > $1::timestamp and action = $2 group by action", actual)
+
+	// both sides are summed into a single row
+	actual = makeSQLQueryRollingVolume([]string{"market1"}, nil, nil, DailyVolumeActionBoth)
+	assert.Equal(t, "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1') AND date_utc > $1::timestamp having count(*) > 0", actual)
+}
+
+func TestVolumeWindowQueryArgMidnightBoundary(t *testing.T) {
+	// a trade placed just before midnight should still count against the rolling window right after midnight
+	justAfterMidnight := time.Date(2021, 3, 5, 0, 5, 0, 0, time.UTC)
+	assert.Equal(t, "2021/03/05", VolumeWindowDaily.QueryArg(justAfterMidnight))
+	assert.Equal(t, "2021/03/04 00:05:00 UTC", VolumeWindowRolling24h.QueryArg(justAfterMidnight))
+
+	// the query arg is always converted to UTC so the local timezone of the machine does not move the boundary
+	inOtherZone := justAfterMidnight.In(time.FixedZone("UTC-8", -8*60*60))
+	assert.Equal(t, "2021/03/05", VolumeWindowDaily.QueryArg(inOtherZone))
+	assert.Equal(t, "2021/03/04 00:05:00 UTC", VolumeWindowRolling24h.QueryArg(inOtherZone))
+}
+
+func TestVolumeWindowKey(t *testing.T) {
+	// Thursday
+	input := time.Date(2021, 3, 4, 15, 16, 17, 0, time.UTC)
+	assert.Equal(t, "hourly:2021/03/04 15", VolumeWindowHourly.Key(input))
+	assert.Equal(t, "daily:2021/03/04", VolumeWindowDaily.Key(input))
+	assert.Equal(t, "weekly:2021/03/01", VolumeWindowWeekly.Key(input))
+	assert.Equal(t, "rolling24h:2021/03/04 15", VolumeWindowRolling24h.Key(input))
+
+	// the key changes when the window rolls over so values saved in the previous window are discarded
+	beforeMidnight := time.Date(2021, 3, 7, 23, 59, 59, 0, time.UTC)
+	afterMidnight := beforeMidnight.Add(2 * time.Second)
+	assert.Equal(t, "daily:2021/03/07", VolumeWindowDaily.Key(beforeMidnight))
+	assert.Equal(t, "daily:2021/03/08", VolumeWindowDaily.Key(afterMidnight))
+	assert.Equal(t, "weekly:2021/03/01", VolumeWindowWeekly.Key(beforeMidnight))
+	assert.Equal(t, "weekly:2021/03/08", VolumeWindowWeekly.Key(afterMidnight))
+}
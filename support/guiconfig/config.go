@@ -1,6 +1,10 @@
 package guiconfig
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
 	"github.com/stellar/kelp/support/utils"
 )
 
@@ -13,6 +17,26 @@ type Auth0Config struct {
 
 type GUIConfig struct {
 	Auth0Config 		*Auth0Config `valid:"-" toml:"AUTH0" json:"auth0"`
+	BearerToken         string       `valid:"-" toml:"BEARER_TOKEN" json:"bearer_token"`
+	DisableAuth         bool         `valid:"-" toml:"DISABLE_AUTH" json:"disable_auth"`
+}
+
+// NeedsBearerToken returns true when requests are authenticated with the BEARER_TOKEN, i.e. when AUTH0 is not enabled and auth has not been disabled
+func (g GUIConfig) NeedsBearerToken() bool {
+	if g.Auth0Config != nil && g.Auth0Config.Auth0Enabled {
+		return false
+	}
+	return !g.DisableAuth
+}
+
+// GenerateBearerToken returns a random token to use when BEARER_TOKEN is not set in the GUI config
+func GenerateBearerToken() (string, error) {
+	b := make([]byte, 32)
+	_, e := rand.Read(b)
+	if e != nil {
+		return "", fmt.Errorf("could not read random bytes for the bearer token: %s", e)
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // String impl.
@@ -20,5 +44,6 @@ func (g GUIConfig) String() string {
 	return utils.StructString(g, 0, map[string]func(interface{}) interface{}{
 		"CLIENT_ID":        utils.Hide,
 		"DOMAIN":        	utils.Hide,
+		"BEARER_TOKEN":     utils.Hide,
 	})
 }
\ No newline at end of file
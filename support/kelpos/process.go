@@ -2,10 +2,14 @@ package kelpos
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os/exec"
+	"sync"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
 )
@@ -46,26 +50,70 @@ func (kos *KelpOS) Stop(userID string, namespace string) error {
 		}
 
 		log.Printf("killing process %d\n", p.Cmd.Process.Pid)
-		return p.Cmd.Process.Kill()
+		return killProcess(p.Cmd)
 	}
 	return fmt.Errorf("process with userID '%s' and namespace '%s' does not exist", userID, namespace)
 }
 
+// CommandError is returned when a blocking command fails, it includes the output captured from the command
+type CommandError struct {
+	Command   string
+	UserID    string
+	Namespace string
+	Stdout    string
+	Stderr    string
+	Err       error
+	TimedOut  bool
+}
+
+// Error is the error method
+func (ce *CommandError) Error() string {
+	if ce.TimedOut {
+		return fmt.Sprintf("command '%s' for userID '%s' and namespace '%s' timed out and was killed: (err=%v, stdout=%s, stderr=%s)",
+			ce.Command, ce.UserID, ce.Namespace, ce.Err, ce.Stdout, ce.Stderr)
+	}
+	return fmt.Sprintf("error in command '%s' for userID '%s' and namespace '%s': (err=%v, stdout=%s, stderr=%s)",
+		ce.Command, ce.UserID, ce.Namespace, ce.Err, ce.Stdout, ce.Stderr)
+}
+
 // Blocking runs a bash command and blocks
 func (kos *KelpOS) Blocking(userID string, namespace string, cmd string) ([]byte, error) {
-	p, e := kos.Background(userID, namespace, cmd)
+	return kos.blocking(context.Background(), userID, namespace, exec.Command("bash", "-c", cmd))
+}
+
+// BlockingArgs runs the program with the provided args directly (without a shell) and blocks
+func (kos *KelpOS) BlockingArgs(userID string, namespace string, name string, args ...string) ([]byte, error) {
+	return kos.BlockingArgsContext(context.Background(), userID, namespace, name, args...)
+}
+
+// BlockingArgsContext is the same as BlockingArgs but kills the process (and any processes started by it) when the context
+// is done, returning a *CommandError with TimedOut set if the deadline of the context was exceeded
+func (kos *KelpOS) BlockingArgsContext(ctx context.Context, userID string, namespace string, name string, args ...string) ([]byte, error) {
+	c := exec.Command(name, args...)
+	setProcessGroup(c)
+	return kos.blocking(ctx, userID, namespace, c)
+}
+
+func (kos *KelpOS) blocking(ctx context.Context, userID string, namespace string, c *exec.Cmd) ([]byte, error) {
+	// capture stderr so we can include it in the error if the command fails
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+
+	p, e := kos.start(userID, namespace, c)
 	if e != nil {
-		return nil, fmt.Errorf("could not run bash command in background '%s': %s", cmd, e)
+		return nil, fmt.Errorf("could not run command in background '%s': %s", c.String(), e)
 	}
 
 	// defer unregistration of process because regardless of whether it succeeds or fails it will not be active on the system anymore
 	defer func() {
-		eInner := kos.Unregister(userID, namespace)
-		if eInner != nil {
-			log.Fatalf("error unregistering bash command '%s': %s", cmd, eInner)
-		}
+		// the process may have already been unregistered if it was cancelled using Stop
+		kos.SafeUnregister(userID, namespace)
 	}()
 
+	// kill the process when the context is done so a hanging command does not block forever
+	done := killOnDone(ctx, c)
+	defer close(done)
+
 	var outputBytes []byte
 	var eRead error
 	var eWait error
@@ -86,16 +134,141 @@ func (kos *KelpOS) Blocking(userID string, namespace string, cmd string) ([]byte
 
 	// now check for errors
 	if eWait != nil || eRead != nil {
-		return nil, fmt.Errorf("error in bash command '%s' for userID '%s' and namespace '%s': (eWait=%s, outputBytes=%s, eRead=%v)",
-			cmd, userID, namespace, eWait, string(outputBytes), eRead)
+		err := eWait
+		if err == nil {
+			err = eRead
+		}
+		return nil, &CommandError{
+			Command:   c.String(),
+			UserID:    userID,
+			Namespace: namespace,
+			Stdout:    string(outputBytes),
+			Stderr:    stderr.String(),
+			Err:       err,
+			TimedOut:  ctx.Err() == context.DeadlineExceeded,
+		}
 	}
 
 	return outputBytes, nil
 }
 
+// killOnDone kills the process of the started command when the context is done, close the returned channel once the process
+// has exited to stop watching the context
+func killOnDone(ctx context.Context, c *exec.Cmd) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Printf("context done for command '%s' (%s), killing process %d\n", c.String(), ctx.Err(), c.Process.Pid)
+			eKill := killProcess(c)
+			if eKill != nil {
+				log.Printf("error killing process %d: %s\n", c.Process.Pid, eKill)
+			}
+		case <-done:
+		}
+	}()
+	return done
+}
+
+// LineHandler is invoked for every line of output from a streaming command, isStderr is true when the line was written to stderr
+type LineHandler func(line string, isStderr bool)
+
+// StreamingArgsContext runs the program with the provided args directly (without a shell) and invokes the handler for every line
+// written to stdout or stderr as soon as it is written, blocking until the process exits. Calls to the handler are never concurrent.
+// The process is killed when the context is done. If the command fails then the error is a *CommandError which includes the
+// stderr output (stdout is not included since it has already been passed to the handler).
+func (kos *KelpOS) StreamingArgsContext(ctx context.Context, userID string, namespace string, handler LineHandler, name string, args ...string) error {
+	c := exec.Command(name, args...)
+	setProcessGroup(c)
+
+	stderrReader, e := c.StderrPipe()
+	if e != nil {
+		return fmt.Errorf("could not get Stderr pipe for command '%s': %s", c.String(), e)
+	}
+
+	p, e := kos.start(userID, namespace, c)
+	if e != nil {
+		return fmt.Errorf("could not run command in background '%s': %s", c.String(), e)
+	}
+
+	// defer unregistration of process because regardless of whether it succeeds or fails it will not be active on the system anymore
+	defer func() {
+		// the process may have already been unregistered if it was cancelled using Stop
+		kos.SafeUnregister(userID, namespace)
+	}()
+
+	done := killOnDone(ctx, c)
+	defer close(done)
+
+	handlerLock := &sync.Mutex{}
+	var stderr bytes.Buffer
+	scanLines := func(r io.Reader, isStderr bool) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Split(bufio.ScanLines)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			handlerLock.Lock()
+			if isStderr {
+				stderr.WriteString(line + "\n")
+			}
+			handler(line, isStderr)
+			handlerLock.Unlock()
+		}
+		return scanner.Err()
+	}
+
+	var eStdout error
+	var eStderr error
+	threadTracker := multithreading.MakeThreadTracker()
+	e = threadTracker.TriggerGoroutine(func(inputs []interface{}) {
+		eStdout = scanLines(p.Stdout, false)
+	}, nil)
+	if e != nil {
+		return fmt.Errorf("error while triggering goroutine to read stdout from process: %s", e)
+	}
+	e = threadTracker.TriggerGoroutine(func(inputs []interface{}) {
+		eStderr = scanLines(stderrReader, true)
+	}, nil)
+	if e != nil {
+		return fmt.Errorf("error while triggering goroutine to read stderr from process: %s", e)
+	}
+	// all reads from the pipes need to complete before we call Wait()
+	threadTracker.Wait()
+	eWait := c.Wait()
+
+	// now check for errors
+	if eWait != nil || eStdout != nil || eStderr != nil {
+		err := eWait
+		if err == nil {
+			err = eStdout
+		}
+		if err == nil {
+			err = eStderr
+		}
+		return &CommandError{
+			Command:   c.String(),
+			UserID:    userID,
+			Namespace: namespace,
+			Stderr:    stderr.String(),
+			Err:       err,
+			TimedOut:  ctx.Err() == context.DeadlineExceeded,
+		}
+	}
+	return nil
+}
+
 // Background runs the provided bash command in the background and registers the command
 func (kos *KelpOS) Background(userID string, namespace string, cmd string) (*Process, error) {
-	c := exec.Command("bash", "-c", cmd)
+	return kos.start(userID, namespace, exec.Command("bash", "-c", cmd))
+}
+
+// BackgroundArgs runs the program with the provided args directly (without a shell) in the background and registers the command
+func (kos *KelpOS) BackgroundArgs(userID string, namespace string, name string, args ...string) (*Process, error) {
+	return kos.start(userID, namespace, exec.Command(name, args...))
+}
+
+func (kos *KelpOS) start(userID string, namespace string, c *exec.Cmd) (*Process, error) {
 	// always execute commands from the working directory (specify as native since underlying OS handles it)
 	// using dotKelpWorkingDir as working directory since all our config files and log files are located in here and we want
 	// to have the shortest path lengths to accommodate for the 260 character file path limit in windows
@@ -104,16 +277,16 @@ func (kos *KelpOS) Background(userID string, namespace string, cmd string) (*Pro
 
 	stdinWriter, e := c.StdinPipe()
 	if e != nil {
-		return nil, fmt.Errorf("could not get Stdin pipe for bash command '%s': %s", cmd, e)
+		return nil, fmt.Errorf("could not get Stdin pipe for command '%s': %s", c.String(), e)
 	}
 	stdoutReader, e := c.StdoutPipe()
 	if e != nil {
-		return nil, fmt.Errorf("could not get Stdout pipe for bash command '%s': %s", cmd, e)
+		return nil, fmt.Errorf("could not get Stdout pipe for command '%s': %s", c.String(), e)
 	}
 
 	e = c.Start()
 	if e != nil {
-		return nil, fmt.Errorf("could not start bash command '%s': %s", cmd, e)
+		return nil, fmt.Errorf("could not start command '%s': %s", c.String(), e)
 	}
 
 	p := &Process{
@@ -123,7 +296,7 @@ func (kos *KelpOS) Background(userID string, namespace string, cmd string) (*Pro
 	}
 	e = kos.register(userID, namespace, p)
 	if e != nil {
-		return nil, fmt.Errorf("error registering bash command '%s': %s", cmd, e)
+		return nil, fmt.Errorf("error registering command '%s': %s", c.String(), e)
 	}
 
 	return p, nil
@@ -0,0 +1,119 @@
+package kelpos
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestKelpOS(t *testing.T) *KelpOS {
+	workingDir, e := MakeOsPathBase()
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	return &KelpOS{
+		binDir:              workingDir,
+		dotKelpWorkingDir:   workingDir,
+		processes:           map[string]Process{},
+		processLock:         &sync.Mutex{},
+		userBotData:         map[string]*UserBotData{},
+		userBotDataLock:     &sync.Mutex{},
+		silentRegistrations: true,
+	}
+}
+
+func TestBlockingArgsContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	kos := makeTestKelpOS(t)
+
+	output, e := kos.BlockingArgsContext(context.Background(), "user", "echo", "echo", "hello; world")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the args are not interpreted by a shell
+	assert.Equal(t, "hello; world\n", string(output))
+
+	_, e = kos.BlockingArgsContext(context.Background(), "user", "ls", "ls", "/path/that/does/not/exist")
+	if !assert.Error(t, e) {
+		return
+	}
+	ce, ok := e.(*CommandError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.False(t, ce.TimedOut)
+	assert.NotEqual(t, "", ce.Stderr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, e = kos.BlockingArgsContext(ctx, "user", "sleep", "sleep", "10")
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.True(t, time.Since(start) < 5*time.Second)
+	ce, ok = e.(*CommandError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.True(t, ce.TimedOut)
+
+	// the process is unregistered once it has finished
+	_, exists := kos.GetProcess("user", "sleep")
+	assert.False(t, exists)
+}
+
+func TestStreamingArgsContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	kos := makeTestKelpOS(t)
+
+	stdoutLines := []string{}
+	stderrLines := []string{}
+	handler := func(line string, isStderr bool) {
+		if isStderr {
+			stderrLines = append(stderrLines, line)
+		} else {
+			stdoutLines = append(stdoutLines, line)
+		}
+	}
+
+	e := kos.StreamingArgsContext(context.Background(), "user", "sh", handler, "sh", "-c", "echo a; echo b; echo c 1>&2")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{"a", "b"}, stdoutLines)
+	assert.Equal(t, []string{"c"}, stderrLines)
+
+	stderrLines = []string{}
+	e = kos.StreamingArgsContext(context.Background(), "user", "sh", handler, "sh", "-c", "echo failed 1>&2; exit 1")
+	if !assert.Error(t, e) {
+		return
+	}
+	ce, ok := e.(*CommandError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.False(t, ce.TimedOut)
+	assert.Equal(t, "failed\n", ce.Stderr)
+	assert.Equal(t, []string{"failed"}, stderrLines)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	e = kos.StreamingArgsContext(ctx, "user", "sleep", handler, "sleep", "10")
+	if !assert.Error(t, e) {
+		return
+	}
+	ce, ok = e.(*CommandError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.True(t, ce.TimedOut)
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package kelpos
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group so we can kill any child processes along with it
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcess kills the process group of the command if it was started in its own process group, otherwise only the process
+func killProcess(c *exec.Cmd) error {
+	if c.SysProcAttr != nil && c.SysProcAttr.Setpgid {
+		// a negative pid sends the signal to every process in the process group
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+	return c.Process.Kill()
+}
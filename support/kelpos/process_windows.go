@@ -0,0 +1,13 @@
+package kelpos
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a noop on windows
+func setProcessGroup(c *exec.Cmd) {}
+
+// killProcess kills the process of the command
+func killProcess(c *exec.Cmd) error {
+	return c.Process.Kill()
+}
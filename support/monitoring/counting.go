@@ -0,0 +1,53 @@
+package monitoring
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/stellar/kelp/api"
+)
+
+// CountingAlert wraps an api.Alert and counts the number of times an alert was triggered
+type CountingAlert struct {
+	inner        api.Alert
+	triggerCount uint64 // only accessed atomically
+}
+
+// ensure CountingAlert implements the api.Alert interface
+var _ api.Alert = &CountingAlert{}
+
+// MakeCountingAlert wraps the inner alert so the number of triggers can be reported
+func MakeCountingAlert(inner api.Alert) (*CountingAlert, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("the inner alert cannot be nil")
+	}
+
+	return &CountingAlert{
+		inner:        inner,
+		triggerCount: 0,
+	}, nil
+}
+
+// Trigger impl.
+func (c *CountingAlert) Trigger(description string, details interface{}) (string, error) {
+	return c.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity counts the trigger and passes it through to the inner alert, triggers that the inner alert fails to send are counted too
+func (c *CountingAlert) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	atomic.AddUint64(&c.triggerCount, 1)
+	return c.inner.TriggerWithSeverity(severity, description, details)
+}
+
+// Resolve is passed through to the inner alert
+func (c *CountingAlert) Resolve(dedupKey string) error {
+	return c.inner.Resolve(dedupKey)
+}
+
+// TriggerCount returns the number of times an alert was triggered, it returns 0 for a nil CountingAlert
+func (c *CountingAlert) TriggerCount() uint64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&c.triggerCount)
+}
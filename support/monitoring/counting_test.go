@@ -0,0 +1,36 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestCountingAlert(t *testing.T) {
+	_, e := MakeCountingAlert(nil)
+	assert.Error(t, e)
+
+	var nilAlert *CountingAlert
+	assert.Equal(t, uint64(0), nilAlert.TriggerCount())
+
+	inner := &recordingAlert{failures: 1}
+	c, e := MakeCountingAlert(inner)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// failed triggers are counted too
+	_, e = c.Trigger("error A", nil)
+	assert.Error(t, e)
+	key, e := c.TriggerWithSeverity(api.AlertSeverityCritical, "error B", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-1", key)
+	assert.Equal(t, uint64(2), c.TriggerCount())
+
+	// resolving does not change the count
+	assert.NoError(t, c.Resolve(key))
+	assert.Equal(t, []string{"key-1"}, inner.resolved)
+	assert.Equal(t, uint64(2), c.TriggerCount())
+}
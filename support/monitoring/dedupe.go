@@ -0,0 +1,135 @@
+package monitoring
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// dedupeAlert wraps an api.Alert and suppresses repeat triggers of the same description within the cooldown
+type dedupeAlert struct {
+	inner    api.Alert
+	cooldown time.Duration
+	lock     *sync.Mutex
+	entries  map[string]*dedupeEntry
+}
+
+// ensure dedupeAlert implements the api.Alert interface
+var _ api.Alert = &dedupeAlert{}
+
+// dedupeEntry tracks the triggers of a description that were suppressed in the current cooldown window
+type dedupeEntry struct {
+	dedupKey      string
+	numSuppressed int
+	lastSeverity  api.AlertSeverity
+	lastDetails   interface{}
+}
+
+// makeDedupeAlert wraps the inner alert so that a description is sent at most once per cooldown, any repeats that were
+// suppressed during the cooldown are sent as a single summary when the cooldown expires
+func makeDedupeAlert(inner api.Alert, cooldown time.Duration) (api.Alert, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("the inner alert cannot be nil")
+	}
+	if cooldown <= 0 {
+		return nil, fmt.Errorf("the cooldown needs to be greater than 0, was %s", cooldown)
+	}
+
+	return &dedupeAlert{
+		inner:    inner,
+		cooldown: cooldown,
+		lock:     &sync.Mutex{},
+		entries:  map[string]*dedupeEntry{},
+	}, nil
+}
+
+// Trigger impl.
+func (d *dedupeAlert) Trigger(description string, details interface{}) (string, error) {
+	return d.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity sends the alert to the inner alert unless the same description was already sent within the cooldown,
+// suppressed triggers return the dedup key of the alert that was sent
+func (d *dedupeAlert) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	d.lock.Lock()
+	if entry, ok := d.entries[description]; ok {
+		entry.numSuppressed++
+		entry.lastSeverity = severity
+		entry.lastDetails = details
+		dedupKey := entry.dedupKey
+		d.lock.Unlock()
+		return dedupKey, nil
+	}
+	entry := &dedupeEntry{}
+	d.entries[description] = entry
+	d.lock.Unlock()
+
+	dedupKey, e := d.inner.TriggerWithSeverity(severity, description, details)
+	if e != nil {
+		// drop the entry so the next trigger is sent again instead of being suppressed for the whole cooldown
+		d.lock.Lock()
+		delete(d.entries, description)
+		d.lock.Unlock()
+		return "", e
+	}
+	d.lock.Lock()
+	entry.dedupKey = dedupKey
+	d.lock.Unlock()
+
+	// the cooldown only starts once the alert was sent
+	time.AfterFunc(d.cooldown, func() {
+		e := d.flushEntry(description, entry)
+		if e != nil {
+			log.Printf("unable to send the summary of suppressed alerts for description '%s': %s\n", description, e)
+		}
+	})
+	return dedupKey, nil
+}
+
+// Resolve ends the cooldown window of the alert with the dedup key, without sending a summary, and passes the resolve
+// through to the inner alert so that the next trigger of the same description is sent again
+func (d *dedupeAlert) Resolve(dedupKey string) error {
+	d.lock.Lock()
+	for description, entry := range d.entries {
+		if dedupKey != "" && entry.dedupKey == dedupKey {
+			delete(d.entries, description)
+		}
+	}
+	d.lock.Unlock()
+
+	return d.inner.Resolve(dedupKey)
+}
+
+// flush ends the cooldown window for the description and sends a summary if any triggers were suppressed during the window
+func (d *dedupeAlert) flush(description string) error {
+	d.lock.Lock()
+	entry, ok := d.entries[description]
+	d.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	return d.flushEntry(description, entry)
+}
+
+// flushEntry is flush for the entry of a specific cooldown window, it does nothing if that window already ended so that the
+// timer of a window that was resolved early does not end the window of a later trigger
+func (d *dedupeAlert) flushEntry(description string, entry *dedupeEntry) error {
+	d.lock.Lock()
+	if d.entries[description] != entry {
+		d.lock.Unlock()
+		return nil
+	}
+	delete(d.entries, description)
+	d.lock.Unlock()
+
+	if entry.numSuppressed == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("%s (repeated %d more time(s) within the %s cooldown)", description, entry.numSuppressed, d.cooldown)
+	_, e := d.inner.TriggerWithSeverity(entry.lastSeverity, summary, entry.lastDetails)
+	return e
+}
@@ -0,0 +1,167 @@
+package monitoring
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+type recordingAlert struct {
+	lock     sync.Mutex
+	triggers []string
+	resolved []string
+	failures int // number of upcoming triggers that fail
+}
+
+var _ api.Alert = &recordingAlert{}
+
+func (r *recordingAlert) Trigger(description string, details interface{}) (string, error) {
+	return r.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+func (r *recordingAlert) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		return "", fmt.Errorf("could not send alert")
+	}
+	r.triggers = append(r.triggers, fmt.Sprintf("%s: %s", severity, description))
+	return fmt.Sprintf("key-%d", len(r.triggers)), nil
+}
+
+func (r *recordingAlert) Resolve(dedupKey string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resolved = append(r.resolved, dedupKey)
+	return nil
+}
+
+func (r *recordingAlert) getTriggers() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string{}, r.triggers...)
+}
+
+func TestDedupeAlert(t *testing.T) {
+	inner := &recordingAlert{}
+	// use a long cooldown so the timer does not fire during the test, we flush manually instead
+	alert, e := makeDedupeAlert(inner, time.Hour)
+	if !assert.NoError(t, e) {
+		return
+	}
+	d := alert.(*dedupeAlert)
+
+	keyA, e := d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-1", keyA)
+	// suppressed triggers return the dedup key of the alert that was sent
+	keyA, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-1", keyA)
+	_, e = d.TriggerWithSeverity(api.AlertSeverityCritical, "error A", nil)
+	assert.NoError(t, e)
+	keyB, e := d.Trigger("error B", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-2", keyB)
+	assert.Equal(t, []string{"warning: error A", "warning: error B"}, inner.triggers)
+
+	// flushing sends a single summary for the suppressed repeats
+	assert.NoError(t, d.flush("error A"))
+	assert.Equal(t, "critical: error A (repeated 2 more time(s) within the 1h0m0s cooldown)", inner.triggers[2])
+
+	// flushing without any repeats does not send anything
+	assert.NoError(t, d.flush("error B"))
+	assert.Equal(t, 3, len(inner.triggers))
+
+	// once the cooldown has expired the description is sent again
+	_, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "warning: error A", inner.triggers[3])
+
+	// resolving is passed through to the inner alert
+	assert.NoError(t, d.Resolve(keyA))
+	assert.Equal(t, []string{"key-1"}, inner.resolved)
+}
+
+func TestDedupeAlertResolve(t *testing.T) {
+	inner := &recordingAlert{}
+	alert, e := makeDedupeAlert(inner, time.Hour)
+	if !assert.NoError(t, e) {
+		return
+	}
+	d := alert.(*dedupeAlert)
+
+	key, e := d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	_, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.NoError(t, d.Resolve(key))
+	assert.Equal(t, []string{"key-1"}, inner.resolved)
+
+	// the resolved alert does not suppress the next trigger and the suppressed repeat is not summarized
+	key, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-2", key)
+	assert.Equal(t, []string{"warning: error A", "warning: error A"}, inner.getTriggers())
+
+	// the timer of the resolved window does not end the window of the new trigger
+	d.lock.Lock()
+	entry := d.entries["error A"]
+	d.lock.Unlock()
+	assert.NoError(t, d.flushEntry("error A", &dedupeEntry{}))
+	d.lock.Lock()
+	assert.Equal(t, entry, d.entries["error A"])
+	d.lock.Unlock()
+}
+
+func TestDedupeAlertCooldownExpires(t *testing.T) {
+	inner := &recordingAlert{}
+	alert, e := makeDedupeAlert(inner, 10*time.Millisecond)
+	if !assert.NoError(t, e) {
+		return
+	}
+	d := alert.(*dedupeAlert)
+
+	_, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	_, e = d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, []string{"warning: error A", "warning: error A (repeated 1 more time(s) within the 10ms cooldown)"}, inner.getTriggers())
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	assert.Equal(t, 0, len(d.entries))
+}
+
+func TestDedupeAlertInnerFailure(t *testing.T) {
+	inner := &recordingAlert{failures: 1}
+	alert, e := makeDedupeAlert(inner, time.Hour)
+	if !assert.NoError(t, e) {
+		return
+	}
+	d := alert.(*dedupeAlert)
+
+	_, e = d.Trigger("error A", nil)
+	assert.NotNil(t, e)
+	d.lock.Lock()
+	assert.Equal(t, 0, len(d.entries))
+	d.lock.Unlock()
+
+	// the failed send does not start a cooldown so the next trigger is sent
+	key, e := d.Trigger("error A", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "key-1", key)
+	assert.Equal(t, []string{"warning: error A"}, inner.getTriggers())
+}
+
+func TestMakeDedupeAlertNeedsPositiveCooldown(t *testing.T) {
+	_, e := makeDedupeAlert(&noopAlert{}, 0)
+	assert.NotNil(t, e)
+}
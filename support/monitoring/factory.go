@@ -1,6 +1,10 @@
 package monitoring
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/stellar/kelp/api"
 )
 
@@ -10,16 +14,67 @@ var _ api.Alert = &noopAlert{}
 
 // Trigger is simply a noop for the default Alert, meaning that the client
 // hasn't specified a monitoring service that's supported.
-func (p *noopAlert) Trigger(description string, details interface{}) error {
+func (p *noopAlert) Trigger(description string, details interface{}) (string, error) {
+	return "", nil
+}
+
+// TriggerWithSeverity is also a noop
+func (p *noopAlert) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	return "", nil
+}
+
+// Resolve is also a noop
+func (p *noopAlert) Resolve(dedupKey string) error {
 	return nil
 }
 
+// AlertOptions are the additional options needed by some types of services
+type AlertOptions struct {
+	ChatID             string // Telegram
+	WebhookMethod      string // Webhook, defaults to POST
+	WebhookBearerToken string // Webhook, optional
+	OpsGenieTeam       string // OpsGenie, optional
+	CooldownSeconds    int64  // all types, repeats of the same alert are suppressed within the cooldown when > 0
+}
+
+// supportedAlertTypes are the values of alertType that are recognized by MakeAlert
+var supportedAlertTypes = []string{"PagerDuty", "Slack", "Telegram", "Webhook", "OpsGenie"}
+
 // MakeAlert creates an Alert based on the type of the service (eg Pager Duty) and its corresponding API key.
+// It returns a noop Alert when alertType is empty and an error when alertType is not recognized.
 func MakeAlert(alertType string, apiKey string) (api.Alert, error) {
+	return MakeAlertWithOptions(alertType, apiKey, AlertOptions{})
+}
+
+// MakeAlertWithOptions is the same as MakeAlert but also passes the options to services that need them (eg Telegram, Webhook).
+func MakeAlertWithOptions(alertType string, apiKey string, options AlertOptions) (api.Alert, error) {
+	alert, e := makeAlertByType(alertType, apiKey, options)
+	if e != nil {
+		return nil, e
+	}
+
+	if options.CooldownSeconds > 0 {
+		return makeDedupeAlert(alert, time.Duration(options.CooldownSeconds)*time.Second)
+	}
+	return alert, nil
+}
+
+func makeAlertByType(alertType string, apiKey string, options AlertOptions) (api.Alert, error) {
 	switch alertType {
 	case "PagerDuty":
 		return makePagerDuty(apiKey)
-	default:
+	case "Slack":
+		return makeSlack(apiKey)
+	case "Telegram":
+		return makeTelegram(apiKey, options.ChatID)
+	case "Webhook":
+		return makeWebhook(apiKey, options.WebhookMethod, options.WebhookBearerToken)
+	case "OpsGenie":
+		return makeOpsGenie(apiKey, options.OpsGenieTeam)
+	case "":
 		return &noopAlert{}, nil
+	default:
+		// alert types are case-sensitive so we don't want a typo to silently disable alerting
+		return nil, fmt.Errorf("unrecognized alert type '%s', needs to be one of: %s", alertType, strings.Join(supportedAlertTypes, ", "))
 	}
 }
@@ -0,0 +1,37 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeAlertType(t *testing.T) {
+	testCases := []struct {
+		alertType string
+		wantNoop  bool
+		wantErr   bool
+	}{
+		{alertType: "", wantNoop: true},
+		{alertType: "Slack", wantNoop: false},
+		{alertType: "Pagerduty", wantErr: true},
+		{alertType: "slack", wantErr: true},
+		{alertType: "Email", wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.alertType, func(t *testing.T) {
+			alert, e := MakeAlert(k.alertType, "https://hooks.slack.com/services/abc")
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			_, isNoop := alert.(*noopAlert)
+			assert.Equal(t, k.wantNoop, isNoop)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package monitoring
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+const opsGenieAlertsURL = "https://api.opsgenie.com/v2/alerts"
+
+const opsGenieTimeout = 10 * time.Second
+
+// opsGenieMaxMessageLength is the max number of characters allowed by OpsGenie in the message of an alert
+const opsGenieMaxMessageLength = 130
+
+type opsGenie struct {
+	url        string
+	apiKey     string
+	team       string
+	httpClient *http.Client
+}
+
+// ensure opsGenie implements the api.Alert interface
+var _ api.Alert = &opsGenie{}
+
+type opsGenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type opsGenieCreateAlertRequest struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description,omitempty"`
+	Details     map[string]string   `json:"details,omitempty"`
+	Responders  []opsGenieResponder `json:"responders,omitempty"`
+	Priority    string              `json:"priority"`
+	Source      string              `json:"source"`
+}
+
+type opsGenieCloseAlertRequest struct {
+	Source string `json:"source"`
+}
+
+func makeOpsGenie(apiKey string, team string) (api.Alert, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("the API key needs to be specified for OpsGenie alerts")
+	}
+
+	return &opsGenie{
+		url:        opsGenieAlertsURL,
+		apiKey:     apiKey,
+		team:       team,
+		httpClient: &http.Client{Timeout: opsGenieTimeout},
+	}, nil
+}
+
+// Trigger creates an OpsGenie alert with the api.DefaultAlertSeverity.
+func (o *opsGenie) Trigger(description string, details interface{}) (string, error) {
+	return o.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity creates an OpsGenie alert using the Alert API. The description is used as the message of the alert and the
+// details are converted to the key-value pairs of the alert's details. The alert is assigned to the team when one is configured.
+// The returned dedup key is the alias of the alert, which is derived from the description so OpsGenie groups repeats of an open alert.
+func (o *opsGenie) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	if description == "" {
+		return "", fmt.Errorf("the description of an OpsGenie alert cannot be empty")
+	}
+
+	detailsMap, e := makeOpsGenieDetails(details)
+	if e != nil {
+		return "", fmt.Errorf("could not convert details for OpsGenie alert: %s", e)
+	}
+
+	alias := makeOpsGenieAlias(description)
+	req := opsGenieCreateAlertRequest{
+		Message:  description,
+		Alias:    alias,
+		Details:  detailsMap,
+		Priority: opsGeniePriority(severity),
+		Source:   "kelp",
+	}
+	if runes := []rune(description); len(runes) > opsGenieMaxMessageLength {
+		// the message is truncated by OpsGenie so we send the full text in the description as well
+		req.Message = string(runes[:opsGenieMaxMessageLength])
+		req.Description = description
+	}
+	if o.team != "" {
+		req.Responders = []opsGenieResponder{{Name: o.team, Type: "team"}}
+	}
+
+	e = o.post(o.url, req)
+	if e != nil {
+		return "", fmt.Errorf("encountered an error while sending an OpsGenie alert: %s", e)
+	}
+	log.Printf("Triggered OpsGenie alert (severity=%s). Alias for reference: %s\n", severity, alias)
+	return alias, nil
+}
+
+// Resolve closes the OpsGenie alert that was created with the alias returned as the dedup key
+func (o *opsGenie) Resolve(dedupKey string) error {
+	if dedupKey == "" {
+		return fmt.Errorf("the dedup key is needed to resolve an OpsGenie alert")
+	}
+
+	closeURL := fmt.Sprintf("%s/%s/close?identifierType=alias", o.url, url.PathEscape(dedupKey))
+	e := o.post(closeURL, opsGenieCloseAlertRequest{Source: "kelp"})
+	if e != nil {
+		return fmt.Errorf("encountered an error while closing OpsGenie alert with alias '%s': %s", dedupKey, e)
+	}
+	log.Printf("Closed OpsGenie alert with alias: %s\n", dedupKey)
+	return nil
+}
+
+// post sends the request as json to the url of the Alert API, OpsGenie processes the request asynchronously and responds with a 202
+func (o *opsGenie) post(reqURL string, req interface{}) error {
+	body, e := json.Marshal(req)
+	if e != nil {
+		return fmt.Errorf("could not marshal OpsGenie request: %s", e)
+	}
+	httpReq, e := http.NewRequest(http.MethodPost, reqURL, bytes.NewReader(body))
+	if e != nil {
+		return fmt.Errorf("could not create OpsGenie request: %s", e)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "GenieKey "+o.apiKey)
+
+	resp, e := o.httpClient.Do(httpReq)
+	if e != nil {
+		return fmt.Errorf("could not send OpsGenie request: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("OpsGenie responded with a non-2xx status code (%d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// makeOpsGenieAlias derives a stable alias from the description, OpsGenie limits the alias to 512 characters
+func makeOpsGenieAlias(description string) string {
+	return fmt.Sprintf("kelp-%x", sha256.Sum256([]byte(description)))
+}
+
+// opsGeniePriority converts the severity to one of the priorities used by OpsGenie (P1 is the highest, P5 the lowest)
+func opsGeniePriority(severity api.AlertSeverity) string {
+	switch severity {
+	case api.AlertSeverityInfo:
+		return "P5"
+	case api.AlertSeverityCritical:
+		return "P1"
+	default:
+		return "P3"
+	}
+}
+
+// makeOpsGenieDetails converts the details into the string key-value pairs accepted by OpsGenie, details that are not an object
+// are sent under the key "details"
+func makeOpsGenieDetails(details interface{}) (map[string]string, error) {
+	if details == nil {
+		return nil, nil
+	}
+
+	detailsBytes, e := json.Marshal(details)
+	if e != nil {
+		return nil, fmt.Errorf("could not marshal details: %s", e)
+	}
+
+	var detailsMap map[string]interface{}
+	e = json.Unmarshal(detailsBytes, &detailsMap)
+	if e != nil {
+		return map[string]string{"details": string(detailsBytes)}, nil
+	}
+
+	m := map[string]string{}
+	for k, v := range detailsMap {
+		if s, ok := v.(string); ok {
+			m[k] = s
+			continue
+		}
+		valueBytes, e := json.Marshal(v)
+		if e != nil {
+			return nil, fmt.Errorf("could not marshal value for key '%s': %s", k, e)
+		}
+		m[k] = string(valueBytes)
+	}
+	return m, nil
+}
@@ -0,0 +1,158 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestTriggerOpsGenie(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		team            string
+		description     string
+		details         interface{}
+		responseStatus  int
+		wantMessage     string
+		wantDescription string
+		wantDetails     map[string]string
+		wantResponders  []opsGenieResponder
+		errorExpected   bool
+	}{
+		{
+			testName:       "Tests that the description and details are mapped to the alert",
+			team:           "",
+			description:    "bot crashed",
+			details:        map[string]interface{}{"bot": "bot_a", "num_errors": 3},
+			responseStatus: http.StatusAccepted,
+			wantMessage:    "bot crashed",
+			wantDetails:    map[string]string{"bot": "bot_a", "num_errors": "3"},
+			errorExpected:  false,
+		}, {
+			testName:       "Tests that the team is added as a responder",
+			team:           "market_making",
+			description:    "bot crashed",
+			details:        nil,
+			responseStatus: http.StatusAccepted,
+			wantMessage:    "bot crashed",
+			wantResponders: []opsGenieResponder{{Name: "market_making", Type: "team"}},
+			errorExpected:  false,
+		}, {
+			testName:        "Tests that long descriptions are truncated in the message",
+			team:            "",
+			description:     strings.Repeat("a", 200),
+			details:         "not an object",
+			responseStatus:  http.StatusAccepted,
+			wantMessage:     strings.Repeat("a", 130),
+			wantDescription: strings.Repeat("a", 200),
+			wantDetails:     map[string]string{"details": `"not an object"`},
+			errorExpected:   false,
+		}, {
+			testName:        "Tests that multi-byte characters are not split when truncating the message",
+			team:            "",
+			description:     strings.Repeat("é", 131),
+			details:         nil,
+			responseStatus:  http.StatusAccepted,
+			wantMessage:     strings.Repeat("é", 130),
+			wantDescription: strings.Repeat("é", 131),
+			errorExpected:   false,
+		}, {
+			testName:       "Tests that a non-2xx response causes an error",
+			team:           "",
+			description:    "bot crashed",
+			details:        nil,
+			responseStatus: http.StatusUnauthorized,
+			wantMessage:    "bot crashed",
+			errorExpected:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var gotAuth, gotPath string
+			var gotBody opsGenieCreateAlertRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAuth = r.Header.Get("Authorization")
+				gotPath = r.URL.Path
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotBody)
+				w.WriteHeader(tc.responseStatus)
+			}))
+			defer server.Close()
+
+			alert, e := makeOpsGenie("apiKey123", tc.team)
+			if !assert.NoError(t, e) {
+				return
+			}
+			// requests to OpsGenie should never hang the bot
+			assert.Equal(t, opsGenieTimeout, alert.(*opsGenie).httpClient.Timeout)
+			alert.(*opsGenie).url = server.URL + "/v2/alerts"
+
+			dedupKey, e := alert.TriggerWithSeverity(api.AlertSeverityCritical, tc.description, tc.details)
+			if tc.errorExpected {
+				assert.Error(t, e)
+				assert.Equal(t, "", dedupKey)
+			} else {
+				assert.NoError(t, e)
+				// the alias is returned as the dedup key
+				assert.Equal(t, makeOpsGenieAlias(tc.description), dedupKey)
+			}
+			assert.Equal(t, "/v2/alerts", gotPath)
+			assert.Equal(t, "GenieKey apiKey123", gotAuth)
+			assert.Equal(t, makeOpsGenieAlias(tc.description), gotBody.Alias)
+			assert.Equal(t, tc.wantMessage, gotBody.Message)
+			assert.Equal(t, tc.wantDescription, gotBody.Description)
+			assert.Equal(t, tc.wantDetails, gotBody.Details)
+			assert.Equal(t, tc.wantResponders, gotBody.Responders)
+			assert.Equal(t, "P1", gotBody.Priority)
+		})
+	}
+}
+
+func TestResolveOpsGenie(t *testing.T) {
+	var gotMethod, gotPath, gotIdentifierType, gotAuth string
+	var gotBody opsGenieCloseAlertRequest
+	responseStatus := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotIdentifierType = r.URL.Query().Get("identifierType")
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &gotBody)
+		w.WriteHeader(responseStatus)
+	}))
+	defer server.Close()
+
+	alert, e := makeOpsGenie("apiKey123", "")
+	if !assert.NoError(t, e) {
+		return
+	}
+	alert.(*opsGenie).url = server.URL + "/v2/alerts"
+
+	alias := makeOpsGenieAlias("bot crashed")
+	assert.NoError(t, alert.Resolve(alias))
+	assert.Equal(t, "POST", gotMethod)
+	assert.Equal(t, "/v2/alerts/"+alias+"/close", gotPath)
+	assert.Equal(t, "alias", gotIdentifierType)
+	assert.Equal(t, "GenieKey apiKey123", gotAuth)
+	assert.Equal(t, "kelp", gotBody.Source)
+
+	// the alias is needed to close the alert
+	assert.Error(t, alert.Resolve(""))
+
+	responseStatus = http.StatusNotFound
+	assert.Error(t, alert.Resolve(alias))
+}
+
+func TestMakeOpsGenieEmptyAPIKey(t *testing.T) {
+	_, e := MakeAlertWithOptions("OpsGenie", "", AlertOptions{})
+	assert.Error(t, e)
+}
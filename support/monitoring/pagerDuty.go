@@ -1,8 +1,10 @@
 package monitoring
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/PagerDuty/go-pagerduty"
 	"github.com/stellar/kelp/api"
@@ -10,30 +12,89 @@ import (
 
 type pagerDuty struct {
 	serviceKey string
+	source     string
 }
 
 // ensure pagerDuty implements the api.Alert interface
 var _ api.Alert = &pagerDuty{}
 
 func makePagerDuty(serviceKey string) (api.Alert, error) {
+	// the source is required by the v2 events API and is used to identify the machine that the alert is coming from
+	source, e := os.Hostname()
+	if e != nil {
+		source = "kelp"
+	}
+
 	return &pagerDuty{
 		serviceKey: serviceKey,
+		source:     source,
 	}, nil
 }
 
-// Trigger creates a PagerDuty trigger. The description is required and cannot be empty. Supplementary
-// details can be optionally provided as key-value pairs as part of the details parameter.
-func (p *pagerDuty) Trigger(description string, details interface{}) error {
-	event := pagerduty.Event{
-		ServiceKey:  p.serviceKey,
-		Type:        "trigger",
-		Description: description,
-		Details:     details,
+// Trigger creates a PagerDuty trigger with the api.DefaultAlertSeverity.
+func (p *pagerDuty) Trigger(description string, details interface{}) (string, error) {
+	return p.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity creates a PagerDuty trigger. The description is required and cannot be empty. Supplementary
+// details can be optionally provided as key-value pairs as part of the details parameter. The returned dedup key is derived
+// from the description so repeated triggers of the same description are grouped into the same PagerDuty incident.
+func (p *pagerDuty) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	if description == "" {
+		return "", fmt.Errorf("the description of a PagerDuty alert cannot be empty")
+	}
+
+	// we use the v2 events API because the v1 API does not support severity
+	event := pagerduty.V2Event{
+		RoutingKey: p.serviceKey,
+		Action:     "trigger",
+		DedupKey:   makePagerDutyDedupKey(description),
+		Payload: &pagerduty.V2Payload{
+			Summary:  description,
+			Source:   p.source,
+			Severity: pagerDutySeverity(severity),
+			Details:  details,
+		},
+	}
+	response, e := pagerduty.ManageEvent(event)
+	if e != nil {
+		return "", fmt.Errorf("encountered an error while sending a PagerDuty alert: %s", e)
+	}
+	log.Printf("Triggered PagerDuty alert (severity=%s). Dedup key for reference: %s\n", severity, response.DedupKey)
+	return response.DedupKey, nil
+}
+
+// Resolve resolves the PagerDuty incident that was triggered with the dedup key
+func (p *pagerDuty) Resolve(dedupKey string) error {
+	if dedupKey == "" {
+		return fmt.Errorf("the dedup key is needed to resolve a PagerDuty alert")
 	}
-	response, e := pagerduty.CreateEvent(event)
+
+	_, e := pagerduty.ManageEvent(pagerduty.V2Event{
+		RoutingKey: p.serviceKey,
+		Action:     "resolve",
+		DedupKey:   dedupKey,
+	})
 	if e != nil {
-		return fmt.Errorf("encountered an error while sending a PagerDuty alert: %s", e)
+		return fmt.Errorf("encountered an error while resolving PagerDuty alert with dedup key '%s': %s", dedupKey, e)
 	}
-	log.Printf("Triggered PagerDuty alert. Incident key for reference: %s\n", response.IncidentKey)
+	log.Printf("Resolved PagerDuty alert with dedup key: %s\n", dedupKey)
 	return nil
 }
+
+// makePagerDutyDedupKey derives a stable dedup key from the description, PagerDuty limits the key to 255 characters
+func makePagerDutyDedupKey(description string) string {
+	return fmt.Sprintf("kelp-%x", sha256.Sum256([]byte(description)))
+}
+
+// pagerDutySeverity converts the severity to one of the values accepted by PagerDuty (critical, error, warning, info)
+func pagerDutySeverity(severity api.AlertSeverity) string {
+	switch severity {
+	case api.AlertSeverityInfo:
+		return "info"
+	case api.AlertSeverityCritical:
+		return "critical"
+	default:
+		return "warning"
+	}
+}
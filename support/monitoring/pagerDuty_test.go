@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
 )
 
 func TestTriggerPagerDuty(t *testing.T) {
@@ -62,7 +64,7 @@ func TestTriggerPagerDuty(t *testing.T) {
 			if !assert.Nil(t, e) {
 				return
 			}
-			e = pagerDutyAlert.Trigger(tc.description, tc.details)
+			_, e = pagerDutyAlert.Trigger(tc.description, tc.details)
 			if tc.errorExpected {
 				assert.NotNil(t, e)
 			} else {
@@ -71,3 +73,17 @@ func TestTriggerPagerDuty(t *testing.T) {
 		})
 	}
 }
+
+func TestPagerDutySeverity(t *testing.T) {
+	assert.Equal(t, "info", pagerDutySeverity(api.AlertSeverityInfo))
+	assert.Equal(t, "warning", pagerDutySeverity(api.AlertSeverityWarning))
+	assert.Equal(t, "critical", pagerDutySeverity(api.AlertSeverityCritical))
+	assert.Equal(t, "warning", pagerDutySeverity(api.AlertSeverity("unknown")))
+}
+
+func TestMakePagerDutyDedupKey(t *testing.T) {
+	key := makePagerDutyDedupKey("error A")
+	assert.Equal(t, key, makePagerDutyDedupKey("error A"))
+	assert.NotEqual(t, key, makePagerDutyDedupKey("error B"))
+	assert.True(t, len(key) <= 255)
+}
@@ -0,0 +1,45 @@
+package monitoring
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/stellar/kelp/support/networking"
+)
+
+// prometheusEndpoint represents a monitoring API endpoint that serves the metrics of a prometheus registry in the prometheus
+// text format so it can be scraped
+type prometheusEndpoint struct {
+	path      string
+	gatherer  prometheus.Gatherer
+	authLevel networking.AuthLevel
+}
+
+// MakePrometheusEndpoint creates an Endpoint for the monitoring server that serves the metrics gathered by the gatherer
+func MakePrometheusEndpoint(path string, gatherer prometheus.Gatherer, authLevel networking.AuthLevel) (networking.Endpoint, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("endpoint path must begin with /")
+	}
+	return &prometheusEndpoint{
+		path:      path,
+		gatherer:  gatherer,
+		authLevel: authLevel,
+	}, nil
+}
+
+func (p *prometheusEndpoint) GetAuthLevel() networking.AuthLevel {
+	return p.authLevel
+}
+
+func (p *prometheusEndpoint) GetPath() string {
+	return p.path
+}
+
+// GetHandlerFunc returns a HandlerFunc that writes the gathered metrics
+func (p *prometheusEndpoint) GetHandlerFunc() http.HandlerFunc {
+	return promhttp.HandlerFor(p.gatherer, promhttp.HandlerOpts{}).ServeHTTP
+}
@@ -0,0 +1,152 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+const slackTimeout = 10 * time.Second
+
+type slack struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// ensure slack implements the api.Alert interface
+var _ api.Alert = &slack{}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Fallback string       `json:"fallback"`
+	Color    string       `json:"color"`
+	Fields   []slackField `json:"fields"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+func makeSlack(webhookURL string) (api.Alert, error) {
+	return &slack{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: slackTimeout},
+	}, nil
+}
+
+// Trigger posts the description to the Slack incoming webhook with the api.DefaultAlertSeverity.
+func (s *slack) Trigger(description string, details interface{}) (string, error) {
+	return s.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity posts the description to the Slack incoming webhook. Supplementary details can be optionally provided
+// as key-value pairs as part of the details parameter, which are sent as the fields of an attachment.
+func (s *slack) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	msg := slackMessage{
+		Text: fmt.Sprintf("[%s] %s", strings.ToUpper(severity.String()), description),
+	}
+	if details != nil {
+		fields, e := makeSlackFields(details)
+		if e != nil {
+			return "", fmt.Errorf("could not convert details to slack fields: %s", e)
+		}
+		msg.Attachments = []slackAttachment{{
+			Fallback: description,
+			Color:    slackColor(severity),
+			Fields:   fields,
+		}}
+	}
+
+	body, e := json.Marshal(msg)
+	if e != nil {
+		return "", fmt.Errorf("could not marshal slack message: %s", e)
+	}
+
+	resp, e := s.httpClient.Post(s.webhookURL, "application/json", bytes.NewReader(body))
+	if e != nil {
+		return "", fmt.Errorf("encountered an error while sending a Slack alert: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("slack webhook responded with a non-2xx status code (%d), check that the webhook URL is correct: %s", resp.StatusCode, string(respBody))
+	}
+	log.Printf("Triggered Slack alert\n")
+	return "", nil
+}
+
+// Resolve is a noop because Slack alerts cannot be resolved
+func (s *slack) Resolve(dedupKey string) error {
+	return nil
+}
+
+// slackColor returns the color of the attachment for the severity
+func slackColor(severity api.AlertSeverity) string {
+	switch severity {
+	case api.AlertSeverityInfo:
+		return "good"
+	case api.AlertSeverityCritical:
+		return "danger"
+	default:
+		return "warning"
+	}
+}
+
+// makeSlackFields converts the details into one field per key when it is an object, or a single field otherwise
+func makeSlackFields(details interface{}) ([]slackField, error) {
+	detailsBytes, e := json.Marshal(details)
+	if e != nil {
+		return nil, fmt.Errorf("could not marshal details: %s", e)
+	}
+
+	var detailsMap map[string]interface{}
+	e = json.Unmarshal(detailsBytes, &detailsMap)
+	if e != nil {
+		// details is not an object so we send it as a single field
+		return []slackField{{
+			Title: "details",
+			Value: string(detailsBytes),
+			Short: false,
+		}}, nil
+	}
+
+	// sort the keys so the fields are in a deterministic order
+	keys := []string{}
+	for k := range detailsMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := []slackField{}
+	for _, k := range keys {
+		value := fmt.Sprintf("%v", detailsMap[k])
+		if _, ok := detailsMap[k].(string); !ok {
+			valueBytes, e := json.Marshal(detailsMap[k])
+			if e != nil {
+				return nil, fmt.Errorf("could not marshal value for key '%s': %s", k, e)
+			}
+			value = string(valueBytes)
+		}
+		fields = append(fields, slackField{
+			Title: k,
+			Value: value,
+			Short: true,
+		})
+	}
+	return fields, nil
+}
@@ -0,0 +1,93 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerSlack(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		description    string
+		details        interface{}
+		responseStatus int
+		wantMessage    slackMessage
+		errorExpected  bool
+	}{
+		{
+			testName:       "Tests that a Slack alert without details is sent",
+			description:    "Testing monitoring package. Not a real incident!",
+			details:        nil,
+			responseStatus: http.StatusOK,
+			wantMessage:    slackMessage{Text: "[WARNING] Testing monitoring package. Not a real incident!"},
+			errorExpected:  false,
+		}, {
+			testName:    "Tests that details are sent as attachment fields",
+			description: "Testing monitoring package. Not a real incident!",
+			details: struct {
+				LoadAvg     float64 `json:"load_avg"`
+				NumRequests int     `json:"num_requests"`
+			}{
+				LoadAvg:     0.5,
+				NumRequests: 100,
+			},
+			responseStatus: http.StatusOK,
+			wantMessage: slackMessage{
+				Text: "[WARNING] Testing monitoring package. Not a real incident!",
+				Attachments: []slackAttachment{{
+					Fallback: "Testing monitoring package. Not a real incident!",
+					Color:    "warning",
+					Fields: []slackField{
+						{Title: "load_avg", Value: "0.5", Short: true},
+						{Title: "num_requests", Value: "100", Short: true},
+					},
+				}},
+			},
+			errorExpected: false,
+		}, {
+			testName:       "Tests that a non-2xx response causes an error",
+			description:    "Testing monitoring package. Not a real incident!",
+			details:        nil,
+			responseStatus: http.StatusNotFound,
+			wantMessage:    slackMessage{Text: "[WARNING] Testing monitoring package. Not a real incident!"},
+			errorExpected:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var gotMessage slackMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotMessage)
+				w.WriteHeader(tc.responseStatus)
+			}))
+			defer server.Close()
+
+			slackAlert, e := MakeAlert("Slack", server.URL)
+			if !assert.Nil(t, e) {
+				return
+			}
+			_, e = slackAlert.Trigger(tc.description, tc.details)
+			if tc.errorExpected {
+				assert.NotNil(t, e)
+			} else {
+				assert.Nil(t, e)
+			}
+			assert.Equal(t, tc.wantMessage, gotMessage)
+		})
+	}
+}
+
+func TestMakeSlackFieldsNonObject(t *testing.T) {
+	fields, e := makeSlackFields([]int{1, 2})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []slackField{{Title: "details", Value: "[1,2]", Short: false}}, fields)
+}
@@ -0,0 +1,126 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/networking"
+)
+
+const telegramBaseURL = "https://api.telegram.org"
+
+const telegramTimeout = 10 * time.Second
+
+// telegramMaxMessageLength is the max number of characters allowed by Telegram in a single message
+const telegramMaxMessageLength = 4096
+
+type telegram struct {
+	baseURL    string
+	botToken   string
+	chatID     string
+	httpClient *http.Client
+}
+
+// ensure telegram implements the api.Alert interface
+var _ api.Alert = &telegram{}
+
+type telegramSendMessageRequest struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func makeTelegram(botToken string, chatID string) (api.Alert, error) {
+	if chatID == "" {
+		return nil, fmt.Errorf("the chat ID needs to be specified for Telegram alerts")
+	}
+
+	return &telegram{
+		baseURL:    telegramBaseURL,
+		botToken:   botToken,
+		chatID:     chatID,
+		httpClient: &http.Client{Timeout: telegramTimeout},
+	}, nil
+}
+
+// Trigger sends the description to the Telegram chat using the bot with the api.DefaultAlertSeverity.
+func (t *telegram) Trigger(description string, details interface{}) (string, error) {
+	return t.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity sends the description to the Telegram chat using the bot. Supplementary details can be optionally provided
+// as part of the details parameter, which are appended to the message. Messages longer than Telegram's limit are sent in chunks.
+func (t *telegram) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	text := fmt.Sprintf("[%s] %s", strings.ToUpper(severity.String()), description)
+	if details != nil {
+		detailsBytes, e := json.MarshalIndent(details, "", "  ")
+		if e != nil {
+			return "", fmt.Errorf("could not marshal details: %s", e)
+		}
+		text = fmt.Sprintf("%s\n\n%s", text, string(detailsBytes))
+	}
+
+	chunks := chunkMessage(text, telegramMaxMessageLength)
+	for i, chunk := range chunks {
+		e := t.sendMessage(chunk)
+		if e != nil {
+			return "", fmt.Errorf("encountered an error while sending chunk %d of %d of a Telegram alert: %s", i+1, len(chunks), e)
+		}
+	}
+	log.Printf("Triggered Telegram alert in %d message(s)\n", len(chunks))
+	return "", nil
+}
+
+// Resolve is a noop because Telegram alerts cannot be resolved
+func (t *telegram) Resolve(dedupKey string) error {
+	return nil
+}
+
+func (t *telegram) sendMessage(text string) error {
+	data, e := json.Marshal(telegramSendMessageRequest{
+		ChatID: t.chatID,
+		Text:   text,
+	})
+	if e != nil {
+		return fmt.Errorf("could not marshal request: %s", e)
+	}
+
+	reqURL := fmt.Sprintf("%s/bot%s/sendMessage", t.baseURL, t.botToken)
+	var response telegramResponse
+	e = networking.JSONRequest(t.httpClient, "POST", reqURL, string(data), map[string]string{"Content-Type": "application/json"}, &response, "")
+	if e != nil {
+		return fmt.Errorf("could not send message: %s", e)
+	}
+
+	if !response.Ok {
+		return fmt.Errorf("telegram responded with an error, check that the bot token and chat ID are correct: %s", response.Description)
+	}
+	return nil
+}
+
+// chunkMessage splits the message into chunks with at most maxLength characters each
+func chunkMessage(message string, maxLength int) []string {
+	runes := []rune(message)
+	if len(runes) <= maxLength {
+		return []string{message}
+	}
+
+	chunks := []string{}
+	for len(runes) > maxLength {
+		chunks = append(chunks, string(runes[:maxLength]))
+		runes = runes[maxLength:]
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -0,0 +1,117 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkMessage(t *testing.T) {
+	testCases := []struct {
+		message   string
+		maxLength int
+		want      []string
+	}{
+		{
+			message:   "hello",
+			maxLength: 10,
+			want:      []string{"hello"},
+		}, {
+			message:   "hello",
+			maxLength: 5,
+			want:      []string{"hello"},
+		}, {
+			message:   "hello world",
+			maxLength: 5,
+			want:      []string{"hello", " worl", "d"},
+		}, {
+			message:   "héllo wörld",
+			maxLength: 4,
+			want:      []string{"héll", "o wö", "rld"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.message, func(t *testing.T) {
+			assert.Equal(t, k.want, chunkMessage(k.message, k.maxLength))
+		})
+	}
+}
+
+func TestTriggerTelegram(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		description   string
+		details       interface{}
+		response      string
+		wantTexts     []string
+		errorExpected bool
+	}{
+		{
+			testName:      "Tests that a Telegram alert is sent",
+			description:   "Testing monitoring package. Not a real incident!",
+			details:       map[string]int{"num_requests": 100},
+			response:      `{"ok":true}`,
+			wantTexts:     []string{"[WARNING] Testing monitoring package. Not a real incident!\n\n{\n  \"num_requests\": 100\n}"},
+			errorExpected: false,
+		}, {
+			testName:      "Tests that long messages are chunked",
+			description:   strings.Repeat("a", telegramMaxMessageLength-len("[WARNING] ")+1),
+			details:       nil,
+			response:      `{"ok":true}`,
+			wantTexts:     []string{"[WARNING] " + strings.Repeat("a", telegramMaxMessageLength-len("[WARNING] ")), "a"},
+			errorExpected: false,
+		}, {
+			testName:      "Tests that an error response causes an error",
+			description:   "Testing monitoring package. Not a real incident!",
+			details:       nil,
+			response:      `{"ok":false,"description":"Bad Request: chat not found"}`,
+			wantTexts:     []string{"[WARNING] Testing monitoring package. Not a real incident!"},
+			errorExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			gotTexts := []string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/botbotToken/sendMessage", r.URL.Path)
+				body, _ := ioutil.ReadAll(r.Body)
+				var req telegramSendMessageRequest
+				_ = json.Unmarshal(body, &req)
+				assert.Equal(t, "chatID", req.ChatID)
+				gotTexts = append(gotTexts, req.Text)
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			alert, e := makeTelegram("botToken", "chatID")
+			if !assert.Nil(t, e) {
+				return
+			}
+			// requests to Telegram should never hang the bot
+			assert.Equal(t, telegramTimeout, alert.(*telegram).httpClient.Timeout)
+			alert.(*telegram).baseURL = server.URL
+
+			_, e = alert.Trigger(tc.description, tc.details)
+			if tc.errorExpected {
+				assert.NotNil(t, e)
+			} else {
+				assert.Nil(t, e)
+			}
+			assert.Equal(t, tc.wantTexts, gotTexts)
+		})
+	}
+}
+
+func TestMakeTelegramNeedsChatID(t *testing.T) {
+	_, e := MakeAlertWithOptions("Telegram", "botToken", AlertOptions{})
+	assert.NotNil(t, e)
+}
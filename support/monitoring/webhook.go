@@ -0,0 +1,104 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+const webhookTimeout = 10 * time.Second
+
+type webhook struct {
+	url         string
+	method      string
+	bearerToken string
+	hostname    string
+	httpClient  *http.Client
+}
+
+// ensure webhook implements the api.Alert interface
+var _ api.Alert = &webhook{}
+
+type webhookBody struct {
+	Severity    string      `json:"severity"`
+	Description string      `json:"description"`
+	Details     interface{} `json:"details"`
+	Timestamp   string      `json:"timestamp"`
+	Hostname    string      `json:"hostname"`
+}
+
+func makeWebhook(url string, method string, bearerToken string) (api.Alert, error) {
+	if method == "" {
+		method = http.MethodPost
+	}
+	if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch {
+		return nil, fmt.Errorf("invalid method '%s' for the webhook alert, needs to be one of POST, PUT, or PATCH", method)
+	}
+
+	hostname, e := os.Hostname()
+	if e != nil {
+		log.Printf("could not get hostname for the webhook alert, continuing without it: %s\n", e)
+		hostname = ""
+	}
+
+	return &webhook{
+		url:         url,
+		method:      method,
+		bearerToken: bearerToken,
+		hostname:    hostname,
+		httpClient:  &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// Trigger sends the alert to the webhook URL with the api.DefaultAlertSeverity.
+func (w *webhook) Trigger(description string, details interface{}) (string, error) {
+	return w.TriggerWithSeverity(api.DefaultAlertSeverity, description, details)
+}
+
+// TriggerWithSeverity sends the description and details to the webhook URL as a JSON body, along with the time and the hostname of this machine.
+func (w *webhook) TriggerWithSeverity(severity api.AlertSeverity, description string, details interface{}) (string, error) {
+	body, e := json.Marshal(webhookBody{
+		Severity:    severity.String(),
+		Description: description,
+		Details:     details,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Hostname:    w.hostname,
+	})
+	if e != nil {
+		return "", fmt.Errorf("could not marshal webhook body: %s", e)
+	}
+
+	req, e := http.NewRequest(w.method, w.url, bytes.NewReader(body))
+	if e != nil {
+		return "", fmt.Errorf("could not create webhook request: %s", e)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.bearerToken)
+	}
+
+	resp, e := w.httpClient.Do(req)
+	if e != nil {
+		return "", fmt.Errorf("encountered an error while sending a webhook alert: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("webhook responded with a non-2xx status code (%d): %s", resp.StatusCode, string(respBody))
+	}
+	log.Printf("Triggered webhook alert\n")
+	return "", nil
+}
+
+// Resolve is a noop because webhook alerts cannot be resolved
+func (w *webhook) Resolve(dedupKey string) error {
+	return nil
+}
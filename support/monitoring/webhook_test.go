@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerWebhook(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		options        AlertOptions
+		responseStatus int
+		wantMethod     string
+		wantAuth       string
+		errorExpected  bool
+	}{
+		{
+			testName:       "Tests that the default method is POST without an auth header",
+			options:        AlertOptions{},
+			responseStatus: http.StatusOK,
+			wantMethod:     "POST",
+			wantAuth:       "",
+			errorExpected:  false,
+		}, {
+			testName:       "Tests that the method and bearer token can be configured",
+			options:        AlertOptions{WebhookMethod: "PUT", WebhookBearerToken: "token123"},
+			responseStatus: http.StatusNoContent,
+			wantMethod:     "PUT",
+			wantAuth:       "Bearer token123",
+			errorExpected:  false,
+		}, {
+			testName:       "Tests that a non-2xx response causes an error",
+			options:        AlertOptions{},
+			responseStatus: http.StatusInternalServerError,
+			wantMethod:     "POST",
+			wantAuth:       "",
+			errorExpected:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var gotMethod, gotAuth string
+			var gotBody webhookBody
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod = r.Method
+				gotAuth = r.Header.Get("Authorization")
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotBody)
+				w.WriteHeader(tc.responseStatus)
+			}))
+			defer server.Close()
+
+			alert, e := MakeAlertWithOptions("Webhook", server.URL, tc.options)
+			if !assert.Nil(t, e) {
+				return
+			}
+			// requests to the webhook should never hang the bot
+			assert.Equal(t, webhookTimeout, alert.(*webhook).httpClient.Timeout)
+			_, e = alert.Trigger("Testing monitoring package. Not a real incident!", map[string]interface{}{"num_requests": 100.0})
+			if tc.errorExpected {
+				assert.NotNil(t, e)
+			} else {
+				assert.Nil(t, e)
+			}
+
+			assert.Equal(t, tc.wantMethod, gotMethod)
+			assert.Equal(t, tc.wantAuth, gotAuth)
+			assert.Equal(t, "warning", gotBody.Severity)
+			assert.Equal(t, "Testing monitoring package. Not a real incident!", gotBody.Description)
+			assert.Equal(t, map[string]interface{}{"num_requests": 100.0}, gotBody.Details)
+			_, e = time.Parse(time.RFC3339, gotBody.Timestamp)
+			assert.Nil(t, e)
+		})
+	}
+}
+
+func TestMakeWebhookInvalidMethod(t *testing.T) {
+	_, e := MakeAlertWithOptions("Webhook", "http://localhost", AlertOptions{WebhookMethod: "GET"})
+	assert.NotNil(t, e)
+}
@@ -1,6 +1,7 @@
 package networking
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,25 @@ import (
 	"github.com/cavaliercoder/grab"
 )
 
+// StatusCodeError is returned by JSONRequest when a response was received from the server but it could not be used
+type StatusCodeError struct {
+	StatusCode int
+	msg        string
+}
+
+// Error impl.
+func (e *StatusCodeError) Error() string {
+	return e.msg
+}
+
+// makeStatusCodeError wraps the formatted error message along with the status code of the response
+func makeStatusCodeError(statusCode int, format string, args ...interface{}) error {
+	return &StatusCodeError{
+		StatusCode: statusCode,
+		msg:        fmt.Sprintf(format, args...),
+	}
+}
+
 // JSONRequestDynamicHeaders submits an HTTP web request and parses the response into the responseData object as JSON
 func JSONRequestDynamicHeaders(
 	httpClient *http.Client,
@@ -23,13 +43,28 @@ func JSONRequestDynamicHeaders(
 	headers map[string]HeaderFn,
 	responseData interface{}, // the passed in responseData should be a pointer
 	errorKey string,
+) error {
+	return JSONRequestDynamicHeadersContext(context.Background(), httpClient, method, reqURL, data, headers, responseData, errorKey)
+}
+
+// JSONRequestDynamicHeadersContext is the same as JSONRequestDynamicHeaders but the request is cancelled when the context is done
+func JSONRequestDynamicHeadersContext(
+	ctx context.Context,
+	httpClient *http.Client,
+	method string,
+	reqURL string,
+	data string,
+	headers map[string]HeaderFn,
+	responseData interface{}, // the passed in responseData should be a pointer
+	errorKey string,
 ) error {
 	headersMap := map[string]string{}
 	for header, fn := range headers {
 		headersMap[header] = fn(method, reqURL, data)
 	}
 
-	return JSONRequest(
+	return JSONRequestContext(
+		ctx,
 		httpClient,
 		method,
 		reqURL,
@@ -49,9 +84,23 @@ func JSONRequest(
 	headers map[string]string,
 	responseData interface{}, // the passed in responseData should be a pointer
 	errorKey string,
+) error {
+	return JSONRequestContext(context.Background(), httpClient, method, reqURL, data, headers, responseData, errorKey)
+}
+
+// JSONRequestContext is the same as JSONRequest but the request is cancelled when the context is done
+func JSONRequestContext(
+	ctx context.Context,
+	httpClient *http.Client,
+	method string,
+	reqURL string,
+	data string,
+	headers map[string]string,
+	responseData interface{}, // the passed in responseData should be a pointer
+	errorKey string,
 ) error {
 	// create http request
-	req, e := http.NewRequest(method, reqURL, strings.NewReader(data))
+	req, e := http.NewRequestWithContext(ctx, method, reqURL, strings.NewReader(data))
 	if e != nil {
 		return fmt.Errorf("could not create http request: %s", e)
 	}
@@ -71,30 +120,30 @@ func JSONRequest(
 	// read response
 	body, e := ioutil.ReadAll(resp.Body)
 	if e != nil {
-		return fmt.Errorf("could not read http response: %s", e)
+		return makeStatusCodeError(resp.StatusCode, "could not read http response: %s", e)
 	}
 	bodyString := string(body)
 
 	// ensure Content-Type is json
 	contentType, _, e := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if e != nil {
-		return fmt.Errorf("could not read 'Content-Type' header in http response: %s | response body: %s", e, bodyString)
+		return makeStatusCodeError(resp.StatusCode, "could not read 'Content-Type' header in http response: %s | response body: %s", e, bodyString)
 	}
 	if contentType != "application/json" && contentType != "application/hal+json" {
-		return fmt.Errorf("invalid 'Content-Type' header in http response ('%s'), expecting 'application/json' or 'application/hal+json', response body: %s", contentType, bodyString)
+		return makeStatusCodeError(resp.StatusCode, "invalid 'Content-Type' header in http response ('%s'), expecting 'application/json' or 'application/hal+json', response body: %s", contentType, bodyString)
 	}
 
 	if errorKey != "" {
 		var errorResponse interface{}
 		e = json.Unmarshal(body, &errorResponse)
 		if e != nil {
-			return fmt.Errorf("could not unmarshall response body to check for an error response: %s | bodyString: %s", e, bodyString)
+			return makeStatusCodeError(resp.StatusCode, "could not unmarshall response body to check for an error response: %s | bodyString: %s", e, bodyString)
 		}
 
 		switch er := errorResponse.(type) {
 		case map[string]interface{}:
 			if _, ok := er[errorKey]; ok {
-				return fmt.Errorf("error in response, bodyString: %s", bodyString)
+				return makeStatusCodeError(resp.StatusCode, "error in response, bodyString: %s", bodyString)
 			}
 		}
 	}
@@ -103,7 +152,7 @@ func JSONRequest(
 		// parse response, the passed in responseData should be a pointer
 		e = json.Unmarshal(body, responseData)
 		if e != nil {
-			return fmt.Errorf("could not unmarshall response body into json: %s | response body: %s", e, bodyString)
+			return makeStatusCodeError(resp.StatusCode, "could not unmarshall response body into json: %s | response body: %s", e, bodyString)
 		}
 	}
 
@@ -1,9 +1,11 @@
 package postgresdb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // TimestampFormatString is the format to be used when inserting timestamps in the database
@@ -49,3 +51,18 @@ func ExecuteStatement(db *sql.DB, sqlStatement string) error {
 
 	return nil
 }
+
+// Ping verifies that a connection to the database can be established within the timeout
+func Ping(db *sql.DB, timeout time.Duration) error {
+	if db == nil {
+		return fmt.Errorf("the provided db should be non-nil")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	e := db.PingContext(ctx)
+	if e != nil {
+		return fmt.Errorf("could not ping db: %s", e)
+	}
+	return nil
+}
@@ -1,17 +1,29 @@
 package sdk
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/logger"
 	"github.com/stellar/kelp/support/networking"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -21,25 +33,92 @@ var ccxtBaseURL = "http://localhost:3000"
 
 // SetBaseURL allows setting the base URL for ccxt
 func SetBaseURL(baseURL string) error {
-	ccxtBaseURL = strings.TrimSuffix(baseURL, "/")
+	normalized, e := normalizeCcxtBaseURL(baseURL)
+	if e != nil {
+		return fmt.Errorf("invalid ccxt base URL: %w", e)
+	}
+	ccxtBaseURL = normalized
 	log.Printf("updated ccxtBaseURL to '%s'\n", ccxtBaseURL)
 	return nil
 }
 
+// normalizeCcxtBaseURL trims a single trailing '/' so paths can be appended to the base URL, and rejects URLs without a scheme or host
+func normalizeCcxtBaseURL(baseURL string) (string, error) {
+	parsed, e := url.Parse(baseURL)
+	if e != nil {
+		return "", fmt.Errorf("could not parse URL '%s': %w", baseURL, e)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("URL '%s' needs a scheme and a host, eg. http://localhost:3000", baseURL)
+	}
+	return strings.TrimSuffix(baseURL, "/"), nil
+}
+
+// legacyInstanceNames uses the old scheme that only hashes the API key when naming instances on the CCXT REST server
+var legacyInstanceNames = false
+
+// SetLegacyInstanceNames allows using the old naming scheme for instances so instances created by older versions of kelp are reused
+func SetLegacyInstanceNames(useLegacy bool) {
+	legacyInstanceNames = useLegacy
+	log.Printf("updated legacyInstanceNames to %v\n", legacyInstanceNames)
+}
+
 // GetBaseURL returns the base URL for ccxt
 func GetBaseURL() string {
 	return ccxtBaseURL
 }
 
 // Ccxt Rest SDK (https://github.com/franz-see/ccxt-rest, https://github.com/ccxt/ccxt/)
+//
+// A Ccxt is safe for concurrent use by multiple goroutines once it is returned by MakeInitializedCcxtExchange. Requests do not modify the
+// Ccxt apart from the cached markets, capabilities and symbols, which are guarded by cacheLock because they are replaced when the markets
+// are refreshed or the instance is recreated. All other fields are only set during initialization
 type Ccxt struct {
-	httpClient   *http.Client
-	exchangeName string
-	instanceName string
-	markets      map[string]CcxtMarket
-	headersMap   map[string]networking.HeaderFn
+	httpClient     *http.Client
+	timeout        time.Duration
+	proxyURL       string
+	transport      *http.Transport
+	maxRetries     int
+	retryBaseDelay time.Duration
+	logger         logger.Logger
+	rateLimit      float64
+	rateLimiter    *rateLimiter
+	exchangeName   string
+	instanceName   string
+	cacheLock      sync.RWMutex // guards markets, has and symbols
+	markets        map[string]CcxtMarket
+	headersMap     map[string]networking.HeaderFn
+	restHeaders    map[string]string // static headers that are sent with every request to the CCXT REST server
+	has            map[string]interface{}
+	symbols        []string
+
+	// used in place of the hashed instance name, see WithInstanceName and WithInstanceNamePrefix
+	instanceNameOverride string
+	instanceNamePrefix   string
+
+	// sorted list of the orderbook limits supported by the exchange, empty if any limit is supported
+	orderBookLimits []int
+
+	// used to recreate the instance when it no longer exists on the CCXT server, such as after the server was restarted
+	apiKey         api.ExchangeAPIKey
+	params         []api.ExchangeParam
+	reinitializing int32 // accessed atomically, set to 1 while the instance is being recreated
+
+	// used to delete the instance from the CCXT server, see Close
+	createdInstance bool  // true when initialize created the instance rather than reusing an existing instance
+	closed          int32 // accessed atomically, set to 1 once Close was called
 }
 
+// instanceRef tracks the use of an instance on the CCXT server by the Ccxt objects in this process
+type instanceRef struct {
+	users   int
+	created bool // true when the instance was created by this process rather than reused
+}
+
+// instanceRefs is keyed by the instance URL so an instance is only deleted once none of the Ccxt objects in this process use it
+var instanceRefs = map[string]*instanceRef{}
+var instanceRefsLock = &sync.Mutex{}
+
 // CcxtMarket represents the result of a LoadMarkets call
 type CcxtMarket struct {
 	// only contains currently needed data
@@ -58,51 +137,284 @@ type CcxtMarket struct {
 		} `json:"cost"`
 	} `json:"limits"`
 	Precision struct {
-		Amount int8 `json:"amount"`
-		Price  int8 `json:"price"`
+		Amount *int8 `json:"amount"` // nil when the market does not have the precision, 0 means whole units
+		Price  *int8 `json:"price"`  // nil when the market does not have the precision, 0 means whole units
 	} `json:"precision"`
+	Taker float64 `json:"taker"` // taker fee as a fraction of the cost, i.e. 0.001 is 0.1%
+	Maker float64 `json:"maker"` // maker fee as a fraction of the cost, i.e. 0.001 is 0.1%
 }
 
 const pathExchanges = "/exchanges"
 
+const instanceKeyHashLength = 16
+const maxInstanceNameLength = 128
+const defaultMaxRetries = 3
+const defaultRetryBaseDelay = 500 * time.Millisecond
+const maxFetchTradesRangeIterations = 100
+
+// instanceNameRegex matches the names that can be used in the path of the instance on the CCXT server
+var instanceNameRegex = regexp.MustCompile("^[a-zA-Z0-9._-]+$")
+
+// knownOrderBookLimits are the only orderbook limits accepted by these exchanges, CCXT does not include them in the market metadata
+var knownOrderBookLimits = map[string][]int{
+	"binance": {5, 10, 20, 50, 100, 500, 1000, 5000},
+}
+
+// CcxtOption sets an optional value on the Ccxt instance when it is constructed
+type CcxtOption func(c *Ccxt)
+
+// WithHTTPClient sets the http client used for all requests to the CCXT REST server, defaults to http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) CcxtOption {
+	return func(c *Ccxt) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithProxy routes all requests to the CCXT REST server through the proxy, which can be an http, https or socks5 URL. Defaults to the
+// value of the HTTPS_PROXY env var when neither a proxy nor a transport is specified
+func WithProxy(proxyURL string) CcxtOption {
+	return func(c *Ccxt) {
+		c.proxyURL = proxyURL
+	}
+}
+
+// WithTransport sets the transport used for all requests to the CCXT REST server, use this for full control over proxies and connections.
+// This takes precedence over WithProxy
+func WithTransport(transport *http.Transport) CcxtOption {
+	return func(c *Ccxt) {
+		c.transport = transport
+	}
+}
+
+// WithTimeout sets a timeout on each request to the CCXT REST server, the default is to have no timeout
+func WithTimeout(timeout time.Duration) CcxtOption {
+	return func(c *Ccxt) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets the number of times a failed fetch request is retried and the base delay which is doubled after every attempt,
+// a maxRetries of 0 disables retries. Defaults to 3 retries with a base delay of 500ms
+func WithRetries(maxRetries int, baseDelay time.Duration) CcxtOption {
+	return func(c *Ccxt) {
+		c.maxRetries = maxRetries
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// WithLogger sets the logger used for the messages of this instance, such as when the instance is created or a request is retried.
+// Defaults to a basic logger that uses the standard log package
+func WithLogger(l logger.Logger) CcxtOption {
+	return func(c *Ccxt) {
+		c.logger = l
+	}
+}
+
+// log returns the logger of this instance, falling back to a basic logger for instances that were not made with the constructor
+func (c *Ccxt) log() logger.Logger {
+	if c.logger == nil {
+		return logger.MakeBasicLogger()
+	}
+	return c.logger
+}
+
+// WithRateLimit limits the requests to the exchange to requestsPerSecond, with bursts of up to requestsPerSecond requests. The limit
+// is shared by all Ccxt instances of the same exchange. Defaults to no limit
+func WithRateLimit(requestsPerSecond float64) CcxtOption {
+	return func(c *Ccxt) {
+		c.rateLimit = requestsPerSecond
+	}
+}
+
+// WithOrderBookLimits sets the orderbook limits supported by the exchange, FetchOrderBook clamps the requested limit to one of these values.
+// Defaults to the known limits of the exchange, or no clamping if the limits of the exchange are not known
+func WithOrderBookLimits(limits ...int) CcxtOption {
+	return func(c *Ccxt) {
+		c.orderBookLimits = limits
+	}
+}
+
+// WithRestHeaders sets static headers that are sent with every request to the CCXT REST server, such as an Authorization header when the
+// server is behind an authenticating reverse proxy. The headers are merged with the exchange headers passed to the constructor, which take
+// precedence when both specify the same header
+func WithRestHeaders(headers map[string]string) CcxtOption {
+	return func(c *Ccxt) {
+		c.restHeaders = headers
+	}
+}
+
+// WithInstanceName uses the name verbatim as the name of the instance on the CCXT REST server instead of the name derived from the hash
+// of the API key, params and headers. This gives instances human-readable names and lets bots share an instance intentionally. The name
+// can only contain letters, digits, '.', '_' and '-'
+func WithInstanceName(name string) CcxtOption {
+	return func(c *Ccxt) {
+		c.instanceNameOverride = name
+	}
+}
+
+// WithInstanceNamePrefix prepends the prefix and an underscore to the name derived from the hash of the API key, params and headers so
+// instances can be identified on the CCXT REST server. The prefix can only contain letters, digits, '.', '_' and '-'
+func WithInstanceNamePrefix(prefix string) CcxtOption {
+	return func(c *Ccxt) {
+		c.instanceNamePrefix = prefix
+	}
+}
+
 // MakeInitializedCcxtExchange constructs an instance of Ccxt that is bound to a specific exchange instance on the CCXT REST server
-func MakeInitializedCcxtExchange(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) (*Ccxt, error) {
-	if strings.HasSuffix(ccxtBaseURL, "/") {
-		return nil, fmt.Errorf("invalid format for ccxtBaseURL: %s", ccxtBaseURL)
+func MakeInitializedCcxtExchange(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader, options ...CcxtOption) (*Ccxt, error) {
+	return MakeInitializedCcxtExchangeContext(context.Background(), exchangeName, apiKey, params, headers, options...)
+}
+
+// MakeInitializedCcxtExchangeContext is the same as MakeInitializedCcxtExchange but the requests made during initialization are cancelled when the context is done
+func MakeInitializedCcxtExchangeContext(ctx context.Context, exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader, options ...CcxtOption) (*Ccxt, error) {
+	normalizedBaseURL, e := normalizeCcxtBaseURL(ccxtBaseURL)
+	if e != nil {
+		return nil, fmt.Errorf("invalid format for ccxtBaseURL: %w", e)
 	}
+	ccxtBaseURL = normalizedBaseURL
 
-	instanceName, e := makeInstanceName(exchangeName, apiKey, params, headers)
+	// resolve the secrets before hashing so the instance name is based on the actual key and not on the reference
+	apiKey, e = resolveAPIKey(apiKey)
 	if e != nil {
-		return nil, fmt.Errorf("cannot make instance name: %s", e)
+		return nil, fmt.Errorf("cannot resolve the secrets of the api key: %w", e)
+	}
+	instanceName, e := makeInstanceName(exchangeName, apiKey, params, headers, legacyInstanceNames)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make instance name: %w", e)
 	}
 	c := &Ccxt{
-		httpClient:   http.DefaultClient,
-		exchangeName: exchangeName,
-		instanceName: instanceName,
+		httpClient:     http.DefaultClient,
+		maxRetries:     defaultMaxRetries,
+		retryBaseDelay: defaultRetryBaseDelay,
+		logger:         logger.MakeBasicLogger(),
+		exchangeName:   exchangeName,
+		instanceName:   instanceName,
+		apiKey:         apiKey,
+		params:         params,
+	}
+	if limits, ok := knownOrderBookLimits[exchangeName]; ok {
+		c.orderBookLimits = limits
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.logger == nil {
+		c.logger = logger.MakeBasicLogger()
+	}
+	c.instanceName, e = applyInstanceNameOptions(c.instanceName, c.instanceNameOverride, c.instanceNamePrefix)
+	if e != nil {
+		return nil, fmt.Errorf("invalid instance name: %w", e)
+	}
+	if c.rateLimit < 0 {
+		return nil, fmt.Errorf("the rate limit cannot be negative, was %f", c.rateLimit)
+	}
+	if c.rateLimit > 0 {
+		c.rateLimiter = getRateLimiter(exchangeName, c.rateLimit)
+	}
+	for _, limit := range c.orderBookLimits {
+		if limit <= 0 {
+			return nil, fmt.Errorf("the orderbook limits need to be positive, was %v", c.orderBookLimits)
+		}
+	}
+	restHeaders := map[string]string{}
+	for header, value := range c.restHeaders {
+		if strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("the rest headers cannot have an empty header name")
+		}
+		restHeaders[header] = value
+	}
+	// copy so later changes to the caller's map are not sent
+	c.restHeaders = restHeaders
+	// copy before sorting so we don't modify the caller's slice or the known limits
+	c.orderBookLimits = append([]int{}, c.orderBookLimits...)
+	sort.Ints(c.orderBookLimits)
+	e = c.configureTransport()
+	if e != nil {
+		return nil, fmt.Errorf("cannot configure transport: %w", e)
+	}
+	if c.timeout > 0 {
+		// copy the client so we don't modify a client that may be shared, such as http.DefaultClient
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
 	}
 
-	e = c.initialize(apiKey, params, headers)
+	e = c.initialize(ctx, apiKey, params, headers)
 	if e != nil {
-		return nil, fmt.Errorf("error when initializing Ccxt exchange: %s", e)
+		return nil, fmt.Errorf("error when initializing Ccxt exchange: %w", e)
 	}
+	c.acquireInstance()
 
 	return c, nil
 }
 
+// configureTransport sets the transport on the http client when a transport or proxy was specified. When neither was specified and the
+// http client uses the default transport then it uses the proxy from the HTTPS_PROXY env var, if set
+func (c *Ccxt) configureTransport() error {
+	transport := c.transport
+	if transport == nil {
+		proxyURL := c.proxyURL
+		if proxyURL == "" && c.httpClient.Transport == nil {
+			proxyURL = getProxyFromEnv()
+		}
+		if proxyURL == "" {
+			return nil
+		}
+
+		parsedProxyURL, e := parseProxyURL(proxyURL)
+		if e != nil {
+			return e
+		}
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(parsedProxyURL)
+		c.log().Infof("requests to CCXT will use the proxy at host '%s'\n", parsedProxyURL.Host)
+	}
+
+	// copy the client so we don't modify a client that may be shared, such as http.DefaultClient
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
+func getProxyFromEnv() string {
+	if v := os.Getenv("HTTPS_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("https_proxy")
+}
+
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	parsed, e := url.Parse(proxyURL)
+	if e != nil {
+		// don't include the error since it contains the URL, which may have credentials
+		return nil, fmt.Errorf("could not parse proxy URL")
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s' in proxy URL, needs to be one of http, https, socks5 or socks5h", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("proxy URL needs a host")
+	}
+	return parsed, nil
+}
+
 // exchangeList contains a list of supported exchanges
 var exchangeList *[]string
 
 // GetExchangeList gets a list of all supported exchanges
 func GetExchangeList() []string {
 	if exchangeList == nil {
-		loadExchangeList()
+		loadExchangeList(map[string]string{})
 	}
 	return *exchangeList
 }
 
-func loadExchangeList() {
+func loadExchangeList(headers map[string]string) {
 	var output []string
-	e := networking.JSONRequest(http.DefaultClient, "GET", ccxtBaseURL+pathExchanges, "", map[string]string{}, &output, "error")
+	e := networking.JSONRequest(http.DefaultClient, "GET", ccxtBaseURL+pathExchanges, "", headers, &output, "error")
 	if e != nil {
 		eMsg1 := strings.Contains(e.Error(), "could not execute http request")
 		eMsg2 := strings.Contains(e.Error(), ccxtBaseURL+"/exchanges: dial tcp")
@@ -116,10 +428,17 @@ func loadExchangeList() {
 	exchangeList = &output
 }
 
-func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) error {
+func (c *Ccxt) initialize(ctx context.Context, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) error {
+	// the rest headers are needed from the first request since the CCXT REST server may be behind an authenticating proxy
+	headersMap := makeStaticHeaderFns(c.restHeaders)
+	c.headersMap = headersMap
+
 	// validate that exchange name is in the exchange list
+	if exchangeList == nil {
+		loadExchangeList(c.restHeaders)
+	}
 	exchangeListed := false
-	el := GetExchangeList()
+	el := *exchangeList
 	for _, name := range el {
 		if name == c.exchangeName {
 			exchangeListed = true
@@ -132,60 +451,411 @@ func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam,
 
 	// list all the instances of the exchange
 	var instanceList []string
-	e := networking.JSONRequest(c.httpClient, "GET", ccxtBaseURL+pathExchanges+"/"+c.exchangeName, "", map[string]string{}, &instanceList, "error")
+	e := c.requestWithRetry(ctx, "GET", ccxtBaseURL+pathExchanges+"/"+c.exchangeName, "", &instanceList)
 	if e != nil {
-		return fmt.Errorf("error getting list of exchange instances for exchange '%s': %s", c.exchangeName, e)
+		return fmt.Errorf("error getting list of exchange instances for exchange '%s': %w", c.exchangeName, e)
 	}
 
 	// make a new instance if needed
 	if !c.hasInstance(instanceList) {
-		e = c.newInstance(apiKey, params)
+		e = c.newInstance(ctx, apiKey, params)
 		if e != nil {
-			return fmt.Errorf("error creating new instance '%s' for exchange '%s': %s", c.instanceName, c.exchangeName, e)
+			return fmt.Errorf("error creating new instance '%s' for exchange '%s': %w", c.instanceName, c.exchangeName, e)
 		}
-		log.Printf("created new instance '%s' for exchange '%s'\n", c.instanceName, c.exchangeName)
+		c.createdInstance = true
+		c.log().Infof("created new instance '%s' for exchange '%s'\n", c.instanceName, c.exchangeName)
 	} else {
-		log.Printf("instance '%s' for exchange '%s' already exists\n", c.instanceName, c.exchangeName)
+		c.log().Infof("instance '%s' for exchange '%s' already exists\n", c.instanceName, c.exchangeName)
 	}
 
 	// load markets to populate fields related to markets
+	e = c.loadMarkets(ctx, false)
+	if e != nil {
+		return fmt.Errorf("error loading markets: %w", e)
+	}
+
+	// the exchange headers are added to the rest headers
+	headersMap = makeStaticHeaderFns(c.restHeaders)
+	ccxtHeaderMappings := makeHeaderMappingsFromNewTimestamp()
+	for _, header := range headers {
+		headerFn, e := networking.MakeHeaderFn(header.Value, ccxtHeaderMappings)
+		if e != nil {
+			return fmt.Errorf("unable to make header function with key (%s) and value (%s): %w", header.Header, header.Value, e)
+		}
+		headersMap[header.Header] = headerFn
+	}
+	c.headersMap = headersMap
+
+	// load the capabilities and symbols of the exchange once so we don't need to fetch them on every call
+	e = c.loadExchangeDetails(ctx)
+	if e != nil {
+		return fmt.Errorf("error loading details for exchange instance (exchange=%s, instanceName=%s): %w", c.exchangeName, c.instanceName, e)
+	}
+
+	return nil
+}
+
+// makeStaticHeaderFns makes a header function for each header that always returns the value of the header
+func makeStaticHeaderFns(headers map[string]string) map[string]networking.HeaderFn {
+	headersMap := map[string]networking.HeaderFn{}
+	for header, value := range headers {
+		value := value
+		headersMap[header] = func(method string, requestURL string, body string) string {
+			return value
+		}
+	}
+	return headersMap
+}
+
+// instanceURL is the URL of the instance on the CCXT server
+func (c *Ccxt) instanceURL() string {
+	return ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+}
+
+// acquireInstance records that this Ccxt uses the instance
+func (c *Ccxt) acquireInstance() {
+	instanceRefsLock.Lock()
+	defer instanceRefsLock.Unlock()
+
+	ref, ok := instanceRefs[c.instanceURL()]
+	if !ok {
+		ref = &instanceRef{}
+		instanceRefs[c.instanceURL()] = ref
+	}
+	ref.users++
+	ref.created = ref.created || c.createdInstance
+}
+
+// releaseInstance records that this Ccxt no longer uses the instance and returns true if the instance should be deleted, which is when
+// the instance was created by this process and is not used by any other Ccxt in this process
+func (c *Ccxt) releaseInstance() bool {
+	instanceRefsLock.Lock()
+	defer instanceRefsLock.Unlock()
+
+	ref, ok := instanceRefs[c.instanceURL()]
+	if !ok {
+		return false
+	}
+	ref.users--
+	if ref.users > 0 {
+		return false
+	}
+	delete(instanceRefs, c.instanceURL())
+	return ref.created
+}
+
+// Close deletes the instance from the CCXT server when the bot shuts down so instances are not orphaned on long-running servers.
+// Instances that already existed when this process started may be shared with other running bots, and instances still used by another
+// Ccxt in this process are in use, so neither of those is deleted. Close is idempotent and the Ccxt should not be used after it is closed
+func (c *Ccxt) Close() error {
+	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		return nil
+	}
+
+	if !c.releaseInstance() {
+		c.log().Infof("not deleting instance '%s' of exchange '%s' because it may be shared\n", c.instanceName, c.exchangeName)
+		return nil
+	}
+	return c.DeleteInstance()
+}
+
+// DeleteInstance deletes the instance from the CCXT server, it does not return an error if the instance no longer exists
+func (c *Ccxt) DeleteInstance() error {
+	// use requestOnce because request would recreate the instance if it does not exist
+	e := c.requestOnce(context.Background(), "DELETE", c.instanceURL(), "", nil)
+	if e != nil {
+		var statusCodeError *networking.StatusCodeError
+		if errors.As(e, &statusCodeError) && statusCodeError.StatusCode == http.StatusNotFound {
+			c.log().Infof("instance '%s' of exchange '%s' was already deleted\n", c.instanceName, c.exchangeName)
+			return nil
+		}
+		return fmt.Errorf("error deleting instance '%s' of exchange '%s': %w", c.instanceName, c.exchangeName, e)
+	}
+	c.log().Infof("deleted instance '%s' of exchange '%s'\n", c.instanceName, c.exchangeName)
+	return nil
+}
+
+// loadMarkets calls the /loadMarkets endpoint on CCXT and sets the markets on the ccxt instance, reload forces CCXT to refetch the markets from the exchange
+func (c *Ccxt) loadMarkets(ctx context.Context, reload bool) error {
+	data := ""
+	if reload {
+		data = "[true]"
+	}
+
 	var marketsResponse interface{}
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/loadMarkets"
-	e = networking.JSONRequest(c.httpClient, "POST", url, "", map[string]string{}, &marketsResponse, "error")
+	e := c.requestWithRetry(ctx, "POST", url, data, &marketsResponse)
 	if e != nil {
-		return fmt.Errorf("error loading markets for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+		return fmt.Errorf("error loading markets for exchange instance (exchange=%s, instanceName=%s): %w", c.exchangeName, c.instanceName, e)
 	}
 	// decode markets and sets it on the ccxt instance
 	var markets map[string]CcxtMarket
 	e = mapstructure.Decode(marketsResponse, &markets)
 	if e != nil {
-		return fmt.Errorf("error converting loadMarkets output to a map of Market for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+		return fmt.Errorf("error converting loadMarkets output to a map of Market for exchange instance (exchange=%s, instanceName=%s): %w", c.exchangeName, c.instanceName, e)
 	}
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
 	c.markets = markets
+	return nil
+}
 
-	headersMap := map[string]networking.HeaderFn{}
-	ccxtHeaderMappings := makeHeaderMappingsFromNewTimestamp()
-	for _, header := range headers {
-		headerFn, e := networking.MakeHeaderFn(header.Value, ccxtHeaderMappings)
+// RefreshMarkets reloads the markets and the cached list of symbols, use this when new listings are expected on the exchange
+func (c *Ccxt) RefreshMarkets() error {
+	ctx := context.Background()
+	e := c.loadMarkets(ctx, true)
+	if e != nil {
+		return fmt.Errorf("error reloading markets: %w", e)
+	}
+
+	e = c.loadExchangeDetails(ctx)
+	if e != nil {
+		return fmt.Errorf("error reloading details for exchange instance (exchange=%s, instanceName=%s): %w", c.exchangeName, c.instanceName, e)
+	}
+	c.cacheLock.RLock()
+	numMarkets, numSymbols := len(c.markets), len(c.symbols)
+	c.cacheLock.RUnlock()
+	c.log().Infof("refreshed markets for instance '%s' of exchange '%s': %d markets, %d symbols\n", c.instanceName, c.exchangeName, numMarkets, numSymbols)
+	return nil
+}
+
+// loadExchangeDetails reads the "has" and "symbols" fields from the details of the exchange instance
+func (c *Ccxt) loadExchangeDetails(ctx context.Context) error {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var exchangeOutput interface{}
+	e := c.requestWithRetry(ctx, "GET", url, "", &exchangeOutput)
+	if e != nil {
+		return fmt.Errorf("error fetching details of exchange instance: %w", e)
+	}
+
+	exchangeMap, ok := exchangeOutput.(map[string]interface{})
+	if !ok {
+		return c.unexpectedShapeError("could not convert exchange details to a map[string]interface{}, type = %s", reflect.TypeOf(exchangeOutput))
+	}
+
+	has := map[string]interface{}{}
+	if hasValue, ok := exchangeMap["has"]; ok {
+		if hasMap, ok := hasValue.(map[string]interface{}); ok {
+			has = hasMap
+		}
+	}
+
+	if _, ok := exchangeMap["symbols"]; !ok {
+		return c.unexpectedShapeError("'symbols' field not in result of exchange details")
+	}
+	symbolsList, ok := exchangeMap["symbols"].([]interface{})
+	if !ok {
+		return c.unexpectedShapeError("could not convert 'symbols' field to a []interface{}, type = %s", reflect.TypeOf(exchangeMap["symbols"]))
+	}
+	symbols := []string{}
+	for _, p := range symbolsList {
+		symbol, ok := p.(string)
+		if !ok {
+			return c.unexpectedShapeError("could not convert symbol to a string, type = %s", reflect.TypeOf(p))
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	c.has = has
+	c.symbols = symbols
+	return nil
+}
+
+// supportsMethod returns false only when the exchange explicitly reports that it does not support the CCXT method
+func (c *Ccxt) supportsMethod(method string) bool {
+	c.cacheLock.RLock()
+	v, ok := c.has[method]
+	c.cacheLock.RUnlock()
+	if !ok {
+		// assume it is supported when we don't know, so we defer to the exchange
+		return true
+	}
+
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	// CCXT uses values such as "emulated" for methods that are supported through other endpoints
+	return true
+}
+
+// request makes a request to the CCXT REST server for this instance and decodes the json response into output, which should be a pointer.
+// If the instance no longer exists on the CCXT server (such as after the server was restarted) then it recreates the instance and returns
+// an error of the kind ErrInstanceRecreated. The request is not repeated here since it may not be safe to repeat, requestWithRetry repeats it
+func (c *Ccxt) request(ctx context.Context, method string, url string, data string, output interface{}) error {
+	e := c.requestOnce(ctx, method, url, data, output)
+	if e == nil || !c.isInstanceNotFoundError(ctx, url, e) {
+		return e
+	}
+
+	// only one request recreates the instance at a time, this also prevents a loop when the requests made to recreate it fail in the same way
+	if !atomic.CompareAndSwapInt32(&c.reinitializing, 0, 1) {
+		return e
+	}
+	c.log().Infof("instance '%s' of exchange '%s' was not found on the CCXT server (the server may have been restarted), recreating it: %s\n", c.instanceName, c.exchangeName, e)
+	reinitErr := c.reinitialize(ctx)
+	atomic.StoreInt32(&c.reinitializing, 0)
+	if reinitErr != nil {
+		return fmt.Errorf("could not recreate instance '%s' of exchange '%s' that was not found on the CCXT server: %s (original error: %w)", c.instanceName, c.exchangeName, reinitErr, e)
+	}
+	c.log().Infof("recreated instance '%s' of exchange '%s', the request was not repeated (method=%s, url=%s)\n", c.instanceName, c.exchangeName, method, url)
+
+	return makeCcxtError(ErrInstanceRecreated, e)
+}
+
+// requestOnce makes a single request to the CCXT REST server, see request
+func (c *Ccxt) requestOnce(ctx context.Context, method string, url string, data string, output interface{}) error {
+	if c.rateLimiter != nil {
+		e := c.rateLimiter.wait(ctx)
 		if e != nil {
-			return fmt.Errorf("unable to make header function with key (%s) and value (%s): %s", header.Header, header.Value, e)
+			return fmt.Errorf("request was not made (method=%s, url=%s): %w", method, url, e)
 		}
-		headersMap[header.Header] = headerFn
 	}
-	c.headersMap = headersMap
 
+	e := networking.JSONRequestDynamicHeadersContext(ctx, c.httpClient, method, url, data, c.headersMap, output, "error")
+	if e == nil {
+		return nil
+	}
+	if isTimeoutError(e) {
+		e = fmt.Errorf("request timed out (timeout=%s, method=%s, url=%s): %w", c.timeout, method, url, e)
+	}
+	return classifyRequestError(e)
+}
+
+// isInstanceNotFoundError returns true when a request to an endpoint of this instance failed because the instance does not exist on the
+// CCXT server. The CCXT server also responds with a 404 for errors from the exchange such as OrderNotFound, so we only treat the 404 as a
+// missing instance when the instance is not in the list of instances of the exchange
+func (c *Ccxt) isInstanceNotFoundError(ctx context.Context, url string, e error) bool {
+	var statusCodeError *networking.StatusCodeError
+	if !errors.As(e, &statusCodeError) || statusCodeError.StatusCode != http.StatusNotFound {
+		return false
+	}
+	instanceURL := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+	if url != instanceURL && !strings.HasPrefix(url, instanceURL+"/") {
+		return false
+	}
+
+	var instanceList []string
+	listErr := c.requestOnce(ctx, "GET", ccxtBaseURL+pathExchanges+"/"+c.exchangeName, "", &instanceList)
+	if listErr != nil {
+		c.log().Infof("could not list the instances of exchange '%s' to check whether instance '%s' exists after a 404: %s\n", c.exchangeName, c.instanceName, listErr)
+		return false
+	}
+	return !c.hasInstance(instanceList)
+}
+
+// reinitialize creates the instance on the CCXT server again and reloads the markets and details of the exchange
+func (c *Ccxt) reinitialize(ctx context.Context) error {
+	e := c.newInstance(ctx, c.apiKey, c.params)
+	if e != nil {
+		return fmt.Errorf("error creating new instance: %w", e)
+	}
+
+	e = c.loadMarkets(ctx, false)
+	if e != nil {
+		return fmt.Errorf("error loading markets: %w", e)
+	}
+
+	e = c.loadExchangeDetails(ctx)
+	if e != nil {
+		return fmt.Errorf("error loading details for exchange instance: %w", e)
+	}
 	return nil
 }
 
+// requestWithRetry is the same as request but retries with an exponential backoff on errors that are likely to be transient, and
+// repeats the request once when the instance was recreated. This should only be used for requests that are safe to repeat, i.e. it
+// should never be used to create or cancel orders
+func (c *Ccxt) requestWithRetry(ctx context.Context, method string, url string, data string, output interface{}) error {
+	delay := c.retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		e := c.request(ctx, method, url, data, output)
+		if errors.Is(e, ErrInstanceRecreated) {
+			// use requestOnce so we don't recreate the instance again if it is still not found
+			c.log().Infof("repeating the request on the recreated instance '%s' of exchange '%s' (method=%s, url=%s)\n", c.instanceName, c.exchangeName, method, url)
+			e = c.requestOnce(ctx, method, url, data, output)
+		}
+		if e == nil || attempt >= c.maxRetries || ctx.Err() != nil || !isRetryableError(e) {
+			return e
+		}
+
+		c.log().Infof("request to CCXT failed (attempt %d of %d, method=%s, url=%s), retrying in %s: %s\n", attempt+1, c.maxRetries+1, method, url, delay, e)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context done while waiting to retry request: %s (last error: %w)", ctx.Err(), e)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isRetryableError returns true for connection errors and 5xx responses, 4xx responses are never retried
+func isRetryableError(e error) bool {
+	var statusCodeError *networking.StatusCodeError
+	if errors.As(e, &statusCodeError) {
+		return statusCodeError.StatusCode >= 500 && statusCodeError.StatusCode != http.StatusNotImplemented
+	}
+	// errors without a status code happened before we received a response, which includes timeouts
+	return strings.Contains(e.Error(), "could not execute http request")
+}
+
+func isTimeoutError(e error) bool {
+	msg := e.Error()
+	return strings.Contains(msg, "Client.Timeout exceeded") || strings.Contains(msg, "context deadline exceeded") || strings.Contains(msg, "i/o timeout")
+}
+
+// unexpectedShapeError is returned when the json in a response from CCXT does not have the structure we expect, for example when the
+// exchange returns an error object or an array in place of a map
+func (c *Ccxt) unexpectedShapeError(format string, args ...interface{}) error {
+	return fmt.Errorf("unexpected response shape from exchange '%s': %s", c.exchangeName, fmt.Sprintf(format, args...))
+}
+
+// applyInstanceNameOptions returns the instance name to use given the hashed instance name and the values of WithInstanceName and
+// WithInstanceNamePrefix, which cannot both be set
+func applyInstanceNameOptions(hashedName string, override string, prefix string) (string, error) {
+	if override != "" && prefix != "" {
+		return "", fmt.Errorf("cannot set both an instance name ('%s') and an instance name prefix ('%s')", override, prefix)
+	}
+
+	if override == "" && prefix == "" {
+		return hashedName, nil
+	}
+
+	name := override
+	if prefix != "" {
+		if !instanceNameRegex.MatchString(prefix) {
+			return "", fmt.Errorf("prefix '%s' can only contain letters, digits, '.', '_' and '-'", prefix)
+		}
+		name = prefix + "_" + hashedName
+	}
+
+	if !instanceNameRegex.MatchString(name) {
+		return "", fmt.Errorf("name '%s' can only contain letters, digits, '.', '_' and '-'", name)
+	}
+	if len(name) > maxInstanceNameLength {
+		return "", fmt.Errorf("name '%s' cannot be longer than %d characters", name, maxInstanceNameLength)
+	}
+	return name, nil
+}
+
 // makeInstanceName takes all those inputs that create a distinctly initialized instance
-func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) (string, error) {
+func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader, legacyNaming bool) (string, error) {
 	keyHash := ""
-	if apiKey.Key != "" {
-		keyHashNum, e := utils.HashString(apiKey.Key)
+	if apiKey.Key != "" && legacyNaming {
+		// only include the passphrase when it is set so instance names for keys without a passphrase remain unchanged
+		keyToHash := apiKey.Key
+		if apiKey.Passphrase != "" {
+			keyToHash = apiKey.Key + apiKey.Passphrase
+		}
+		keyHashNum, e := utils.HashString(keyToHash)
 		if e != nil {
-			return "", fmt.Errorf("could not hash apiKey.Key: %s", e)
+			return "", fmt.Errorf("could not hash apiKey.Key: %w", e)
 		}
 		keyHash = fmt.Sprintf("%d", keyHashNum)
+	} else if apiKey.Key != "" {
+		keyHash = makeKeyHash(apiKey)
 	}
 
 	paramsHash := ""
@@ -193,7 +863,7 @@ func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []a
 		paramsHashNum, e := utils.ToJSONHash(params)
 		if e != nil {
 			s := fmt.Sprintf("%v", params)
-			return "", fmt.Errorf("could not hash params (%s): %s", s, e)
+			return "", fmt.Errorf("could not hash params (%s): %w", s, e)
 		}
 		paramsHash = fmt.Sprintf("%d", paramsHashNum)
 	}
@@ -203,7 +873,7 @@ func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []a
 		headersHashNum, e := utils.ToJSONHash(headers)
 		if e != nil {
 			s := fmt.Sprintf("%v", headers)
-			return "", fmt.Errorf("could not hash headers (%s): %s", s, e)
+			return "", fmt.Errorf("could not hash headers (%s): %w", s, e)
 		}
 		headersHash = fmt.Sprintf("%d", headersHashNum)
 	}
@@ -211,6 +881,13 @@ func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []a
 	return fmt.Sprintf("%s_%s_%s_%s", exchangeName, keyHash, paramsHash, headersHash), nil
 }
 
+// makeKeyHash hashes the full set of credentials with SHA-256 so distinct credentials map to distinct instances
+func makeKeyHash(apiKey api.ExchangeAPIKey) string {
+	// separate the fields so different splits of the same concatenated string produce different hashes
+	sum := sha256.Sum256([]byte(strings.Join([]string{apiKey.Key, apiKey.Secret, apiKey.Passphrase}, "\x00")))
+	return hex.EncodeToString(sum[:])[:instanceKeyHashLength]
+}
+
 func (c *Ccxt) hasInstance(instanceList []string) bool {
 	for _, i := range instanceList {
 		if i == c.instanceName {
@@ -220,163 +897,563 @@ func (c *Ccxt) hasInstance(instanceList []string) bool {
 	return false
 }
 
-func (c *Ccxt) newInstance(apiKey api.ExchangeAPIKey, params []api.ExchangeParam) error {
+func (c *Ccxt) newInstance(ctx context.Context, apiKey api.ExchangeAPIKey, params []api.ExchangeParam) error {
 	// this is a map of string to interface{} becuase the param can be of type string, number, or bool
 	data := map[string]interface{}{
 		"id":     c.instanceName,
 		"apiKey": apiKey.Key,
 		"secret": apiKey.Secret,
 	}
+	if apiKey.Passphrase != "" {
+		data["password"] = apiKey.Passphrase
+	}
 	// values that occur later in the list will override previous values (this is by design, so default values can be overriden by config values)
 	for _, param := range params {
 		data[param.Param] = param.Value
 	}
 	jsonData, e := json.Marshal(data)
 	if e != nil {
-		return fmt.Errorf("error marshaling instanceName '%s' as ID for exchange '%s': %s", c.instanceName, c.exchangeName, e)
+		return fmt.Errorf("error marshaling instanceName '%s' as ID for exchange '%s': %w", c.instanceName, c.exchangeName, e)
 	}
 
 	var newInstance map[string]interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", ccxtBaseURL+pathExchanges+"/"+c.exchangeName, string(jsonData), c.headersMap, &newInstance, "error")
+	e = c.request(ctx, "POST", ccxtBaseURL+pathExchanges+"/"+c.exchangeName, string(jsonData), &newInstance)
 	if e != nil {
-		return fmt.Errorf("error in web request when creating new exchange instance for exchange '%s': %s", c.exchangeName, e)
+		return fmt.Errorf("error in web request when creating new exchange instance for exchange '%s': %w", c.exchangeName, e)
 	}
 
 	if _, ok := newInstance["urls"]; !ok {
 		return fmt.Errorf("check for new instance of exchange '%s' failed for instanceName: %s", c.exchangeName, c.instanceName)
 	}
-	return nil
+	return nil
+}
+
+// symbolExists returns an error if the symbol does not exist, it only consults the cached markets and symbols (see RefreshMarkets)
+func (c *Ccxt) symbolExists(tradingPair string) error {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	if _, ok := c.markets[tradingPair]; ok {
+		c.log().Infof("found trading pair symbol '%s' in markets map\n", tradingPair)
+		return nil
+	}
+
+	for _, symbol := range c.symbols {
+		if tradingPair == symbol {
+			// exists
+			return nil
+		}
+	}
+	return makeCcxtError(ErrSymbolNotFound, fmt.Errorf("trading pair '%s' does not exist in the list of %d symbols on exchange '%s'", tradingPair, len(c.symbols), c.exchangeName))
+}
+
+// SymbolExists returns an error if the trading pair is not listed on the exchange, trading pair is the CCXT version of the trading pair
+func (c *Ccxt) SymbolExists(tradingPair string) error {
+	return c.symbolExists(tradingPair)
+}
+
+// GetExchangeName returns the name of the exchange on CCXT, such as "binance"
+func (c *Ccxt) GetExchangeName() string {
+	return c.exchangeName
+}
+
+// GetMarket returns the CcxtMarket instance
+func (c *Ccxt) GetMarket(tradingPair string) *CcxtMarket {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	if v, ok := c.markets[tradingPair]; ok {
+		return &v
+	}
+	return nil
+}
+
+// FetchMarket returns the market of the trading pair from the markets that were loaded during initialization, which includes the
+// precision, limits and fees. Use RefreshMarkets to reload the markets. Trading pair is the CCXT version of the trading pair
+func (c *Ccxt) FetchMarket(tradingPair string) (CcxtMarket, error) {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	market, ok := c.markets[tradingPair]
+	if !ok {
+		return CcxtMarket{}, makeCcxtError(ErrSymbolNotFound, fmt.Errorf("trading pair '%s' does not exist in the %d loaded markets on exchange '%s'", tradingPair, len(c.markets), c.exchangeName))
+	}
+	return market, nil
+}
+
+// GetMarkets returns all the markets, the returned map should not be modified since it is shared with the Ccxt until the markets are reloaded
+func (c *Ccxt) GetMarkets() map[string]CcxtMarket {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	return c.markets
+}
+
+// CcxtStatus values reported by FetchStatus
+const (
+	CcxtStatusOK          = "ok"
+	CcxtStatusMaintenance = "maintenance"
+	CcxtStatusError       = "error"
+)
+
+// CcxtStatus represents the result of a FetchStatus call, Eta is nil when the exchange does not report when it expects to be back up
+type CcxtStatus struct {
+	Status  string
+	Updated int64
+	Eta     *int64
+	URL     string
+}
+
+// IsOK returns true when the exchange is operational
+func (s CcxtStatus) IsOK() bool {
+	return s.Status == CcxtStatusOK
+}
+
+// FetchStatus calls the /fetchStatus endpoint on CCXT to check that the CCXT REST server and the exchange are reachable and that the exchange
+// is not in maintenance. If the exchange does not support fetchStatus then it falls back to fetching the ticker of a listed symbol, reporting
+// an "error" status if that fails
+func (c *Ccxt) FetchStatus() (CcxtStatus, error) {
+	return c.FetchStatusContext(context.Background())
+}
+
+// FetchStatusContext is the same as FetchStatus but the request is cancelled when the context is done
+func (c *Ccxt) FetchStatusContext(ctx context.Context) (CcxtStatus, error) {
+	if !c.supportsMethod("fetchStatus") {
+		return c.fetchStatusFromTicker(ctx)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchStatus"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e := c.requestWithRetry(ctx, "POST", url, "", &output)
+	if e != nil {
+		return CcxtStatus{}, fmt.Errorf("error fetching status of exchange '%s': %w", c.exchangeName, e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return CcxtStatus{}, c.unexpectedShapeError("could not convert fetchStatus output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	var status CcxtStatus
+	e = mapstructure.Decode(outputMap, &status)
+	if e != nil {
+		return CcxtStatus{}, c.unexpectedShapeError("could not decode fetchStatus output (%v) to a CcxtStatus: %s", outputMap, e)
+	}
+	if status.Status == "" {
+		return CcxtStatus{}, c.unexpectedShapeError("fetchStatus output did not contain a 'status' field: %v", outputMap)
+	}
+	return status, nil
+}
+
+// fetchStatusFromTicker checks the status of the exchange by fetching the ticker of the first listed symbol since it is a cheap public call
+func (c *Ccxt) fetchStatusFromTicker(ctx context.Context) (CcxtStatus, error) {
+	c.cacheLock.RLock()
+	symbols := c.symbols
+	c.cacheLock.RUnlock()
+	if len(symbols) == 0 {
+		return CcxtStatus{}, fmt.Errorf("exchange '%s' does not support fetchStatus and has no symbols to fetch a ticker for", c.exchangeName)
+	}
+
+	symbol := symbols[0]
+	_, e := c.FetchTickerRawContext(ctx, symbol)
+	if e != nil {
+		return CcxtStatus{Status: CcxtStatusError}, fmt.Errorf("exchange '%s' does not support fetchStatus and fetching the ticker for '%s' failed: %w", c.exchangeName, symbol, e)
+	}
+	return CcxtStatus{
+		Status:  CcxtStatusOK,
+		Updated: time.Now().UnixNano() / int64(time.Millisecond),
+	}, nil
+}
+
+// CcxtTicker represents the result of a FetchTicker call, fields that are missing or null in the response are left as zero values
+type CcxtTicker struct {
+	Symbol      string
+	Bid         float64
+	Ask         float64
+	Last        float64
+	High        float64
+	Low         float64
+	BaseVolume  float64
+	QuoteVolume float64
+	Timestamp   int64
+	Datetime    string
+}
+
+// FetchTicker calls the /fetchTicker endpoint on CCXT, trading pair is the CCXT version of the trading pair
+func (c *Ccxt) FetchTicker(tradingPair string) (*CcxtTicker, error) {
+	return c.FetchTickerContext(context.Background(), tradingPair)
+}
+
+// FetchTickerContext is the same as FetchTicker but the request is cancelled when the context is done
+func (c *Ccxt) FetchTickerContext(ctx context.Context, tradingPair string) (*CcxtTicker, error) {
+	tickerMap, e := c.FetchTickerRawContext(ctx, tradingPair)
+	if e != nil {
+		return nil, e
+	}
+
+	var ticker CcxtTicker
+	e = mapstructure.Decode(tickerMap, &ticker)
+	if e != nil {
+		return nil, fmt.Errorf("error converting ticker for trading pair '%s' to a CcxtTicker: %w", tradingPair, e)
+	}
+	return &ticker, nil
+}
+
+// FetchTickerRaw is the same as FetchTicker but returns the ticker as it was returned by CCXT
+func (c *Ccxt) FetchTickerRaw(tradingPair string) (map[string]interface{}, error) {
+	return c.FetchTickerRawContext(context.Background(), tradingPair)
+}
+
+// FetchTickerRawContext is the same as FetchTickerRaw but the request is cancelled when the context is done
+func (c *Ccxt) FetchTickerRawContext(ctx context.Context, tradingPair string) (map[string]interface{}, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
+	}
+
+	// marshal input data
+	data, e := json.Marshal(&[]string{tradingPair})
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling tradingPair '%s' as an array for exchange '%s': %w", tradingPair, c.exchangeName, e)
+	}
+
+	// fetch ticker for symbol
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTicker"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = c.requestWithRetry(ctx, "POST", url, string(data), &output)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching tickers for trading pair '%s': %w", tradingPair, e)
+	}
+
+	tickerMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert fetchTicker output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+	return tickerMap, nil
+}
+
+// FetchTickers calls the /fetchTickers endpoint on CCXT so we can fetch the tickers for many trading pairs in one request, trading pairs are the CCXT
+// version of the trading pairs. The result is keyed by trading pair. Falls back to calling FetchTicker for each trading pair if the exchange does not support fetchTickers
+func (c *Ccxt) FetchTickers(tradingPairs []string) (map[string]map[string]interface{}, error) {
+	return c.FetchTickersContext(context.Background(), tradingPairs)
+}
+
+// FetchTickersContext is the same as FetchTickers but the requests are cancelled when the context is done
+func (c *Ccxt) FetchTickersContext(ctx context.Context, tradingPairs []string) (map[string]map[string]interface{}, error) {
+	for _, tradingPair := range tradingPairs {
+		e := c.symbolExists(tradingPair)
+		if e != nil {
+			return nil, fmt.Errorf("symbol does not exist: %w", e)
+		}
+	}
+
+	if !c.supportsMethod("fetchTickers") {
+		result := map[string]map[string]interface{}{}
+		for _, tradingPair := range tradingPairs {
+			tickerMap, e := c.FetchTickerRawContext(ctx, tradingPair)
+			if e != nil {
+				return nil, fmt.Errorf("error fetching ticker for trading pair '%s' (fallback since exchange '%s' does not support fetchTickers): %w", tradingPair, c.exchangeName, e)
+			}
+			result[tradingPair] = tickerMap
+		}
+		return result, nil
+	}
+
+	// marshal input data, the first argument to fetchTickers is the list of symbols
+	data, e := json.Marshal(&[]interface{}{tradingPairs})
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling tradingPairs %v as an array for exchange '%s': %w", tradingPairs, c.exchangeName, e)
+	}
+
+	// fetch tickers for symbols
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTickers"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = c.requestWithRetry(ctx, "POST", url, string(data), &output)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching tickers for trading pairs %v: %w", tradingPairs, e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert fetchTickers output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	// some exchanges return the tickers for all symbols, so only pick out the ones that were requested
+	result := map[string]map[string]interface{}{}
+	for _, tradingPair := range tradingPairs {
+		v, ok := outputMap[tradingPair]
+		if !ok {
+			return nil, fmt.Errorf("ticker for trading pair '%s' was missing in the response from fetchTickers", tradingPair)
+		}
+		tickerMap, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, c.unexpectedShapeError("could not convert ticker for trading pair '%s' to a map[string]interface{}, type = %s", tradingPair, reflect.TypeOf(v))
+		}
+		result[tradingPair] = tickerMap
+	}
+	return result, nil
+}
+
+// CcxtOrder represents an order in the orderbook
+type CcxtOrder struct {
+	Price  float64
+	Amount float64
+}
+
+// FetchOrderBook calls the /fetchOrderBook endpoint on CCXT, trading pair is the CCXT version of the trading pair. params are the
+// exchange-specific params passed through to CCXT and can be nil
+func (c *Ccxt) FetchOrderBook(tradingPair string, limit *int, params map[string]interface{}) (map[string][]CcxtOrder, error) {
+	return c.FetchOrderBookContext(context.Background(), tradingPair, limit, params)
+}
+
+// FetchOrderBookContext is the same as FetchOrderBook but the request is cancelled when the context is done
+func (c *Ccxt) FetchOrderBookContext(ctx context.Context, tradingPair string, limit *int, params map[string]interface{}) (map[string][]CcxtOrder, error) {
+	return c.fetchOrderBook(ctx, tradingPair, limit, params, orderBookSides)
+}
+
+// sides of the orderbook, these are the keys of the map returned by FetchOrderBook
+const (
+	OrderBookSideAsks = "asks"
+	OrderBookSideBids = "bids"
+)
+
+var orderBookSides = []string{OrderBookSideAsks, OrderBookSideBids}
+
+// FetchOrderBookSide is the same as FetchOrderBook but only parses and returns one side of the orderbook, which is cheaper on deep
+// orderbooks when the caller only needs that side. side is either OrderBookSideAsks or OrderBookSideBids
+func (c *Ccxt) FetchOrderBookSide(tradingPair string, side string, limit *int, params map[string]interface{}) ([]CcxtOrder, error) {
+	return c.FetchOrderBookSideContext(context.Background(), tradingPair, side, limit, params)
+}
+
+// FetchOrderBookSideContext is the same as FetchOrderBookSide but the request is cancelled when the context is done
+func (c *Ccxt) FetchOrderBookSideContext(ctx context.Context, tradingPair string, side string, limit *int, params map[string]interface{}) ([]CcxtOrder, error) {
+	if side != OrderBookSideAsks && side != OrderBookSideBids {
+		return nil, fmt.Errorf("invalid side '%s', needs to be either '%s' or '%s'", side, OrderBookSideAsks, OrderBookSideBids)
+	}
+
+	result, e := c.fetchOrderBook(ctx, tradingPair, limit, params, []string{side})
+	if e != nil {
+		return nil, e
+	}
+	return result[side], nil
 }
 
-// symbolExists returns an error if the symbol does not exist
-func (c *Ccxt) symbolExists(tradingPair string) error {
-	if _, ok := c.markets[tradingPair]; ok {
-		log.Printf("found trading pair symbol '%s' in markets map", tradingPair)
-		return nil
+// fetchOrderBook fetches the orderbook and only parses the sides that are passed in
+func (c *Ccxt) fetchOrderBook(ctx context.Context, tradingPair string, limit *int, params map[string]interface{}, sides []string) (map[string][]CcxtOrder, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
 	}
 
-	// get list of symbols available on exchange
-	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
-	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
-	var exchangeOutput interface{}
-	e := networking.JSONRequestDynamicHeaders(c.httpClient, "GET", url, "", c.headersMap, &exchangeOutput, "error")
+	data, e := c.makeOrderBookInput(tradingPair, limit, params)
 	if e != nil {
-		return fmt.Errorf("error fetching details of exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+		return nil, e
 	}
 
-	exchangeMap := exchangeOutput.(map[string]interface{})
-	if _, ok := exchangeMap["symbols"]; !ok {
-		return fmt.Errorf("'symbols' field not in result of exchange details (exchange=%s, instanceName=%s)", c.exchangeName, c.instanceName)
+	// fetch orderbook for symbol
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchOrderBook"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = c.requestWithRetry(ctx, "POST", url, data, &output)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching orderbook for trading pair '%s': %w", tradingPair, e)
 	}
 
-	symbolsList := exchangeMap["symbols"].([]interface{})
-	for _, p := range symbolsList {
-		symbol := p.(string)
-		if tradingPair == symbol {
-			// exists
-			return nil
-		}
+	result, e := c.parseOrderBook(output, limit, sides)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing orderbook for trading pair '%s': %w", tradingPair, e)
 	}
-	return fmt.Errorf("trading pair '%s' does not exist in the list of %d symbols on exchange '%s'", tradingPair, len(symbolsList), c.exchangeName)
+	return result, nil
 }
 
-// GetMarket returns the CcxtMarket instance
-func (c *Ccxt) GetMarket(tradingPair string) *CcxtMarket {
-	if v, ok := c.markets[tradingPair]; ok {
-		return &v
-	}
-	return nil
+// FetchL2OrderBook calls the /fetchL2OrderBook endpoint on CCXT, which returns the orderbook aggregated by price so there is a single
+// entry for each price level. When the exchange does not support the endpoint it fetches the orderbook and aggregates it by price
+func (c *Ccxt) FetchL2OrderBook(tradingPair string, limit *int) (map[string][]CcxtOrder, error) {
+	return c.FetchL2OrderBookContext(context.Background(), tradingPair, limit)
 }
 
-// GetMarkets returns all the markets
-func (c *Ccxt) GetMarkets() map[string]CcxtMarket {
-	return c.markets
-}
+// FetchL2OrderBookContext is the same as FetchL2OrderBook but the request is cancelled when the context is done
+func (c *Ccxt) FetchL2OrderBookContext(ctx context.Context, tradingPair string, limit *int) (map[string][]CcxtOrder, error) {
+	if !c.supportsMethod("fetchL2OrderBook") {
+		// this is how CCXT emulates the endpoint, the limit is applied to the orderbook before it is aggregated
+		orderbook, e := c.FetchOrderBookContext(ctx, tradingPair, limit, nil)
+		if e != nil {
+			return nil, e
+		}
+		return aggregateOrderBook(orderbook), nil
+	}
 
-// FetchTicker calls the /fetchTicker endpoint on CCXT, trading pair is the CCXT version of the trading pair
-func (c *Ccxt) FetchTicker(tradingPair string) (map[string]interface{}, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
 	}
 
-	// marshal input data
-	data, e := json.Marshal(&[]string{tradingPair})
+	data, e := c.makeOrderBookInput(tradingPair, limit, nil)
 	if e != nil {
-		return nil, fmt.Errorf("error marshaling tradingPair '%s' as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+		return nil, e
 	}
 
-	// fetch ticker for symbol
-	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTicker"
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchL2OrderBook"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	var output interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e = c.requestWithRetry(ctx, "POST", url, data, &output)
 	if e != nil {
-		return nil, fmt.Errorf("error fetching tickers for trading pair '%s': %s", tradingPair, e)
+		return nil, fmt.Errorf("error fetching L2 orderbook for trading pair '%s': %w", tradingPair, e)
 	}
 
-	tickerMap := output.(map[string]interface{})
-	return tickerMap, nil
-}
-
-// CcxtOrder represents an order in the orderbook
-type CcxtOrder struct {
-	Price  float64
-	Amount float64
+	result, e := c.parseOrderBook(output, limit, orderBookSides)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing L2 orderbook for trading pair '%s': %w", tradingPair, e)
+	}
+	return result, nil
 }
 
-// FetchOrderBook calls the /fetchOrderBook endpoint on CCXT, trading pair is the CCXT version of the trading pair
-func (c *Ccxt) FetchOrderBook(tradingPair string, limit *int) (map[string][]CcxtOrder, error) {
-	e := c.symbolExists(tradingPair)
+// makeOrderBookInput makes the json input of the orderbook endpoints, the limit is clamped to the limits supported by the exchange
+func (c *Ccxt) makeOrderBookInput(tradingPair string, limit *int, params map[string]interface{}) (string, error) {
+	inputData := []interface{}{tradingPair}
+	if limit != nil {
+		requestLimit := clampOrderBookLimit(*limit, c.orderBookLimits)
+		if requestLimit != *limit {
+			c.log().Infof("clamped orderbook limit from %d to %d for trading pair '%s' on exchange '%s' which supports the limits %v\n", *limit, requestLimit, tradingPair, c.exchangeName, c.orderBookLimits)
+		}
+		inputData = append(inputData, fmt.Sprintf("%d", requestLimit))
+	} else if params != nil {
+		// CCXT expects the params in the position after the limit so we need to pass in a null limit
+		inputData = append(inputData, nil)
+	}
+	if params != nil {
+		inputData = append(inputData, params)
+	}
+	data, e := json.Marshal(&inputData)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return "", fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
+	return string(data), nil
+}
 
-	// marshal input data
-	var data []byte
-	if limit != nil {
-		data, e = json.Marshal(&[]string{tradingPair, fmt.Sprintf("%d", *limit)})
-		if e != nil {
-			return nil, fmt.Errorf("error marshaling tradingPair '%s' as an array for exchange '%s' with limit=%d: %s", tradingPair, c.exchangeName, *limit, e)
+// aggregateOrderBook sums the amounts of the orders with the same price on each side of an orderbook returned by parseOrderBook, the
+// sides remain sorted with the top of the book first
+func aggregateOrderBook(orderbook map[string][]CcxtOrder) map[string][]CcxtOrder {
+	result := map[string][]CcxtOrder{}
+	for side, orders := range orderbook {
+		aggregated := []CcxtOrder{}
+		for _, o := range orders {
+			// the side is sorted by price so orders with the same price are next to each other
+			if len(aggregated) > 0 && aggregated[len(aggregated)-1].Price == o.Price {
+				aggregated[len(aggregated)-1].Amount += o.Amount
+				continue
+			}
+			aggregated = append(aggregated, o)
 		}
-	} else {
-		data, e = json.Marshal(&[]string{tradingPair})
-		if e != nil {
-			return nil, fmt.Errorf("error marshaling tradingPair '%s' as an array for exchange '%s' with no limit: %s", tradingPair, c.exchangeName, e)
+		result[side] = aggregated
+	}
+	return result
+}
+
+// clampOrderBookLimit returns the smallest supported limit that is at least the requested limit so we fetch enough levels (the
+// result is capped to the requested limit when parsed), or the largest supported limit if the requested limit is above all of them.
+// supportedLimits should be sorted and the limit is unchanged when supportedLimits is empty
+func clampOrderBookLimit(limit int, supportedLimits []int) int {
+	if len(supportedLimits) == 0 {
+		return limit
+	}
+
+	for _, supported := range supportedLimits {
+		if supported >= limit {
+			return supported
 		}
 	}
+	return supportedLimits[len(supportedLimits)-1]
+}
 
-	// fetch orderbook for symbol
-	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchOrderBook"
-	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
-	var output interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
-	if e != nil {
-		return nil, fmt.Errorf("error fetching orderbook for trading pair '%s': %s", tradingPair, e)
+// parseOrderBook converts the output of fetchOrderBook into a map with the "asks" sorted by ascending price and the "bids" sorted
+// by descending price so the first element of each is the top of the book. Each side is capped at limit entries when limit is not nil
+// since some exchanges return more levels than requested. Only the sides that are passed in are parsed and included in the map
+func (c *Ccxt) parseOrderBook(output interface{}, limit *int, sides []string) (map[string][]CcxtOrder, error) {
+	orderbookMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert fetchOrderBook output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
 	}
 
 	result := map[string][]CcxtOrder{}
-	tickerMap := output.(map[string]interface{})
-	for k, v := range tickerMap {
-		if k != "asks" && k != "bids" {
+	for _, side := range sides {
+		v, ok := orderbookMap[side]
+		if !ok {
 			continue
 		}
 
-		parsedList := []CcxtOrder{}
-		// parse the list into the struct
-		ordersList := v.([]interface{})
-		for _, o := range ordersList {
-			order := o.([]interface{})
-			parsedList = append(parsedList, CcxtOrder{
-				Price:  order[0].(float64),
-				Amount: order[1].(float64),
-			})
+		parsedList, e := parseOrderBookSide(side, v)
+		if e != nil {
+			return nil, c.unexpectedShapeError("%s", e)
+		}
+
+		if side == OrderBookSideAsks {
+			sort.SliceStable(parsedList, func(i, j int) bool { return parsedList[i].Price < parsedList[j].Price })
+		} else {
+			sort.SliceStable(parsedList, func(i, j int) bool { return parsedList[i].Price > parsedList[j].Price })
+		}
+
+		if limit != nil && *limit >= 0 && len(parsedList) > *limit {
+			parsedList = parsedList[:*limit]
 		}
-		result[k] = parsedList
+		result[side] = parsedList
 	}
 	return result, nil
 }
 
+// parseOrderBookSide parses a list of [price, amount, ...] entries, any elements after the amount (such as the order count) are ignored
+func parseOrderBookSide(side string, v interface{}) ([]CcxtOrder, error) {
+	ordersList, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert %s to a []interface{}, type = %s", side, reflect.TypeOf(v))
+	}
+
+	parsedList := []CcxtOrder{}
+	for i, o := range ordersList {
+		order, ok := o.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("could not convert %s entry at index %d to a []interface{}, type = %s", side, i, reflect.TypeOf(o))
+		}
+		if len(order) < 2 {
+			return nil, fmt.Errorf("%s entry at index %d needs at least a price and an amount but had %d elements", side, i, len(order))
+		}
+
+		price, e := parseNumber(order[0])
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price of %s entry at index %d: %w", side, i, e)
+		}
+		amount, e := parseNumber(order[1])
+		if e != nil {
+			return nil, fmt.Errorf("could not parse amount of %s entry at index %d: %w", side, i, e)
+		}
+
+		parsedList = append(parsedList, CcxtOrder{
+			Price:  price,
+			Amount: amount,
+		})
+	}
+	return parsedList, nil
+}
+
+// parseNumber converts a json number to a float64, some exchanges encode numbers as strings
+func parseNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, e := strconv.ParseFloat(n, 64)
+		if e != nil {
+			return 0, fmt.Errorf("could not parse string '%s' as a float64: %w", n, e)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("could not convert value to a float64, type = %s", reflect.TypeOf(v))
+	}
+}
+
 // CcxtTrade represents a trade
 type CcxtTrade struct {
 	Amount    float64     `json:"amount"`
@@ -388,42 +1465,131 @@ type CcxtTrade struct {
 	Side      string      `json:"side"`
 	Symbol    string      `json:"symbol"`
 	Timestamp int64       `json:"timestamp"`
-	Fee       struct {
-		Cost     float64 `json:"cost"`
-		Currency string  `json:"currency"`
-	} `json:"fee"`
+	Fee       *CcxtFee    `json:"fee"` // nil when the exchange returns a null fee
 }
 
-// FetchTrades calls the /fetchTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair
+// CcxtFee represents the fee paid on a trade, Currency is the CCXT code of the asset in which the fee was paid
+type CcxtFee struct {
+	Cost     float64 `json:"cost"`
+	Currency string  `json:"currency"`
+}
+
+// FetchTrades calls the /fetchTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair. params are the
+// exchange-specific params passed through to CCXT and can be nil
 // TODO take in since and limit values to match CCXT's API
-func (c *Ccxt) FetchTrades(tradingPair string) ([]CcxtTrade, error) {
+func (c *Ccxt) FetchTrades(tradingPair string, params map[string]interface{}) ([]CcxtTrade, error) {
+	return c.FetchTradesContext(context.Background(), tradingPair, params)
+}
+
+// FetchTradesContext is the same as FetchTrades but the request is cancelled when the context is done
+func (c *Ccxt) FetchTradesContext(ctx context.Context, tradingPair string, params map[string]interface{}) ([]CcxtTrade, error) {
+	return c.fetchTrades(ctx, tradingPair, nil, params)
+}
+
+// FetchTradesRange fetches all the trades with a timestamp (in millis) in the range [since, until), trading pair is the CCXT
+// version of the trading pair. It pages through the trades by advancing the since value past the last returned trade until it
+// reaches until or the exchange returns no more trades
+func (c *Ccxt) FetchTradesRange(tradingPair string, since int64, until int64) ([]CcxtTrade, error) {
+	return c.FetchTradesRangeContext(context.Background(), tradingPair, since, until)
+}
+
+// FetchTradesRangeContext is the same as FetchTradesRange but the requests are cancelled when the context is done
+func (c *Ccxt) FetchTradesRangeContext(ctx context.Context, tradingPair string, since int64, until int64) ([]CcxtTrade, error) {
+	if until <= since {
+		return nil, fmt.Errorf("until (%d) needs to be greater than since (%d)", until, since)
+	}
+
+	trades := []CcxtTrade{}
+	seenIDs := map[string]bool{}
+	cursor := since
+	// we cap the number of iterations because some exchanges ignore the since value and will always return the same trades
+	for i := 0; i < maxFetchTradesRangeIterations; i++ {
+		page, e := c.fetchTrades(ctx, tradingPair, &cursor, nil)
+		if e != nil {
+			return nil, fmt.Errorf("error fetching page %d of trades for range [%d, %d): %w", i+1, since, until, e)
+		}
+		if len(page) == 0 {
+			return trades, nil
+		}
+
+		numNew := 0
+		lastTimestamp := cursor
+		for _, t := range page {
+			if t.Timestamp > lastTimestamp {
+				lastTimestamp = t.Timestamp
+			}
+			if t.Timestamp < since || t.Timestamp >= until || seenIDs[t.ID] {
+				continue
+			}
+			seenIDs[t.ID] = true
+			trades = append(trades, t)
+			numNew++
+		}
+		if lastTimestamp >= until {
+			return trades, nil
+		}
+
+		// the next page starts at the last timestamp in case the page ended in the middle of trades with the same timestamp,
+		// the repeated trades are dropped above. When there were no new trades we need to move past the last timestamp
+		if numNew > 0 && lastTimestamp > cursor {
+			cursor = lastTimestamp
+		} else {
+			cursor = lastTimestamp + 1
+		}
+	}
+	return nil, fmt.Errorf("reached the limit of %d requests when fetching trades for range [%d, %d), the exchange may not support paging by since", maxFetchTradesRangeIterations, since, until)
+}
+
+// fetchTrades calls the /fetchTrades endpoint on CCXT, maybeSince and params are optional
+func (c *Ccxt) fetchTrades(ctx context.Context, tradingPair string, maybeSince *int64, params map[string]interface{}) ([]CcxtTrade, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
 	}
 
 	// marshal input data
-	data, e := json.Marshal(&[]string{tradingPair})
+	inputData := []interface{}{tradingPair}
+	if maybeSince != nil || params != nil {
+		// CCXT expects the args in the order (symbol, since, limit, params) so we need to pass in null values for any missing args
+		var since interface{}
+		if maybeSince != nil {
+			since = *maybeSince
+		}
+		inputData = append(inputData, since, nil)
+		if params != nil {
+			inputData = append(inputData, params)
+		}
+	}
+	data, e := json.Marshal(&inputData)
 	if e != nil {
-		return nil, fmt.Errorf("error marshaling input (tradingPair=%s) as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
 
 	// fetch trades for symbol
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTrades"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	output := []CcxtTrade{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e = c.requestWithRetry(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("error fetching trades for trading pair '%s': %s", tradingPair, e)
+		return nil, fmt.Errorf("error fetching trades for trading pair '%s': %w", tradingPair, e)
 	}
 	return output, nil
 }
 
 // FetchMyTrades calls the /fetchMyTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair
 func (c *Ccxt) FetchMyTrades(tradingPair string, limit int, maybeCursorStart interface{}) ([]CcxtTrade, error) {
+	return c.FetchMyTradesContext(context.Background(), tradingPair, limit, maybeCursorStart)
+}
+
+// FetchMyTradesContext is the same as FetchMyTrades but the request is cancelled when the context is done
+func (c *Ccxt) FetchMyTradesContext(ctx context.Context, tradingPair string, limit int, maybeCursorStart interface{}) ([]CcxtTrade, error) {
+	if !c.supportsMethod("fetchMyTrades") {
+		return nil, fmt.Errorf("exchange '%s' does not support fetchMyTrades", c.exchangeName)
+	}
+
 	e := c.symbolExists(tradingPair)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
 	}
 
 	// marshal input data
@@ -431,23 +1597,63 @@ func (c *Ccxt) FetchMyTrades(tradingPair string, limit int, maybeCursorStart int
 	if maybeCursorStart == nil {
 		data, e = json.Marshal(&[]string{tradingPair, strconv.Itoa(limit)})
 		if e != nil {
-			return nil, fmt.Errorf("error marshaling input (tradingPair=%s) as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+			return nil, fmt.Errorf("error marshaling input (tradingPair=%s) as an array for exchange '%s': %w", tradingPair, c.exchangeName, e)
 		}
 	} else {
 		cursorString := fmt.Sprintf("%v", maybeCursorStart)
 		data, e = json.Marshal(&[]string{tradingPair, cursorString, strconv.Itoa(limit)})
 		if e != nil {
-			return nil, fmt.Errorf("error marshaling input (tradingPair=%s, maybeCursorStart=%v) as an array for exchange '%s': %s", tradingPair, maybeCursorStart, c.exchangeName, e)
+			return nil, fmt.Errorf("error marshaling input (tradingPair=%s, maybeCursorStart=%v) as an array for exchange '%s': %w", tradingPair, maybeCursorStart, c.exchangeName, e)
+		}
+	}
+	return c.fetchMyTrades(ctx, tradingPair, data)
+}
+
+// FetchMyTradesSince calls the /fetchMyTrades endpoint on CCXT with the since timestamp (in millis) and limit, both of which are optional and
+// are left to the defaults of the exchange when nil. Trading pair is the CCXT version of the trading pair
+func (c *Ccxt) FetchMyTradesSince(tradingPair string, since *int64, limit *int) ([]CcxtTrade, error) {
+	return c.FetchMyTradesSinceContext(context.Background(), tradingPair, since, limit)
+}
+
+// FetchMyTradesSinceContext is the same as FetchMyTradesSince but the request is cancelled when the context is done
+func (c *Ccxt) FetchMyTradesSinceContext(ctx context.Context, tradingPair string, since *int64, limit *int) ([]CcxtTrade, error) {
+	if !c.supportsMethod("fetchMyTrades") {
+		return nil, fmt.Errorf("exchange '%s' does not support fetchMyTrades", c.exchangeName)
+	}
+
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
+	}
+
+	// marshal input data, CCXT expects the args in the order (symbol, since, limit) so we need to pass in a null since when only the limit is set
+	inputData := []interface{}{tradingPair}
+	if since != nil || limit != nil {
+		var sinceArg interface{}
+		if since != nil {
+			sinceArg = *since
 		}
+		inputData = append(inputData, sinceArg)
+	}
+	if limit != nil {
+		inputData = append(inputData, *limit)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
+	return c.fetchMyTrades(ctx, tradingPair, data)
+}
 
+// fetchMyTrades calls the /fetchMyTrades endpoint on CCXT with the marshaled input data
+func (c *Ccxt) fetchMyTrades(ctx context.Context, tradingPair string, data []byte) ([]CcxtTrade, error) {
 	// fetch trades for symbol
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchMyTrades"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	output := []CcxtTrade{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e := c.requestWithRetry(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("error fetching trades for trading pair '%s': %s", tradingPair, e)
+		return nil, fmt.Errorf("error fetching trades for trading pair '%s': %w", tradingPair, e)
 	}
 	return output, nil
 }
@@ -461,19 +1667,30 @@ type CcxtBalance struct {
 
 // FetchBalance calls the /fetchBalance endpoint on CCXT
 func (c *Ccxt) FetchBalance() (map[string]CcxtBalance, error) {
+	return c.FetchBalanceContext(context.Background())
+}
+
+// FetchBalanceContext is the same as FetchBalance but the request is cancelled when the context is done
+func (c *Ccxt) FetchBalanceContext(ctx context.Context) (map[string]CcxtBalance, error) {
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchBalance"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	var output interface{}
-	e := networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, "", c.headersMap, &output, "error")
+	e := c.requestWithRetry(ctx, "POST", url, "", &output)
 	if e != nil {
-		return nil, fmt.Errorf("error fetching balance: %s", e)
+		return nil, fmt.Errorf("error fetching balance: %w", e)
 	}
 
-	outputMap := output.(map[string]interface{})
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert fetchBalance output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
 	if _, ok := outputMap["total"]; !ok {
-		return nil, fmt.Errorf("result from call to fetchBalance did not contain 'total' field: %v", output)
+		return nil, c.unexpectedShapeError("result from call to fetchBalance did not contain 'total' field: %v", output)
+	}
+	totals, ok := outputMap["total"].(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert 'total' field of fetchBalance output to a map[string]interface{}, type = %s", reflect.TypeOf(outputMap["total"]))
 	}
-	totals := outputMap["total"].(map[string]interface{})
 
 	result := map[string]CcxtBalance{}
 	for asset, v := range totals {
@@ -481,72 +1698,68 @@ func (c *Ccxt) FetchBalance() (map[string]CcxtBalance, error) {
 		if b, ok := v.(float64); ok {
 			totalBalance = b
 		} else {
-			return nil, fmt.Errorf("could not convert total balance for asset '%s' from interface{} to float64", asset)
+			return nil, c.unexpectedShapeError("could not convert total balance for asset '%s' from interface{} to float64, type = %s", asset, reflect.TypeOf(v))
 		}
 		if totalBalance == 0 {
 			continue
 		}
 
-		assetData := outputMap[asset].(map[string]interface{})
+		assetData, ok := outputMap[asset].(map[string]interface{})
+		if !ok {
+			return nil, c.unexpectedShapeError("could not convert balance for asset '%s' to a map[string]interface{}, type = %s", asset, reflect.TypeOf(outputMap[asset]))
+		}
 		var assetBalance CcxtBalance
 		e = mapstructure.Decode(assetData, &assetBalance)
 		if e != nil {
-			return nil, fmt.Errorf("error converting balance map to CcxtBalance for asset '%s': %s", asset, e)
+			return nil, fmt.Errorf("error converting balance map to CcxtBalance for asset '%s': %w", asset, e)
 		}
 		result[asset] = assetBalance
 	}
 	return result, nil
 }
 
-// CcxtOpenOrder represents an open order
-type CcxtOpenOrder struct {
-	Amount    float64
-	Cost      float64
-	Filled    float64
-	ID        string
-	Price     float64
-	Side      string
-	Status    string
-	Symbol    string
-	Type      string
-	Timestamp int64
-}
-
 // FetchOpenOrders calls the /fetchOpenOrders endpoint on CCXT
 func (c *Ccxt) FetchOpenOrders(tradingPairs []string) (map[string][]CcxtOpenOrder, error) {
+	return c.FetchOpenOrdersContext(context.Background(), tradingPairs)
+}
+
+// FetchOpenOrdersContext is the same as FetchOpenOrders but the request is cancelled when the context is done
+func (c *Ccxt) FetchOpenOrdersContext(ctx context.Context, tradingPairs []string) (map[string][]CcxtOpenOrder, error) {
 	for _, p := range tradingPairs {
 		e := c.symbolExists(p)
 		if e != nil {
-			return nil, fmt.Errorf("symbol does not exist: %s", e)
+			return nil, fmt.Errorf("symbol does not exist: %w", e)
 		}
 	}
 
 	// marshal input data
 	data, e := json.Marshal(&tradingPairs)
 	if e != nil {
-		return nil, fmt.Errorf("error marshaling input (tradingPairs=%v) for exchange '%s': %s", tradingPairs, c.exchangeName, e)
+		return nil, fmt.Errorf("error marshaling input (tradingPairs=%v) for exchange '%s': %w", tradingPairs, c.exchangeName, e)
 	}
 
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchOpenOrders"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	var output interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e = c.requestWithRetry(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("error fetching open orders: %s", e)
+		return nil, fmt.Errorf("error fetching open orders: %w", e)
 	}
 
 	result := map[string][]CcxtOpenOrder{}
-	outputList := output.([]interface{})
+	outputList, ok := output.([]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert fetchOpenOrders output to a []interface{}, type = %s", reflect.TypeOf(output))
+	}
 	for _, elem := range outputList {
 		elemMap, ok := elem.(map[string]interface{})
 		if !ok {
-			return nil, fmt.Errorf("could not convert the element in the result to a map[string]interface{}, type = %s", reflect.TypeOf(elem))
+			return nil, c.unexpectedShapeError("could not convert the element in the result to a map[string]interface{}, type = %s", reflect.TypeOf(elem))
 		}
 
-		var openOrder CcxtOpenOrder
-		e = mapstructure.Decode(elemMap, &openOrder)
+		openOrder, e := parseCcxtOrder(elemMap)
 		if e != nil {
-			return nil, fmt.Errorf("could not decode open order element (%v): %s", elemMap, e)
+			return nil, fmt.Errorf("could not decode open order element (%v): %w", elemMap, e)
 		}
 
 		var orderList []CcxtOpenOrder
@@ -556,7 +1769,7 @@ func (c *Ccxt) FetchOpenOrders(tradingPairs []string) (map[string][]CcxtOpenOrde
 			orderList = []CcxtOpenOrder{}
 		}
 
-		orderList = append(orderList, openOrder)
+		orderList = append(orderList, *openOrder)
 		result[openOrder.Symbol] = orderList
 	}
 	return result, nil
@@ -564,10 +1777,55 @@ func (c *Ccxt) FetchOpenOrders(tradingPairs []string) (map[string][]CcxtOpenOrde
 
 // CreateLimitOrder calls the /createOrder endpoint on CCXT with a limit price and the order type set to "limit"
 func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64, price float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
-	orderType := "limit"
+	return c.CreateLimitOrderContext(context.Background(), tradingPair, side, amount, price, maybeExchangeSpecificParams)
+}
+
+// CreateLimitOrderContext is the same as CreateLimitOrder but the request is cancelled when the context is done
+func (c *Ccxt) CreateLimitOrderContext(ctx context.Context, tradingPair string, side string, amount float64, price float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
+	return c.createOrder(ctx, tradingPair, side, "limit", amount, &price, maybeExchangeSpecificParams)
+}
+
+// CreateOrder calls the /createOrder endpoint on CCXT, orderType can be either "limit" or "market" and price should be nil for market orders.
+// params are the exchange-specific params passed through to CCXT (e.g. timeInForce, clientOrderId) and can be nil
+func (c *Ccxt) CreateOrder(tradingPair string, side string, orderType string, amount float64, price *float64, params map[string]interface{}) (*CcxtOpenOrder, error) {
+	return c.CreateOrderContext(context.Background(), tradingPair, side, orderType, amount, price, params)
+}
+
+// CreateOrderContext is the same as CreateOrder but the request is cancelled when the context is done
+func (c *Ccxt) CreateOrderContext(ctx context.Context, tradingPair string, side string, orderType string, amount float64, price *float64, params map[string]interface{}) (*CcxtOpenOrder, error) {
+	// a nil map in an interface{} is not a nil interface{} so we need to check it here
+	var maybeExchangeSpecificParams interface{}
+	if params != nil {
+		maybeExchangeSpecificParams = params
+	}
+	return c.createOrder(ctx, tradingPair, side, orderType, amount, price, maybeExchangeSpecificParams)
+}
+
+func (c *Ccxt) createOrder(ctx context.Context, tradingPair string, side string, orderType string, amount float64, price *float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
+	if side != "buy" && side != "sell" {
+		return nil, fmt.Errorf("invalid side '%s', needs to be either 'buy' or 'sell'", side)
+	}
+	if orderType == "limit" && price == nil {
+		return nil, fmt.Errorf("price cannot be nil for a limit order")
+	} else if orderType == "market" && price != nil {
+		return nil, fmt.Errorf("price needs to be nil for a market order but was %f", *price)
+	} else if orderType != "limit" && orderType != "market" {
+		return nil, fmt.Errorf("invalid orderType '%s', needs to be either 'limit' or 'market'", orderType)
+	}
+
 	e := c.symbolExists(tradingPair)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
+	}
+
+	// round to the precision of the market so the exchange does not reject the order because of floating point errors in the amount or price,
+	// the order is submitted as-is when the market was not loaded since we don't know its precision
+	if market := c.GetMarket(tradingPair); market != nil {
+		roundedAmount, roundedPrice, e := RoundOrderToMarket(*market, amount, price)
+		if e != nil {
+			return nil, fmt.Errorf("cannot create %s order on trading pair '%s' because it is invalid after rounding to the market precision: %w", orderType, tradingPair, e)
+		}
+		amount, price = roundedAmount, roundedPrice
 	}
 
 	// marshal input data
@@ -576,43 +1834,184 @@ func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64,
 		orderType,
 		side,
 		amount,
-		price,
+	}
+	if price != nil {
+		inputData = append(inputData, *price)
+	} else if maybeExchangeSpecificParams != nil {
+		// CCXT expects the params in the position after the price so we need to pass in a null price for market orders
+		inputData = append(inputData, nil)
 	}
 	if maybeExchangeSpecificParams != nil {
 		inputData = append(inputData, maybeExchangeSpecificParams)
 	}
 	data, e := json.Marshal(&inputData)
 	if e != nil {
-		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
 
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/createOrder"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	var output interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e = c.request(ctx, "POST", url, string(data), &output)
+	if e != nil {
+		// the error contains the response body so the rejection message from the exchange is surfaced as-is
+		return nil, fmt.Errorf("error creating %s order: %w", orderType, e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, c.unexpectedShapeError("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	openOrder, e := parseCcxtOrder(outputMap)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %w", outputMap, e)
+	}
+
+	return openOrder, nil
+}
+
+// CcxtDepositAddress represents the result of a FetchDepositAddress call, Tag is the memo or destination tag needed by some assets
+type CcxtDepositAddress struct {
+	Currency string
+	Address  string
+	Tag      string
+}
+
+// FetchDepositAddress calls the /fetchDepositAddress endpoint on CCXT, asset is the CCXT currency code (e.g. "XLM")
+func (c *Ccxt) FetchDepositAddress(asset string) (CcxtDepositAddress, error) {
+	return c.FetchDepositAddressContext(context.Background(), asset)
+}
+
+// FetchDepositAddressContext is the same as FetchDepositAddress but the request is cancelled when the context is done
+func (c *Ccxt) FetchDepositAddressContext(ctx context.Context, asset string) (CcxtDepositAddress, error) {
+	if !c.supportsMethod("fetchDepositAddress") {
+		return CcxtDepositAddress{}, fmt.Errorf("exchange '%s' does not support fetchDepositAddress", c.exchangeName)
+	}
+	if asset == "" {
+		return CcxtDepositAddress{}, fmt.Errorf("asset cannot be empty")
+	}
+
+	// marshal input data
+	data, e := json.Marshal(&[]string{asset})
+	if e != nil {
+		return CcxtDepositAddress{}, fmt.Errorf("error marshaling input (asset=%s) as an array for exchange '%s': %w", asset, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchDepositAddress"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = c.requestWithRetry(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("error creating order: %s", e)
+		return CcxtDepositAddress{}, fmt.Errorf("error fetching deposit address for asset '%s': %w", asset, e)
 	}
 
 	outputMap, ok := output.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+		return CcxtDepositAddress{}, c.unexpectedShapeError("could not convert fetchDepositAddress output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	var depositAddress CcxtDepositAddress
+	e = mapstructure.Decode(outputMap, &depositAddress)
+	if e != nil {
+		return CcxtDepositAddress{}, c.unexpectedShapeError("could not decode fetchDepositAddress output (%v) to a CcxtDepositAddress: %s", outputMap, e)
+	}
+	if depositAddress.Address == "" {
+		return CcxtDepositAddress{}, c.unexpectedShapeError("fetchDepositAddress output did not contain an address: %v", outputMap)
+	}
+	return depositAddress, nil
+}
+
+// CcxtTransaction represents a deposit or withdrawal
+type CcxtTransaction struct {
+	ID        string
+	TxID      string
+	Timestamp int64
+	Address   string
+	Tag       string
+	Type      string
+	Amount    float64
+	Currency  string
+	Status    string
+}
+
+// Withdraw calls the /withdraw endpoint on CCXT to withdraw the amount of the asset to the address, tag can be empty if the asset does not
+// need a memo or destination tag. This request is never retried since a retry could result in a duplicate withdrawal
+func (c *Ccxt) Withdraw(asset string, amount float64, address string, tag string) (CcxtTransaction, error) {
+	return c.WithdrawContext(context.Background(), asset, amount, address, tag)
+}
+
+// WithdrawContext is the same as Withdraw but the request is cancelled when the context is done
+func (c *Ccxt) WithdrawContext(ctx context.Context, asset string, amount float64, address string, tag string) (CcxtTransaction, error) {
+	if !c.supportsMethod("withdraw") {
+		return CcxtTransaction{}, fmt.Errorf("exchange '%s' does not support withdraw", c.exchangeName)
+	}
+	if asset == "" {
+		return CcxtTransaction{}, fmt.Errorf("asset cannot be empty")
+	}
+	if strings.TrimSpace(address) == "" {
+		return CcxtTransaction{}, fmt.Errorf("address cannot be empty")
+	}
+	if amount <= 0 {
+		return CcxtTransaction{}, fmt.Errorf("amount needs to be positive but was %f", amount)
+	}
+
+	// marshal input data
+	inputData := []interface{}{
+		asset,
+		amount,
+		address,
+	}
+	if tag != "" {
+		inputData = append(inputData, tag)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return CcxtTransaction{}, fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
 
-	var openOrder CcxtOpenOrder
-	e = mapstructure.Decode(outputMap, &openOrder)
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/withdraw"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	// use request and not requestWithRetry since withdrawals are not safe to repeat
+	e = c.request(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %s", outputMap, e)
+		return CcxtTransaction{}, fmt.Errorf("error withdrawing %f of asset '%s': %w", amount, asset, e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return CcxtTransaction{}, c.unexpectedShapeError("could not convert withdraw output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
 	}
 
-	return &openOrder, nil
+	var transaction CcxtTransaction
+	e = mapstructure.Decode(outputMap, &transaction)
+	if e != nil {
+		return CcxtTransaction{}, c.unexpectedShapeError("could not decode withdraw output (%v) to a CcxtTransaction: %s", outputMap, e)
+	}
+	// CCXT only guarantees that the id is returned for a withdrawal
+	if transaction.Currency == "" {
+		transaction.Currency = asset
+	}
+	if transaction.Amount == 0 {
+		transaction.Amount = amount
+	}
+	if transaction.Address == "" {
+		transaction.Address = address
+	}
+	return transaction, nil
 }
 
 // CancelOrder calls the /cancelOrder endpoint on CCXT with the orderID and tradingPair
 func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder, error) {
+	return c.CancelOrderContext(context.Background(), orderID, tradingPair)
+}
+
+// CancelOrderContext is the same as CancelOrder but the request is cancelled when the context is done
+func (c *Ccxt) CancelOrderContext(ctx context.Context, orderID string, tradingPair string) (*CcxtOpenOrder, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
-		return nil, fmt.Errorf("symbol does not exist: %s", e)
+		return nil, fmt.Errorf("symbol does not exist: %w", e)
 	}
 
 	// marshal input data
@@ -622,27 +2021,26 @@ func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder,
 	}
 	data, e := json.Marshal(&inputData)
 	if e != nil {
-		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %w", inputData, c.exchangeName, e)
 	}
 
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/cancelOrder"
 	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
 	var output interface{}
-	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	e = c.request(ctx, "POST", url, string(data), &output)
 	if e != nil {
-		return nil, fmt.Errorf("error canceling order: %s", e)
+		return nil, fmt.Errorf("error canceling order: %w", e)
 	}
 
 	outputMap, ok := output.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+		return nil, c.unexpectedShapeError("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
 	}
 
-	var openOrder CcxtOpenOrder
-	e = mapstructure.Decode(outputMap, &openOrder)
+	openOrder, e := parseCcxtOrder(outputMap)
 	if e != nil {
-		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %s", outputMap, e)
+		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %w", outputMap, e)
 	}
 
-	return &openOrder, nil
+	return openOrder, nil
 }
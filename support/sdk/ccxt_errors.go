@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/kelp/support/networking"
+)
+
+// kinds of errors returned by the Ccxt methods, callers can branch on the kind using errors.Is, for example:
+//
+//	if errors.Is(e, sdk.ErrRateLimited) { ... }
+var (
+	// ErrSymbolNotFound is returned when the trading pair is not listed on the exchange
+	ErrSymbolNotFound = errors.New("symbol not found")
+	// ErrRateLimited is returned when the exchange rejected the request because too many requests were made
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInsufficientFunds is returned when the account does not have enough funds for the order or withdrawal
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrExchangeDown is returned when the exchange (or the CCXT REST server) could not be reached or is in maintenance
+	ErrExchangeDown = errors.New("exchange down")
+	// ErrInstanceRecreated is returned when the instance was missing on the CCXT server (such as after the server was restarted) and was
+	// recreated. The request was not repeated since it may not be safe to repeat, such as creating or cancelling an order
+	ErrInstanceRecreated = errors.New("instance recreated")
+)
+
+// CcxtError wraps an error with its kind, which is one of the ErrXxx values above. The message is the message of the wrapped error
+type CcxtError struct {
+	Kind error
+	Err  error
+}
+
+// Error impl.
+func (e *CcxtError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error so errors.As can find the underlying error, such as a *networking.StatusCodeError
+func (e *CcxtError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true when the target is the kind of this error
+func (e *CcxtError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// makeCcxtError wraps the error with the kind
+func makeCcxtError(kind error, e error) error {
+	return &CcxtError{
+		Kind: kind,
+		Err:  e,
+	}
+}
+
+// names of the error classes in CCXT that are included in the response when the exchange returns an error
+var (
+	ccxtRateLimitedErrorNames       = []string{"RateLimitExceeded", "DDoSProtection"}
+	ccxtInsufficientFundsErrorNames = []string{"InsufficientFunds"}
+	ccxtSymbolNotFoundErrorNames    = []string{"BadSymbol"}
+	ccxtExchangeDownErrorNames      = []string{"ExchangeNotAvailable", "OnMaintenance"}
+)
+
+// classifyRequestError wraps the error of a request to the CCXT REST server with its kind, based on the status code of the response and the
+// name of the CCXT error class in the response. It returns the error unchanged when the kind is not known
+func classifyRequestError(e error) error {
+	msg := e.Error()
+	var statusCodeError *networking.StatusCodeError
+	hasStatusCode := errors.As(e, &statusCodeError)
+
+	if (hasStatusCode && statusCodeError.StatusCode == http.StatusTooManyRequests) || containsAny(msg, ccxtRateLimitedErrorNames) {
+		return makeCcxtError(ErrRateLimited, e)
+	}
+	if containsAny(msg, ccxtInsufficientFundsErrorNames) {
+		return makeCcxtError(ErrInsufficientFunds, e)
+	}
+	if containsAny(msg, ccxtSymbolNotFoundErrorNames) {
+		return makeCcxtError(ErrSymbolNotFound, e)
+	}
+	if containsAny(msg, ccxtExchangeDownErrorNames) {
+		return makeCcxtError(ErrExchangeDown, e)
+	}
+	if hasStatusCode && (statusCodeError.StatusCode == http.StatusServiceUnavailable || statusCodeError.StatusCode == http.StatusGatewayTimeout) {
+		return makeCcxtError(ErrExchangeDown, e)
+	}
+	// errors without a status code happened before we received a response, such as connection errors and timeouts
+	if !hasStatusCode && (isRetryableError(e) || isTimeoutError(e)) {
+		return makeCcxtError(ErrExchangeDown, e)
+	}
+	return e
+}
+
+// containsAny returns true if the message contains any of the values
+func containsAny(msg string, values []string) bool {
+	for _, v := range values {
+		if strings.Contains(msg, v) {
+			return true
+		}
+	}
+	return false
+}
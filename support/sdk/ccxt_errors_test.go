@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/networking"
+)
+
+func TestClassifyRequestError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    error
+		wantKind error
+	}{
+		{
+			name:     "rate limited status code",
+			input:    &networking.StatusCodeError{StatusCode: http.StatusTooManyRequests},
+			wantKind: ErrRateLimited,
+		}, {
+			name:     "rate limited error name",
+			input:    fmt.Errorf(`error in response, bodyString: {"error": "DDoSProtection: binance 418 I'm a teapot"}`),
+			wantKind: ErrRateLimited,
+		}, {
+			name:     "insufficient funds",
+			input:    fmt.Errorf(`error in response, bodyString: {"error": "InsufficientFunds: binance Account has insufficient balance"}`),
+			wantKind: ErrInsufficientFunds,
+		}, {
+			name:     "bad symbol",
+			input:    fmt.Errorf(`error in response, bodyString: {"error": "BadSymbol: binance does not have market symbol ABC/XYZ"}`),
+			wantKind: ErrSymbolNotFound,
+		}, {
+			name:     "maintenance",
+			input:    fmt.Errorf(`error in response, bodyString: {"error": "OnMaintenance: binance is under maintenance"}`),
+			wantKind: ErrExchangeDown,
+		}, {
+			name:     "service unavailable",
+			input:    &networking.StatusCodeError{StatusCode: http.StatusServiceUnavailable},
+			wantKind: ErrExchangeDown,
+		}, {
+			name:     "connection error",
+			input:    fmt.Errorf("could not execute http request: dial tcp 127.0.0.1:3000: connect: connection refused"),
+			wantKind: ErrExchangeDown,
+		}, {
+			name:     "unknown",
+			input:    &networking.StatusCodeError{StatusCode: http.StatusBadRequest},
+			wantKind: nil,
+		},
+	}
+
+	allKinds := []error{ErrSymbolNotFound, ErrRateLimited, ErrInsufficientFunds, ErrExchangeDown}
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := classifyRequestError(k.input)
+			if k.wantKind == nil {
+				assert.Equal(t, k.input, e)
+				return
+			}
+
+			// the kind is preserved when the error is wrapped further
+			wrapped := fmt.Errorf("error fetching ticker: %w", e)
+			for _, kind := range allKinds {
+				assert.Equal(t, kind == k.wantKind, errors.Is(wrapped, kind), kind.Error())
+			}
+			assert.Equal(t, k.input.Error(), e.Error())
+
+			// the underlying error is still available
+			var ccxtError *CcxtError
+			if assert.True(t, errors.As(wrapped, &ccxtError)) {
+				assert.Equal(t, k.input, ccxtError.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,1278 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+const fakeInstanceName = "binance___"
+const fakeInstancePath = pathExchanges + "/binance/" + fakeInstanceName
+
+// fakeResponse is the response returned by the fakeCcxtServer for a request
+type fakeResponse struct {
+	statusCode int
+	body       string
+	pages      []string // when set, successive requests get successive pages and the last page is repeated once exhausted
+	// when set, the status code of each page, successive requests get successive status codes and the last one is repeated once exhausted
+	pageStatusCodes []int
+}
+
+// fakeCcxtServer is an httptest based stand-in for the CCXT REST server so we can test the SDK without a live server.
+// Responses are keyed by "METHOD path" and any request without a response gets a 404 with an error body
+type fakeCcxtServer struct {
+	server    *httptest.Server
+	responses map[string]fakeResponse
+	requests  []string
+	bodies    []string      // request bodies in the same order as requests
+	headers   []http.Header // request headers in the same order as requests
+	counts    map[string]int
+	lock      *sync.Mutex
+}
+
+// startFakeCcxtServer starts the server and points the SDK at it, the returned function stops the server and restores the SDK
+func startFakeCcxtServer(responses map[string]fakeResponse) (*fakeCcxtServer, func()) {
+	f := &fakeCcxtServer{
+		responses: responses,
+		requests:  []string{},
+		bodies:    []string{},
+		headers:   []http.Header{},
+		counts:    map[string]int{},
+		lock:      &sync.Mutex{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+
+	prevBaseURL := ccxtBaseURL
+	prevExchangeList := exchangeList
+	ccxtBaseURL = f.server.URL
+	exchangeList = &[]string{"binance"}
+	return f, func() {
+		f.server.Close()
+		ccxtBaseURL = prevBaseURL
+		exchangeList = prevExchangeList
+	}
+}
+
+func (f *fakeCcxtServer) handle(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := ioutil.ReadAll(r.Body)
+	f.lock.Lock()
+	f.requests = append(f.requests, key)
+	f.bodies = append(f.bodies, string(body))
+	f.headers = append(f.headers, r.Header.Clone())
+	response, ok := f.responses[key]
+	count := f.counts[key]
+	f.counts[key]++
+	f.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(fmt.Sprintf(`{"error": "no fake response for '%s'"}`, key)))
+		return
+	}
+	statusCode := response.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	responseBody := response.body
+	if len(response.pages) > 0 {
+		responseBody = response.pages[minInt(count, len(response.pages)-1)]
+	}
+	if len(response.pageStatusCodes) > 0 {
+		statusCode = response.pageStatusCodes[minInt(count, len(response.pageStatusCodes)-1)]
+	}
+	w.WriteHeader(statusCode)
+	w.Write([]byte(responseBody))
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// initResponses are the responses needed to initialize a new instance of binance with an empty API key
+func initResponses() map[string]fakeResponse {
+	return map[string]fakeResponse{
+		"GET " + pathExchanges + "/binance":         {body: `[]`},
+		"POST " + pathExchanges + "/binance":        {body: `{"urls": {}}`},
+		"POST " + fakeInstancePath + "/loadMarkets": {body: `{"XLM/BTC": {"symbol": "XLM/BTC", "base": "XLM", "quote": "BTC"}}`},
+		"GET " + fakeInstancePath:                   {body: `{"has": {"fetchStatus": false}, "symbols": ["XLM/BTC", "BTC/USDT"]}`},
+	}
+}
+
+// withResponses returns the init responses with the additional responses added, overriding any existing keys
+func withResponses(additional map[string]fakeResponse) map[string]fakeResponse {
+	responses := initResponses()
+	for k, v := range additional {
+		responses[k] = v
+	}
+	return responses
+}
+
+// countingRoundTripper counts the requests that go through it so we can check that an injected transport is used
+type countingRoundTripper struct {
+	count int
+}
+
+func (rt *countingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.count++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// recordingLogger records the messages logged through it so we can check that an injected logger is used
+type recordingLogger struct {
+	lock     *sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(msg string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *recordingLogger) Info(msg string) {
+	l.record(msg)
+}
+
+func (l *recordingLogger) Infof(msg string, args ...interface{}) {
+	l.record(fmt.Sprintf(msg, args...))
+}
+
+func (l *recordingLogger) Error(msg string) {
+	l.record(msg)
+}
+
+func (l *recordingLogger) Errorf(msg string, args ...interface{}) {
+	l.record(fmt.Sprintf(msg, args...))
+}
+
+func makeFakeCcxt(t *testing.T) *Ccxt {
+	c, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithRetries(0, time.Millisecond))
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	return c
+}
+
+func TestInitializeWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overrides map[string]fakeResponse
+		wantError bool
+	}{
+		{
+			name:      "success",
+			overrides: map[string]fakeResponse{},
+			wantError: false,
+		}, {
+			name: "existing instance",
+			overrides: map[string]fakeResponse{
+				"GET " + pathExchanges + "/binance":  {body: `["` + fakeInstanceName + `"]`},
+				"POST " + pathExchanges + "/binance": {statusCode: http.StatusBadRequest, body: `{"error": "instance already exists"}`},
+			},
+			wantError: false,
+		}, {
+			name: "error creating instance",
+			overrides: map[string]fakeResponse{
+				"POST " + pathExchanges + "/binance": {statusCode: http.StatusBadRequest, body: `{"error": "invalid params"}`},
+			},
+			wantError: true,
+		}, {
+			name: "malformed instance list",
+			overrides: map[string]fakeResponse{
+				"GET " + pathExchanges + "/binance": {body: `{"instances": []}`},
+			},
+			wantError: true,
+		}, {
+			name: "error loading markets",
+			overrides: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/loadMarkets": {statusCode: http.StatusInternalServerError, body: `{"error": "exchange unavailable"}`},
+			},
+			wantError: true,
+		}, {
+			name: "malformed symbols",
+			overrides: map[string]fakeResponse{
+				"GET " + fakeInstancePath: {body: `{"has": {}, "symbols": {"XLM/BTC": true}}`},
+			},
+			wantError: true,
+		}, {
+			name: "missing symbols",
+			overrides: map[string]fakeResponse{
+				"GET " + fakeInstancePath: {body: `{"has": {}}`},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(withResponses(k.overrides))
+			defer stop()
+
+			rt := &countingRoundTripper{}
+			c, e := MakeInitializedCcxtExchange(
+				"binance",
+				api.ExchangeAPIKey{},
+				[]api.ExchangeParam{},
+				[]api.ExchangeHeader{},
+				WithHTTPClient(&http.Client{Transport: rt}),
+				WithRetries(0, time.Millisecond),
+			)
+			// the injected transport is used for all requests to the instance
+			assert.True(t, rt.count > 0)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			// the details of the exchange are loaded once during initialization
+			assert.Contains(t, f.requests, "GET "+fakeInstancePath)
+			assert.NoError(t, c.symbolExists("XLM/BTC"))
+			assert.NoError(t, c.symbolExists("BTC/USDT"))
+			assert.Error(t, c.symbolExists("XLM/USDT"))
+			assert.False(t, c.supportsMethod("fetchStatus"))
+			assert.True(t, c.supportsMethod("fetchTicker"))
+		})
+	}
+}
+
+func TestFetchTickerWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name       string
+		response   fakeResponse
+		wantTicker *CcxtTicker
+	}{
+		{
+			name:       "success",
+			response:   fakeResponse{body: `{"symbol": "XLM/BTC", "bid": 0.1, "ask": 0.2, "last": null, "timestamp": 1590000000000}`},
+			wantTicker: &CcxtTicker{Symbol: "XLM/BTC", Bid: 0.1, Ask: 0.2, Timestamp: 1590000000000},
+		}, {
+			name:     "error body",
+			response: fakeResponse{statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+		}, {
+			name:     "malformed",
+			response: fakeResponse{body: `["XLM/BTC", 0.1]`},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchTicker": k.response,
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			ticker, e := c.FetchTicker("XLM/BTC")
+			if k.wantTicker == nil {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantTicker, ticker)
+		})
+	}
+}
+
+func TestFetchOrderBookWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response fakeResponse
+		wantAsks []CcxtOrder
+		wantBids []CcxtOrder
+		wantErr  bool
+	}{
+		{
+			name:     "success",
+			response: fakeResponse{body: `{"asks": [[0.2, 10], [0.3, 5]], "bids": [[0.1, 20]], "nonce": 1}`},
+			wantAsks: []CcxtOrder{{Price: 0.2, Amount: 10}, {Price: 0.3, Amount: 5}},
+			wantBids: []CcxtOrder{{Price: 0.1, Amount: 20}},
+		}, {
+			name:     "error body",
+			response: fakeResponse{statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+			wantErr:  true,
+		}, {
+			name:     "malformed",
+			response: fakeResponse{body: `{"asks": "none", "bids": []}`},
+			wantErr:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchOrderBook": k.response,
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			ob, e := c.FetchOrderBook("XLM/BTC", nil, nil)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAsks, ob["asks"])
+			assert.Equal(t, k.wantBids, ob["bids"])
+		})
+	}
+}
+
+func TestFetchOrderBookSideWithFakeServer(t *testing.T) {
+	_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"POST " + fakeInstancePath + "/fetchOrderBook": {body: `{"asks": [[0.3, 5], [0.2, 10]], "bids": [[0.1, 20], [0.15, 2]], "nonce": 1}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	testCases := []struct {
+		side       string
+		wantOrders []CcxtOrder
+		wantErr    bool
+	}{
+		{
+			side:       OrderBookSideAsks,
+			wantOrders: []CcxtOrder{{Price: 0.2, Amount: 10}, {Price: 0.3, Amount: 5}},
+		}, {
+			side:       OrderBookSideBids,
+			wantOrders: []CcxtOrder{{Price: 0.15, Amount: 2}, {Price: 0.1, Amount: 20}},
+		}, {
+			side:    "both",
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.side, func(t *testing.T) {
+			orders, e := c.FetchOrderBookSide("XLM/BTC", k.side, nil, nil)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOrders, orders)
+		})
+	}
+}
+
+func TestFetchL2OrderBookWithFakeServer(t *testing.T) {
+	limit := 2
+	testCases := []struct {
+		name         string
+		has          string
+		responses    map[string]fakeResponse
+		limit        *int
+		wantEndpoint string
+		wantBody     string
+		wantAsks     []CcxtOrder
+		wantBids     []CcxtOrder
+		wantErr      bool
+	}{
+		{
+			name: "supported",
+			has:  `{"fetchL2OrderBook": true}`,
+			responses: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchL2OrderBook": {body: `{"asks": [[0.3, 5], [0.2, 10]], "bids": [[0.1, 20]], "nonce": 1}`},
+			},
+			limit:        nil,
+			wantEndpoint: "/fetchL2OrderBook",
+			wantBody:     `["XLM/BTC"]`,
+			wantAsks:     []CcxtOrder{{Price: 0.2, Amount: 10}, {Price: 0.3, Amount: 5}},
+			wantBids:     []CcxtOrder{{Price: 0.1, Amount: 20}},
+		}, {
+			// binance only supports a limit of 5 or more so the request is clamped and the result is capped to the requested limit
+			name: "supported with limit",
+			has:  `{}`,
+			responses: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchL2OrderBook": {body: `{"asks": [[0.2, 10], [0.3, 5], [0.4, 1]], "bids": []}`},
+			},
+			limit:        &limit,
+			wantEndpoint: "/fetchL2OrderBook",
+			wantBody:     `["XLM/BTC","5"]`,
+			wantAsks:     []CcxtOrder{{Price: 0.2, Amount: 10}, {Price: 0.3, Amount: 5}},
+			wantBids:     []CcxtOrder{},
+		}, {
+			name: "unsupported aggregates the orderbook",
+			has:  `{"fetchL2OrderBook": false}`,
+			responses: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchOrderBook": {body: `{"asks": [[0.2, 10, "a"], [0.3, 5, "b"], [0.2, 2.5, "c"]], "bids": [[0.1, 20, "d"], [0.05, 1, "e"], [0.1, 4, "f"]]}`},
+			},
+			limit:        nil,
+			wantEndpoint: "/fetchOrderBook",
+			wantBody:     `["XLM/BTC"]`,
+			wantAsks:     []CcxtOrder{{Price: 0.2, Amount: 12.5}, {Price: 0.3, Amount: 5}},
+			wantBids:     []CcxtOrder{{Price: 0.1, Amount: 24}, {Price: 0.05, Amount: 1}},
+		}, {
+			name: "error body",
+			has:  `{"fetchL2OrderBook": true}`,
+			responses: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchL2OrderBook": {statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+			},
+			wantErr: true,
+		}, {
+			name: "unsupported with error body",
+			has:  `{"fetchL2OrderBook": false}`,
+			responses: map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchOrderBook": {statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			responses := map[string]fakeResponse{
+				"GET " + fakeInstancePath: {body: `{"has": ` + k.has + `, "symbols": ["XLM/BTC"]}`},
+			}
+			for key, response := range k.responses {
+				responses[key] = response
+			}
+			f, stop := startFakeCcxtServer(withResponses(responses))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			ob, e := c.FetchL2OrderBook("XLM/BTC", k.limit)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAsks, ob["asks"])
+			assert.Equal(t, k.wantBids, ob["bids"])
+
+			lastRequest := len(f.requests) - 1
+			assert.Equal(t, "POST "+fakeInstancePath+k.wantEndpoint, f.requests[lastRequest])
+			assert.Equal(t, k.wantBody, f.bodies[lastRequest])
+		})
+	}
+}
+
+func TestFetchTradesWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name       string
+		response   fakeResponse
+		wantTrades []CcxtTrade
+		wantErr    bool
+	}{
+		{
+			name:     "success",
+			response: fakeResponse{body: `[{"id": "t1", "symbol": "XLM/BTC", "side": "buy", "price": 0.1, "amount": 10, "cost": 1, "timestamp": 1590000000000}]`},
+			wantTrades: []CcxtTrade{
+				{ID: "t1", Symbol: "XLM/BTC", Side: "buy", Price: 0.1, Amount: 10, Cost: 1, Timestamp: 1590000000000},
+			},
+		}, {
+			name: "fee",
+			response: fakeResponse{body: `[
+				{"id": "t1", "symbol": "XLM/BTC", "side": "buy", "price": 0.1, "amount": 10, "cost": 1, "timestamp": 1590000000000, "fee": {"cost": 0.001, "currency": "BTC"}},
+				{"id": "t2", "symbol": "XLM/BTC", "side": "sell", "price": 0.1, "amount": 5, "cost": 0.5, "timestamp": 1590000000001, "fee": null}
+			]`},
+			wantTrades: []CcxtTrade{
+				{ID: "t1", Symbol: "XLM/BTC", Side: "buy", Price: 0.1, Amount: 10, Cost: 1, Timestamp: 1590000000000, Fee: &CcxtFee{Cost: 0.001, Currency: "BTC"}},
+				{ID: "t2", Symbol: "XLM/BTC", Side: "sell", Price: 0.1, Amount: 5, Cost: 0.5, Timestamp: 1590000000001},
+			},
+		}, {
+			name:       "empty",
+			response:   fakeResponse{body: `[]`},
+			wantTrades: []CcxtTrade{},
+		}, {
+			name:     "error body",
+			response: fakeResponse{statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+			wantErr:  true,
+		}, {
+			name:     "malformed",
+			response: fakeResponse{body: `{"trades": []}`},
+			wantErr:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchTrades": k.response,
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			trades, e := c.FetchTrades("XLM/BTC", nil)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantTrades, trades)
+		})
+	}
+}
+
+func TestFetchTradesRangeWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name         string
+		response     fakeResponse
+		wantIDs      []string
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name: "pages until no more trades",
+			response: fakeResponse{pages: []string{
+				`[{"id": "t1", "timestamp": 1000}, {"id": "t2", "timestamp": 2000}]`,
+				`[{"id": "t2", "timestamp": 2000}, {"id": "t3", "timestamp": 3000}]`,
+				`[]`,
+			}},
+			wantIDs:      []string{"t1", "t2", "t3"},
+			wantRequests: 3,
+		}, {
+			name: "stops at until",
+			response: fakeResponse{pages: []string{
+				`[{"id": "t1", "timestamp": 1000}, {"id": "t2", "timestamp": 2000}]`,
+				`[{"id": "t3", "timestamp": 4000}, {"id": "t4", "timestamp": 5000}]`,
+			}},
+			wantIDs:      []string{"t1", "t2", "t3"},
+			wantRequests: 2,
+		}, {
+			name: "drops trades before since",
+			response: fakeResponse{pages: []string{
+				`[{"id": "t0", "timestamp": 500}, {"id": "t1", "timestamp": 1000}]`,
+				`[]`,
+			}},
+			wantIDs:      []string{"t1"},
+			wantRequests: 2,
+		}, {
+			name:         "exchange ignores since",
+			response:     fakeResponse{body: `[{"id": "t1", "timestamp": 1000}]`},
+			wantRequests: maxFetchTradesRangeIterations,
+			wantErr:      true,
+		}, {
+			name:         "error body",
+			response:     fakeResponse{statusCode: http.StatusBadRequest, body: `{"error": "bad symbol"}`},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchTrades": k.response,
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			trades, e := c.FetchTradesRange("XLM/BTC", 1000, 4500)
+			assert.Equal(t, k.wantRequests, f.counts["POST "+fakeInstancePath+"/fetchTrades"])
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			ids := []string{}
+			for _, trade := range trades {
+				ids = append(ids, trade.ID)
+			}
+			assert.Equal(t, k.wantIDs, ids)
+		})
+	}
+}
+
+func TestFetchMyTradesSinceWithFakeServer(t *testing.T) {
+	since := int64(1577836800000)
+	limit := 50
+	testCases := []struct {
+		name     string
+		since    *int64
+		limit    *int
+		has      string
+		wantBody string // empty when the request should not be sent
+	}{
+		{
+			name:     "since and limit",
+			since:    &since,
+			limit:    &limit,
+			wantBody: `["XLM/BTC",1577836800000,50]`,
+		}, {
+			name:     "only since",
+			since:    &since,
+			wantBody: `["XLM/BTC",1577836800000]`,
+		}, {
+			name:     "only limit",
+			limit:    &limit,
+			wantBody: `["XLM/BTC",null,50]`,
+		}, {
+			name:     "neither",
+			wantBody: `["XLM/BTC"]`,
+		}, {
+			name:     "not supported by the exchange",
+			since:    &since,
+			has:      `{"has": {"fetchMyTrades": false}, "symbols": ["XLM/BTC", "BTC/USDT"]}`,
+			wantBody: "",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			responses := map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchMyTrades": {body: `[{"id": "1", "symbol": "XLM/BTC", "timestamp": 1577836800001, "side": "buy", "price": 0.00001, "amount": 10}]`},
+			}
+			if k.has != "" {
+				responses["GET "+fakeInstancePath] = fakeResponse{body: k.has}
+			}
+			f, stop := startFakeCcxtServer(withResponses(responses))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			trades, e := c.FetchMyTradesSince("XLM/BTC", k.since, k.limit)
+			if k.wantBody == "" {
+				if assert.Error(t, e) {
+					assert.Contains(t, e.Error(), "does not support fetchMyTrades")
+				}
+				assert.Equal(t, 0, f.counts["POST "+fakeInstancePath+"/fetchMyTrades"])
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			if assert.Equal(t, 1, len(trades)) {
+				assert.Equal(t, "1", trades[0].ID)
+			}
+			for i, request := range f.requests {
+				if request == "POST "+fakeInstancePath+"/fetchMyTrades" {
+					assert.Equal(t, k.wantBody, f.bodies[i])
+				}
+			}
+		})
+	}
+
+	_, stop := startFakeCcxtServer(initResponses())
+	defer stop()
+	c := makeFakeCcxt(t)
+	_, e := c.FetchMyTradesSince("BTC/XLM", &since, &limit)
+	assert.Error(t, e)
+}
+
+func TestFetchMarketWithFakeServer(t *testing.T) {
+	_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"POST " + fakeInstancePath + "/loadMarkets": {body: `{"XLM/BTC": {
+			"symbol": "XLM/BTC",
+			"base": "XLM",
+			"quote": "BTC",
+			"limits": {"amount": {"min": 1.0}, "price": {"min": 0.00000001}, "cost": {"min": 0.0001}},
+			"precision": {"amount": 0, "price": 8},
+			"taker": 0.001,
+			"maker": 0.0005
+		}, "XLM/USD": {
+			"symbol": "XLM/USD",
+			"base": "XLM",
+			"quote": "USD",
+			"precision": {"amount": null, "price": 4}
+		}}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	market, e := c.FetchMarket("XLM/BTC")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "XLM", market.Base)
+	assert.Equal(t, "BTC", market.Quote)
+	// a precision of 0 is kept so it can be told apart from a missing precision
+	if assert.NotNil(t, market.Precision.Amount) {
+		assert.Equal(t, int8(0), *market.Precision.Amount)
+	}
+	if assert.NotNil(t, market.Precision.Price) {
+		assert.Equal(t, int8(8), *market.Precision.Price)
+	}
+	assert.Equal(t, 1.0, market.Limits.Amount.Min)
+	assert.Equal(t, 0.0001, market.Limits.Cost.Min)
+	assert.Equal(t, 0.001, market.Taker)
+	assert.Equal(t, 0.0005, market.Maker)
+
+	market, e = c.FetchMarket("XLM/USD")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Nil(t, market.Precision.Amount)
+	if assert.NotNil(t, market.Precision.Price) {
+		assert.Equal(t, int8(4), *market.Precision.Price)
+	}
+
+	// BTC/USDT is listed in the symbols of the exchange but was not in the loaded markets
+	_, e = c.FetchMarket("BTC/USDT")
+	assert.Error(t, e)
+}
+
+func TestInitializeWithLogger(t *testing.T) {
+	_, stop := startFakeCcxtServer(initResponses())
+	defer stop()
+
+	l := &recordingLogger{lock: &sync.Mutex{}}
+	_, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithLogger(l), WithRetries(0, time.Millisecond))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{fmt.Sprintf("created new instance '%s' for exchange 'binance'\n", fakeInstanceName)}, l.messages)
+
+	// a nil logger falls back to the basic logger, as does an instance that was not made with the constructor
+	c, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithLogger(nil))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NotNil(t, c.logger)
+	assert.NotNil(t, (&Ccxt{}).log())
+}
+
+func TestInitializeTrimsTrailingSlashWithFakeServer(t *testing.T) {
+	f, stop := startFakeCcxtServer(initResponses())
+	defer stop()
+	ccxtBaseURL = f.server.URL + "/"
+
+	_, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithRetries(0, time.Millisecond))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, f.server.URL, ccxtBaseURL)
+	for _, request := range f.requests {
+		assert.False(t, strings.Contains(request, "//"), request)
+	}
+}
+
+func TestInitializeWithRestHeadersWithFakeServer(t *testing.T) {
+	f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"GET " + pathExchanges:                      {body: `["binance"]`},
+		"POST " + fakeInstancePath + "/fetchTicker": {body: `{"symbol": "XLM/BTC", "last": 0.000023}`},
+	}))
+	defer stop()
+	// the exchange list needs to be fetched with the headers too
+	exchangeList = nil
+
+	restHeaders := map[string]string{"Authorization": "Bearer abc", "X-Proxy": "rest"}
+	c, e := MakeInitializedCcxtExchange(
+		"binance",
+		api.ExchangeAPIKey{},
+		[]api.ExchangeParam{},
+		[]api.ExchangeHeader{{Header: "X-Proxy", Value: "exchange"}, {Header: "X-Exchange", Value: "value"}},
+		WithRestHeaders(restHeaders),
+		WithRetries(0, time.Millisecond),
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// changes to the caller's map are not sent
+	restHeaders["Authorization"] = "Bearer changed"
+	_, e = c.FetchTicker("XLM/BTC")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	if !assert.Equal(t, "GET "+pathExchanges, f.requests[0]) {
+		return
+	}
+	for i, header := range f.headers {
+		assert.Equal(t, "Bearer abc", header.Get("Authorization"), f.requests[i])
+	}
+	// the exchange headers are added once initialized and take precedence over the rest headers
+	last := f.headers[len(f.headers)-1]
+	assert.Equal(t, "exchange", last.Get("X-Proxy"))
+	assert.Equal(t, "value", last.Get("X-Exchange"))
+
+	_, e = MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithRestHeaders(map[string]string{" ": "value"}))
+	assert.Error(t, e)
+}
+
+func TestInitializeWithInstanceNameWithFakeServer(t *testing.T) {
+	instancePath := pathExchanges + "/binance/my-bot"
+	f, stop := startFakeCcxtServer(map[string]fakeResponse{
+		"GET " + pathExchanges + "/binance":     {body: `[]`},
+		"POST " + pathExchanges + "/binance":    {body: `{"urls": {}}`},
+		"POST " + instancePath + "/loadMarkets": {body: `{"XLM/BTC": {"symbol": "XLM/BTC", "base": "XLM", "quote": "BTC"}}`},
+		"GET " + instancePath:                   {body: `{"has": {}, "symbols": ["XLM/BTC"]}`},
+	})
+	defer stop()
+
+	c, e := MakeInitializedCcxtExchange(
+		"binance",
+		api.ExchangeAPIKey{Key: "key", Secret: "secret"},
+		[]api.ExchangeParam{},
+		[]api.ExchangeHeader{},
+		WithInstanceName("my-bot"),
+		WithRetries(0, time.Millisecond),
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "my-bot", c.instanceName)
+	// the instance is created with the name in place of the hashed name
+	assert.Contains(t, f.bodies[1], `"id":"my-bot"`)
+	assert.Contains(t, f.requests, "GET "+instancePath)
+
+	_, e = MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithInstanceName("my/bot"))
+	assert.Error(t, e)
+}
+
+func TestFetchOrderBookClampsLimitWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name        string
+		options     []CcxtOption
+		limit       int
+		wantBody    string
+		wantNumLogs int
+	}{
+		{
+			name:     "supported limit",
+			limit:    20,
+			wantBody: `["XLM/BTC","20"]`,
+		}, {
+			name:        "rounds up to the next supported limit",
+			limit:       7,
+			wantBody:    `["XLM/BTC","10"]`,
+			wantNumLogs: 1,
+		}, {
+			name:        "clamps to the max supported limit",
+			limit:       10000,
+			wantBody:    `["XLM/BTC","5000"]`,
+			wantNumLogs: 1,
+		}, {
+			name:        "overridden limits",
+			options:     []CcxtOption{WithOrderBookLimits(100, 25, 1)},
+			limit:       7,
+			wantBody:    `["XLM/BTC","25"]`,
+			wantNumLogs: 1,
+		}, {
+			name:     "no limits",
+			options:  []CcxtOption{WithOrderBookLimits()},
+			limit:    7,
+			wantBody: `["XLM/BTC","7"]`,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchOrderBook": {body: `{"asks": [[0.5, 1], [0.6, 1]], "bids": [[0.4, 1]]}`},
+			}))
+			defer stop()
+			l := &recordingLogger{lock: &sync.Mutex{}}
+			options := append([]CcxtOption{WithLogger(l), WithRetries(0, time.Millisecond)}, k.options...)
+			c, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, options...)
+			if !assert.NoError(t, e) {
+				return
+			}
+			numInitLogs := len(l.messages)
+
+			limit := k.limit
+			orderbook, e := c.FetchOrderBook("XLM/BTC", &limit, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBody, f.bodies[len(f.bodies)-1])
+			assert.Equal(t, k.wantNumLogs, len(l.messages)-numInitLogs)
+			assert.Equal(t, 2, len(orderbook["asks"]))
+		})
+	}
+
+	_, stop := startFakeCcxtServer(initResponses())
+	defer stop()
+	_, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithOrderBookLimits(0, 5))
+	assert.Error(t, e)
+}
+
+func TestRecreateEvictedInstanceWithFakeServer(t *testing.T) {
+	notFound := `{"error": "exchange instance not found"}`
+	ticker := `{"symbol": "XLM/BTC", "last": 0.5}`
+	testCases := []struct {
+		name                string
+		fetchTicker         fakeResponse
+		createInstance      fakeResponse
+		instanceList        *fakeResponse
+		wantError           bool
+		wantCreateRequests  int
+		wantTickerRequests  int
+		wantRecreateMessage bool
+	}{
+		{
+			name:                "recreated",
+			fetchTicker:         fakeResponse{pages: []string{notFound, ticker}, pageStatusCodes: []int{http.StatusNotFound, http.StatusOK}},
+			createInstance:      fakeResponse{body: `{"urls": {}}`},
+			wantCreateRequests:  2,
+			wantTickerRequests:  2,
+			wantRecreateMessage: true,
+		}, {
+			name:                "still not found after recreating",
+			fetchTicker:         fakeResponse{statusCode: http.StatusNotFound, body: notFound},
+			createInstance:      fakeResponse{body: `{"urls": {}}`},
+			wantError:           true,
+			wantCreateRequests:  2,
+			wantTickerRequests:  2,
+			wantRecreateMessage: true,
+		}, {
+			name:        "error recreating",
+			fetchTicker: fakeResponse{statusCode: http.StatusNotFound, body: notFound},
+			createInstance: fakeResponse{
+				pages:           []string{`{"urls": {}}`, `{"error": "invalid params"}`},
+				pageStatusCodes: []int{http.StatusOK, http.StatusBadRequest},
+			},
+			wantError:          true,
+			wantCreateRequests: 2,
+			wantTickerRequests: 1,
+		}, {
+			// the CCXT server also responds with a 404 for errors from the exchange, which should not recreate the instance
+			name:           "not found error while the instance exists",
+			fetchTicker:    fakeResponse{statusCode: http.StatusNotFound, body: notFound},
+			createInstance: fakeResponse{body: `{"urls": {}}`},
+			instanceList: &fakeResponse{
+				pages: []string{`[]`, fmt.Sprintf(`["%s"]`, fakeInstanceName)},
+			},
+			wantError:          true,
+			wantCreateRequests: 1,
+			wantTickerRequests: 1,
+		}, {
+			name:               "not evicted",
+			fetchTicker:        fakeResponse{body: ticker},
+			createInstance:     fakeResponse{body: `{"urls": {}}`},
+			wantCreateRequests: 1,
+			wantTickerRequests: 1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			responses := map[string]fakeResponse{
+				"POST " + pathExchanges + "/binance":        k.createInstance,
+				"POST " + fakeInstancePath + "/fetchTicker": k.fetchTicker,
+			}
+			if k.instanceList != nil {
+				responses["GET "+pathExchanges+"/binance"] = *k.instanceList
+			}
+			f, stop := startFakeCcxtServer(withResponses(responses))
+			defer stop()
+			l := &recordingLogger{lock: &sync.Mutex{}}
+			c, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{}, WithLogger(l), WithRetries(0, time.Millisecond))
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			_, e = c.FetchTicker("XLM/BTC")
+			assert.Equal(t, k.wantCreateRequests, f.counts["POST "+pathExchanges+"/binance"])
+			assert.Equal(t, k.wantTickerRequests, f.counts["POST "+fakeInstancePath+"/fetchTicker"])
+			hasRecreateMessage := false
+			for _, m := range l.messages {
+				if strings.HasPrefix(m, fmt.Sprintf("recreated instance '%s'", fakeInstanceName)) {
+					hasRecreateMessage = true
+				}
+			}
+			assert.Equal(t, k.wantRecreateMessage, hasRecreateMessage)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			assert.NoError(t, e)
+		})
+	}
+}
+
+func TestRecreateEvictedInstanceDoesNotRepeatCancelWithFakeServer(t *testing.T) {
+	cancelKey := "POST " + fakeInstancePath + "/cancelOrder"
+	f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		cancelKey: {statusCode: http.StatusNotFound, body: `{"error": "exchange instance not found"}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	_, e := c.CancelOrder("order1", "XLM/BTC")
+	assert.True(t, errors.Is(e, ErrInstanceRecreated), e)
+	// the instance is recreated but the cancel is left to the caller since it is not safe to repeat
+	assert.Equal(t, 2, f.counts["POST "+pathExchanges+"/binance"])
+	assert.Equal(t, 1, f.counts[cancelKey])
+}
+
+func TestCloseWithFakeServer(t *testing.T) {
+	deleteKey := "DELETE " + fakeInstancePath
+	testCases := []struct {
+		name          string
+		instanceList  string
+		numCcxt       int
+		wantDeletions []int // the number of delete requests after closing each Ccxt
+	}{
+		{
+			name:          "created instance",
+			instanceList:  `[]`,
+			numCcxt:       1,
+			wantDeletions: []int{1},
+		}, {
+			name:          "created instance used by another Ccxt",
+			instanceList:  `[]`,
+			numCcxt:       2,
+			wantDeletions: []int{0, 1},
+		}, {
+			name:          "existing instance",
+			instanceList:  fmt.Sprintf(`["%s"]`, fakeInstanceName),
+			numCcxt:       1,
+			wantDeletions: []int{0},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"GET " + pathExchanges + "/binance": {body: k.instanceList},
+				deleteKey:                           {body: `{}`},
+			}))
+			defer stop()
+
+			ccxts := []*Ccxt{}
+			for i := 0; i < k.numCcxt; i++ {
+				ccxts = append(ccxts, makeFakeCcxt(t))
+			}
+			for i, c := range ccxts {
+				if !assert.NoError(t, c.Close()) {
+					return
+				}
+				assert.Equal(t, k.wantDeletions[i], f.counts[deleteKey])
+			}
+
+			// closing again is a no-op
+			assert.NoError(t, ccxts[0].Close())
+			assert.Equal(t, k.wantDeletions[len(k.wantDeletions)-1], f.counts[deleteKey])
+		})
+	}
+}
+
+func TestDeleteInstanceWithFakeServer(t *testing.T) {
+	deleteKey := "DELETE " + fakeInstancePath
+	testCases := []struct {
+		name      string
+		response  *fakeResponse
+		wantError bool
+	}{
+		{
+			name:     "deleted",
+			response: &fakeResponse{body: `{}`},
+		}, {
+			// the fake server responds with a 404 when there is no response
+			name:     "already deleted",
+			response: nil,
+		}, {
+			name:      "server error",
+			response:  &fakeResponse{statusCode: http.StatusInternalServerError, body: `{"error": "internal error"}`},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			additional := map[string]fakeResponse{}
+			if k.response != nil {
+				additional[deleteKey] = *k.response
+			}
+			f, stop := startFakeCcxtServer(withResponses(additional))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			e := c.DeleteInstance()
+			assert.Equal(t, 1, f.counts[deleteKey])
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			assert.NoError(t, e)
+		})
+	}
+}
+
+// TestConcurrentFetchesWithFakeServer fetches from many goroutines while the markets are being refreshed, run it with -race to detect data races
+func TestConcurrentFetchesWithFakeServer(t *testing.T) {
+	_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"POST " + fakeInstancePath + "/fetchTicker":    {body: `{"symbol": "XLM/BTC", "last": 0.5}`},
+		"POST " + fakeInstancePath + "/fetchOrderBook": {body: `{"asks": [[0.2, 10]], "bids": [[0.1, 20]], "nonce": 1}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	numGoroutines := 8
+	numIterations := 10
+	errs := make(chan error, numGoroutines*numIterations*4)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < numIterations; j++ {
+				if _, e := c.FetchTicker("XLM/BTC"); e != nil {
+					errs <- fmt.Errorf("FetchTicker: %s", e)
+				}
+				if _, e := c.FetchOrderBook("XLM/BTC", nil, nil); e != nil {
+					errs <- fmt.Errorf("FetchOrderBook: %s", e)
+				}
+				if _, e := c.FetchMarket("XLM/BTC"); e != nil {
+					errs <- fmt.Errorf("FetchMarket: %s", e)
+				}
+				c.GetMarkets()
+				// one goroutine keeps replacing the cached markets while the others read them
+				if i == 0 {
+					if e := c.RefreshMarkets(); e != nil {
+						errs <- fmt.Errorf("RefreshMarkets: %s", e)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for e := range errs {
+		assert.NoError(t, e)
+	}
+}
+
+func TestErrorKindsWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		symbol   string
+		response fakeResponse
+		wantKind error
+	}{
+		{
+			name:     "symbol not listed",
+			symbol:   "ABC/XYZ",
+			response: fakeResponse{body: `{}`},
+			wantKind: ErrSymbolNotFound,
+		}, {
+			name:     "rate limited",
+			symbol:   "XLM/BTC",
+			response: fakeResponse{statusCode: http.StatusTooManyRequests, body: `{"error": "RateLimitExceeded: binance too many requests"}`},
+			wantKind: ErrRateLimited,
+		}, {
+			name:     "exchange down",
+			symbol:   "XLM/BTC",
+			response: fakeResponse{statusCode: http.StatusInternalServerError, body: `{"error": "ExchangeNotAvailable: binance GET https://api.binance.com 502 Bad Gateway"}`},
+			wantKind: ErrExchangeDown,
+		}, {
+			name:     "unknown",
+			symbol:   "XLM/BTC",
+			response: fakeResponse{statusCode: http.StatusBadRequest, body: `{"error": "something went wrong"}`},
+			wantKind: nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/fetchTicker": k.response,
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			_, e := c.FetchTicker(k.symbol)
+			if !assert.Error(t, e) {
+				return
+			}
+			for _, kind := range []error{ErrSymbolNotFound, ErrRateLimited, ErrInsufficientFunds, ErrExchangeDown} {
+				assert.Equal(t, kind == k.wantKind, errors.Is(e, kind), kind.Error())
+			}
+		})
+	}
+}
+
+func TestContextVariantsWithFakeServer(t *testing.T) {
+	testCases := []struct {
+		endpoint string
+		call     func(ctx context.Context, c *Ccxt) error
+	}{
+		{
+			endpoint: "fetchTickers",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.FetchTickersContext(ctx, []string{"XLM/BTC"})
+				return e
+			},
+		}, {
+			endpoint: "fetchMyTrades",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.FetchMyTradesContext(ctx, "XLM/BTC", 10, nil)
+				return e
+			},
+		}, {
+			endpoint: "fetchBalance",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.FetchBalanceContext(ctx)
+				return e
+			},
+		}, {
+			endpoint: "fetchOpenOrders",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.FetchOpenOrdersContext(ctx, []string{"XLM/BTC"})
+				return e
+			},
+		}, {
+			endpoint: "createOrder",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.CreateLimitOrderContext(ctx, "XLM/BTC", "buy", 10, 0.00001, nil)
+				return e
+			},
+		}, {
+			endpoint: "fetchDepositAddress",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.FetchDepositAddressContext(ctx, "XLM")
+				return e
+			},
+		}, {
+			endpoint: "withdraw",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.WithdrawContext(ctx, "XLM", 10, "GABC", "")
+				return e
+			},
+		}, {
+			endpoint: "cancelOrder",
+			call: func(ctx context.Context, c *Ccxt) error {
+				_, e := c.CancelOrderContext(ctx, "1", "XLM/BTC")
+				return e
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.endpoint, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(initResponses())
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			// the request is not sent when the context is already done
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			e := k.call(ctx, c)
+			if assert.Error(t, e) {
+				assert.Contains(t, e.Error(), context.Canceled.Error())
+			}
+			assert.Equal(t, 0, f.counts["POST "+fakeInstancePath+"/"+k.endpoint])
+		})
+	}
+}
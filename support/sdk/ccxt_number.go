@@ -0,0 +1,45 @@
+package sdk
+
+import (
+	"github.com/stellar/kelp/model"
+)
+
+// PriceNumber returns the price as a model.Number rounded to the precision, use this instead of the raw float64 to avoid float errors
+func (o CcxtOrder) PriceNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(o.Price, precision)
+}
+
+// AmountNumber returns the amount as a model.Number with the precision
+func (o CcxtOrder) AmountNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(o.Amount, precision)
+}
+
+// PriceNumber returns the price as a model.Number with the precision
+func (t CcxtTrade) PriceNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(t.Price, precision)
+}
+
+// AmountNumber returns the amount as a model.Number with the precision
+func (t CcxtTrade) AmountNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(t.Amount, precision)
+}
+
+// CostNumber returns the cost as a model.Number with the precision
+func (t CcxtTrade) CostNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(t.Cost, precision)
+}
+
+// PriceNumber returns the price as a model.Number with the precision
+func (o CcxtOpenOrder) PriceNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(o.Price, precision)
+}
+
+// AmountNumber returns the amount as a model.Number with the precision
+func (o CcxtOpenOrder) AmountNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(o.Amount, precision)
+}
+
+// FilledNumber returns the filled amount as a model.Number with the precision
+func (o CcxtOpenOrder) FilledNumber(precision int8) *model.Number {
+	return model.NumberFromFloat(o.Filled, precision)
+}
@@ -0,0 +1,37 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCcxtNumbers(t *testing.T) {
+	order := CcxtOrder{Price: 0.12345678, Amount: 1000.06}
+	trade := CcxtTrade{Price: 0.000023456, Amount: 12.3456, Cost: 0.00028958}
+	openOrder := CcxtOpenOrder{Price: 1.23456, Amount: 10.0, Filled: 3.33333}
+
+	testCases := []struct {
+		name      string
+		got       string
+		wantValue string
+	}{
+		{name: "order price", got: order.PriceNumber(4).AsString(), wantValue: "0.1235"},
+		{name: "order amount", got: order.AmountNumber(1).AsString(), wantValue: "1000.1"},
+		{name: "order amount zero precision", got: order.AmountNumber(0).AsString(), wantValue: "1000"},
+		{name: "trade price", got: trade.PriceNumber(7).AsString(), wantValue: "0.0000235"},
+		{name: "trade amount", got: trade.AmountNumber(2).AsString(), wantValue: "12.35"},
+		{name: "trade cost", got: trade.CostNumber(8).AsString(), wantValue: "0.00028958"},
+		{name: "open order price", got: openOrder.PriceNumber(2).AsString(), wantValue: "1.23"},
+		{name: "open order amount", got: openOrder.AmountNumber(3).AsString(), wantValue: "10.000"},
+		{name: "open order filled", got: openOrder.FilledNumber(3).AsString(), wantValue: "3.333"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.wantValue, k.got)
+		})
+	}
+
+	assert.Equal(t, int8(4), order.PriceNumber(4).Precision())
+}
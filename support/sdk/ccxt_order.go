@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CcxtOpenOrder represents an order returned by CCXT from the createOrder, cancelOrder and fetchOpenOrders endpoints. Fields that the
+// exchange did not return (or returned as null) are left as the zero value
+type CcxtOpenOrder struct {
+	Amount    float64
+	Cost      float64
+	Filled    float64
+	ID        string
+	Price     float64
+	Remaining float64
+	Side      string
+	Status    string
+	Symbol    string
+	Type      string
+	Timestamp int64 // milliseconds since the epoch
+}
+
+// parseCcxtOrder converts the order object in a response from CCXT to a CcxtOpenOrder. Exchanges format the fields differently, for
+// example some return the ID as a number and the amounts as strings, so this accepts either representation and treats missing and null
+// fields as unset. It only returns an error when a field has a type or value that cannot be converted
+func parseCcxtOrder(orderMap map[string]interface{}) (*CcxtOpenOrder, error) {
+	o := &CcxtOpenOrder{}
+	var e error
+	stringFields := []struct {
+		key   string
+		value *string
+	}{
+		{"id", &o.ID},
+		{"symbol", &o.Symbol},
+		{"side", &o.Side},
+		{"type", &o.Type},
+		{"status", &o.Status},
+	}
+	for _, f := range stringFields {
+		*f.value, e = parseCcxtOrderString(orderMap[f.key])
+		if e != nil {
+			return nil, fmt.Errorf("could not parse field '%s' of order: %w", f.key, e)
+		}
+	}
+
+	floatFields := []struct {
+		key   string
+		value *float64
+	}{
+		{"price", &o.Price},
+		{"amount", &o.Amount},
+		{"filled", &o.Filled},
+		{"cost", &o.Cost},
+	}
+	for _, f := range floatFields {
+		*f.value, _, e = parseCcxtOrderFloat(orderMap[f.key])
+		if e != nil {
+			return nil, fmt.Errorf("could not parse field '%s' of order: %w", f.key, e)
+		}
+	}
+
+	remaining, hasRemaining, e := parseCcxtOrderFloat(orderMap["remaining"])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse field 'remaining' of order: %w", e)
+	}
+	if hasRemaining {
+		o.Remaining = remaining
+	} else if o.Amount > 0 {
+		// some exchanges only return the amount and filled fields
+		o.Remaining = o.Amount - o.Filled
+	}
+
+	o.Timestamp, e = parseCcxtOrderTimestamp(orderMap["timestamp"], orderMap["datetime"])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse timestamp of order: %w", e)
+	}
+	return o, nil
+}
+
+// parseCcxtOrderString converts a string or numeric value to a string, nil is converted to ""
+func parseCcxtOrderString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("unexpected type %s for a string value (%v)", reflect.TypeOf(v), v)
+	}
+}
+
+// parseCcxtOrderFloat converts a numeric or string value to a float64 and returns whether the value was set, nil and "" are not set
+func parseCcxtOrderFloat(v interface{}) (float64, bool, error) {
+	switch value := v.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return value, true, nil
+	case json.Number:
+		f, e := value.Float64()
+		if e != nil {
+			return 0, false, fmt.Errorf("could not parse number '%s': %w", value, e)
+		}
+		return f, true, nil
+	case string:
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return 0, false, nil
+		}
+		f, e := strconv.ParseFloat(value, 64)
+		if e != nil {
+			return 0, false, fmt.Errorf("could not parse number '%s': %w", value, e)
+		}
+		return f, true, nil
+	default:
+		return 0, false, fmt.Errorf("unexpected type %s for a numeric value (%v)", reflect.TypeOf(v), v)
+	}
+}
+
+// parseCcxtOrderTimestamp returns the timestamp in milliseconds, falling back to the ISO 8601 datetime when the timestamp is not set
+func parseCcxtOrderTimestamp(timestamp interface{}, datetime interface{}) (int64, error) {
+	millis, hasTimestamp, e := parseCcxtOrderFloat(timestamp)
+	if e != nil {
+		return 0, e
+	}
+	if hasTimestamp {
+		return int64(millis), nil
+	}
+
+	datetimeString, e := parseCcxtOrderString(datetime)
+	if e != nil {
+		return 0, e
+	}
+	if datetimeString == "" {
+		return 0, nil
+	}
+	t, e := time.Parse(time.RFC3339Nano, datetimeString)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse datetime '%s': %w", datetimeString, e)
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
@@ -0,0 +1,210 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCcxtOrder(t *testing.T) {
+	testCases := []struct {
+		name      string
+		orderJSON string
+		wantOrder *CcxtOpenOrder
+	}{
+		{
+			name: "binance",
+			orderJSON: `{
+				"info": {"symbol": "XLMBTC", "orderId": 28457, "status": "NEW"},
+				"id": "28457",
+				"clientOrderId": "x-R4BD3S82",
+				"timestamp": 1499827319559,
+				"datetime": "2017-07-12T02:41:59.559Z",
+				"lastTradeTimestamp": null,
+				"symbol": "XLM/BTC",
+				"type": "limit",
+				"timeInForce": "GTC",
+				"side": "buy",
+				"price": 0.0000231,
+				"amount": 100,
+				"cost": 0.00023100,
+				"average": null,
+				"filled": 10,
+				"remaining": 90,
+				"status": "open",
+				"fee": null,
+				"trades": []
+			}`,
+			wantOrder: &CcxtOpenOrder{
+				Amount:    100,
+				Cost:      0.000231,
+				Filled:    10,
+				ID:        "28457",
+				Price:     0.0000231,
+				Remaining: 90,
+				Side:      "buy",
+				Status:    "open",
+				Symbol:    "XLM/BTC",
+				Type:      "limit",
+				Timestamp: 1499827319559,
+			},
+		}, {
+			// kraken returns the open time in seconds with a fractional part so the timestamp has a fractional part
+			name: "kraken",
+			orderJSON: `{
+				"id": "OQCLML-BW3P3-BUCMWZ",
+				"timestamp": 1616666666123.456,
+				"datetime": "2021-03-25T10:04:26.123Z",
+				"status": "open",
+				"symbol": "XLM/USD",
+				"type": "limit",
+				"side": "sell",
+				"price": 0.41,
+				"amount": 250,
+				"filled": 0,
+				"remaining": null,
+				"cost": 0,
+				"fee": {"cost": 0, "rate": null, "currency": "USD"}
+			}`,
+			wantOrder: &CcxtOpenOrder{
+				Amount:    250,
+				ID:        "OQCLML-BW3P3-BUCMWZ",
+				Price:     0.41,
+				Remaining: 250,
+				Side:      "sell",
+				Status:    "open",
+				Symbol:    "XLM/USD",
+				Type:      "limit",
+				Timestamp: 1616666666123,
+			},
+		}, {
+			name: "numeric id and string amounts",
+			orderJSON: `{
+				"id": 448364249,
+				"timestamp": "1444272165252",
+				"symbol": "BTC/USD",
+				"type": "limit",
+				"side": "sell",
+				"price": "10000.5",
+				"amount": "0.01",
+				"filled": "0.004",
+				"remaining": "0.006",
+				"status": "open"
+			}`,
+			wantOrder: &CcxtOpenOrder{
+				Amount:    0.01,
+				Filled:    0.004,
+				ID:        "448364249",
+				Price:     10000.5,
+				Remaining: 0.006,
+				Side:      "sell",
+				Status:    "open",
+				Symbol:    "BTC/USD",
+				Type:      "limit",
+				Timestamp: 1444272165252,
+			},
+		}, {
+			name: "market order with null price and timestamp",
+			orderJSON: `{
+				"id": "5c1e3c47-0a04-4b6c-a4d7-2b5a3f5b6c7d",
+				"timestamp": null,
+				"datetime": "2020-01-02T03:04:05.678Z",
+				"symbol": "XLM/USDT",
+				"type": "market",
+				"side": "buy",
+				"price": null,
+				"amount": 50,
+				"filled": 50,
+				"remaining": 0,
+				"status": "closed"
+			}`,
+			wantOrder: &CcxtOpenOrder{
+				Amount:    50,
+				Filled:    50,
+				ID:        "5c1e3c47-0a04-4b6c-a4d7-2b5a3f5b6c7d",
+				Side:      "buy",
+				Status:    "closed",
+				Symbol:    "XLM/USDT",
+				Type:      "market",
+				Timestamp: 1577934245678,
+			},
+		}, {
+			// some exchanges only return the raw response when cancelling an order
+			name:      "cancel response with only info",
+			orderJSON: `{"info": {"count": 1}}`,
+			wantOrder: &CcxtOpenOrder{},
+		}, {
+			name:      "invalid price",
+			orderJSON: `{"id": "1", "price": "abc"}`,
+			wantOrder: nil,
+		}, {
+			name:      "invalid id",
+			orderJSON: `{"id": {"value": "1"}}`,
+			wantOrder: nil,
+		}, {
+			name:      "invalid datetime",
+			orderJSON: `{"id": "1", "datetime": "yesterday"}`,
+			wantOrder: nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var orderMap map[string]interface{}
+			if !assert.NoError(t, json.Unmarshal([]byte(k.orderJSON), &orderMap)) {
+				return
+			}
+
+			order, e := parseCcxtOrder(orderMap)
+			if k.wantOrder == nil {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantOrder.Remaining, order.Remaining, 1e-12)
+			order.Remaining = k.wantOrder.Remaining
+			assert.Equal(t, k.wantOrder, order)
+		})
+	}
+}
+
+func TestOrderEndpointsWithFakeServer(t *testing.T) {
+	binanceOrder := `{"id": "28457", "timestamp": 1499827319559, "symbol": "XLM/BTC", "type": "limit", "side": "buy", "price": 0.0000231, "amount": 100, "filled": 0, "remaining": 100, "status": "open"}`
+	numericOrder := `{"id": 448364249, "timestamp": "1444272165252", "symbol": "XLM/BTC", "type": "limit", "side": "sell", "price": "0.0000240", "amount": "50", "filled": null, "status": "open"}`
+	f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"POST " + fakeInstancePath + "/fetchOpenOrders": {body: `[` + binanceOrder + `, ` + numericOrder + `]`},
+		"POST " + fakeInstancePath + "/createOrder":     {body: numericOrder},
+		"POST " + fakeInstancePath + "/cancelOrder":     {body: `{"info": {"count": 1}, "id": null}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	openOrders, e := c.FetchOpenOrders([]string{"XLM/BTC"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(openOrders["XLM/BTC"])) {
+		return
+	}
+	assert.Equal(t, "28457", openOrders["XLM/BTC"][0].ID)
+	assert.Equal(t, 100.0, openOrders["XLM/BTC"][0].Remaining)
+	assert.Equal(t, "448364249", openOrders["XLM/BTC"][1].ID)
+	assert.Equal(t, 0.000024, openOrders["XLM/BTC"][1].Price)
+	assert.Equal(t, 50.0, openOrders["XLM/BTC"][1].Remaining)
+
+	createdOrder, e := c.CreateLimitOrder("XLM/BTC", "sell", 50, 0.000024, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, openOrders["XLM/BTC"][1], *createdOrder)
+
+	canceledOrder, e := c.CancelOrder("448364249", "XLM/BTC")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, CcxtOpenOrder{}, *canceledOrder)
+	assert.Contains(t, f.requests, "POST "+fakeInstancePath+"/cancelOrder")
+}
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/model"
+)
+
+// RoundOrderToMarket rounds the amount and price of an order down to the precision of the market so the order is not rejected by exchanges
+// that enforce step sizes, e.g. an amount of 0.30000000000000004 is rounded to 0.3. The price is nil for market orders.
+//
+// A precision of 0 rounds to whole units. When the market does not have the amount precision the amount uses the price precision, and
+// the value is not rounded if neither precision is available.
+//
+// It returns an error if the rounded amount or price is zero or below the min limits of the market
+func RoundOrderToMarket(market CcxtMarket, amount float64, price *float64) (float64, *float64, error) {
+	amountPrecision := market.Precision.Amount
+	if amountPrecision == nil {
+		amountPrecision = market.Precision.Price
+	}
+	roundedAmount := roundDownToPrecision(amount, amountPrecision)
+	if roundedAmount <= 0 {
+		return 0, nil, fmt.Errorf("amount (%.12f) is zero after rounding down to the amount precision (%s) of market '%s'", amount, precisionString(amountPrecision), market.Symbol)
+	}
+	if roundedAmount < market.Limits.Amount.Min {
+		return 0, nil, fmt.Errorf("amount (%.12f) rounded to the amount precision (%s) is %.12f which is below the min amount (%.12f) of market '%s'",
+			amount, precisionString(amountPrecision), roundedAmount, market.Limits.Amount.Min, market.Symbol)
+	}
+
+	if price == nil {
+		return roundedAmount, nil, nil
+	}
+	roundedPrice := roundDownToPrecision(*price, market.Precision.Price)
+	if roundedPrice <= 0 {
+		return 0, nil, fmt.Errorf("price (%.12f) is zero after rounding down to the price precision (%s) of market '%s'", *price, precisionString(market.Precision.Price), market.Symbol)
+	}
+	if roundedPrice < market.Limits.Price.Min {
+		return 0, nil, fmt.Errorf("price (%.12f) rounded to the price precision (%s) is %.12f which is below the min price (%.12f) of market '%s'",
+			*price, precisionString(market.Precision.Price), roundedPrice, market.Limits.Price.Min, market.Symbol)
+	}
+	if cost := roundedAmount * roundedPrice; cost < market.Limits.Cost.Min {
+		return 0, nil, fmt.Errorf("cost (%.12f) of the rounded amount (%.12f) and rounded price (%.12f) is below the min cost (%.12f) of market '%s'",
+			cost, roundedAmount, roundedPrice, market.Limits.Cost.Min, market.Symbol)
+	}
+	return roundedAmount, &roundedPrice, nil
+}
+
+// RoundOrder is RoundOrderToMarket for the market of the trading pair, it returns an error if the market is not loaded
+func (c *Ccxt) RoundOrder(tradingPair string, amount float64, price *float64) (float64, *float64, error) {
+	market, e := c.FetchMarket(tradingPair)
+	if e != nil {
+		return 0, nil, fmt.Errorf("could not fetch market: %w", e)
+	}
+	return RoundOrderToMarket(market, amount, price)
+}
+
+// roundDownToPrecision truncates the value to the number of decimals, a nil precision leaves the value unchanged (see RoundOrderToMarket)
+func roundDownToPrecision(value float64, precision *int8) float64 {
+	if precision == nil {
+		return value
+	}
+	return model.NumberFromFloatRoundTruncate(value, *precision).AsFloat()
+}
+
+// precisionString formats the precision for error messages
+func precisionString(precision *int8) string {
+	if precision == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d", *precision)
+}
@@ -0,0 +1,266 @@
+package sdk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func int8Ptr(i int8) *int8 {
+	return &i
+}
+
+func makeTestMarket(amountPrecision *int8, pricePrecision *int8, minAmount float64, minPrice float64, minCost float64) CcxtMarket {
+	market := CcxtMarket{Symbol: "XLM/BTC", Base: "XLM", Quote: "BTC"}
+	market.Precision.Amount = amountPrecision
+	market.Precision.Price = pricePrecision
+	market.Limits.Amount.Min = minAmount
+	market.Limits.Price.Min = minPrice
+	market.Limits.Cost.Min = minCost
+	return market
+}
+
+func TestRoundOrderToMarket(t *testing.T) {
+	floatPtr := func(f float64) *float64 {
+		return &f
+	}
+
+	testCases := []struct {
+		name       string
+		market     CcxtMarket
+		amount     float64
+		price      *float64
+		wantAmount float64
+		wantPrice  *float64
+		wantError  bool
+	}{
+		{
+			name:       "floating point error",
+			market:     makeTestMarket(int8Ptr(1), int8Ptr(8), 0, 0, 0),
+			amount:     0.1 + 0.2,
+			price:      floatPtr(0.000012345678),
+			wantAmount: 0.3,
+			wantPrice:  floatPtr(0.00001234),
+		}, {
+			name:       "rounds down",
+			market:     makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0, 0),
+			amount:     10.239,
+			price:      floatPtr(0.12349),
+			wantAmount: 10.23,
+			wantPrice:  floatPtr(0.1234),
+		}, {
+			name:       "market order",
+			market:     makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0, 0),
+			amount:     10.239,
+			price:      nil,
+			wantAmount: 10.23,
+			wantPrice:  nil,
+		}, {
+			name:       "amount uses price precision when missing",
+			market:     makeTestMarket(nil, int8Ptr(3), 0, 0, 0),
+			amount:     10.2399,
+			price:      floatPtr(0.12349),
+			wantAmount: 10.239,
+			wantPrice:  floatPtr(0.123),
+		}, {
+			name:       "no precision",
+			market:     makeTestMarket(nil, nil, 0, 0, 0),
+			amount:     10.2399,
+			price:      floatPtr(0.12349),
+			wantAmount: 10.2399,
+			wantPrice:  floatPtr(0.12349),
+		}, {
+			name:       "zero precision rounds to whole units",
+			market:     makeTestMarket(int8Ptr(0), int8Ptr(0), 0, 0, 0),
+			amount:     10.2399,
+			price:      floatPtr(3.12349),
+			wantAmount: 10,
+			wantPrice:  floatPtr(3),
+		}, {
+			name:      "amount rounds to zero with zero precision",
+			market:    makeTestMarket(int8Ptr(0), int8Ptr(4), 0, 0, 0),
+			amount:    0.9,
+			price:     floatPtr(0.1),
+			wantError: true,
+		}, {
+			name:       "at min limits",
+			market:     makeTestMarket(int8Ptr(2), int8Ptr(4), 1.0, 0.1, 0.1),
+			amount:     1.0,
+			price:      floatPtr(0.1),
+			wantAmount: 1.0,
+			wantPrice:  floatPtr(0.1),
+		}, {
+			name:      "amount rounds to zero",
+			market:    makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0, 0),
+			amount:    0.009,
+			price:     floatPtr(0.1),
+			wantError: true,
+		}, {
+			name:      "price rounds to zero",
+			market:    makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0, 0),
+			amount:    10,
+			price:     floatPtr(0.00009),
+			wantError: true,
+		}, {
+			name:      "amount below min",
+			market:    makeTestMarket(int8Ptr(2), int8Ptr(4), 1.0, 0, 0),
+			amount:    1.009 - 0.01,
+			price:     floatPtr(0.1),
+			wantError: true,
+		}, {
+			name:      "price below min",
+			market:    makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0.1, 0),
+			amount:    10,
+			price:     floatPtr(0.09999),
+			wantError: true,
+		}, {
+			name:      "cost below min",
+			market:    makeTestMarket(int8Ptr(2), int8Ptr(4), 0, 0, 1.0),
+			amount:    10,
+			price:     floatPtr(0.09999),
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			amount, price, e := RoundOrderToMarket(k.market, k.amount, k.price)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAmount, amount)
+			if k.wantPrice == nil {
+				assert.Nil(t, price)
+				return
+			}
+			if assert.NotNil(t, price) {
+				assert.Equal(t, *k.wantPrice, *price)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRoundsToMarketWithFakeServer(t *testing.T) {
+	server, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+		"POST " + fakeInstancePath + "/loadMarkets": {body: `{"XLM/BTC": {
+			"symbol": "XLM/BTC",
+			"base": "XLM",
+			"quote": "BTC",
+			"limits": {"amount": {"min": 1.0}, "price": {"min": 0.00000001}, "cost": {"min": 0.0001}},
+			"precision": {"amount": 1, "price": 6}
+		}}`},
+		"POST " + fakeInstancePath + "/createOrder": {body: `{"id": "1"}`},
+	}))
+	defer stop()
+	c := makeFakeCcxt(t)
+
+	price := 0.0000123456
+	_, e := c.CreateLimitOrder("XLM/BTC", "sell", 100.1+0.2, price, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	lastBody := server.bodies[len(server.bodies)-1]
+	assert.Equal(t, `["XLM/BTC","limit","sell",100.3,0.000012]`, lastBody)
+
+	// rounds to a cost below the min cost so the order is not submitted
+	numRequests := len(server.requests)
+	_, e = c.CreateLimitOrder("XLM/BTC", "sell", 1.09, price, nil)
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "min cost")
+	}
+	assert.Equal(t, numRequests, len(server.requests), fmt.Sprintf("requests: %v", server.requests))
+
+	amount, roundedPrice, e := c.RoundOrder("XLM/BTC", 20.19, &price)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 20.1, amount)
+	assert.Equal(t, 0.000012, *roundedPrice)
+
+	_, _, e = c.RoundOrder("BTC/USDT", 20.19, &price)
+	assert.Error(t, e)
+}
+
+func TestCreateOrderWithRoundingWithFakeServer(t *testing.T) {
+	floatPtr := func(f float64) *float64 {
+		return &f
+	}
+	createdOrder := `{"id": "1", "symbol": "XLM/BTC", "type": "limit", "side": "buy", "price": 0.0000231, "amount": 0.3, "status": "open"}`
+	testCases := []struct {
+		name      string
+		orderType string
+		amount    float64
+		price     *float64
+		wantBody  string // empty when the order should be rejected before it is sent
+	}{
+		{
+			name:      "limit order is rounded down",
+			orderType: "limit",
+			amount:    0.1 + 0.2, // 0.30000000000000004
+			price:     floatPtr(0.0000231999),
+			wantBody:  `["XLM/BTC","limit","buy",0.3,0.0000231]`,
+		}, {
+			name:      "market order is rounded down",
+			orderType: "market",
+			amount:    1.23456,
+			price:     nil,
+			wantBody:  `["XLM/BTC","market","buy",1.2]`,
+		}, {
+			name:      "amount rounds to zero",
+			orderType: "limit",
+			amount:    0.09,
+			price:     floatPtr(0.0000231),
+			wantBody:  "",
+		}, {
+			name:      "amount below the min amount",
+			orderType: "limit",
+			amount:    0.19,
+			price:     floatPtr(0.0000231),
+			wantBody:  "",
+		}, {
+			name:      "price rounds to zero",
+			orderType: "limit",
+			amount:    10,
+			price:     floatPtr(0.00000001),
+			wantBody:  "",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, stop := startFakeCcxtServer(withResponses(map[string]fakeResponse{
+				"POST " + fakeInstancePath + "/loadMarkets": {body: `{"XLM/BTC": {
+					"symbol": "XLM/BTC",
+					"base": "XLM",
+					"quote": "BTC",
+					"limits": {"amount": {"min": 0.2}},
+					"precision": {"amount": 1, "price": 7}
+				}}`},
+				"POST " + fakeInstancePath + "/createOrder": {body: createdOrder},
+			}))
+			defer stop()
+			c := makeFakeCcxt(t)
+
+			order, e := c.CreateOrder("XLM/BTC", "buy", k.orderType, k.amount, k.price, nil)
+			if k.wantBody == "" {
+				assert.Error(t, e)
+				assert.NotContains(t, f.requests, "POST "+fakeInstancePath+"/createOrder")
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "1", order.ID)
+			for i, request := range f.requests {
+				if request == "POST "+fakeInstancePath+"/createOrder" {
+					assert.Equal(t, k.wantBody, f.bodies[i])
+				}
+			}
+		})
+	}
+}
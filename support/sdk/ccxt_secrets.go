@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/stellar/kelp/api"
+)
+
+// prefixes of the values of an api.ExchangeAPIKey that reference a secret instead of containing it
+const (
+	// secretRefEnv references an environment variable, eg "env:BINANCE_SECRET"
+	secretRefEnv = "env:"
+	// secretRefFile references a file, such as a docker or kubernetes secret, eg "file:/run/secrets/binance_secret"
+	secretRefFile = "file:"
+)
+
+// resolveAPIKey returns a copy of the apiKey where the values that reference a secret are replaced by the value of the secret
+func resolveAPIKey(apiKey api.ExchangeAPIKey) (api.ExchangeAPIKey, error) {
+	key, e := resolveSecret(apiKey.Key)
+	if e != nil {
+		return api.ExchangeAPIKey{}, fmt.Errorf("could not resolve apiKey.Key: %w", e)
+	}
+	secret, e := resolveSecret(apiKey.Secret)
+	if e != nil {
+		return api.ExchangeAPIKey{}, fmt.Errorf("could not resolve apiKey.Secret: %w", e)
+	}
+	passphrase, e := resolveSecret(apiKey.Passphrase)
+	if e != nil {
+		return api.ExchangeAPIKey{}, fmt.Errorf("could not resolve apiKey.Passphrase: %w", e)
+	}
+	return api.ExchangeAPIKey{
+		Key:        key,
+		Secret:     secret,
+		Passphrase: passphrase,
+	}, nil
+}
+
+// resolveSecret returns the value of the secret if the value references one, otherwise it returns the value unchanged
+func resolveSecret(value string) (string, error) {
+	if strings.HasPrefix(value, secretRefEnv) {
+		name := strings.TrimPrefix(value, secretRefEnv)
+		if name == "" {
+			return "", fmt.Errorf("the reference '%s' is missing the name of the environment variable", value)
+		}
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("the referenced environment variable '%s' is not set", name)
+		}
+		return secret, nil
+	}
+
+	if strings.HasPrefix(value, secretRefFile) {
+		filename := strings.TrimPrefix(value, secretRefFile)
+		if filename == "" {
+			return "", fmt.Errorf("the reference '%s' is missing the path of the file", value)
+		}
+		bytes, e := ioutil.ReadFile(filename)
+		if e != nil {
+			return "", fmt.Errorf("could not read the referenced secret file '%s': %w", filename, e)
+		}
+		// files usually end with a newline which is not part of the secret
+		secret := strings.TrimSpace(string(bytes))
+		if secret == "" {
+			return "", fmt.Errorf("the referenced secret file '%s' is empty", filename)
+		}
+		return secret, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelp_ccxt_secrets")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	secretFile := filepath.Join(dir, "secret")
+	if !assert.NoError(t, ioutil.WriteFile(secretFile, []byte("fileSecret\n"), 0600)) {
+		return
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if !assert.NoError(t, ioutil.WriteFile(emptyFile, []byte("\n"), 0600)) {
+		return
+	}
+
+	os.Setenv("KELP_TEST_CCXT_SECRET", "envSecret")
+	defer os.Unsetenv("KELP_TEST_CCXT_SECRET")
+	os.Unsetenv("KELP_TEST_CCXT_SECRET_MISSING")
+
+	testCases := []struct {
+		value     string
+		want      string
+		wantError bool
+	}{
+		{value: "", want: ""},
+		{value: "plainSecret", want: "plainSecret"},
+		{value: "env:KELP_TEST_CCXT_SECRET", want: "envSecret"},
+		{value: "env:KELP_TEST_CCXT_SECRET_MISSING", wantError: true},
+		{value: "env:", wantError: true},
+		{value: "file:" + secretFile, want: "fileSecret"},
+		{value: "file:" + emptyFile, wantError: true},
+		{value: "file:" + filepath.Join(dir, "missing"), wantError: true},
+		{value: "file:", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.value, func(t *testing.T) {
+			secret, e := resolveSecret(k.value)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, secret)
+		})
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	os.Setenv("KELP_TEST_CCXT_KEY", "key")
+	defer os.Unsetenv("KELP_TEST_CCXT_KEY")
+	os.Setenv("KELP_TEST_CCXT_SECRET", "secret")
+	defer os.Unsetenv("KELP_TEST_CCXT_SECRET")
+	os.Unsetenv("KELP_TEST_CCXT_PASSPHRASE")
+
+	apiKey, e := resolveAPIKey(api.ExchangeAPIKey{
+		Key:        "env:KELP_TEST_CCXT_KEY",
+		Secret:     "env:KELP_TEST_CCXT_SECRET",
+		Passphrase: "passphrase",
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, api.ExchangeAPIKey{Key: "key", Secret: "secret", Passphrase: "passphrase"}, apiKey)
+
+	_, e = resolveAPIKey(api.ExchangeAPIKey{
+		Key:        "env:KELP_TEST_CCXT_KEY",
+		Secret:     "env:KELP_TEST_CCXT_SECRET",
+		Passphrase: "env:KELP_TEST_CCXT_PASSPHRASE",
+	})
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "apiKey.Passphrase")
+		assert.Contains(t, e.Error(), "KELP_TEST_CCXT_PASSPHRASE")
+	}
+}
@@ -1,10 +1,17 @@
 package sdk
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -20,6 +27,7 @@ func TestMakeInstanceName(t *testing.T) {
 		params       []api.ExchangeParam
 		headers      []api.ExchangeHeader
 		wantName     string
+		wantLegacy   string
 	}{
 		// keys cases
 		{
@@ -29,6 +37,7 @@ func TestMakeInstanceName(t *testing.T) {
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
 			wantName:     "binance___",
+			wantLegacy:   "binance___",
 		}, {
 			testName:     "binance, no key but has secret",
 			exchangeName: "binance",
@@ -36,34 +45,47 @@ func TestMakeInstanceName(t *testing.T) {
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
 			wantName:     "binance___",
+			wantLegacy:   "binance___",
 		}, {
 			testName:     "binance, has key and secret",
 			exchangeName: "binance",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "binance_1746258028__",
+			wantName:     "binance_e490cd4c8d9221de__",
+			wantLegacy:   "binance_1746258028__",
 		}, {
 			testName:     "binance, different key with same secret",
 			exchangeName: "binance",
 			apiKey:       api.ExchangeAPIKey{Key: "key2", Secret: "secret"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "binance_944401402__",
+			wantName:     "binance_0710e12c3b7714de__",
+			wantLegacy:   "binance_944401402__",
 		}, {
 			testName:     "binance, different key and different secret",
 			exchangeName: "binance",
 			apiKey:       api.ExchangeAPIKey{Key: "key2", Secret: "secret2"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "binance_944401402__",
+			wantName:     "binance_2f13d90ee6b5e725__",
+			wantLegacy:   "binance_944401402__",
 		}, {
 			testName:     "kraken, has key and secret",
 			exchangeName: "kraken",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "kraken_1746258028__",
+			wantName:     "kraken_e490cd4c8d9221de__",
+			wantLegacy:   "kraken_1746258028__",
+		}, {
+			testName:     "kucoin, has key, secret, and passphrase",
+			exchangeName: "kucoin",
+			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret", Passphrase: "passphrase"},
+			params:       []api.ExchangeParam{},
+			headers:      []api.ExchangeHeader{},
+			wantName:     "kucoin_682f4fc727f35a8c__",
+			wantLegacy:   "kucoin_1181637480__",
 		},
 		// params cases - value can be any type
 		{
@@ -73,20 +95,23 @@ func TestMakeInstanceName(t *testing.T) {
 			params:       []api.ExchangeParam{{Param: "p", Value: "v"}, {Param: "p2", Value: "true"}},
 			headers:      []api.ExchangeHeader{},
 			wantName:     "binance__3356960995_",
+			wantLegacy:   "binance__3356960995_",
 		}, {
 			testName:     "kraken, has key and secret, has params",
 			exchangeName: "kraken",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{{Param: "p", Value: "v"}, {Param: "p2", Value: "true"}},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "kraken_1746258028_3356960995_",
+			wantName:     "kraken_e490cd4c8d9221de_3356960995_",
+			wantLegacy:   "kraken_1746258028_3356960995_",
 		}, {
 			testName:     "kraken, has key and secret, has params with bool value",
 			exchangeName: "kraken",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{{Param: "p", Value: "v"}, {Param: "p2", Value: true}},
 			headers:      []api.ExchangeHeader{},
-			wantName:     "kraken_1746258028_3623553427_",
+			wantName:     "kraken_e490cd4c8d9221de_3623553427_",
+			wantLegacy:   "kraken_1746258028_3623553427_",
 		},
 		// headers cases - headers is only string values
 		{
@@ -96,20 +121,23 @@ func TestMakeInstanceName(t *testing.T) {
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{{Header: "h", Value: "v"}, {Header: "h", Value: "true"}},
 			wantName:     "binance___2734440189",
+			wantLegacy:   "binance___2734440189",
 		}, {
 			testName:     "kraken, has key and secret, has headers set 1",
 			exchangeName: "kraken",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{{Header: "h", Value: "v"}, {Header: "h", Value: "true"}},
-			wantName:     "kraken_1746258028__2734440189",
+			wantName:     "kraken_e490cd4c8d9221de__2734440189",
+			wantLegacy:   "kraken_1746258028__2734440189",
 		}, {
 			testName:     "kraken, has key and secret, has headers set 2",
 			exchangeName: "kraken",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{},
 			headers:      []api.ExchangeHeader{{Header: "h", Value: "v"}, {Header: "h2", Value: "true"}},
-			wantName:     "kraken_1746258028__2688111915",
+			wantName:     "kraken_e490cd4c8d9221de__2688111915",
+			wantLegacy:   "kraken_1746258028__2688111915",
 		},
 		// all parts included
 		{
@@ -119,28 +147,289 @@ func TestMakeInstanceName(t *testing.T) {
 			params:       []api.ExchangeParam{{Param: "p", Value: "v"}, {Param: "p2", Value: "true"}},
 			headers:      []api.ExchangeHeader{{Header: "h", Value: "v"}, {Header: "h", Value: "true"}},
 			wantName:     "binance__3356960995_2734440189",
+			wantLegacy:   "binance__3356960995_2734440189",
 		}, {
 			testName:     "binance, has key and secret, has params, has headers",
 			exchangeName: "binance",
 			apiKey:       api.ExchangeAPIKey{Key: "key", Secret: "secret"},
 			params:       []api.ExchangeParam{{Param: "p", Value: "v"}, {Param: "p2", Value: "true"}},
 			headers:      []api.ExchangeHeader{{Header: "h", Value: "v"}, {Header: "h", Value: "true"}},
-			wantName:     "binance_1746258028_3356960995_2734440189",
+			wantName:     "binance_e490cd4c8d9221de_3356960995_2734440189",
+			wantLegacy:   "binance_1746258028_3356960995_2734440189",
 		},
 	}
 
 	for _, k := range testCases {
 		t.Run(k.testName, func(t *testing.T) {
-			actualName, e := makeInstanceName(k.exchangeName, k.apiKey, k.params, k.headers)
+			actualName, e := makeInstanceName(k.exchangeName, k.apiKey, k.params, k.headers, false)
 			if !assert.Nil(t, e) {
 				return
 			}
-
 			assert.Equal(t, k.wantName, actualName)
+
+			actualLegacyName, e := makeInstanceName(k.exchangeName, k.apiKey, k.params, k.headers, true)
+			if !assert.Nil(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantLegacy, actualLegacyName)
+		})
+	}
+}
+
+func TestApplyInstanceNameOptions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		override  string
+		prefix    string
+		wantName  string
+		wantError bool
+	}{
+		{
+			name:     "no options",
+			wantName: "binance_e490cd4c8d9221de__",
+		}, {
+			name:     "override",
+			override: "my-bot.binance_1",
+			wantName: "my-bot.binance_1",
+		}, {
+			name:     "prefix",
+			prefix:   "my-bot",
+			wantName: "my-bot_binance_e490cd4c8d9221de__",
+		}, {
+			name:      "override and prefix",
+			override:  "my-bot",
+			prefix:    "my-bot",
+			wantError: true,
+		}, {
+			name:      "override with a slash",
+			override:  "my/bot",
+			wantError: true,
+		}, {
+			name:      "override with a space",
+			override:  "my bot",
+			wantError: true,
+		}, {
+			name:      "prefix with a slash",
+			prefix:    "../bot",
+			wantError: true,
+		}, {
+			name:      "override too long",
+			override:  strings.Repeat("a", maxInstanceNameLength+1),
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := applyInstanceNameOptions("binance_e490cd4c8d9221de__", k.override, k.prefix)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantName, actual)
+		})
+	}
+}
+
+func TestSupportsMethod(t *testing.T) {
+	c := &Ccxt{
+		exchangeName: "binance",
+		has: map[string]interface{}{
+			"fetchMyTrades":    true,
+			"fetchTickers":     false,
+			"fetchOrderBook":   "emulated",
+			"fetchL2OrderBook": nil,
+		},
+	}
+
+	testCases := []struct {
+		method string
+		want   bool
+	}{
+		{method: "fetchMyTrades", want: true},
+		{method: "fetchTickers", want: false},
+		{method: "fetchOrderBook", want: true},
+		{method: "fetchL2OrderBook", want: true},
+		{method: "missingMethod", want: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.method, func(t *testing.T) {
+			assert.Equal(t, k.want, c.supportsMethod(k.method))
 		})
 	}
 }
 
+func TestSymbolExistsUsesCache(t *testing.T) {
+	c := &Ccxt{
+		exchangeName: "binance",
+		markets:      map[string]CcxtMarket{"XLM/BTC": {Symbol: "XLM/BTC"}},
+		symbols:      []string{"XLM/BTC", "BTC/USDT"},
+	}
+
+	assert.NoError(t, c.symbolExists("XLM/BTC"))
+	assert.NoError(t, c.symbolExists("BTC/USDT"))
+	e := c.symbolExists("XLM/USDT")
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.Equal(t, "trading pair 'XLM/USDT' does not exist in the list of 2 symbols on exchange 'binance'", e.Error())
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c := &Ccxt{
+		httpClient: &http.Client{Timeout: 10 * time.Millisecond},
+		timeout:    10 * time.Millisecond,
+	}
+	var output interface{}
+	e := c.request(context.Background(), "GET", server.URL, "", &output)
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(e.Error(), "request timed out (timeout=10ms"), e.Error())
+}
+
+func TestRequestWithRetry(t *testing.T) {
+	testCases := []struct {
+		statusCode   int
+		wantAttempts int
+	}{
+		{statusCode: http.StatusOK, wantAttempts: 1},
+		{statusCode: http.StatusBadRequest, wantAttempts: 1},
+		{statusCode: http.StatusNotImplemented, wantAttempts: 1},
+		{statusCode: http.StatusBadGateway, wantAttempts: 3},
+		{statusCode: http.StatusServiceUnavailable, wantAttempts: 3},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%d", k.statusCode), func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(k.statusCode)
+				if k.statusCode == http.StatusOK {
+					w.Write([]byte("{}"))
+				} else {
+					w.Write([]byte(`{"error": "failed"}`))
+				}
+			}))
+			defer server.Close()
+
+			c := &Ccxt{
+				httpClient:     http.DefaultClient,
+				maxRetries:     2,
+				retryBaseDelay: time.Millisecond,
+			}
+			var output interface{}
+			e := c.requestWithRetry(context.Background(), "GET", server.URL, "", &output)
+			assert.Equal(t, k.statusCode != http.StatusOK, e != nil)
+			assert.Equal(t, k.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestRequestWithRetryCancelled(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": "failed"}`))
+	}))
+	defer server.Close()
+
+	c := &Ccxt{
+		httpClient:     http.DefaultClient,
+		maxRetries:     5,
+		retryBaseDelay: time.Minute,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var output interface{}
+	e := c.requestWithRetry(ctx, "GET", server.URL, "", &output)
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.Equal(t, 1, attempts)
+	assert.True(t, strings.HasPrefix(e.Error(), "context done while waiting to retry request"), e.Error())
+}
+
+func TestConfigureTransport(t *testing.T) {
+	proxied := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// requests sent through a proxy have the absolute URL of the target
+		proxied = append(proxied, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer proxy.Close()
+
+	c := &Ccxt{
+		httpClient: http.DefaultClient,
+		proxyURL:   proxy.URL,
+	}
+	if !assert.NoError(t, c.configureTransport()) {
+		return
+	}
+	// the shared default client should not be modified
+	assert.Nil(t, http.DefaultClient.Transport)
+
+	var output interface{}
+	e := c.request(context.Background(), "GET", "http://ccxt.invalid:3000/exchanges", "", &output)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{"http://ccxt.invalid:3000/exchanges"}, proxied)
+
+	for _, invalidProxyURL := range []string{"ftp://proxy:21", "http://", "://proxy"} {
+		c := &Ccxt{
+			httpClient: http.DefaultClient,
+			proxyURL:   invalidProxyURL,
+		}
+		assert.Error(t, c.configureTransport(), invalidProxyURL)
+	}
+}
+
+func TestConfigureTransportFromEnv(t *testing.T) {
+	prevProxy := os.Getenv("HTTPS_PROXY")
+	defer os.Setenv("HTTPS_PROXY", prevProxy)
+	os.Setenv("HTTPS_PROXY", "socks5://localhost:1080")
+
+	c := &Ccxt{httpClient: http.DefaultClient}
+	if !assert.NoError(t, c.configureTransport()) {
+		return
+	}
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !assert.True(t, ok) {
+		return
+	}
+	req, _ := http.NewRequest("GET", "http://localhost:3000/exchanges", nil)
+	proxyURL, e := transport.Proxy(req)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "socks5://localhost:1080", proxyURL.String())
+
+	// an explicit transport takes precedence over the env var
+	explicitTransport := &http.Transport{}
+	c = &Ccxt{httpClient: http.DefaultClient, transport: explicitTransport}
+	if !assert.NoError(t, c.configureTransport()) {
+		return
+	}
+	assert.Equal(t, explicitTransport, c.httpClient.Transport)
+}
+
 func TestMakeValid(t *testing.T) {
 	if testing.Short() {
 		return
@@ -183,17 +472,26 @@ func TestFetchTickers(t *testing.T) {
 		return
 	}
 
-	m, e := c.FetchTicker("BTC/USDT")
+	ticker, e := c.FetchTicker("BTC/USDT")
 	if e != nil {
 		assert.Fail(t, fmt.Sprintf("error when fetching tickers: %s", e))
 		return
 	}
 
+	assert.Equal(t, "BTC/USDT", ticker.Symbol)
+	assert.True(t, ticker.Ask > 0)
+	assert.True(t, ticker.Bid > 0)
+	assert.True(t, ticker.Bid < ticker.Ask, fmt.Sprintf("bid price (%f) should be less than ask price (%f)", ticker.Bid, ticker.Ask))
+	assert.True(t, ticker.Last > 0)
+	assert.True(t, ticker.Timestamp > 0)
+
+	m, e := c.FetchTickerRaw("BTC/USDT")
+	if e != nil {
+		assert.Fail(t, fmt.Sprintf("error when fetching raw ticker: %s", e))
+		return
+	}
 	assert.Equal(t, "BTC/USDT", m["symbol"].(string))
 	assert.True(t, m["ask"].(float64) > 0)
-	assert.True(t, m["bid"].(float64) > 0)
-	assert.True(t, m["bid"].(float64) < m["ask"].(float64), fmt.Sprintf("bid price (%f) should be less than ask price (%f)", m["bid"].(float64), m["ask"].(float64)))
-	assert.True(t, m["last"].(float64) > 0)
 }
 
 func TestFetchTickersWithMissingSymbol(t *testing.T) {
@@ -220,6 +518,36 @@ func TestFetchTickersWithMissingSymbol(t *testing.T) {
 	// success
 }
 
+func TestFetchTickersBatch(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+
+	c, e := MakeInitializedCcxtExchange("binance", api.ExchangeAPIKey{}, []api.ExchangeParam{}, []api.ExchangeHeader{})
+	if e != nil {
+		assert.Fail(t, fmt.Sprintf("error when making ccxt exchange: %s", e))
+		return
+	}
+
+	tradingPairs := []string{"BTC/USDT", "XLM/USDT"}
+	tickers, e := c.FetchTickers(tradingPairs)
+	if e != nil {
+		assert.Fail(t, fmt.Sprintf("error when fetching tickers: %s", e))
+		return
+	}
+
+	assert.Equal(t, len(tradingPairs), len(tickers))
+	for _, tradingPair := range tradingPairs {
+		m, ok := tickers[tradingPair]
+		if !assert.True(t, ok, fmt.Sprintf("missing ticker for trading pair '%s'", tradingPair)) {
+			return
+		}
+		assert.Equal(t, tradingPair, m["symbol"].(string))
+		assert.True(t, m["ask"].(float64) > 0)
+		assert.True(t, m["bid"].(float64) > 0)
+	}
+}
+
 func TestFetchOrderBook(t *testing.T) {
 	if testing.Short() {
 		return
@@ -296,7 +624,7 @@ func runTestFetchOrderBook(k orderbookTest, t *testing.T) {
 		return
 	}
 
-	m, e := c.FetchOrderBook(k.tradingPair, k.limit)
+	m, e := c.FetchOrderBook(k.tradingPair, k.limit, nil)
 	if e != nil && !k.expectError {
 		assert.Fail(t, fmt.Sprintf("error when fetching orderbook: %s", e))
 		return
@@ -324,6 +652,271 @@ func runTestFetchOrderBook(k orderbookTest, t *testing.T) {
 	validateOrders("bids")
 }
 
+func TestParseOrderBook(t *testing.T) {
+	limit2 := 2
+	testCases := []struct {
+		name     string
+		response string
+		limit    *int
+		wantAsks []CcxtOrder
+		wantBids []CcxtOrder
+	}{
+		{
+			name:     "sorted",
+			response: `{"asks": [[1.1, 5], [1.2, 6]], "bids": [[1.0, 7], [0.9, 8]]}`,
+			wantAsks: []CcxtOrder{{Price: 1.1, Amount: 5}, {Price: 1.2, Amount: 6}},
+			wantBids: []CcxtOrder{{Price: 1.0, Amount: 7}, {Price: 0.9, Amount: 8}},
+		}, {
+			name:     "unsorted",
+			response: `{"asks": [[1.2, 6], [1.1, 5]], "bids": [[0.9, 8], [1.0, 7]]}`,
+			wantAsks: []CcxtOrder{{Price: 1.1, Amount: 5}, {Price: 1.2, Amount: 6}},
+			wantBids: []CcxtOrder{{Price: 1.0, Amount: 7}, {Price: 0.9, Amount: 8}},
+		}, {
+			name:     "strings and order counts",
+			response: `{"asks": [["1.1", "5", 3]], "bids": [["1.0", 7, 1]], "timestamp": 1}`,
+			wantAsks: []CcxtOrder{{Price: 1.1, Amount: 5}},
+			wantBids: []CcxtOrder{{Price: 1.0, Amount: 7}},
+		}, {
+			name:     "limit",
+			response: `{"asks": [[1.3, 7], [1.1, 5], [1.2, 6]], "bids": [[0.8, 9], [1.0, 7], [0.9, 8]]}`,
+			limit:    &limit2,
+			wantAsks: []CcxtOrder{{Price: 1.1, Amount: 5}, {Price: 1.2, Amount: 6}},
+			wantBids: []CcxtOrder{{Price: 1.0, Amount: 7}, {Price: 0.9, Amount: 8}},
+		},
+	}
+
+	c := &Ccxt{exchangeName: "binance"}
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var output interface{}
+			if !assert.NoError(t, json.Unmarshal([]byte(k.response), &output)) {
+				return
+			}
+
+			m, e := c.parseOrderBook(output, k.limit, orderBookSides)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAsks, m["asks"])
+			assert.Equal(t, k.wantBids, m["bids"])
+		})
+	}
+}
+
+func TestParseOrderBookInvalid(t *testing.T) {
+	for _, response := range []string{
+		`{"asks": [[1.1]], "bids": []}`,
+		`{"asks": [["abc", 5]], "bids": []}`,
+		`{"asks": [[1.1, true]], "bids": []}`,
+	} {
+		t.Run(response, func(t *testing.T) {
+			var output interface{}
+			if !assert.NoError(t, json.Unmarshal([]byte(response), &output)) {
+				return
+			}
+
+			c := &Ccxt{exchangeName: "binance"}
+			_, e := c.parseOrderBook(output, nil, orderBookSides)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestParseOrderBookSides(t *testing.T) {
+	// the bids are malformed so parsing fails unless they are skipped
+	response := `{"asks": [[1.2, 6], [1.1, 5]], "bids": [["abc", 8]]}`
+	var output interface{}
+	if !assert.NoError(t, json.Unmarshal([]byte(response), &output)) {
+		return
+	}
+
+	c := &Ccxt{exchangeName: "binance"}
+	m, e := c.parseOrderBook(output, nil, []string{OrderBookSideAsks})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string][]CcxtOrder{"asks": {{Price: 1.1, Amount: 5}, {Price: 1.2, Amount: 6}}}, m)
+
+	_, e = c.parseOrderBook(output, nil, []string{OrderBookSideBids})
+	assert.Error(t, e)
+}
+
+func TestMalformedResponses(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response string
+		call     func(c *Ccxt) error
+	}{
+		{
+			name:     "fetchTicker array",
+			response: `[1, 2]`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchTicker("XLM/BTC")
+				return e
+			},
+		}, {
+			name:     "fetchOrderBook string",
+			response: `"not an orderbook"`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOrderBook("XLM/BTC", nil, nil)
+				return e
+			},
+		}, {
+			name:     "fetchOrderBook asks map",
+			response: `{"asks": {"price": 1.1}, "bids": []}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOrderBook("XLM/BTC", nil, nil)
+				return e
+			},
+		}, {
+			name:     "fetchOrderBook order not a list",
+			response: `{"asks": [1.1, 5], "bids": []}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOrderBook("XLM/BTC", nil, nil)
+				return e
+			},
+		}, {
+			name:     "fetchBalance array",
+			response: `[]`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchBalance()
+				return e
+			},
+		}, {
+			name:     "fetchBalance total array",
+			response: `{"total": [1, 2]}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchBalance()
+				return e
+			},
+		}, {
+			name:     "fetchBalance missing asset",
+			response: `{"total": {"XLM": 10}}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchBalance()
+				return e
+			},
+		}, {
+			name:     "fetchOpenOrders map",
+			response: `{"message": "something went wrong"}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOpenOrders([]string{"XLM/BTC"})
+				return e
+			},
+		}, {
+			name:     "cancelOrder array",
+			response: `[]`,
+			call: func(c *Ccxt) error {
+				_, e := c.CancelOrder("123", "XLM/BTC")
+				return e
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(k.response))
+			}))
+			defer server.Close()
+
+			prevBaseURL := ccxtBaseURL
+			ccxtBaseURL = server.URL
+			defer func() { ccxtBaseURL = prevBaseURL }()
+
+			c := &Ccxt{
+				exchangeName: "binance",
+				instanceName: "instance",
+				httpClient:   http.DefaultClient,
+				markets:      map[string]CcxtMarket{"XLM/BTC": {Symbol: "XLM/BTC"}},
+				symbols:      []string{"XLM/BTC"},
+			}
+			e := k.call(c)
+			if !assert.Error(t, e) {
+				return
+			}
+			assert.Contains(t, e.Error(), "unexpected response shape from exchange 'binance'")
+		})
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	eta := int64(1600000000000)
+	testCases := []struct {
+		name       string
+		has        map[string]interface{}
+		statusCode int
+		response   string
+		wantStatus CcxtStatus
+		wantError  bool
+	}{
+		{
+			name:       "ok",
+			has:        map[string]interface{}{"fetchStatus": true},
+			statusCode: http.StatusOK,
+			response:   `{"status": "ok", "updated": 1590000000000, "eta": null, "url": null}`,
+			wantStatus: CcxtStatus{Status: CcxtStatusOK, Updated: 1590000000000},
+		}, {
+			name:       "maintenance",
+			has:        map[string]interface{}{"fetchStatus": true},
+			statusCode: http.StatusOK,
+			response:   `{"status": "maintenance", "updated": 1590000000000, "eta": 1600000000000, "url": "https://status.example.com"}`,
+			wantStatus: CcxtStatus{Status: CcxtStatusMaintenance, Updated: 1590000000000, Eta: &eta, URL: "https://status.example.com"},
+		}, {
+			name:       "missing status",
+			has:        map[string]interface{}{"fetchStatus": true},
+			statusCode: http.StatusOK,
+			response:   `{"updated": 1590000000000}`,
+			wantError:  true,
+		}, {
+			name:       "fallback ok",
+			has:        map[string]interface{}{"fetchStatus": false},
+			statusCode: http.StatusOK,
+			response:   `{"symbol": "XLM/BTC", "bid": 0.1, "ask": 0.2}`,
+			wantStatus: CcxtStatus{Status: CcxtStatusOK},
+		}, {
+			name:       "fallback error",
+			has:        map[string]interface{}{"fetchStatus": false},
+			statusCode: http.StatusBadRequest,
+			response:   `{"error": "exchange not available"}`,
+			wantStatus: CcxtStatus{Status: CcxtStatusError},
+			wantError:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(k.statusCode)
+				w.Write([]byte(k.response))
+			}))
+			defer server.Close()
+
+			prevBaseURL := ccxtBaseURL
+			ccxtBaseURL = server.URL
+			defer func() { ccxtBaseURL = prevBaseURL }()
+
+			c := &Ccxt{
+				exchangeName: "binance",
+				instanceName: "instance",
+				httpClient:   http.DefaultClient,
+				has:          k.has,
+				symbols:      []string{"XLM/BTC"},
+			}
+			status, e := c.FetchStatus()
+			assert.Equal(t, k.wantError, e != nil, fmt.Sprintf("%v", e))
+			assert.Equal(t, k.wantStatus.Status, status.Status)
+			assert.Equal(t, k.wantStatus.Eta, status.Eta)
+			assert.Equal(t, k.wantStatus.URL, status.URL)
+			if k.wantStatus.Updated != 0 {
+				assert.Equal(t, k.wantStatus.Updated, status.Updated)
+			}
+		})
+	}
+}
+
 func TestFetchTrades(t *testing.T) {
 	if testing.Short() {
 		return
@@ -374,7 +967,7 @@ func TestFetchTrades(t *testing.T) {
 				return
 			}
 
-			trades, e := c.FetchTrades(k.tradingPair)
+			trades, e := c.FetchTrades(k.tradingPair, nil)
 			if e != nil {
 				assert.Fail(t, fmt.Sprintf("error when fetching trades: %s", e))
 				return
@@ -479,7 +1072,8 @@ func validateTrades(trades []CcxtTrade, expectedFields []string, tradingPair str
 		if supportsField("timestamp") && !assert.True(t, trade.Timestamp > 0) {
 			return
 		}
-		if supportsField("fee") && !assert.NotNil(t, trade.Fee) && !assert.True(t, trade.Fee.Cost > 0) {
+		// some exchanges return a null fee for public trades
+		if supportsField("fee") && trade.Fee != nil && !assert.True(t, trade.Fee.Cost >= 0) {
 			return
 		}
 	}
@@ -685,6 +1279,52 @@ func TestCreateLimitOrder(t *testing.T) {
 	}
 }
 
+func TestCreateOrderInvalidInputs(t *testing.T) {
+	price := 0.00004228
+	c := &Ccxt{exchangeName: "binance"}
+	for _, k := range []struct {
+		name      string
+		side      string
+		orderType string
+		price     *float64
+		wantErr   string
+	}{
+		{
+			name:      "invalid side",
+			side:      "hold",
+			orderType: "limit",
+			price:     &price,
+			wantErr:   "invalid side 'hold', needs to be either 'buy' or 'sell'",
+		}, {
+			name:      "limit order without price",
+			side:      "sell",
+			orderType: "limit",
+			price:     nil,
+			wantErr:   "price cannot be nil for a limit order",
+		}, {
+			name:      "market order with price",
+			side:      "buy",
+			orderType: "market",
+			price:     &price,
+			wantErr:   "price needs to be nil for a market order but was 0.000042",
+		}, {
+			name:      "invalid order type",
+			side:      "buy",
+			orderType: "stop",
+			price:     nil,
+			wantErr:   "invalid orderType 'stop', needs to be either 'limit' or 'market'",
+		},
+	} {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := c.CreateOrder("XLM/BTC", k.side, k.orderType, 40, k.price, nil)
+			if !assert.Error(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantErr, e.Error())
+		})
+	}
+}
+
 func TestCancelOrder(t *testing.T) {
 	if testing.Short() {
 		return
@@ -782,3 +1422,211 @@ func validateCanceledOrder(t *testing.T, openOrder *CcxtOpenOrder, tradingPair s
 
 	return true
 }
+
+func TestFetchDepositAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"currency": "XLM", "address": "GABC", "tag": "12345", "info": {}}`))
+	}))
+	defer server.Close()
+
+	prevBaseURL := ccxtBaseURL
+	ccxtBaseURL = server.URL
+	defer func() { ccxtBaseURL = prevBaseURL }()
+
+	c := &Ccxt{
+		exchangeName: "binance",
+		instanceName: "instance",
+		httpClient:   http.DefaultClient,
+	}
+	depositAddress, e := c.FetchDepositAddress("XLM")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, CcxtDepositAddress{Currency: "XLM", Address: "GABC", Tag: "12345"}, depositAddress)
+}
+
+func TestWithdraw(t *testing.T) {
+	attempts := 0
+	requestBodies := []string{}
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		requestBodies = append(requestBodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		if statusCode == http.StatusOK {
+			w.Write([]byte(`{"id": "w1", "info": {}}`))
+		} else {
+			w.Write([]byte(`{"error": "failed"}`))
+		}
+	}))
+	defer server.Close()
+
+	prevBaseURL := ccxtBaseURL
+	ccxtBaseURL = server.URL
+	defer func() { ccxtBaseURL = prevBaseURL }()
+
+	c := &Ccxt{
+		exchangeName:   "binance",
+		instanceName:   "instance",
+		httpClient:     http.DefaultClient,
+		maxRetries:     3,
+		retryBaseDelay: time.Millisecond,
+	}
+
+	// invalid inputs never make a request
+	_, e := c.Withdraw("XLM", 10, "", "")
+	assert.Error(t, e)
+	_, e = c.Withdraw("XLM", 0, "GABC", "")
+	assert.Error(t, e)
+	_, e = c.Withdraw("XLM", -1, "GABC", "")
+	assert.Error(t, e)
+	assert.Equal(t, 0, attempts)
+
+	transaction, e := c.Withdraw("XLM", 10, "GABC", "12345")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, CcxtTransaction{ID: "w1", Currency: "XLM", Amount: 10, Address: "GABC"}, transaction)
+	assert.Equal(t, []string{`["XLM",10,"GABC","12345"]`}, requestBodies)
+
+	// failed withdrawals are not retried
+	attempts = 0
+	statusCode = http.StatusServiceUnavailable
+	_, e = c.Withdraw("XLM", 10, "GABC", "")
+	assert.Error(t, e)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestExchangeSpecificParams(t *testing.T) {
+	limit := 5
+	price := 0.5
+	testCases := []struct {
+		name     string
+		response string
+		call     func(c *Ccxt) error
+		wantBody string
+	}{
+		{
+			name:     "fetchOrderBook without params",
+			response: `{"asks": [], "bids": []}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOrderBook("XLM/BTC", &limit, nil)
+				return e
+			},
+			wantBody: `["XLM/BTC","5"]`,
+		}, {
+			name:     "fetchOrderBook with params and no limit",
+			response: `{"asks": [], "bids": []}`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchOrderBook("XLM/BTC", nil, map[string]interface{}{"group": 1})
+				return e
+			},
+			wantBody: `["XLM/BTC",null,{"group":1}]`,
+		}, {
+			name:     "fetchTrades with params",
+			response: `[]`,
+			call: func(c *Ccxt) error {
+				_, e := c.FetchTrades("XLM/BTC", map[string]interface{}{"type": "spot"})
+				return e
+			},
+			wantBody: `["XLM/BTC",null,null,{"type":"spot"}]`,
+		}, {
+			name:     "createOrder with params",
+			response: `{"id": "1"}`,
+			call: func(c *Ccxt) error {
+				_, e := c.CreateOrder("XLM/BTC", "buy", "limit", 10, &price, map[string]interface{}{"timeInForce": "IOC"})
+				return e
+			},
+			wantBody: `["XLM/BTC","limit","buy",10,0.5,{"timeInForce":"IOC"}]`,
+		}, {
+			name:     "createOrder market without params",
+			response: `{"id": "1"}`,
+			call: func(c *Ccxt) error {
+				_, e := c.CreateOrder("XLM/BTC", "buy", "market", 10, nil, nil)
+				return e
+			},
+			wantBody: `["XLM/BTC","market","buy",10]`,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var gotBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				gotBody = string(body)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(k.response))
+			}))
+			defer server.Close()
+
+			prevBaseURL := ccxtBaseURL
+			ccxtBaseURL = server.URL
+			defer func() { ccxtBaseURL = prevBaseURL }()
+
+			c := &Ccxt{
+				exchangeName: "binance",
+				instanceName: "instance",
+				httpClient:   http.DefaultClient,
+				symbols:      []string{"XLM/BTC"},
+			}
+			if !assert.NoError(t, k.call(c)) {
+				return
+			}
+			assert.Equal(t, k.wantBody, gotBody)
+		})
+	}
+}
+
+func TestClampOrderBookLimit(t *testing.T) {
+	testCases := []struct {
+		limit           int
+		supportedLimits []int
+		want            int
+	}{
+		{limit: 7, supportedLimits: []int{}, want: 7},
+		{limit: 5, supportedLimits: []int{5, 10, 20}, want: 5},
+		{limit: 6, supportedLimits: []int{5, 10, 20}, want: 10},
+		{limit: 1, supportedLimits: []int{5, 10, 20}, want: 5},
+		{limit: 21, supportedLimits: []int{5, 10, 20}, want: 20},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%d/%v", k.limit, k.supportedLimits), func(t *testing.T) {
+			assert.Equal(t, k.want, clampOrderBookLimit(k.limit, k.supportedLimits))
+		})
+	}
+}
+
+func TestNormalizeCcxtBaseURL(t *testing.T) {
+	testCases := []struct {
+		baseURL   string
+		want      string
+		wantError bool
+	}{
+		{baseURL: "http://localhost:3000", want: "http://localhost:3000"},
+		{baseURL: "http://localhost:3000/", want: "http://localhost:3000"},
+		{baseURL: "https://proxy.example.com/ccxt/", want: "https://proxy.example.com/ccxt"},
+		{baseURL: "localhost:3000", wantError: true},
+		{baseURL: "/ccxt", wantError: true},
+		{baseURL: "http://", wantError: true},
+		{baseURL: "", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.baseURL, func(t *testing.T) {
+			actual, e := normalizeCcxtBaseURL(k.baseURL)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, actual)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that allows requestsPerSecond requests on average with bursts of up to burst requests
+type rateLimiter struct {
+	lock              *sync.Mutex
+	requestsPerSecond float64
+	burst             float64
+	tokens            float64
+	lastRefill        time.Time
+}
+
+// rateLimitersByExchange holds one rateLimiter per exchange so that all Ccxt instances of an exchange share the same limit
+var rateLimitersByExchange = map[string]*rateLimiter{}
+var rateLimitersLock = &sync.Mutex{}
+
+// makeRateLimiter is a factory method, the bucket starts out full
+func makeRateLimiter(requestsPerSecond float64) *rateLimiter {
+	burst := math.Max(1.0, math.Floor(requestsPerSecond))
+	return &rateLimiter{
+		lock:              &sync.Mutex{},
+		requestsPerSecond: requestsPerSecond,
+		burst:             burst,
+		tokens:            burst,
+		lastRefill:        time.Now(),
+	}
+}
+
+// getRateLimiter returns the shared rateLimiter of the exchange, updating the rate if it is different from the existing rate
+func getRateLimiter(exchangeName string, requestsPerSecond float64) *rateLimiter {
+	rateLimitersLock.Lock()
+	defer rateLimitersLock.Unlock()
+
+	l, ok := rateLimitersByExchange[exchangeName]
+	if !ok {
+		l = makeRateLimiter(requestsPerSecond)
+		rateLimitersByExchange[exchangeName] = l
+		return l
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.requestsPerSecond != requestsPerSecond {
+		log.Printf("updating rate limit for exchange '%s' from %.2f to %.2f requests per second\n", exchangeName, l.requestsPerSecond, requestsPerSecond)
+		l.refill(time.Now())
+		l.requestsPerSecond = requestsPerSecond
+		l.burst = math.Max(1.0, math.Floor(requestsPerSecond))
+		l.tokens = math.Min(l.tokens, l.burst)
+	}
+	return l
+}
+
+// refill adds the tokens accumulated since the last refill, should only be called when holding the lock
+func (l *rateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	if elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.requestsPerSecond)
+		l.lastRefill = now
+	}
+}
+
+// wait blocks until a request can be made, it returns an error without waiting if the context deadline would expire first
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.lock.Lock()
+	now := time.Now()
+	l.refill(now)
+	// reserve the token up front so concurrent callers queue up behind each other
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.requestsPerSecond * float64(time.Second))
+	}
+	l.lock.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		l.release()
+		return fmt.Errorf("rate limit would delay the request by %s which is past the context deadline", delay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.release()
+		return fmt.Errorf("context done while waiting for the rate limit: %s", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// release gives back a token that was reserved but not used
+func (l *rateLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	l := makeRateLimiter(20)
+
+	// the bucket starts out full so the burst is not delayed
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, l.wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) < 25*time.Millisecond)
+
+	// the next request waits for a token to be refilled
+	start = time.Now()
+	assert.NoError(t, l.wait(context.Background()))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestRateLimiterRespectsDeadline(t *testing.T) {
+	l := makeRateLimiter(1)
+	assert.NoError(t, l.wait(context.Background()))
+
+	// the next token is a second away so a request with a shorter deadline fails without waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Error(t, l.wait(ctx))
+	assert.True(t, time.Since(start) < 10*time.Millisecond)
+
+	// the reserved token was released so the wait time did not grow
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	assert.True(t, l.tokens > -1)
+}
+
+func TestGetRateLimiterIsSharedByExchange(t *testing.T) {
+	a := getRateLimiter("test_exchange_a", 5)
+	assert.Equal(t, a, getRateLimiter("test_exchange_a", 5))
+	assert.NotEqual(t, a, getRateLimiter("test_exchange_b", 5))
+
+	// a different rate updates the shared limiter
+	assert.Equal(t, a, getRateLimiter("test_exchange_a", 2))
+	assert.Equal(t, 2.0, a.requestsPerSecond)
+	assert.Equal(t, 2.0, a.burst)
+}
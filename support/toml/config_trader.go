@@ -4,8 +4,9 @@ import "github.com/stellar/kelp/api"
 
 // ExchangeAPIKeysToml is the toml representation of ExchangeAPIKeys
 type ExchangeAPIKeysToml []struct {
-	Key    string `valid:"-" toml:"KEY"`
-	Secret string `valid:"-" toml:"SECRET"`
+	Key        string `valid:"-" toml:"KEY"`
+	Secret     string `valid:"-" toml:"SECRET"`
+	Passphrase string `valid:"-" toml:"PASSPHRASE"`
 }
 
 // ToExchangeAPIKeys converts object
@@ -13,8 +14,9 @@ func (t *ExchangeAPIKeysToml) ToExchangeAPIKeys() []api.ExchangeAPIKey {
 	apiKeys := []api.ExchangeAPIKey{}
 	for _, apiKey := range *t {
 		apiKeys = append(apiKeys, api.ExchangeAPIKey{
-			Key:    apiKey.Key,
-			Secret: apiKey.Secret,
+			Key:        apiKey.Key,
+			Secret:     apiKey.Secret,
+			Passphrase: apiKey.Passphrase,
 		})
 	}
 	return apiKeys
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/support/postgresdb"
 	"github.com/stellar/kelp/support/toml"
 	"github.com/stellar/kelp/support/utils"
@@ -39,6 +40,15 @@ type BotConfig struct {
 	SynchronizeStateLoadEnable         bool       `valid:"-" toml:"SYNCHRONIZE_STATE_LOAD_ENABLE"`
 	SynchronizeStateLoadMaxRetries     int        `valid:"-" toml:"SYNCHRONIZE_STATE_LOAD_MAX_RETRIES"`
 	FillTrackerLastTradeCursorOverride string     `valid:"-" toml:"FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
+	FillTrackerCsvFilePath             string     `valid:"-" toml:"FILL_TRACKER_CSV_FILE_PATH" json:"fill_tracker_csv_file_path"`
+	FillTrackerWebhookURL              string     `valid:"-" toml:"FILL_TRACKER_WEBHOOK_URL" json:"fill_tracker_webhook_url"`
+	FillTrackerWebhookStrict           bool       `valid:"-" toml:"FILL_TRACKER_WEBHOOK_STRICT" json:"fill_tracker_webhook_strict"`
+	FillTrackerPnlEnabled              bool       `valid:"-" toml:"FILL_TRACKER_PNL_ENABLED" json:"fill_tracker_pnl_enabled"`
+	FillTrackerLogJSON                 bool       `valid:"-" toml:"FILL_TRACKER_LOG_JSON" json:"fill_tracker_log_json"`
+	FillTrackerLogFilePath             string     `valid:"-" toml:"FILL_TRACKER_LOG_FILE_PATH" json:"fill_tracker_log_file_path"`
+	FillTrackerDbBatchSize             int        `valid:"-" toml:"FILL_TRACKER_DB_BATCH_SIZE" json:"fill_tracker_db_batch_size"`
+	FillTrackerDbFlushIntervalMillis   int64      `valid:"-" toml:"FILL_TRACKER_DB_FLUSH_INTERVAL_MILLIS" json:"fill_tracker_db_flush_interval_millis"`
+	DbHealthCheckIntervalSeconds       int64      `valid:"-" toml:"DB_HEALTH_CHECK_INTERVAL_SECONDS" json:"db_health_check_interval_seconds"`
 	HorizonURL                         string     `valid:"-" toml:"HORIZON_URL" json:"horizon_url"`
 	CcxtRestURL                        *string    `valid:"-" toml:"CCXT_REST_URL" json:"ccxt_rest_url"`
 	DollarValueFeedBaseAsset           string     `valid:"-" toml:"DOLLAR_VALUE_FEED_BASE_ASSET" json:"dollar_value_feed_base_asset"`
@@ -55,6 +65,11 @@ type BotConfig struct {
 	Filters                            []string                 `valid:"-" toml:"FILTERS" json:"filters"`
 	AlertType                          string                   `valid:"-" toml:"ALERT_TYPE" json:"alert_type"`
 	AlertAPIKey                        string                   `valid:"-" toml:"ALERT_API_KEY" json:"alert_api_key"`
+	AlertChatID                        string                   `valid:"-" toml:"ALERT_CHAT_ID" json:"alert_chat_id"`
+	AlertWebhookMethod                 string                   `valid:"-" toml:"ALERT_WEBHOOK_METHOD" json:"alert_webhook_method"`
+	AlertWebhookBearerToken            string                   `valid:"-" toml:"ALERT_WEBHOOK_BEARER_TOKEN" json:"alert_webhook_bearer_token"`
+	AlertOpsGenieTeam                  string                   `valid:"-" toml:"ALERT_OPSGENIE_TEAM" json:"alert_opsgenie_team"`
+	AlertCooldownSeconds               int64                    `valid:"-" toml:"ALERT_COOLDOWN_SECONDS" json:"alert_cooldown_seconds"`
 	MonitoringPort                     uint16                   `valid:"-" toml:"MONITORING_PORT" json:"monitoring_port"`
 	MonitoringTLSCert                  string                   `valid:"-" toml:"MONITORING_TLS_CERT" json:"monitoring_tls_cert"`
 	MonitoringTLSKey                   string                   `valid:"-" toml:"MONITORING_TLS_KEY" json:"monitoring_tls_key"`
@@ -126,15 +141,16 @@ func MakeBotConfig(
 // String impl.
 func (b BotConfig) String() string {
 	return utils.StructString(b, 0, map[string]func(interface{}) interface{}{
-		"EXCHANGE_API_KEYS":        utils.Hide,
-		"EXCHANGE_PARAMS":          utils.Hide,
-		"EXCHANGE_HEADERS":         utils.Hide,
-		"SOURCE_SECRET_SEED":       utils.SecretKey2PublicKey,
-		"TRADING_SECRET_SEED":      utils.SecretKey2PublicKey,
-		"ALERT_API_KEY":            utils.Hide,
-		"GOOGLE_CLIENT_ID":         utils.Hide,
-		"GOOGLE_CLIENT_SECRET":     utils.Hide,
-		"ACCEPTABLE_GOOGLE_EMAILS": utils.Hide,
+		"EXCHANGE_API_KEYS":          utils.Hide,
+		"EXCHANGE_PARAMS":            utils.Hide,
+		"EXCHANGE_HEADERS":           utils.Hide,
+		"SOURCE_SECRET_SEED":         utils.SecretKey2PublicKey,
+		"TRADING_SECRET_SEED":        utils.SecretKey2PublicKey,
+		"ALERT_API_KEY":              utils.Hide,
+		"ALERT_WEBHOOK_BEARER_TOKEN": utils.Hide,
+		"GOOGLE_CLIENT_ID":           utils.Hide,
+		"GOOGLE_CLIENT_SECRET":       utils.Hide,
+		"ACCEPTABLE_GOOGLE_EMAILS":   utils.Hide,
 	})
 }
 
@@ -202,6 +218,11 @@ func (b *BotConfig) Init() error {
 	}
 	b.assetQuote = *asset
 
+	_, e = api.ParseSubmitMode(b.SubmitMode)
+	if e != nil {
+		return fmt.Errorf("invalid SUBMIT_MODE: %s", e)
+	}
+
 	b.tradingAccount, e = utils.ParseSecret(b.TradingSecretSeed)
 	if e != nil {
 		return e
@@ -48,6 +48,7 @@ type Trader struct {
 	alert                          api.Alert
 	metricsTracker                 *plugins.MetricsTracker
 	startTime                      time.Time
+	onUpdateComplete               func(updateTime time.Time) // can be nil
 
 	// initialized runtime vars
 	deleteCycles int64
@@ -87,6 +88,7 @@ func MakeTrader(
 	alert api.Alert,
 	metricsTracker *plugins.MetricsTracker,
 	startTime time.Time,
+	onUpdateComplete func(updateTime time.Time), // can be nil, invoked at the end of every update cycle
 ) *Trader {
 	return &Trader{
 		api:                            api,
@@ -113,6 +115,7 @@ func MakeTrader(
 		alert:                          alert,
 		metricsTracker:                 metricsTracker,
 		startTime:                      startTime,
+		onUpdateComplete:               onUpdateComplete,
 		// initialized runtime vars
 		deleteCycles: 0,
 	}
@@ -156,6 +159,10 @@ func (t *Trader) Start() {
 				}
 			}
 
+			if t.onUpdateComplete != nil {
+				t.onUpdateComplete(currentUpdateTime)
+			}
+
 			if t.fixedIterations != nil && updateResult.Success {
 				*t.fixedIterations = *t.fixedIterations - 1
 				if *t.fixedIterations <= 0 {
@@ -227,8 +234,13 @@ func (t *Trader) deleteAllOffers(isAsync bool) {
 	log.Printf("%sdeleting all offers, num. continuous update cycles with errors (including this one): %d; (deleteCyclesThreshold to be exceeded=%d)\n", logPrefix, t.deleteCycles, t.deleteCyclesThreshold)
 	dOps := []txnbuild.Operation{}
 	dOps = append(dOps, t.sdex.DeleteAllOffers(t.sellingAOffers)...)
-	t.sellingAOffers = []hProtocol.Offer{}
 	dOps = append(dOps, t.sdex.DeleteAllOffers(t.buyingAOffers)...)
+	if t.submitMode == api.SubmitModeDryRun {
+		// nothing is submitted in dry_run so we keep running instead of exiting, the bot recovers from transient errors as it normally would
+		log.Printf("%sdry_run: would delete %d offers, not deleting them\n", logPrefix, len(dOps))
+		return
+	}
+	t.sellingAOffers = []hProtocol.Offer{}
 	t.buyingAOffers = []hProtocol.Offer{}
 
 	// LOH-3 - we want to guarantee that the bot crashes if the errors exceed deleteCyclesThreshold, so we start a new thread with a sleep timer to crash the bot as a safety
@@ -444,7 +456,10 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	pruneOps, t.buyingAOffers, t.sellingAOffers = t.strategy.PruneExistingOffers(t.buyingAOffers, t.sellingAOffers)
 	numPruneOps = len(pruneOps)
 	log.Printf("created %d operations to prune excess offers\n", numPruneOps)
-	if numPruneOps > 0 {
+	if numPruneOps > 0 && t.submitMode == api.SubmitModeDryRun {
+		// prune ops do not go through the submit filters so we need to skip them here
+		log.Printf("not submitting %d operations to prune excess offers because the submit mode is dry_run\n", numPruneOps)
+	} else if numPruneOps > 0 {
 		// to prune/delete offers the submitMode doesn't matter, so use api.SubmitModeBoth as the default
 		e = t.exchangeShim.SubmitOps(pruneOps, api.SubmitModeBoth, nil)
 		if e != nil {
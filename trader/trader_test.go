@@ -11,6 +11,7 @@ import (
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
 )
 
 func TestIsStateSynchronized(t *testing.T) {
@@ -346,3 +347,19 @@ func createTestMSO(msoType string) *txnbuild.ManageSellOffer {
 	}
 	return &mso
 }
+
+func TestDeleteAllOffersDryRun(t *testing.T) {
+	offer := hProtocol.Offer{ID: 1, Amount: "10.0000000", Price: "0.5000000"}
+	trader := &Trader{
+		sdex:                  &plugins.SDEX{},
+		submitMode:            api.SubmitModeDryRun,
+		deleteCyclesThreshold: 0,
+		sellingAOffers:        []hProtocol.Offer{offer},
+		buyingAOffers:         []hProtocol.Offer{},
+	}
+
+	// returns instead of exiting the process, and keeps the offers since they were not deleted
+	trader.deleteAllOffers(false)
+	assert.Equal(t, []hProtocol.Offer{offer}, trader.sellingAOffers)
+	assert.Equal(t, int64(1), trader.deleteCycles)
+}